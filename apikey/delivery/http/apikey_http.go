@@ -0,0 +1,130 @@
+package httpdelivery
+
+import (
+	"errors"
+	"net/http"
+
+	"go-clean-architecture/apikey/models"
+	apikeyservice "go-clean-architecture/apikey/service"
+	"go-clean-architecture/pkg/auth"
+	errorsutil "go-clean-architecture/utils/errors"
+	requestutil "go-clean-architecture/utils/request"
+	responseutil "go-clean-architecture/utils/response"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type HTTPHandler interface {
+	RegisterRoutes(router chi.Router)
+	GetAll(w http.ResponseWriter, r *http.Request)
+	Create(w http.ResponseWriter, r *http.Request)
+	Rotate(w http.ResponseWriter, r *http.Request)
+	Revoke(w http.ResponseWriter, r *http.Request)
+}
+
+// maxBodySize - limit of API key request bodies
+const maxBodySize = 16 << 10
+
+type HTTPHandlerImpl struct {
+	service apikeyservice.Service
+}
+
+// New - make http handler
+func New(service apikeyservice.Service) HTTPHandler {
+	return &HTTPHandlerImpl{
+		service: service,
+	}
+}
+
+// RegisterRoutes - keys are managed by their owner's user session, a key cannot manage keys
+func (h *HTTPHandlerImpl) RegisterRoutes(router chi.Router) {
+	router.Group(func(r chi.Router) {
+		r.Use(auth.RequireUser)
+		r.Use(responseutil.Negotiate(responseutil.Formats...))
+		r.Use(requestutil.MaxBodySize(maxBodySize))
+
+		r.Get("/apikeys", h.GetAll)
+		r.Post("/apikeys", h.Create)
+		r.Post("/apikeys/{id}/rotate", h.Rotate)
+		r.Delete("/apikeys/{id}", h.Revoke)
+	})
+}
+
+// GetAll - get all API keys http handler
+func (h *HTTPHandlerImpl) GetAll(w http.ResponseWriter, r *http.Request) {
+	results, err := h.service.GetAll(r.Context(), auth.UserID(r))
+	if err != nil {
+		responseutil.ResponseError(w, r, err)
+		return
+	}
+
+	responseutil.ResponseOK(w, r, &responseutil.ResponseSuccess{
+		Data: results,
+	})
+}
+
+// Create - create API key http handler
+func (h *HTTPHandlerImpl) Create(w http.ResponseWriter, r *http.Request) {
+	data := &models.APIKeyRequest{}
+	if err := requestutil.Bind(r, data); err != nil {
+		responseutil.ResponseBindError(w, r, err)
+		return
+	}
+
+	principal := auth.FromContext(r.Context())
+	result, err := h.service.Create(r.Context(), &models.APIKey{
+		Name:    data.Name,
+		Scopes:  data.Scopes,
+		OwnerID: principal.Subject,
+	})
+	if err != nil {
+		responseutil.ResponseError(w, r, err)
+		return
+	}
+
+	responseutil.ResponseCreated(w, r, &responseutil.ResponseSuccess{
+		Data: result,
+	})
+}
+
+// Rotate - rotate API key http handler
+func (h *HTTPHandlerImpl) Rotate(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	result, err := h.service.Rotate(r.Context(), auth.UserID(r), id)
+	if err != nil {
+		if errors.Is(err, errorsutil.ErrNotFound) {
+			responseutil.ResponseNotFound(w, r, "API key not found")
+			return
+		}
+
+		responseutil.ResponseError(w, r, err)
+		return
+	}
+
+	responseutil.ResponseOK(w, r, &responseutil.ResponseSuccess{
+		Data: result,
+	})
+}
+
+// Revoke - revoke API key http handler
+func (h *HTTPHandlerImpl) Revoke(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	err := h.service.Revoke(r.Context(), auth.UserID(r), id)
+	if err != nil {
+		if errors.Is(err, errorsutil.ErrNotFound) {
+			responseutil.ResponseNotFound(w, r, "API key not found")
+			return
+		}
+
+		responseutil.ResponseError(w, r, err)
+		return
+	}
+
+	responseutil.ResponseOK(w, r, &responseutil.ResponseSuccess{
+		Data: responseutil.H{
+			"id": id,
+		},
+	})
+}
@@ -0,0 +1,143 @@
+package httpdelivery_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	apikeydelivery "go-clean-architecture/apikey/delivery/http"
+	"go-clean-architecture/apikey/models"
+	"go-clean-architecture/pkg/auth"
+	pkgvalidator "go-clean-architecture/pkg/validator"
+	errorsutil "go-clean-architecture/utils/errors"
+
+	mockservice "go-clean-architecture/apikey/mocks/service"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var user = &auth.Principal{Subject: "user-1"}
+
+func serve(handler http.Handler, principal *auth.Principal, method string, target string, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if principal != nil {
+		req = req.WithContext(auth.NewContext(req.Context(), principal))
+	}
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	return rr
+}
+
+func newRouter(service *mockservice.Service) chi.Router {
+	router := chi.NewMux()
+	apikeydelivery.New(service).RegisterRoutes(router)
+	return router
+}
+
+func TestAPIKeyRoutes(t *testing.T) {
+	t.Run("when return 401 unauthorized (anonymous)", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+
+		rr := serve(newRouter(mockService), nil, http.MethodGet, "/apikeys", "")
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		mockService.AssertExpectations(t)
+	})
+	t.Run("when return 403 forbidden (called with an API key)", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+
+		rr := serve(newRouter(mockService), &auth.Principal{Subject: "user-1", KeyID: "key-1", Scopes: []string{}}, http.MethodGet, "/apikeys", "")
+
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestAPIKeyCreate(t *testing.T) {
+	t.Run("when return 413 request entity too large", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+
+		rr := serve(newRouter(mockService), user, http.MethodPost, "/apikeys", `{"name":"`+strings.Repeat("a", 32<<10)+`"}`)
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+		mockService.AssertExpectations(t)
+	})
+	t.Run("when return 400 bad request (unknown field)", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+
+		rr := serve(newRouter(mockService), user, http.MethodPost, "/apikeys", `{"name":"ci","scopes":["todo:read"],"owner_id":"user-2"}`)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockService.AssertExpectations(t)
+	})
+	t.Run("when return 400 bad request (unknown scope)", func(t *testing.T) {
+		pkgvalidator.New()
+		mockService := new(mockservice.Service)
+
+		rr := serve(newRouter(mockService), user, http.MethodPost, "/apikeys", `{"name":"ci","scopes":["todo:admin"]}`)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockService.AssertExpectations(t)
+	})
+	t.Run("when return 201 created", func(t *testing.T) {
+		pkgvalidator.New()
+		mockService := new(mockservice.Service)
+		mockService.On("Create", mock.Anything, &models.APIKey{Name: "ci", OwnerID: "user-1", Scopes: []string{"todo:read"}}).
+			Return(&models.CreatedAPIKey{APIKey: &models.APIKey{Name: "ci", KeyHash: "secret-hash"}, Key: "gca_key"}, nil)
+
+		rr := serve(newRouter(mockService), user, http.MethodPost, "/apikeys", `{"name":"ci","scopes":["todo:read"]}`)
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		assert.Contains(t, rr.Body.String(), "gca_key")
+		assert.NotContains(t, rr.Body.String(), "secret-hash")
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestAPIKeyRotate(t *testing.T) {
+	t.Run("when return 404 not found", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+		mockService.On("Rotate", mock.Anything, "user-1", "key-1").Return(nil, errorsutil.ErrNotFound)
+
+		rr := serve(newRouter(mockService), user, http.MethodPost, "/apikeys/key-1/rotate", "")
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		mockService.AssertExpectations(t)
+	})
+	t.Run("when return 200 ok", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+		mockService.On("Rotate", mock.Anything, "user-1", "key-1").Return(&models.CreatedAPIKey{APIKey: &models.APIKey{}, Key: "gca_new"}, nil)
+
+		rr := serve(newRouter(mockService), user, http.MethodPost, "/apikeys/key-1/rotate", "")
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), "gca_new")
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestAPIKeyRevoke(t *testing.T) {
+	t.Run("when return 404 not found", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+		mockService.On("Revoke", mock.Anything, "user-1", "key-1").Return(errorsutil.ErrNotFound)
+
+		rr := serve(newRouter(mockService), user, http.MethodDelete, "/apikeys/key-1", "")
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		mockService.AssertExpectations(t)
+	})
+	t.Run("when return 200 ok", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+		mockService.On("Revoke", mock.Anything, "user-1", "key-1").Return(nil)
+
+		rr := serve(newRouter(mockService), user, http.MethodDelete, "/apikeys/key-1", "")
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		mockService.AssertExpectations(t)
+	})
+}
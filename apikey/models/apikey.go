@@ -0,0 +1,39 @@
+package models
+
+import (
+	pkgvalidator "go-clean-architecture/pkg/validator"
+	"net/http"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// APIKey - API key model, only the sha256 of the key is stored
+type APIKey struct {
+	ID   primitive.ObjectID `json:"id" xml:"id" bson:"_id,omitempty"`
+	Name string             `json:"name" xml:"name" bson:"name"`
+	// Prefix - first characters of the key, shown so owners can tell their keys apart
+	Prefix     string     `json:"prefix" xml:"prefix" bson:"prefix"`
+	KeyHash    string     `json:"-" xml:"-" bson:"keyHash"`
+	OwnerID    string     `json:"owner_id" xml:"owner_id" bson:"ownerId"`
+	Scopes     []string   `json:"scopes" xml:"scopes>scope" bson:"scopes"`
+	CreatedAt  time.Time  `json:"created_at" xml:"created_at" bson:"createdAt"`
+	LastUsedAt *time.Time `json:"last_used_at" xml:"last_used_at,omitempty" bson:"lastUsedAt,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at" xml:"revoked_at,omitempty" bson:"revokedAt,omitempty"`
+}
+
+// CreatedAPIKey - API key with its plain value, returned once by create and rotate
+type CreatedAPIKey struct {
+	*APIKey
+	Key string `json:"key" xml:"key"`
+}
+
+// APIKeyRequest - API key request
+type APIKeyRequest struct {
+	Name   string   `form:"name" json:"name" validate:"required,max=255"`
+	Scopes []string `form:"scopes" json:"scopes" validate:"required,min=1,dive,oneof=todo:read todo:write"`
+}
+
+func (ar *APIKeyRequest) Bind(r *http.Request) error {
+	return pkgvalidator.ValidateStruct(ar)
+}
@@ -0,0 +1,232 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"go-clean-architecture/apikey/models"
+	"go-clean-architecture/pkg/logger"
+	pkgmongodb "go-clean-architecture/pkg/mongodb"
+	errorsutil "go-clean-architecture/utils/errors"
+	timeutil "go-clean-architecture/utils/time"
+)
+
+type Repository interface {
+	// FindAll - keys of ownerID, revoked ones included
+	FindAll(ctx context.Context, ownerID string) ([]*models.APIKey, error)
+	// FindByHash - key that is not revoked by its hash
+	FindByHash(ctx context.Context, keyHash string) (*models.APIKey, error)
+	Store(ctx context.Context, value *models.APIKey) (*models.APIKey, error)
+	// Rotate - replace the hash of a key of ownerID that is not revoked
+	Rotate(ctx context.Context, ownerID string, id string, keyHash string, prefix string) (*models.APIKey, error)
+	Revoke(ctx context.Context, ownerID string, id string) error
+	TouchLastUsed(ctx context.Context, id primitive.ObjectID, at time.Time) error
+}
+
+type RepositoryImpl struct {
+	databases *pkgmongodb.Databases
+	log       logger.Logger
+}
+
+// New will create an object that represent the Repository interface
+func New(databases *pkgmongodb.Databases) Repository {
+	return &RepositoryImpl{
+		databases: databases,
+		log:       logger.Named("apikey/repository"),
+	}
+}
+
+// collection - API key collection of the database of the tenant of ctx
+func (r *RepositoryImpl) collection(ctx context.Context) (*mongo.Collection, error) {
+	db, err := r.databases.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return db.Collection("api_key"), nil
+}
+
+// Indexes - create API key indexes, provisions every database
+func Indexes(ctx context.Context, db *mongo.Database) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	_, err := db.Collection("api_key").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "keyHash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "ownerId", Value: 1}}},
+	})
+	return err
+}
+
+// FindAll - find all API keys of owner
+func (r *RepositoryImpl) FindAll(ctx context.Context, ownerID string) ([]*models.APIKey, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	collection, err := r.collection(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cur, err := collection.Find(ctx, bson.M{"ownerId": ownerID}, options.Find().SetSort(bson.M{"createdAt": -1}))
+	if err != nil {
+		r.logError(ctx, "FindAll", err)
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	results := []*models.APIKey{}
+	if err := cur.All(ctx, &results); err != nil {
+		r.logError(ctx, "FindAll", err)
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// FindByHash - find active API key by hash
+func (r *RepositoryImpl) FindByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result := &models.APIKey{}
+	collection, err := r.collection(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = collection.FindOne(ctx, bson.M{"keyHash": keyHash, "revokedAt": bson.M{"$exists": false}}).Decode(result)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errorsutil.ErrNotFound
+		}
+
+		r.logError(ctx, "FindByHash", err)
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Store - store API key
+func (r *RepositoryImpl) Store(ctx context.Context, value *models.APIKey) (*models.APIKey, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result := &models.APIKey{
+		Name:      value.Name,
+		Prefix:    value.Prefix,
+		KeyHash:   value.KeyHash,
+		OwnerID:   value.OwnerID,
+		Scopes:    value.Scopes,
+		CreatedAt: timeutil.GetTimeNow(),
+	}
+
+	collection, err := r.collection(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := collection.InsertOne(ctx, result)
+	if err != nil {
+		r.logError(ctx, "Store", err)
+		return nil, err
+	}
+	result.ID = res.InsertedID.(primitive.ObjectID)
+
+	return result, nil
+}
+
+// Rotate - replace the key of an active API key
+func (r *RepositoryImpl) Rotate(ctx context.Context, ownerID string, id string, keyHash string, prefix string) (*models.APIKey, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	docID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errorsutil.ErrNotFound
+	}
+
+	result := &models.APIKey{}
+	collection, err := r.collection(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": docID, "ownerId": ownerID, "revokedAt": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"keyHash": keyHash, "prefix": prefix}, "$unset": bson.M{"lastUsedAt": ""}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(result)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errorsutil.ErrNotFound
+		}
+
+		r.logError(ctx, "Rotate", err)
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Revoke - revoke an active API key
+func (r *RepositoryImpl) Revoke(ctx context.Context, ownerID string, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	docID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errorsutil.ErrNotFound
+	}
+
+	collection, err := r.collection(ctx)
+	if err != nil {
+		return err
+	}
+
+	res, err := collection.UpdateOne(ctx,
+		bson.M{"_id": docID, "ownerId": ownerID, "revokedAt": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revokedAt": timeutil.GetTimeNow()}},
+	)
+	if err != nil {
+		r.logError(ctx, "Revoke", err)
+		return err
+	}
+
+	if res.MatchedCount <= 0 {
+		return errorsutil.ErrNotFound
+	}
+
+	return nil
+}
+
+// TouchLastUsed - record when a key was last used
+func (r *RepositoryImpl) TouchLastUsed(ctx context.Context, id primitive.ObjectID, at time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	collection, err := r.collection(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"lastUsedAt": at}})
+	if err != nil {
+		r.logError(ctx, "TouchLastUsed", err)
+		return err
+	}
+
+	return nil
+}
+
+// logError - log a database error with the request context of ctx
+func (r *RepositoryImpl) logError(ctx context.Context, operation string, err error) {
+	r.log.WithContext(ctx).Error("database operation failed", "operation", operation, "error", err)
+}
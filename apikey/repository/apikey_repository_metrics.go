@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"go-clean-architecture/apikey/models"
+	pkgmetrics "go-clean-architecture/pkg/metrics"
+)
+
+type MetricsRepository struct {
+	next Repository
+}
+
+// WithMetrics will wrap a Repository and record the latency of every method
+func WithMetrics(next Repository) Repository {
+	return &MetricsRepository{
+		next: next,
+	}
+}
+
+// FindAll - find all API keys of owner
+func (r *MetricsRepository) FindAll(ctx context.Context, ownerID string) (res []*models.APIKey, err error) {
+	defer pkgmetrics.ObserveRepository("apikey", "FindAll", time.Now(), &err)
+
+	return r.next.FindAll(ctx, ownerID)
+}
+
+// FindByHash - find active API key by hash
+func (r *MetricsRepository) FindByHash(ctx context.Context, keyHash string) (res *models.APIKey, err error) {
+	defer pkgmetrics.ObserveRepository("apikey", "FindByHash", time.Now(), &err)
+
+	return r.next.FindByHash(ctx, keyHash)
+}
+
+// Store - store API key
+func (r *MetricsRepository) Store(ctx context.Context, value *models.APIKey) (res *models.APIKey, err error) {
+	defer pkgmetrics.ObserveRepository("apikey", "Store", time.Now(), &err)
+
+	return r.next.Store(ctx, value)
+}
+
+// Rotate - replace the key of an active API key
+func (r *MetricsRepository) Rotate(ctx context.Context, ownerID string, id string, keyHash string, prefix string) (res *models.APIKey, err error) {
+	defer pkgmetrics.ObserveRepository("apikey", "Rotate", time.Now(), &err)
+
+	return r.next.Rotate(ctx, ownerID, id, keyHash, prefix)
+}
+
+// Revoke - revoke an active API key
+func (r *MetricsRepository) Revoke(ctx context.Context, ownerID string, id string) (err error) {
+	defer pkgmetrics.ObserveRepository("apikey", "Revoke", time.Now(), &err)
+
+	return r.next.Revoke(ctx, ownerID, id)
+}
+
+// TouchLastUsed - record when a key was last used
+func (r *MetricsRepository) TouchLastUsed(ctx context.Context, id primitive.ObjectID, at time.Time) (err error) {
+	defer pkgmetrics.ObserveRepository("apikey", "TouchLastUsed", time.Now(), &err)
+
+	return r.next.TouchLastUsed(ctx, id, at)
+}
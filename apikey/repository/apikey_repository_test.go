@@ -0,0 +1,108 @@
+package repository_test
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"testing"
+
+	"go-clean-architecture/apikey/models"
+	"go-clean-architecture/apikey/repository"
+	pkgmongodb "go-clean-architecture/pkg/mongodb"
+	errorsutil "go-clean-architecture/utils/errors"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestMain(m *testing.M) {
+	// See todo/repository: mtest needs a cluster, skip in short mode
+	flag.Parse()
+	if testing.Short() {
+		log.Print("skipping mtest integration test in short mode")
+		return
+	}
+
+	if err := mtest.Setup(); err != nil {
+		log.Fatal(err)
+	}
+	defer os.Exit(m.Run())
+	if err := mtest.Teardown(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func TestAPIKeyStore(t *testing.T) {
+	os.Setenv("DB_NAME", "test")
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("when success", func(mt *mtest.T) {
+		repo := repository.New(pkgmongodb.NewDatabases(mt.Client, "test"))
+		mt.AddMockResponses(mtest.CreateSuccessResponse())
+
+		result, err := repo.Store(context.Background(), &models.APIKey{Name: "ci", KeyHash: "hash", OwnerID: "user-1"})
+
+		assert.NoError(mt, err)
+		assert.False(mt, result.ID.IsZero())
+		assert.False(mt, result.CreatedAt.IsZero())
+	})
+}
+
+func TestAPIKeyFindByHash(t *testing.T) {
+	os.Setenv("DB_NAME", "test")
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("when success", func(mt *mtest.T) {
+		repo := repository.New(pkgmongodb.NewDatabases(mt.Client, "test"))
+		mt.AddMockResponses(mtest.CreateCursorResponse(1, "test.api_key", mtest.FirstBatch, bson.D{
+			{Key: "_id", Value: primitive.NewObjectID()},
+			{Key: "keyHash", Value: "hash"},
+			{Key: "ownerId", Value: "user-1"},
+		}))
+
+		result, err := repo.FindByHash(context.Background(), "hash")
+
+		assert.NoError(mt, err)
+		assert.Equal(mt, "user-1", result.OwnerID)
+	})
+
+	mt.Run("when not found", func(mt *mtest.T) {
+		repo := repository.New(pkgmongodb.NewDatabases(mt.Client, "test"))
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "test.api_key", mtest.FirstBatch))
+
+		_, err := repo.FindByHash(context.Background(), "hash")
+
+		assert.ErrorIs(mt, err, errorsutil.ErrNotFound)
+	})
+}
+
+func TestAPIKeyRevoke(t *testing.T) {
+	os.Setenv("DB_NAME", "test")
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("when not found", func(mt *mtest.T) {
+		repo := repository.New(pkgmongodb.NewDatabases(mt.Client, "test"))
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 0}, {Key: "nModified", Value: 0}})
+
+		err := repo.Revoke(context.Background(), "user-1", primitive.NewObjectID().Hex())
+
+		assert.ErrorIs(mt, err, errorsutil.ErrNotFound)
+	})
+
+	mt.Run("when id is invalid", func(mt *mtest.T) {
+		repo := repository.New(pkgmongodb.NewDatabases(mt.Client, "test"))
+
+		err := repo.Revoke(context.Background(), "user-1", "not-an-id")
+
+		assert.ErrorIs(mt, err, errorsutil.ErrNotFound)
+	})
+}
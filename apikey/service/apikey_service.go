@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"go-clean-architecture/apikey/models"
+	apikeyrepository "go-clean-architecture/apikey/repository"
+	"go-clean-architecture/pkg/auth"
+	"go-clean-architecture/pkg/logger"
+	userrepository "go-clean-architecture/user/repository"
+	errorsutil "go-clean-architecture/utils/errors"
+	timeutil "go-clean-architecture/utils/time"
+)
+
+// lastUsedResolution - last used timestamps are written at most this often per key
+const lastUsedResolution = time.Minute
+
+// prefixLength - characters of the key kept as its visible prefix
+const prefixLength = len(auth.APIKeyPrefix) + 6
+
+// Service represent the API key service
+type Service interface {
+	GetAll(ctx context.Context, ownerID string) ([]*models.APIKey, error)
+	// Create - generate a key, its plain value is only returned here
+	Create(ctx context.Context, value *models.APIKey) (*models.CreatedAPIKey, error)
+	// Rotate - replace the key, the previous value stops working immediately
+	Rotate(ctx context.Context, ownerID string, id string) (*models.CreatedAPIKey, error)
+	Revoke(ctx context.Context, ownerID string, id string) error
+	// Authenticate - principal of a valid key, acting as its owner with the owner's current roles and the key's scopes
+	Authenticate(ctx context.Context, key string) (*auth.Principal, error)
+}
+
+type ServiceImpl struct {
+	repository apikeyrepository.Repository
+	users      userrepository.Repository
+	log        logger.Logger
+}
+
+// New will create new an ServiceImpl object representation of Service interface, the roles of keys are
+// looked up in users
+func New(repository apikeyrepository.Repository, users userrepository.Repository) Service {
+	return &ServiceImpl{
+		repository: repository,
+		users:      users,
+		log:        logger.Named("apikey/service"),
+	}
+}
+
+// GetAll - get all API keys of owner service
+func (s *ServiceImpl) GetAll(ctx context.Context, ownerID string) ([]*models.APIKey, error) {
+	return s.repository.FindAll(ctx, ownerID)
+}
+
+// Create - create API key service
+func (s *ServiceImpl) Create(ctx context.Context, value *models.APIKey) (*models.CreatedAPIKey, error) {
+	key, err := generateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := s.repository.Store(ctx, &models.APIKey{
+		Name:    value.Name,
+		Prefix:  key[:prefixLength],
+		KeyHash: hashKey(key),
+		OwnerID: value.OwnerID,
+		Scopes:  value.Scopes,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.CreatedAPIKey{APIKey: res, Key: key}, nil
+}
+
+// Rotate - rotate API key service
+func (s *ServiceImpl) Rotate(ctx context.Context, ownerID string, id string) (*models.CreatedAPIKey, error) {
+	key, err := generateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := s.repository.Rotate(ctx, ownerID, id, hashKey(key), key[:prefixLength])
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.CreatedAPIKey{APIKey: res, Key: key}, nil
+}
+
+// Revoke - revoke API key service
+func (s *ServiceImpl) Revoke(ctx context.Context, ownerID string, id string) error {
+	return s.repository.Revoke(ctx, ownerID, id)
+}
+
+// Authenticate - look the key up by hash and record its use
+func (s *ServiceImpl) Authenticate(ctx context.Context, key string) (*auth.Principal, error) {
+	res, err := s.repository.FindByHash(ctx, hashKey(key))
+	if err != nil {
+		if errors.Is(err, errorsutil.ErrNotFound) {
+			return nil, errorsutil.ErrUnauthorized
+		}
+		return nil, err
+	}
+
+	// Roles are those of the owner now, a demoted owner's keys lose the roles they lost and a deleted
+	// owner's keys stop working
+	owner, err := s.users.FindByID(ctx, res.OwnerID)
+	if err != nil {
+		if errors.Is(err, errorsutil.ErrNotFound) {
+			return nil, errorsutil.ErrUnauthorized
+		}
+		return nil, err
+	}
+
+	now := timeutil.GetTimeNow()
+	if res.LastUsedAt == nil || now.Sub(*res.LastUsedAt) >= lastUsedResolution {
+		// A failed bookkeeping write must not fail the request
+		if err := s.repository.TouchLastUsed(ctx, res.ID, now); err != nil {
+			s.log.WithContext(ctx).Warn("recording API key use failed", "key_id", res.ID.Hex(), "error", err)
+		}
+	}
+
+	scopes := res.Scopes
+	if scopes == nil {
+		scopes = []string{}
+	}
+
+	return &auth.Principal{
+		Subject: res.OwnerID,
+		KeyID:   res.ID.Hex(),
+		Scopes:  scopes,
+		Roles:   owner.Roles,
+	}, nil
+}
+
+func generateKey() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return auth.APIKeyPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashKey - keys have 256 bits of entropy, a plain sha256 is enough to make stored hashes useless
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
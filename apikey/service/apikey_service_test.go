@@ -0,0 +1,164 @@
+package service_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+
+	mockrepository "go-clean-architecture/apikey/mocks/repository"
+	"go-clean-architecture/apikey/models"
+	apikeyservice "go-clean-architecture/apikey/service"
+	"go-clean-architecture/pkg/auth"
+	mockuserrepository "go-clean-architecture/user/mocks/repository"
+	usermodels "go-clean-architecture/user/models"
+	errorsutil "go-clean-architecture/utils/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func hash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestAPIKeyCreate(t *testing.T) {
+	t.Run("success when create", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := apikeyservice.New(mockRepository, new(mockuserrepository.Repository))
+
+		var stored *models.APIKey
+		mockRepository.On("Store", mock.Anything, mock.AnythingOfType("*models.APIKey")).
+			Run(func(args mock.Arguments) { stored = args.Get(1).(*models.APIKey) }).
+			Return(func(ctx context.Context, value *models.APIKey) *models.APIKey { return value }, nil)
+
+		result, err := service.Create(context.Background(), &models.APIKey{Name: "ci", OwnerID: "user-1", Scopes: []string{auth.ScopeTodoRead}})
+
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(result.Key, auth.APIKeyPrefix))
+		assert.True(t, strings.HasPrefix(result.Key, stored.Prefix))
+		assert.Equal(t, hash(result.Key), stored.KeyHash)
+		assert.NotContains(t, stored.KeyHash, result.Key)
+		assert.Equal(t, "user-1", stored.OwnerID)
+		mockRepository.AssertExpectations(t)
+	})
+}
+
+func TestAPIKeyRotate(t *testing.T) {
+	t.Run("success when rotate", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := apikeyservice.New(mockRepository, new(mockuserrepository.Repository))
+
+		mockRepository.On("Rotate", mock.Anything, "user-1", "key-1", mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(&models.APIKey{Name: "ci"}, nil)
+
+		result, err := service.Rotate(context.Background(), "user-1", "key-1")
+
+		assert.NoError(t, err)
+		assert.Equal(t, hash(result.Key), mockRepository.Calls[0].Arguments.String(3))
+		mockRepository.AssertExpectations(t)
+	})
+
+	t.Run("error when key is not found", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := apikeyservice.New(mockRepository, new(mockuserrepository.Repository))
+
+		mockRepository.On("Rotate", mock.Anything, "user-1", "key-1", mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(nil, errorsutil.ErrNotFound)
+
+		_, err := service.Rotate(context.Background(), "user-1", "key-1")
+
+		assert.ErrorIs(t, err, errorsutil.ErrNotFound)
+		mockRepository.AssertExpectations(t)
+	})
+}
+
+func TestAPIKeyAuthenticate(t *testing.T) {
+	id := primitive.NewObjectID()
+
+	t.Run("success when key is valid", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		mockUserRepository := new(mockuserrepository.Repository)
+		service := apikeyservice.New(mockRepository, mockUserRepository)
+
+		mockRepository.On("FindByHash", mock.Anything, hash("gca_key")).Return(&models.APIKey{ID: id, OwnerID: "user-1", Scopes: []string{auth.ScopeTodoRead}}, nil)
+		mockUserRepository.On("FindByID", mock.Anything, "user-1").Return(&usermodels.User{Roles: []string{"admin"}}, nil)
+		mockRepository.On("TouchLastUsed", mock.Anything, id, mock.AnythingOfType("time.Time")).Return(nil)
+
+		principal, err := service.Authenticate(context.Background(), "gca_key")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "user-1", principal.Subject)
+		assert.Equal(t, id.Hex(), principal.KeyID)
+		assert.True(t, principal.HasScope(auth.ScopeTodoRead))
+		assert.False(t, principal.HasScope(auth.ScopeTodoWrite))
+		assert.Equal(t, []string{"admin"}, principal.Roles)
+		mockRepository.AssertExpectations(t)
+		mockUserRepository.AssertExpectations(t)
+	})
+
+	t.Run("success when owner was demoted after creating the key", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		mockUserRepository := new(mockuserrepository.Repository)
+		service := apikeyservice.New(mockRepository, mockUserRepository)
+
+		lastUsedAt := time.Now()
+		mockRepository.On("FindByHash", mock.Anything, hash("gca_key")).Return(&models.APIKey{ID: id, OwnerID: "user-1", LastUsedAt: &lastUsedAt}, nil)
+		mockUserRepository.On("FindByID", mock.Anything, "user-1").Return(&usermodels.User{Roles: []string{"viewer"}}, nil)
+
+		principal, err := service.Authenticate(context.Background(), "gca_key")
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"viewer"}, principal.Roles)
+		assert.False(t, principal.HasRole("admin"))
+		mockRepository.AssertExpectations(t)
+		mockUserRepository.AssertExpectations(t)
+	})
+
+	t.Run("success when key was used recently", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		mockUserRepository := new(mockuserrepository.Repository)
+		service := apikeyservice.New(mockRepository, mockUserRepository)
+
+		lastUsedAt := time.Now()
+		mockRepository.On("FindByHash", mock.Anything, hash("gca_key")).Return(&models.APIKey{ID: id, OwnerID: "user-1", LastUsedAt: &lastUsedAt}, nil)
+		mockUserRepository.On("FindByID", mock.Anything, "user-1").Return(&usermodels.User{}, nil)
+
+		principal, err := service.Authenticate(context.Background(), "gca_key")
+
+		assert.NoError(t, err)
+		assert.False(t, principal.HasScope(auth.ScopeTodoRead))
+		mockRepository.AssertNotCalled(t, "TouchLastUsed", mock.Anything, mock.Anything, mock.Anything)
+		mockRepository.AssertExpectations(t)
+	})
+
+	t.Run("error when key is unknown or revoked", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := apikeyservice.New(mockRepository, new(mockuserrepository.Repository))
+
+		mockRepository.On("FindByHash", mock.Anything, hash("gca_key")).Return(nil, errorsutil.ErrNotFound)
+
+		_, err := service.Authenticate(context.Background(), "gca_key")
+
+		assert.ErrorIs(t, err, errorsutil.ErrUnauthorized)
+		mockRepository.AssertExpectations(t)
+	})
+
+	t.Run("error when owner no longer exists", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		mockUserRepository := new(mockuserrepository.Repository)
+		service := apikeyservice.New(mockRepository, mockUserRepository)
+
+		mockRepository.On("FindByHash", mock.Anything, hash("gca_key")).Return(&models.APIKey{ID: id, OwnerID: "user-1"}, nil)
+		mockUserRepository.On("FindByID", mock.Anything, "user-1").Return(nil, errorsutil.ErrNotFound)
+
+		_, err := service.Authenticate(context.Background(), "gca_key")
+
+		assert.ErrorIs(t, err, errorsutil.ErrUnauthorized)
+		mockRepository.AssertNotCalled(t, "TouchLastUsed", mock.Anything, mock.Anything, mock.Anything)
+		mockRepository.AssertExpectations(t)
+		mockUserRepository.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+
+	"go-clean-architecture/apikey/models"
+	"go-clean-architecture/pkg/auth"
+	pkgtracing "go-clean-architecture/pkg/tracing"
+)
+
+type TracingService struct {
+	next Service
+}
+
+// WithTracing will wrap a Service and start a span for every method
+func WithTracing(next Service) Service {
+	return &TracingService{
+		next: next,
+	}
+}
+
+// GetAll - get all API keys of owner service
+func (s *TracingService) GetAll(ctx context.Context, ownerID string) (res []*models.APIKey, err error) {
+	ctx, span := pkgtracing.Start(ctx, "apikey.Service/GetAll")
+	defer pkgtracing.End(span, &err)
+
+	return s.next.GetAll(ctx, ownerID)
+}
+
+// Create - create API key service
+func (s *TracingService) Create(ctx context.Context, value *models.APIKey) (res *models.CreatedAPIKey, err error) {
+	ctx, span := pkgtracing.Start(ctx, "apikey.Service/Create")
+	defer pkgtracing.End(span, &err)
+
+	return s.next.Create(ctx, value)
+}
+
+// Rotate - rotate API key service
+func (s *TracingService) Rotate(ctx context.Context, ownerID string, id string) (res *models.CreatedAPIKey, err error) {
+	ctx, span := pkgtracing.Start(ctx, "apikey.Service/Rotate")
+	defer pkgtracing.End(span, &err)
+
+	return s.next.Rotate(ctx, ownerID, id)
+}
+
+// Revoke - revoke API key service
+func (s *TracingService) Revoke(ctx context.Context, ownerID string, id string) (err error) {
+	ctx, span := pkgtracing.Start(ctx, "apikey.Service/Revoke")
+	defer pkgtracing.End(span, &err)
+
+	return s.next.Revoke(ctx, ownerID, id)
+}
+
+// Authenticate - authenticate API key service
+func (s *TracingService) Authenticate(ctx context.Context, key string) (res *auth.Principal, err error) {
+	ctx, span := pkgtracing.Start(ctx, "apikey.Service/Authenticate")
+	defer pkgtracing.End(span, &err)
+
+	return s.next.Authenticate(ctx, key)
+}
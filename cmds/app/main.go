@@ -1,30 +1,65 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/render"
-	"github.com/sirupsen/logrus"
 
+	apikeyhttpdelivery "go-clean-architecture/apikey/delivery/http"
+	apikeyrepository "go-clean-architecture/apikey/repository"
+	apikeyservice "go-clean-architecture/apikey/service"
+	"go-clean-architecture/pkg/auth"
 	"go-clean-architecture/pkg/config"
+	"go-clean-architecture/pkg/cors"
+	"go-clean-architecture/pkg/grpcserver"
+	"go-clean-architecture/pkg/health"
+	"go-clean-architecture/pkg/loadshed"
 	"go-clean-architecture/pkg/logger"
+	pkgmetrics "go-clean-architecture/pkg/metrics"
 	pkgmongodb "go-clean-architecture/pkg/mongodb"
+	"go-clean-architecture/pkg/policy"
+	"go-clean-architecture/pkg/ratelimit"
+	"go-clean-architecture/pkg/requestid"
+	"go-clean-architecture/pkg/secure"
+	"go-clean-architecture/pkg/server"
+	"go-clean-architecture/pkg/tenant"
+	pkgtracing "go-clean-architecture/pkg/tracing"
 	pkgvalidator "go-clean-architecture/pkg/validator"
+	todographqldelivery "go-clean-architecture/todo/delivery/graphql"
+	todogrpcdelivery "go-clean-architecture/todo/delivery/grpc"
 	todohttpdelivery "go-clean-architecture/todo/delivery/http"
+	todostreamdelivery "go-clean-architecture/todo/delivery/stream"
 	todorepository "go-clean-architecture/todo/repository"
 	todoservice "go-clean-architecture/todo/service"
+	usagehttpdelivery "go-clean-architecture/usage/delivery/http"
+	usagerepository "go-clean-architecture/usage/repository"
+	usageservice "go-clean-architecture/usage/service"
+	userhttpdelivery "go-clean-architecture/user/delivery/http"
+	userrepository "go-clean-architecture/user/repository"
+	userservice "go-clean-architecture/user/service"
+	requestutil "go-clean-architecture/utils/request"
 	responseutil "go-clean-architecture/utils/response"
+	webhookhttpdelivery "go-clean-architecture/webhook/delivery/http"
+	webhookrepository "go-clean-architecture/webhook/repository"
+	webhookservice "go-clean-architecture/webhook/service"
 )
 
 func Routes() *chi.Mux {
 	router := chi.NewRouter()
 	router.Use(
-		render.SetContentType(render.ContentTypeJSON), // Set content-Type headers as application/json
-		middleware.Logger, // Log API request calls
+		requestid.Middleware,                       // Accept or generate X-Request-ID
+		pkgtracing.Middleware,                      // Start a span per request
+		pkgmetrics.Middleware,                      // Record Prometheus request metrics
+		logger.RequestLogger(logger.Named("http")), // Log API request calls
 		// middleware.DefaultCompress, // Compress results, mostly gzipping assets and json
 		middleware.RedirectSlashes, // Redirect slashes to no slash URL versions
 		middleware.Recoverer,       // Recover from panics without crashing server
@@ -36,7 +71,7 @@ func Routes() *chi.Mux {
 // PrintAllRoutes - printing all routes
 func PrintAllRoutes(router *chi.Mux) {
 	walkFunc := func(method string, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
-		logrus.Printf("%s %s\n", method, route) // Walk and print out all routes
+		logger.Named("router").Info("Route registered", "method", method, "route", route) // Walk and print out all routes
 		return nil
 	}
 	if err := chi.Walk(router, walkFunc); err != nil {
@@ -53,12 +88,69 @@ func main() {
 		logger.Error(err)
 	}
 
+	// Logger
+	log, err := logger.New(logger.LoadConfig())
+	if err != nil {
+		logger.Error(err)
+	} else {
+		logger.SetDefault(log)
+	}
+
+	// Error response format
+	responseutil.SetErrorFormat(os.Getenv("ERROR_FORMAT"))
+	responseutil.SetProblemTypeBase(os.Getenv("PROBLEM_TYPE_BASE"))
+
+	// Body limit of routes that set none of their own
+	requestutil.SetDefaultMaxBodySize(int64(config.GetInt("REQUEST_MAX_BODY_SIZE", 1<<20)))
+
+	// Init tracing, before MongoDB so the command monitor uses the configured provider
+	shutdownTracing, err := pkgtracing.Init(context.Background())
+	if err != nil {
+		logger.Error(err)
+	}
+
 	// Init MongoDB
 	_, cancel, client := pkgmongodb.InitMongoDB()
 	defer cancel()
 
 	router := Routes()
 
+	// Security headers and CORS, ahead of load shedding and rate limiting so browsers can read their rejections
+	router.Use(secure.Headers(secure.LoadConfig()))
+	corsConfig := cors.LoadConfig()
+	if corsConfig.Enabled() {
+		corsHandler, err := cors.New(corsConfig)
+		if err != nil {
+			logger.Error(err)
+			os.Exit(1)
+		}
+		router.Use(corsHandler.Handler)
+	}
+
+	// Load shedding, health checks and metrics are never shed
+	loadShedConfig := loadshed.LoadConfig()
+	concurrencyLimit, err := loadshed.NewLimit(loadShedConfig)
+	if err != nil {
+		logger.Error(err)
+	}
+	// The gRPC server sheds with the same shedder, both serve from the same capacity
+	var shedder *loadshed.Shedder
+	if concurrencyLimit != nil {
+		// Live change feeds stay open, they are limited by EVENTS_MAX_CONNECTIONS instead
+		classify := loadshed.Exempt(loadshed.ByMethod("/healthz", "/readyz", "/metrics"), "/todo/events", "/todo/events/ws")
+		shedder = loadshed.New(loadShedConfig, concurrencyLimit, classify)
+		router.Use(shedder.Middleware)
+	}
+
+	// Health
+	healthRegistry := health.New(config.GetDuration("HEALTH_CACHE_TTL", 2*time.Second))
+	healthRegistry.Register("mongodb", config.GetDuration("HEALTH_CHECK_TIMEOUT", 2*time.Second), pkgmongodb.Ping(client))
+	router.Get("/healthz", healthRegistry.LivenessHandler)
+	router.Get("/readyz", healthRegistry.ReadinessHandler)
+
+	// Metrics
+	router.Handle("/metrics", pkgmetrics.Handler())
+
 	router.Get("/", func(w http.ResponseWriter, r *http.Request) {
 		render.JSON(w, r, responseutil.H{
 			"success": "true",
@@ -67,21 +159,273 @@ func main() {
 		})
 	})
 
-	// Repository
-	todoRepo := todorepository.New(client)
+	// Tokens
+	authConfig := auth.LoadConfig()
+	issuer, err := auth.NewIssuer(authConfig)
+	if err != nil {
+		logger.Error(err)
+		os.Exit(1)
+	}
+
+	// Access control, the log level role has to be a policy role so granting it keeps the todo permissions
+	policyConfig := policy.LoadConfig()
+	todoPolicy, err := policy.New(policyConfig)
+	if err != nil {
+		logger.Error(err)
+		os.Exit(1)
+	}
+	logLevelRole := config.GetString("LOG_LEVEL_ROLE", "admin")
+	if !policyConfig.Defines(logLevelRole) {
+		logger.Error(fmt.Errorf("LOG_LEVEL_ROLE %q is not a role of RBAC_ROLES", logLevelRole))
+		os.Exit(1)
+	}
+
+	// Repository, every tenant gets its own database provisioned on first use
+	databases := pkgmongodb.NewDatabases(client, os.Getenv("DB_NAME"), todorepository.Indexes, userrepository.Indexes, apikeyrepository.Indexes, usagerepository.Indexes, webhookrepository.Indexes)
+	if _, err := databases.Get(context.Background()); err != nil {
+		logger.Error(err)
+	}
+	todoRepo := todorepository.WithMetrics(todorepository.New(databases))
+	userRepo := userrepository.WithMetrics(userrepository.New(databases))
+	apikeyRepo := apikeyrepository.WithMetrics(apikeyrepository.New(databases))
+	usageRepo := usagerepository.WithMetrics(usagerepository.New(databases))
+	webhookRepo := webhookrepository.WithMetrics(webhookrepository.New(databases))
+
+	// Service, todo changes are published to in-process subscribers. With change streams every instance
+	// publishes the changes of every instance instead
+	todoEvents := todoservice.NewBroker(config.GetInt("EVENTS_HISTORY", 1000))
+	todoServiceEvents := todoEvents
+	eventsSource := config.GetString("EVENTS_SOURCE", "memory")
+	switch eventsSource {
+	case "memory":
+	case "changestream":
+		todoServiceEvents = todoservice.ReadOnly(todoEvents)
+	default:
+		logger.Error(fmt.Errorf("unsupported EVENTS_SOURCE %q", eventsSource))
+		os.Exit(1)
+	}
+	usageService := usageservice.WithTracing(usageservice.New(usageRepo, todoRepo))
+	todoService := todoservice.WithTracing(todoservice.New(todoRepo, todoPolicy, usageService, todoServiceEvents))
+	userService := userservice.WithTracing(userservice.New(userRepo, issuer, authConfig.RefreshTokenTTL))
+	apikeyService := apikeyservice.WithTracing(apikeyservice.New(apikeyRepo, userRepo))
+	webhookService := webhookservice.WithTracing(webhookservice.New(webhookRepo))
+	webhookConfig := webhookservice.LoadConfig()
 
-	// Service
-	todoService := todoservice.New(todoRepo)
+	// Rate limiting
+	rateLimitConfig := ratelimit.LoadConfig()
+	rateLimitStore, closeRateLimitStore, err := ratelimit.NewStore(rateLimitConfig)
+	if err != nil {
+		logger.Error(err)
+		rateLimitStore, closeRateLimitStore = ratelimit.NewMemoryStore(), func() error { return nil }
+	}
+	rateLimitKey, err := ratelimit.NewKeyFunc(rateLimitConfig, auth.UserID)
+	if err != nil {
+		logger.Error(err)
+		rateLimitKey = ratelimit.ByIP
+	}
+	limiter := ratelimit.New(rateLimitStore, rateLimitKey)
+
+	// A limit that does not parse would turn limiting off, only "off" does
+	todoReadLimit, err := ratelimit.ParseLimit(config.GetString("RATE_LIMIT_TODO_READ", "300/1m"))
+	if err != nil {
+		logger.Error(err)
+		os.Exit(1)
+	}
+	todoWriteLimit, err := ratelimit.ParseLimit(config.GetString("RATE_LIMIT_TODO_WRITE", "60/1m"))
+	if err != nil {
+		logger.Error(err)
+		os.Exit(1)
+	}
 
 	// Handler
 	todoHandler := todohttpdelivery.New(todoService)
-	todoHandler.RegisterRoutes(router)
+	todoGraphQLHandler, err := todographqldelivery.New(todoService, todographqldelivery.Limits{
+		MaxDepth:      config.GetInt("GRAPHQL_MAX_DEPTH", 10),
+		MaxComplexity: config.GetInt("GRAPHQL_MAX_COMPLEXITY", 500),
+	})
+	if err != nil {
+		logger.Error(err)
+		os.Exit(1)
+	}
+	todoStreamHandler := todostreamdelivery.New(todoService, todostreamdelivery.LoadConfig())
+	userHandler := userhttpdelivery.New(userService)
+	apikeyHandler := apikeyhttpdelivery.New(apikeyService)
+	usageHandler := usagehttpdelivery.New(usageService)
+	webhookHandler := webhookhttpdelivery.New(webhookService)
+	authResolver, err := auth.NewResolver(userService, apikeyService)
+	if err != nil {
+		logger.Error(err)
+		os.Exit(1)
+	}
+	tenantConfig := tenant.LoadConfig()
+	tenantResolver, err := tenant.NewResolver(tenantConfig, issuer.Verify)
+	if err != nil {
+		logger.Error(err)
+		os.Exit(1)
+	}
+	router.Group(func(r chi.Router) {
+		// Select the tenant before anything touches its database, callers are resolved within it
+		if tenantConfig.Enabled() {
+			r.Use(tenant.Middleware(tenantResolver, tenant.NewRegistry(tenantConfig)))
+		}
+
+		// Put the caller resolved from the API key, bearer token or trusted header into the request context
+		r.Use(auth.Middleware(authResolver))
+
+		// Runtime log levels, changing them floods the logs with debug entries so only admins may
+		r.With(auth.RequireUser, auth.RequireRole(logLevelRole)).
+			Handle("/debug/log-level", logger.LevelHandler(logger.Default().Levels()))
+
+		userHandler.RegisterRoutes(r)
+		apikeyHandler.RegisterRoutes(r)
+		usageHandler.RegisterRoutes(r)
+		if webhookConfig.Enabled {
+			webhookHandler.RegisterRoutes(r)
+		}
+
+		r.Group(func(r chi.Router) {
+			// Todo requests count towards the daily request quota
+			r.Use(usageHandler.CountRequests)
+			r.Use(limiter.Middleware(
+				ratelimit.Rule{Name: "todo:read", Limit: todoReadLimit, Methods: []string{http.MethodGet}},
+				ratelimit.Rule{Name: "todo:write", Limit: todoWriteLimit, Methods: []string{http.MethodPost, http.MethodPut, http.MethodDelete}},
+			))
+			todoHandler.RegisterRoutes(r)
+			todoStreamHandler.RegisterRoutes(r)
+			// GraphQL queries sent with POST are limited like writes
+			todoGraphQLHandler.RegisterRoutes(r)
+		})
+	})
+
+	// gRPC, the same load shedding, tenant and caller resolution, request quota and rate limits as the HTTP API.
+	// Rules share their buckets with the HTTP rules of the same name
+	grpcConfig := grpcserver.LoadConfig()
+	var grpcAdmit grpcserver.AdmitFunc
+	if shedder != nil {
+		grpcAdmit = grpcserver.Shed(shedder, func(method string) loadshed.Priority {
+			switch method {
+			case "/todo.v1.TodoService/List", "/todo.v1.TodoService/Get":
+				return loadshed.PriorityNormal
+			case "/todo.v1.TodoService/Create", "/todo.v1.TodoService/Update", "/todo.v1.TodoService/Delete":
+				return loadshed.PriorityLow
+			}
+			// Watch and reflection streams stay open like the HTTP change feeds
+			return loadshed.PriorityExempt
+		})
+	}
+	grpcContextFuncs := []grpcserver.ContextFunc{}
+	if tenantConfig.Enabled() {
+		grpcContextFuncs = append(grpcContextFuncs, grpcserver.Tenant(tenantResolver, tenant.NewRegistry(tenantConfig)))
+	}
+	todoReadRule := ratelimit.Rule{Name: "todo:read", Limit: todoReadLimit}
+	todoWriteRule := ratelimit.Rule{Name: "todo:write", Limit: todoWriteLimit}
+	grpcContextFuncs = append(grpcContextFuncs,
+		grpcserver.Auth(authResolver),
+		// Todo calls count towards the daily request quota, reflection does not
+		grpcserver.Service("todo.v1.TodoService", grpcserver.Quota(usageService.CountRequest)),
+		grpcserver.RateLimit(limiter, map[string][]ratelimit.Rule{
+			"/todo.v1.TodoService/List":   {todoReadRule},
+			"/todo.v1.TodoService/Get":    {todoReadRule},
+			"/todo.v1.TodoService/Watch":  {todoReadRule},
+			"/todo.v1.TodoService/Create": {todoWriteRule},
+			"/todo.v1.TodoService/Update": {todoWriteRule},
+			"/todo.v1.TodoService/Delete": {todoWriteRule},
+		}),
+	)
+	grpcServer := grpcserver.New(grpcConfig, grpcAdmit, grpcContextFuncs...)
+	todogrpcdelivery.New(todoService).Register(grpcServer)
 
 	// Print
 	PrintAllRoutes(router)
 
-	addr := fmt.Sprintf("%s%s", ":", os.Getenv("PORT"))
-	err = http.ListenAndServe(addr, router)
+	// Stop on SIGINT / SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Change streams, one per tenant database
+	if eventsSource == "changestream" {
+		changeFeed := todoservice.NewChangeFeed(todoRepo, todoEvents)
+		if !tenantConfig.Enabled() {
+			go changeFeed.Run(ctx)
+		} else {
+			for _, t := range tenant.NewRegistry(tenantConfig).Tenants() {
+				go changeFeed.Run(tenant.NewContext(ctx, t))
+			}
+		}
+	}
+
+	// Webhook deliveries, one dispatcher per tenant database
+	var webhookDispatchers sync.WaitGroup
+	if webhookConfig.Enabled {
+		dispatcher := webhookservice.NewDispatcher(webhookRepo, todoEvents, webhookConfig)
+		tenantContexts := []context.Context{ctx}
+		if tenantConfig.Enabled() {
+			tenantContexts = tenantContexts[:0]
+			for _, t := range tenant.NewRegistry(tenantConfig).Tenants() {
+				tenantContexts = append(tenantContexts, tenant.NewContext(ctx, t))
+			}
+		}
+		for _, tenantCtx := range tenantContexts {
+			webhookDispatchers.Add(1)
+			go func(ctx context.Context) {
+				defer webhookDispatchers.Done()
+				dispatcher.Run(ctx)
+			}(tenantCtx)
+		}
+	}
+
+	srv := server.New(server.LoadConfig(), router)
+	srv.OnShutdownStart(healthRegistry.SetShuttingDown)
+	// Streams never finish on their own, clients reconnect to another instance
+	srv.OnShutdownStart(todoStreamHandler.Shutdown)
+	if grpcConfig.Enabled() {
+		// Stops on the same signal, the HTTP shutdown waits for it before closing shared resources
+		grpcDone := make(chan error, 1)
+		go func() {
+			grpcDone <- grpcServer.Run(ctx, grpcConfig.Addr)
+		}()
+		srv.OnShutdown(func(ctx context.Context) error {
+			select {
+			case err := <-grpcDone:
+				return err
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+	}
+	srv.OnShutdown(func(ctx context.Context) error {
+		// Attempts in flight are abandoned on the same signal, they are made again once their claim expires
+		done := make(chan struct{})
+		go func() {
+			webhookDispatchers.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+	srv.OnShutdown(func(ctx context.Context) error {
+		return client.Disconnect(ctx)
+	})
+	srv.OnShutdown(func(ctx context.Context) error {
+		return closeRateLimitStore()
+	})
+	if shutdownTracing != nil {
+		srv.OnShutdown(shutdownTracing)
+	}
+	srv.OnShutdown(func(ctx context.Context) error {
+		// Closed even when flushing failed, losing the tail of a file beats leaking it
+		syncErr := logger.Sync()
+		if err := logger.Default().Close(); err != nil {
+			return err
+		}
+		return syncErr
+	})
+
+	err = srv.Run(ctx)
 	if err != nil {
 		logger.Error(err)
 	}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"go-clean-architecture/pkg/auth"
+	"go-clean-architecture/pkg/config"
+	"go-clean-architecture/pkg/logger"
+	pkgmongodb "go-clean-architecture/pkg/mongodb"
+	"go-clean-architecture/pkg/policy"
+	"go-clean-architecture/pkg/tenant"
+	pkgvalidator "go-clean-architecture/pkg/validator"
+	clidelivery "go-clean-architecture/todo/delivery/cli"
+	todorepository "go-clean-architecture/todo/repository"
+	todoservice "go-clean-architecture/todo/service"
+	usagerepository "go-clean-architecture/usage/repository"
+	usageservice "go-clean-architecture/usage/service"
+)
+
+const usage = `Usage: todo [flags] <command> [command flags] [arguments]
+
+Talks to the server of -server, or without it to the configured MongoDB
+directly, acting as -user.
+
+Flags:
+`
+
+func main() {
+	os.Exit(run())
+}
+
+func run() int {
+	pkgvalidator.New()
+
+	// Load environment variables, the command line works without a .env file
+	_ = config.LoadConfig()
+
+	// Logger, logs go to stderr and never mix with the output
+	log, err := logger.New(logger.LoadConfig())
+	if err == nil {
+		logger.SetDefault(log)
+		defer logger.Default().Close()
+	}
+
+	flags := flag.NewFlagSet("todo", flag.ContinueOnError)
+	server := flags.String("server", config.GetString("TODO_SERVER", ""), "base URL of a running server, e.g. http://localhost:8080 (TODO_SERVER)")
+	token := flags.String("token", config.GetString("TODO_TOKEN", ""), "access token or API key sent to the server (TODO_TOKEN)")
+	user := flags.String("user", config.GetString("TODO_USER", ""), "user id acting without a server (TODO_USER)")
+	roles := flags.String("roles", config.GetString("TODO_ROLES", "editor"), "comma separated roles of -user (TODO_ROLES)")
+	tenantID := flags.String("tenant", config.GetString("TODO_TENANT", ""), "tenant id when tenancy is enabled (TODO_TENANT)")
+	output := flags.String("o", config.GetString("TODO_OUTPUT", clidelivery.OutputTable), "default output format: table, json or yaml (TODO_OUTPUT)")
+	timeout := flags.Duration("timeout", config.GetDuration("TODO_TIMEOUT", 30*time.Second), "timeout of the command")
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), usage)
+		flags.PrintDefaults()
+		fmt.Fprintf(flags.Output(), "\n%s", clidelivery.Commands)
+	}
+	if err := flags.Parse(os.Args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+
+	// Stop on SIGINT / SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+
+	var client clidelivery.Client
+	if *server != "" {
+		client = clidelivery.NewHTTPClient(&clidelivery.HTTPConfig{
+			Server:       *server,
+			Token:        *token,
+			Tenant:       *tenantID,
+			TenantHeader: config.GetString("TENANT_HEADER", "X-Tenant-ID"),
+		}, &http.Client{})
+	} else {
+		if *user == "" {
+			fmt.Fprintln(os.Stderr, "todo: -user is required without -server")
+			return 2
+		}
+
+		var disconnect func()
+		client, disconnect, err = serviceClient(&auth.Principal{Subject: *user, Roles: splitRoles(*roles)})
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "todo:", err)
+			return 1
+		}
+		defer disconnect()
+
+		// Tenant databases are selected from the context, as tenant.Middleware does for requests
+		if tenantConfig := tenant.LoadConfig(); tenantConfig.Enabled() {
+			t := tenant.NewRegistry(tenantConfig).Lookup(*tenantID)
+			if t == nil {
+				fmt.Fprintf(os.Stderr, "todo: tenant %q is not served, set -tenant\n", *tenantID)
+				return 2
+			}
+			ctx = tenant.NewContext(ctx, t)
+		}
+	}
+
+	cli := clidelivery.New(client, &clidelivery.Config{Output: *output})
+	if err := cli.Run(ctx, flags.Args()); err != nil {
+		usageErr := &clidelivery.UsageError{}
+		if errors.As(err, &usageErr) {
+			if errors.Is(err, flag.ErrHelp) {
+				return 0
+			}
+			return 2
+		}
+		fmt.Fprintln(os.Stderr, "todo:", err)
+		return 1
+	}
+
+	return 0
+}
+
+// serviceClient - client of the todo service over the configured MongoDB, wired like the server without its
+// change feed
+func serviceClient(principal *auth.Principal) (clidelivery.Client, func(), error) {
+	todoPolicy, err := policy.New(policy.LoadConfig())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	_, cancel, client := pkgmongodb.InitMongoDB()
+	disconnect := func() {
+		cancel()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := client.Disconnect(ctx); err != nil {
+			logger.Error(err)
+		}
+	}
+
+	databases := pkgmongodb.NewDatabases(client, os.Getenv("DB_NAME"), todorepository.Indexes, usagerepository.Indexes)
+	todoRepo := todorepository.New(databases)
+	usageService := usageservice.New(usagerepository.New(databases), todoRepo)
+	// Nothing subscribes to the changes of a single command
+	todoService := todoservice.New(todoRepo, todoPolicy, usageService, todoservice.ReadOnly(todoservice.NewBroker(0)))
+
+	return clidelivery.NewServiceClient(todoService, principal), disconnect, nil
+}
+
+func splitRoles(s string) []string {
+	roles := []string{}
+	for _, role := range strings.Split(s, ",") {
+		if role = strings.TrimSpace(role); role != "" {
+			roles = append(roles, role)
+		}
+	}
+
+	return roles
+}
@@ -0,0 +1,203 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"go-clean-architecture/pkg/logger"
+	errorsutil "go-clean-architecture/utils/errors"
+	responseutil "go-clean-architecture/utils/response"
+)
+
+// Principal - authenticated caller of a request
+type Principal struct {
+	// Subject - user id
+	Subject string
+	Email   string
+	// TokenID - id (jti) of the access token, used to revoke it
+	TokenID string
+	// ExpiresAt - expiry of the access token
+	ExpiresAt time.Time
+	// KeyID - id of the API key the request authenticated with, empty for user sessions
+	KeyID string
+	// Scopes - scopes granted to an API key, nil for user sessions which hold every scope
+	Scopes []string
+	// Roles - roles checked by the todo policy, none the policy defines means its default role
+	Roles []string
+	// TenantID - tenant an access token was issued in, empty when tenancy is disabled
+	TenantID string
+}
+
+// Scopes of API keys
+const (
+	ScopeTodoRead  = "todo:read"
+	ScopeTodoWrite = "todo:write"
+)
+
+// KnownScopes - scopes an API key may be granted
+var KnownScopes = []string{ScopeTodoRead, ScopeTodoWrite}
+
+// HasScope - whether the principal was granted scope
+func (p *Principal) HasScope(scope string) bool {
+	if p.Scopes == nil {
+		return true
+	}
+
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HasRole - whether the principal carries role, the policy's default role is not counted
+func (p *Principal) HasRole(role string) bool {
+	for _, r := range p.Roles {
+		if r == role {
+			return true
+		}
+	}
+
+	return false
+}
+
+type contextKey struct{}
+
+// NewContext - copy of ctx carrying p
+func NewContext(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, p)
+}
+
+// FromContext - principal of ctx, nil when the request is anonymous
+func FromContext(ctx context.Context) *Principal {
+	p, _ := ctx.Value(contextKey{}).(*Principal)
+	return p
+}
+
+// UserID - subject of the request principal, empty when anonymous
+func UserID(r *http.Request) string {
+	if p := FromContext(r.Context()); p != nil {
+		return p.Subject
+	}
+
+	return ""
+}
+
+// Authenticator - resolve the principal of an access token
+type Authenticator interface {
+	Authenticate(ctx context.Context, token string) (*Principal, error)
+}
+
+// BearerToken - token of the "Authorization: Bearer" header, empty when there is none
+func BearerToken(r *http.Request) string {
+	header := r.Header.Get("Authorization")
+	if len(header) < 7 || !strings.EqualFold(header[:7], "bearer ") {
+		return ""
+	}
+
+	return strings.TrimSpace(header[7:])
+}
+
+// Middleware - put the principal found by resolver into the request context.
+// Requests without credentials pass through anonymously, invalid credentials are rejected with 401
+func Middleware(resolver Resolver) func(next http.Handler) http.Handler {
+	log := logger.Named("pkg/auth")
+
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			principal, err := resolver.Resolve(r)
+			if err != nil {
+				if !errors.Is(err, errorsutil.ErrUnauthorized) {
+					log.WithContext(r.Context()).Error("authentication failed", "error", err)
+				}
+				responseutil.ResponseUnauthorized(w, r, "Invalid or expired credentials")
+				return
+			}
+			if principal == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), principal)))
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// Require - reject anonymous requests with 401
+func Require(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		if FromContext(r.Context()) == nil {
+			responseutil.ResponseUnauthorized(w, r, "Authentication required")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}
+
+	return http.HandlerFunc(fn)
+}
+
+// RequireScope - reject anonymous requests with 401 and principals lacking scope with 403
+func RequireScope(scope string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			principal := FromContext(r.Context())
+			if principal == nil {
+				responseutil.ResponseUnauthorized(w, r, "Authentication required")
+				return
+			}
+			if !principal.HasScope(scope) {
+				responseutil.ResponseForbidden(w, r, "Missing scope "+scope)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// RequireUser - reject anonymous requests with 401 and API keys with 403, for routes only a user session may call
+func RequireUser(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		principal := FromContext(r.Context())
+		if principal == nil {
+			responseutil.ResponseUnauthorized(w, r, "Authentication required")
+			return
+		}
+		if principal.KeyID != "" {
+			responseutil.ResponseForbidden(w, r, "API keys cannot call this endpoint")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}
+
+	return http.HandlerFunc(fn)
+}
+
+// RequireRole - reject anonymous requests with 401 and principals without role with 403
+func RequireRole(role string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			principal := FromContext(r.Context())
+			if principal == nil {
+				responseutil.ResponseUnauthorized(w, r, "Authentication required")
+				return
+			}
+			if !principal.HasRole(role) {
+				responseutil.ResponseForbidden(w, r, "Missing role "+role)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
@@ -0,0 +1,252 @@
+package auth_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go-clean-architecture/pkg/auth"
+	"go-clean-architecture/pkg/logger"
+	errorsutil "go-clean-architecture/utils/errors"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const secret = "0123456789abcdef0123456789abcdef"
+
+func newIssuer(t *testing.T, issuer string) *auth.Issuer {
+	i, err := auth.NewIssuer(&auth.Config{Secret: secret, Issuer: issuer, AccessTokenTTL: time.Minute})
+	assert.NoError(t, err)
+
+	return i
+}
+
+func TestIssuer(t *testing.T) {
+	issuer := newIssuer(t, "api")
+
+	principal := &auth.Principal{Subject: "user-1", Email: "jane@example.com"}
+	token, err := issuer.Issue(principal)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, principal.TokenID)
+
+	t.Run("when token is valid", func(t *testing.T) {
+		verified, err := issuer.Verify(token)
+
+		assert.NoError(t, err)
+		assert.Equal(t, principal, verified)
+	})
+
+	t.Run("when token is from another issuer", func(t *testing.T) {
+		_, err := newIssuer(t, "other").Verify(token)
+
+		assert.ErrorIs(t, err, errorsutil.ErrUnauthorized)
+	})
+
+	t.Run("when token is expired", func(t *testing.T) {
+		expired := newIssuer(t, "api")
+		expired.SetClock(func() time.Time { return time.Now().Add(2 * time.Minute) })
+
+		_, err := expired.Verify(token)
+
+		assert.ErrorIs(t, err, errorsutil.ErrUnauthorized)
+	})
+
+	t.Run("when token is tampered", func(t *testing.T) {
+		_, err := issuer.Verify(token + "x")
+
+		assert.ErrorIs(t, err, errorsutil.ErrUnauthorized)
+	})
+
+	t.Run("when secret is too short", func(t *testing.T) {
+		_, err := auth.NewIssuer(&auth.Config{Secret: "short", AccessTokenTTL: time.Minute})
+
+		assert.Error(t, err)
+	})
+
+	t.Run("when secret is the example placeholder", func(t *testing.T) {
+		_, err := auth.NewIssuer(&auth.Config{Secret: "change-me-to-a-random-32-byte-secret", AccessTokenTTL: time.Minute})
+
+		assert.Error(t, err)
+	})
+}
+
+type authenticatorFunc func(ctx context.Context, token string) (*auth.Principal, error)
+
+func (f authenticatorFunc) Authenticate(ctx context.Context, token string) (*auth.Principal, error) {
+	return f(ctx, token)
+}
+
+func TestMiddleware(t *testing.T) {
+	authenticator := authenticatorFunc(func(ctx context.Context, token string) (*auth.Principal, error) {
+		if token != "valid" {
+			return nil, errorsutil.ErrUnauthorized
+		}
+		return &auth.Principal{Subject: "user-1"}, nil
+	})
+
+	resolver := auth.Chain(auth.TrustedHeader("X-User-ID"), auth.Bearer(authenticator))
+	handler := auth.Middleware(resolver)(auth.Require(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(auth.UserID(r))) //nolint:errcheck
+	})))
+
+	do := func(authorization string, userID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/todo", nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		if userID != "" {
+			req.Header.Set("X-User-ID", userID)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("when token is valid", func(t *testing.T) {
+		rr := do("Bearer valid", "")
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "user-1", rr.Body.String())
+	})
+
+	t.Run("when token is invalid", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, do("Bearer invalid", "").Code)
+	})
+
+	t.Run("when identity comes from a trusted header", func(t *testing.T) {
+		rr := do("", "user-2")
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "user-2", rr.Body.String())
+	})
+
+	t.Run("when request is anonymous", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, do("", "").Code)
+	})
+}
+
+func TestAPIKey(t *testing.T) {
+	keys := authenticatorFunc(func(ctx context.Context, key string) (*auth.Principal, error) {
+		if key != "gca_valid" {
+			return nil, errorsutil.ErrUnauthorized
+		}
+		return &auth.Principal{Subject: "user-1", KeyID: "key-1", Scopes: []string{auth.ScopeTodoRead}}, nil
+	})
+	tokens := authenticatorFunc(func(ctx context.Context, token string) (*auth.Principal, error) {
+		return &auth.Principal{Subject: "user-2"}, nil
+	})
+
+	resolver := auth.Chain(auth.APIKey("X-API-Key", keys), auth.Bearer(tokens))
+	handler := auth.Middleware(resolver)(auth.RequireScope(auth.ScopeTodoRead)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(auth.UserID(r))) //nolint:errcheck
+	})))
+
+	do := func(header string, value string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/todo", nil)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("when key is sent in X-API-Key", func(t *testing.T) {
+		rr := do("X-API-Key", "gca_valid")
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "user-1", rr.Body.String())
+	})
+
+	t.Run("when key is sent as a bearer token", func(t *testing.T) {
+		rr := do("Authorization", "Bearer gca_valid")
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "user-1", rr.Body.String())
+	})
+
+	t.Run("when key is invalid", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, do("Authorization", "Bearer gca_revoked").Code)
+	})
+
+	t.Run("when bearer token is an access token", func(t *testing.T) {
+		rr := do("Authorization", "Bearer access-token")
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "user-2", rr.Body.String())
+	})
+
+	t.Run("when request is anonymous", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, do("", "").Code)
+	})
+}
+
+func TestRequireScope(t *testing.T) {
+	handler := auth.RequireScope(auth.ScopeTodoWrite)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	do := func(principal *auth.Principal) int {
+		req := httptest.NewRequest(http.MethodPost, "/todo", nil)
+		if principal != nil {
+			req = req.WithContext(auth.NewContext(req.Context(), principal))
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	t.Run("when principal is a user session", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, do(&auth.Principal{Subject: "user-1"}))
+	})
+
+	t.Run("when key has the scope", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, do(&auth.Principal{Subject: "user-1", KeyID: "key-1", Scopes: []string{auth.ScopeTodoWrite}}))
+	})
+
+	t.Run("when key lacks the scope", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, do(&auth.Principal{Subject: "user-1", KeyID: "key-1", Scopes: []string{auth.ScopeTodoRead}}))
+	})
+
+	t.Run("when request is anonymous", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, do(nil))
+	})
+}
+
+func TestRequireRole(t *testing.T) {
+	levels, err := logger.NewLevels("info", "")
+	assert.NoError(t, err)
+	// Mounted like /debug/log-level in cmds/app
+	handler := auth.RequireUser(auth.RequireRole("admin")(logger.LevelHandler(levels)))
+
+	do := func(principal *auth.Principal) int {
+		req := httptest.NewRequest(http.MethodPut, "/debug/log-level", strings.NewReader(`{"level":"debug"}`))
+		if principal != nil {
+			req = req.WithContext(auth.NewContext(req.Context(), principal))
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	t.Run("when request is anonymous", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, do(nil))
+		level, _ := levels.Snapshot()
+		assert.Equal(t, "info", level)
+	})
+
+	t.Run("when principal lacks the role", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, do(&auth.Principal{Subject: "user-1", Roles: []string{"editor"}}))
+	})
+
+	t.Run("when principal is an API key of an admin", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, do(&auth.Principal{Subject: "user-1", KeyID: "key-1", Scopes: []string{}, Roles: []string{"admin"}}))
+	})
+
+	t.Run("when principal has the role", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, do(&auth.Principal{Subject: "user-1", Roles: []string{"admin"}}))
+		level, _ := levels.Snapshot()
+		assert.Equal(t, "debug", level)
+	})
+}
@@ -0,0 +1,148 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+
+	"go-clean-architecture/pkg/config"
+	errorsutil "go-clean-architecture/utils/errors"
+)
+
+// minSecretLength - HS256 keys shorter than the hash output are rejected
+const minSecretLength = 32
+
+// examplePlaceholder - JWT_SECRET an older .env.example shipped, long enough but public
+const examplePlaceholder = "change-me-to-a-random-32-byte-secret"
+
+// Config - token configuration
+type Config struct {
+	// Secret - HS256 signing key, at least 32 bytes
+	Secret string
+	// Issuer - iss claim of issued tokens, verified tokens must carry the same
+	Issuer          string
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+}
+
+// LoadConfig - read token configuration from environment variables
+func LoadConfig() *Config {
+	return &Config{
+		Secret:          config.GetString("JWT_SECRET", ""),
+		Issuer:          config.GetString("JWT_ISSUER", "go-clean-architecture"),
+		AccessTokenTTL:  config.GetDuration("JWT_ACCESS_TOKEN_TTL", 15*time.Minute),
+		RefreshTokenTTL: config.GetDuration("JWT_REFRESH_TOKEN_TTL", 30*24*time.Hour),
+	}
+}
+
+// Claims - claims of access tokens
+type Claims struct {
+	jwt.RegisteredClaims
+	Email string   `json:"email,omitempty"`
+	Roles []string `json:"roles,omitempty"`
+	// TenantID - tokens are only valid in the tenant they were issued in
+	TenantID string `json:"tid,omitempty"`
+}
+
+// Issuer - issue and verify signed access tokens
+type Issuer struct {
+	secret []byte
+	issuer string
+	ttl    time.Duration
+	now    func() time.Time
+}
+
+// NewIssuer - make token issuer from cfg
+func NewIssuer(cfg *Config) (*Issuer, error) {
+	if len(cfg.Secret) < minSecretLength {
+		return nil, fmt.Errorf("auth: JWT secret must be at least %d bytes", minSecretLength)
+	}
+	if cfg.Secret == examplePlaceholder {
+		return nil, errors.New("auth: JWT secret is the public example value, generate a random one")
+	}
+	if cfg.AccessTokenTTL <= 0 {
+		return nil, errors.New("auth: access token ttl must be positive")
+	}
+
+	return &Issuer{
+		secret: []byte(cfg.Secret),
+		issuer: cfg.Issuer,
+		ttl:    cfg.AccessTokenTTL,
+		now:    time.Now,
+	}, nil
+}
+
+// TTL - lifetime of issued access tokens
+func (i *Issuer) TTL() time.Duration {
+	return i.ttl
+}
+
+// Issue - sign an access token for p with a fresh token id, p.TokenID and p.ExpiresAt are set
+func (i *Issuer) Issue(p *Principal) (string, error) {
+	id, err := randomID()
+	if err != nil {
+		return "", err
+	}
+
+	now := i.now()
+	p.TokenID = id
+	p.ExpiresAt = now.Add(i.ttl).Truncate(time.Second)
+
+	claims := &Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        p.TokenID,
+			Subject:   p.Subject,
+			Issuer:    i.issuer,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(p.ExpiresAt),
+		},
+		Email:    p.Email,
+		Roles:    p.Roles,
+		TenantID: p.TenantID,
+	}
+
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(i.secret)
+}
+
+// Verify - principal of a valid token, any invalid, expired or foreign token returns ErrUnauthorized
+func (i *Issuer) Verify(token string) (*Principal, error) {
+	claims := &Claims{}
+	parser := jwt.NewParser(jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	_, err := parser.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		return i.secret, nil
+	})
+	if err != nil {
+		return nil, errorsutil.ErrUnauthorized
+	}
+
+	if !claims.VerifyIssuer(i.issuer, true) || !claims.VerifyExpiresAt(i.now(), true) || claims.Subject == "" || claims.ID == "" {
+		return nil, errorsutil.ErrUnauthorized
+	}
+
+	return &Principal{
+		Subject:   claims.Subject,
+		Email:     claims.Email,
+		Roles:     claims.Roles,
+		TenantID:  claims.TenantID,
+		TokenID:   claims.ID,
+		ExpiresAt: claims.ExpiresAt.Time,
+	}, nil
+}
+
+// SetClock - replace the time source, used by tests
+func (i *Issuer) SetClock(now func() time.Time) {
+	i.now = now
+}
+
+func randomID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
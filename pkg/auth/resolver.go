@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"go-clean-architecture/pkg/config"
+)
+
+// Resolver - resolve the caller of a request, nil when the request does not carry this kind of identity
+type Resolver interface {
+	Resolve(r *http.Request) (*Principal, error)
+}
+
+// ResolverFunc - adapter to use a plain func as Resolver
+type ResolverFunc func(r *http.Request) (*Principal, error)
+
+func (f ResolverFunc) Resolve(r *http.Request) (*Principal, error) {
+	return f(r)
+}
+
+// APIKeyPrefix - prefix of every API key, tells keys and access tokens apart in the Authorization header
+const APIKeyPrefix = "gca_"
+
+// Bearer - principal of the "Authorization: Bearer" access token
+func Bearer(authenticator Authenticator) Resolver {
+	return ResolverFunc(func(r *http.Request) (*Principal, error) {
+		token := BearerToken(r)
+		if token == "" || strings.HasPrefix(token, APIKeyPrefix) {
+			return nil, nil
+		}
+
+		return authenticator.Authenticate(r.Context(), token)
+	})
+}
+
+// APIKey - principal of the API key sent in header (e.g. X-API-Key) or as "Authorization: Bearer <key>"
+func APIKey(header string, authenticator Authenticator) Resolver {
+	return ResolverFunc(func(r *http.Request) (*Principal, error) {
+		key := r.Header.Get(header)
+		if key == "" {
+			if token := BearerToken(r); strings.HasPrefix(token, APIKeyPrefix) {
+				key = token
+			}
+		}
+		if key == "" {
+			return nil, nil
+		}
+
+		return authenticator.Authenticate(r.Context(), key)
+	})
+}
+
+// TrustedHeader - principal whose id is set in header by an authenticating gateway.
+// Only enable it when every request passes that gateway, clients can set any header themselves
+func TrustedHeader(header string) Resolver {
+	return ResolverFunc(func(r *http.Request) (*Principal, error) {
+		id := strings.TrimSpace(r.Header.Get(header))
+		if id == "" {
+			return nil, nil
+		}
+
+		return &Principal{Subject: id}, nil
+	})
+}
+
+// Chain - principal of the first resolver finding one, an error stops the chain
+func Chain(resolvers ...Resolver) Resolver {
+	return ResolverFunc(func(r *http.Request) (*Principal, error) {
+		for _, resolver := range resolvers {
+			principal, err := resolver.Resolve(r)
+			if err != nil || principal != nil {
+				return principal, err
+			}
+		}
+
+		return nil, nil
+	})
+}
+
+// NewResolver - chain of the comma separated AUTH_RESOLVERS (apikey, bearer, header), the header resolver
+// reads AUTH_TRUSTED_HEADER and the apikey resolver AUTH_API_KEY_HEADER
+func NewResolver(tokens Authenticator, keys Authenticator) (Resolver, error) {
+	resolvers := []Resolver{}
+	for _, name := range strings.Split(config.GetString("AUTH_RESOLVERS", "apikey,bearer"), ",") {
+		switch name = strings.ToLower(strings.TrimSpace(name)); name {
+		case "":
+		case "apikey":
+			resolvers = append(resolvers, APIKey(config.GetString("AUTH_API_KEY_HEADER", "X-API-Key"), keys))
+		case "bearer":
+			resolvers = append(resolvers, Bearer(tokens))
+		case "header":
+			resolvers = append(resolvers, TrustedHeader(config.GetString("AUTH_TRUSTED_HEADER", "X-User-ID")))
+		default:
+			return nil, fmt.Errorf("auth: unsupported resolver %q", name)
+		}
+	}
+
+	return Chain(resolvers...), nil
+}
@@ -1,6 +1,10 @@
 package config
 
 import (
+	"os"
+	"strconv"
+	"time"
+
 	"github.com/joho/godotenv"
 )
 
@@ -13,3 +17,43 @@ func LoadConfig() error {
 
 	return nil
 }
+
+// GetString - get environment variable, the default value is used when it is empty
+func GetString(key string, defaultValue string) string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	return value
+}
+
+// GetInt - get environment variable as int, the default value is used when it is empty or invalid
+func GetInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+
+	return value
+}
+
+// GetDuration - get environment variable as duration (e.g. 5s), the default value is used when it is empty or invalid
+func GetDuration(key string, defaultValue time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+
+	return value
+}
+
+// GetBool - get environment variable as bool (e.g. true, 1), the default value is used when it is empty or invalid
+func GetBool(key string, defaultValue bool) bool {
+	value, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+
+	return value
+}
@@ -0,0 +1,215 @@
+package cors
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-clean-architecture/pkg/config"
+)
+
+// Config - cross-origin resource sharing configuration
+type Config struct {
+	// AllowedOrigins - origins that may call the API, "*" allows any origin and a single "*" in an origin
+	// matches one or more characters, e.g. "https://*.example.com". Empty disables CORS
+	AllowedOrigins []string
+	AllowedMethods []string
+	// AllowedHeaders - request headers browsers may send, matched case-insensitively
+	AllowedHeaders []string
+	// ExposedHeaders - response headers scripts may read besides the CORS-safelisted ones
+	ExposedHeaders   []string
+	AllowCredentials bool
+	// MaxAge - how long browsers may cache a preflight response, zero leaves it to the browser
+	MaxAge time.Duration
+}
+
+// LoadConfig - read CORS configuration from environment variables, lists are comma separated
+func LoadConfig() *Config {
+	return &Config{
+		AllowedOrigins:   split(config.GetString("CORS_ALLOWED_ORIGINS", "")),
+		AllowedMethods:   split(config.GetString("CORS_ALLOWED_METHODS", "GET,POST,PUT,DELETE")),
+		AllowedHeaders:   split(config.GetString("CORS_ALLOWED_HEADERS", "Accept,Authorization,Content-Type,Last-Event-ID,X-API-Key,X-Request-ID,X-Tenant-ID")),
+		ExposedHeaders:   split(config.GetString("CORS_EXPOSED_HEADERS", "X-Request-ID,Retry-After,RateLimit-Limit,RateLimit-Remaining,RateLimit-Reset,X-Page,X-Per-Page,X-Page-Count,X-Total-Count")),
+		AllowCredentials: config.GetBool("CORS_ALLOW_CREDENTIALS", false),
+		MaxAge:           config.GetDuration("CORS_MAX_AGE", 10*time.Minute),
+	}
+}
+
+// Enabled - whether any origin is allowed
+func (cfg *Config) Enabled() bool {
+	return len(cfg.AllowedOrigins) > 0
+}
+
+func split(value string) []string {
+	values := []string{}
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+
+	return values
+}
+
+// origin - allowed origin, prefix and suffix around its wildcard when it has one
+type origin struct {
+	prefix   string
+	suffix   string
+	wildcard bool
+}
+
+func (o *origin) matches(value string) bool {
+	if !o.wildcard {
+		return value == o.prefix
+	}
+
+	return len(value) > len(o.prefix)+len(o.suffix) && strings.HasPrefix(value, o.prefix) && strings.HasSuffix(value, o.suffix)
+}
+
+// CORS - middleware answering preflight requests and decorating responses to allowed origins
+type CORS struct {
+	anyOrigin        bool
+	origins          []*origin
+	methods          map[string]bool
+	headers          map[string]bool
+	allowMethods     string
+	allowHeaders     string
+	exposeHeaders    string
+	allowCredentials bool
+	maxAge           string
+}
+
+// New - make CORS middleware from cfg
+func New(cfg *Config) (*CORS, error) {
+	c := &CORS{
+		methods:          map[string]bool{},
+		headers:          map[string]bool{},
+		allowCredentials: cfg.AllowCredentials,
+	}
+
+	for _, o := range cfg.AllowedOrigins {
+		if o == "*" {
+			c.anyOrigin = true
+			continue
+		}
+
+		o = strings.ToLower(o)
+		prefix, suffix, wildcard := strings.Cut(o, "*")
+		if strings.Contains(suffix, "*") {
+			return nil, errors.New("cors: origin " + o + " has more than one wildcard")
+		}
+		c.origins = append(c.origins, &origin{prefix: prefix, suffix: suffix, wildcard: wildcard})
+	}
+	if c.anyOrigin && c.allowCredentials {
+		// Browsers refuse credentials with "Access-Control-Allow-Origin: *", echoing every origin instead would
+		// let any site act with the user's cookies
+		return nil, errors.New("cors: credentials cannot be allowed for any origin")
+	}
+
+	methods := []string{}
+	for _, method := range cfg.AllowedMethods {
+		method = strings.ToUpper(method)
+		c.methods[method] = true
+		methods = append(methods, method)
+	}
+	c.allowMethods = strings.Join(methods, ", ")
+
+	for _, header := range cfg.AllowedHeaders {
+		c.headers[http.CanonicalHeaderKey(header)] = true
+	}
+	c.allowHeaders = strings.Join(cfg.AllowedHeaders, ", ")
+	c.exposeHeaders = strings.Join(cfg.ExposedHeaders, ", ")
+
+	if cfg.MaxAge > 0 {
+		c.maxAge = strconv.Itoa(int(cfg.MaxAge.Seconds()))
+	}
+
+	return c, nil
+}
+
+// allowedOrigin - whether requests from value may be served
+func (c *CORS) allowedOrigin(value string) bool {
+	if c.anyOrigin {
+		return true
+	}
+
+	value = strings.ToLower(value)
+	for _, o := range c.origins {
+		if o.matches(value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// allowedHeaders - whether every header of a comma separated Access-Control-Request-Headers is allowed
+func (c *CORS) allowedHeaders(value string) bool {
+	for _, header := range split(value) {
+		if !c.headers[http.CanonicalHeaderKey(header)] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// setOrigin - allow the origin of the request to read the response
+func (c *CORS) setOrigin(h http.Header, requestOrigin string) {
+	if c.anyOrigin {
+		h.Set("Access-Control-Allow-Origin", "*")
+	} else {
+		h.Set("Access-Control-Allow-Origin", requestOrigin)
+	}
+	if c.allowCredentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+// Handler - answer preflight requests with 204 and add CORS headers to responses to allowed origins,
+// requests without Origin are served untouched
+func (c *CORS) Handler(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		h := w.Header()
+		requestOrigin := r.Header.Get("Origin")
+
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			h.Add("Vary", "Origin")
+			h.Add("Vary", "Access-Control-Request-Method")
+			h.Add("Vary", "Access-Control-Request-Headers")
+
+			// A preflight that is not allowed gets no CORS headers, the browser then blocks the request
+			if c.allowedOrigin(requestOrigin) &&
+				c.methods[strings.ToUpper(r.Header.Get("Access-Control-Request-Method"))] &&
+				c.allowedHeaders(r.Header.Get("Access-Control-Request-Headers")) {
+				c.setOrigin(h, requestOrigin)
+				h.Set("Access-Control-Allow-Methods", c.allowMethods)
+				if c.allowHeaders != "" {
+					h.Set("Access-Control-Allow-Headers", c.allowHeaders)
+				}
+				if c.maxAge != "" {
+					h.Set("Access-Control-Max-Age", c.maxAge)
+				}
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		if requestOrigin != "" {
+			h.Add("Vary", "Origin")
+			if c.allowedOrigin(requestOrigin) {
+				c.setOrigin(h, requestOrigin)
+				if c.exposeHeaders != "" {
+					h.Set("Access-Control-Expose-Headers", c.exposeHeaders)
+				}
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	}
+
+	return http.HandlerFunc(fn)
+}
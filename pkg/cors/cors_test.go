@@ -0,0 +1,147 @@
+package cors_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go-clean-architecture/pkg/cors"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newConfig() *cors.Config {
+	return &cors.Config{
+		AllowedOrigins:   []string{"https://app.example.com", "https://*.example.org"},
+		AllowedMethods:   []string{"GET", "POST"},
+		AllowedHeaders:   []string{"Authorization", "Content-Type"},
+		ExposedHeaders:   []string{"X-Request-ID"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	}
+}
+
+func serve(t *testing.T, cfg *cors.Config, req *http.Request) (*httptest.ResponseRecorder, bool) {
+	c, err := cors.New(cfg)
+	assert.NoError(t, err)
+
+	called := false
+	handler := c.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	return rr, called
+}
+
+func preflight(origin string, method string, headers string) *http.Request {
+	req := httptest.NewRequest(http.MethodOptions, "/todo", nil)
+	req.Header.Set("Origin", origin)
+	req.Header.Set("Access-Control-Request-Method", method)
+	if headers != "" {
+		req.Header.Set("Access-Control-Request-Headers", headers)
+	}
+
+	return req
+}
+
+func TestPreflight(t *testing.T) {
+	t.Run("when allowed", func(t *testing.T) {
+		rr, called := serve(t, newConfig(), preflight("https://app.example.com", "POST", "content-type, authorization"))
+
+		assert.False(t, called)
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		assert.Equal(t, "https://app.example.com", rr.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "true", rr.Header().Get("Access-Control-Allow-Credentials"))
+		assert.Equal(t, "GET, POST", rr.Header().Get("Access-Control-Allow-Methods"))
+		assert.Equal(t, "Authorization, Content-Type", rr.Header().Get("Access-Control-Allow-Headers"))
+		assert.Equal(t, "600", rr.Header().Get("Access-Control-Max-Age"))
+		assert.Contains(t, rr.Header().Values("Vary"), "Origin")
+	})
+
+	t.Run("when the origin matches a wildcard", func(t *testing.T) {
+		rr, _ := serve(t, newConfig(), preflight("https://tenant.example.org", "GET", ""))
+
+		assert.Equal(t, "https://tenant.example.org", rr.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("when the origin is not allowed", func(t *testing.T) {
+		for _, origin := range []string{"https://evil.example.com", "https://.example.org", "https://example.org", "http://tenant.example.org"} {
+			rr, called := serve(t, newConfig(), preflight(origin, "GET", ""))
+
+			assert.False(t, called)
+			assert.Equal(t, http.StatusNoContent, rr.Code)
+			assert.Empty(t, rr.Header().Get("Access-Control-Allow-Origin"), origin)
+		}
+	})
+
+	t.Run("when the method is not allowed", func(t *testing.T) {
+		rr, _ := serve(t, newConfig(), preflight("https://app.example.com", "DELETE", ""))
+
+		assert.Empty(t, rr.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("when a header is not allowed", func(t *testing.T) {
+		rr, _ := serve(t, newConfig(), preflight("https://app.example.com", "GET", "X-Debug"))
+
+		assert.Empty(t, rr.Header().Get("Access-Control-Allow-Origin"))
+	})
+}
+
+func TestActualRequest(t *testing.T) {
+	t.Run("when the origin is allowed", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/todo", nil)
+		req.Header.Set("Origin", "https://app.example.com")
+
+		rr, called := serve(t, newConfig(), req)
+
+		assert.True(t, called)
+		assert.Equal(t, "https://app.example.com", rr.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "X-Request-ID", rr.Header().Get("Access-Control-Expose-Headers"))
+	})
+
+	t.Run("when the origin is not allowed", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/todo", nil)
+		req.Header.Set("Origin", "https://evil.example.com")
+
+		rr, called := serve(t, newConfig(), req)
+
+		assert.True(t, called)
+		assert.Empty(t, rr.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "Origin", rr.Header().Get("Vary"))
+	})
+
+	t.Run("when any origin is allowed", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/todo", nil)
+		req.Header.Set("Origin", "https://anyone.example.net")
+
+		rr, _ := serve(t, &cors.Config{AllowedOrigins: []string{"*"}}, req)
+
+		assert.Equal(t, "*", rr.Header().Get("Access-Control-Allow-Origin"))
+		assert.Empty(t, rr.Header().Get("Access-Control-Allow-Credentials"))
+	})
+
+	t.Run("when there is no origin", func(t *testing.T) {
+		rr, called := serve(t, newConfig(), httptest.NewRequest(http.MethodOptions, "/todo", nil))
+
+		assert.True(t, called)
+		assert.Empty(t, rr.Header().Values("Vary"))
+	})
+}
+
+func TestNew(t *testing.T) {
+	t.Run("when credentials are allowed for any origin", func(t *testing.T) {
+		_, err := cors.New(&cors.Config{AllowedOrigins: []string{"*"}, AllowCredentials: true})
+
+		assert.Error(t, err)
+	})
+
+	t.Run("when an origin has two wildcards", func(t *testing.T) {
+		_, err := cors.New(&cors.Config{AllowedOrigins: []string{"https://*.*.example.com"}})
+
+		assert.Error(t, err)
+	})
+}
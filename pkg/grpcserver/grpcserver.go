@@ -0,0 +1,419 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+
+	"go-clean-architecture/pkg/auth"
+	"go-clean-architecture/pkg/config"
+	"go-clean-architecture/pkg/loadshed"
+	"go-clean-architecture/pkg/logger"
+	"go-clean-architecture/pkg/quota"
+	"go-clean-architecture/pkg/ratelimit"
+	"go-clean-architecture/pkg/tenant"
+	errorsutil "go-clean-architecture/utils/errors"
+)
+
+// Config - grpc server configuration
+type Config struct {
+	// Addr - listen address, empty disables the server
+	Addr string
+	// Reflection - serve the reflection service so tools like grpcurl can discover the API
+	Reflection     bool
+	MaxRecvMsgSize int
+	// ShutdownTimeout - wait for in-flight calls and streams, then close them
+	ShutdownTimeout time.Duration
+}
+
+// LoadConfig - read grpc server configuration from environment variables
+func LoadConfig() *Config {
+	addr := ""
+	if port := config.GetString("GRPC_PORT", "5556"); port != "" {
+		addr = ":" + port
+	}
+
+	return &Config{
+		Addr:            addr,
+		Reflection:      config.GetBool("GRPC_REFLECTION", true),
+		MaxRecvMsgSize:  config.GetInt("GRPC_MAX_RECV_MSG_SIZE", 4<<20),
+		ShutdownTimeout: config.GetDuration("GRPC_SHUTDOWN_TIMEOUT", 30*time.Second),
+	}
+}
+
+// Enabled - whether the grpc server is started
+func (cfg *Config) Enabled() bool {
+	return cfg.Addr != ""
+}
+
+// ContextFunc - derive the context of a call from its metadata. The metadata is presented as the headers of r,
+// with the authority as its host and the full method as its path, so the resolvers of the HTTP API apply as is.
+// A returned error aborts the call, status errors keep their code
+type ContextFunc func(ctx context.Context, r *http.Request) (context.Context, error)
+
+// Tenant - put the tenant resolved by resolver into the context, calls without a tenant are
+// rejected with InvalidArgument and unknown tenants with NotFound
+func Tenant(resolver tenant.Resolver, registry *tenant.Registry) ContextFunc {
+	return func(ctx context.Context, r *http.Request) (context.Context, error) {
+		id, err := resolver.Resolve(r)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, "Invalid tenant")
+		}
+		if id == "" {
+			return nil, status.Error(codes.InvalidArgument, "Tenant required")
+		}
+
+		t := registry.Lookup(id)
+		if t == nil {
+			return nil, status.Error(codes.NotFound, "Tenant not found")
+		}
+
+		return tenant.NewContext(ctx, t), nil
+	}
+}
+
+// Auth - put the principal found by resolver into the context. Calls without credentials pass through
+// anonymously, invalid credentials are rejected with Unauthenticated
+func Auth(resolver auth.Resolver) ContextFunc {
+	log := logger.Named("pkg/grpcserver")
+
+	return func(ctx context.Context, r *http.Request) (context.Context, error) {
+		principal, err := resolver.Resolve(r)
+		if err != nil {
+			if !errors.Is(err, errorsutil.ErrUnauthorized) {
+				log.WithContext(ctx).Error("authentication failed", "error", err)
+			}
+			return nil, status.Error(codes.Unauthenticated, "Invalid or expired credentials")
+		}
+		if principal == nil {
+			return ctx, nil
+		}
+
+		return auth.NewContext(ctx, principal), nil
+	}
+}
+
+// Service - run fn only for calls of the named service, e.g. "todo.v1.TodoService"
+func Service(name string, fn ContextFunc) ContextFunc {
+	prefix := "/" + name + "/"
+
+	return func(ctx context.Context, r *http.Request) (context.Context, error) {
+		if !strings.HasPrefix(r.URL.Path, prefix) {
+			return ctx, nil
+		}
+
+		return fn(ctx, r)
+	}
+}
+
+// Quota - count the call towards the daily request quota of the caller with count, e.g. CountRequest of the usage
+// service. Calls over the quota are rejected with ResourceExhausted, anonymous calls are not counted and
+// failures of count let the call through
+func Quota(count func(ctx context.Context, userID string) error) ContextFunc {
+	log := logger.Named("pkg/grpcserver")
+
+	return func(ctx context.Context, r *http.Request) (context.Context, error) {
+		userID := auth.UserID(r)
+		if userID == "" {
+			return ctx, nil
+		}
+
+		err := count(ctx, userID)
+		exceeded := &quota.ExceededError{}
+		if errors.As(err, &exceeded) {
+			if exceeded.RetryAfter > 0 {
+				setRetryAfter(ctx, strconv.Itoa(int(math.Ceil(exceeded.RetryAfter.Seconds()))))
+			}
+			return nil, status.Error(codes.ResourceExhausted, exceeded.Message())
+		}
+		if err != nil {
+			log.WithContext(ctx).Error("counting request failed", "error", err)
+		}
+
+		return ctx, nil
+	}
+}
+
+// RateLimit - take a token for the call from limiter for every rule of its full method in rules, methods without
+// rules are not limited. Calls over a limit are rejected with ResourceExhausted and a retry-after header
+func RateLimit(limiter *ratelimit.Limiter, rules map[string][]ratelimit.Rule) ContextFunc {
+	return func(ctx context.Context, r *http.Request) (context.Context, error) {
+		methodRules := rules[r.URL.Path]
+		if len(methodRules) == 0 {
+			return ctx, nil
+		}
+
+		result := limiter.Take(r, methodRules...)
+		if result != nil && !result.Allowed {
+			setRetryAfter(ctx, strconv.Itoa(int(math.Ceil(result.RetryAfter.Seconds()))))
+			return nil, status.Error(codes.ResourceExhausted, "Rate limit exceeded")
+		}
+
+		return ctx, nil
+	}
+}
+
+// AdmitFunc - admit a call to method before its ContextFuncs run. done is called with the error of an admitted
+// call once it completed, a returned error rejects the call
+type AdmitFunc func(ctx context.Context, method string) (done func(err error), err error)
+
+// Shed - admit calls while shedder has capacity for the priority classify gives their full method, the excess is
+// rejected with ResourceExhausted and a retry-after header. Unavailable and DeadlineExceeded calls count as dropped
+func Shed(shedder *loadshed.Shedder, classify func(method string) loadshed.Priority) AdmitFunc {
+	return func(ctx context.Context, method string) (func(err error), error) {
+		release, ok := shedder.Acquire(classify(method))
+		if !ok {
+			setRetryAfter(ctx, shedder.RetryAfter())
+			return nil, status.Error(codes.ResourceExhausted, "Service is overloaded")
+		}
+
+		return func(err error) {
+			code := status.Code(err)
+			release(code == codes.Unavailable || code == codes.DeadlineExceeded)
+		}, nil
+	}
+}
+
+// setRetryAfter - tell the client how many seconds to wait before retrying a rejected call
+func setRetryAfter(ctx context.Context, seconds string) {
+	_ = grpc.SetHeader(ctx, metadata.Pairs("retry-after", seconds))
+}
+
+type Server struct {
+	grpcServer      *grpc.Server
+	health          *health.Server
+	shutdownTimeout time.Duration
+	log             logger.Logger
+}
+
+// New - make server from config. admit, when not nil, and then contextFuncs in order run before every call
+// except health checks
+func New(cfg *Config, admit AdmitFunc, contextFuncs ...ContextFunc) *Server {
+	s := &Server{
+		health:          health.NewServer(),
+		shutdownTimeout: cfg.ShutdownTimeout,
+		log:             logger.Named("pkg/grpcserver"),
+	}
+
+	s.grpcServer = grpc.NewServer(
+		grpc.MaxRecvMsgSize(cfg.MaxRecvMsgSize),
+		grpc.ChainUnaryInterceptor(s.unaryInterceptor(admit, contextFuncs)),
+		grpc.ChainStreamInterceptor(s.streamInterceptor(admit, contextFuncs)),
+	)
+
+	healthpb.RegisterHealthServer(s.grpcServer, s.health)
+	if cfg.Reflection {
+		reflection.Register(s.grpcServer)
+	}
+
+	return s
+}
+
+// RegisterService - register a service implementation, grpc.ServiceRegistrar. Its health is reported as serving
+func (s *Server) RegisterService(desc *grpc.ServiceDesc, impl interface{}) {
+	s.grpcServer.RegisterService(desc, impl)
+	s.health.SetServingStatus(desc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+}
+
+// Run - listen on addr until ctx is done, then shutdown gracefully
+func (s *Server) Run(ctx context.Context, addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	return s.Serve(ctx, ln)
+}
+
+// Serve - accept connections on ln until ctx is done, then shutdown gracefully
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	serveErr := make(chan error, 1)
+	go func() {
+		s.log.Info("Listening", "addr", ln.Addr().String(), "protocol", "grpc")
+		serveErr <- s.grpcServer.Serve(ln)
+	}()
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	return s.Shutdown()
+}
+
+// Shutdown - report every service as not serving, then wait for in-flight calls within the shutdown timeout.
+// Streams are long lived, whatever is still open after the timeout is closed
+func (s *Server) Shutdown() error {
+	s.log.Info("Shutting down grpc server")
+	s.health.Shutdown()
+
+	stopped := make(chan struct{})
+	go func() {
+		s.grpcServer.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-time.After(s.shutdownTimeout):
+		s.grpcServer.Stop()
+		return fmt.Errorf("grpcserver: closed calls still running after %s", s.shutdownTimeout)
+	}
+}
+
+func (s *Server) unaryInterceptor(admit AdmitFunc, contextFuncs []ContextFunc) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (res interface{}, err error) {
+		start := time.Now()
+		defer func() {
+			err = s.recover(ctx, recover(), err)
+			s.logCall(ctx, info.FullMethod, start, err)
+		}()
+
+		if isHealthCheck(info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		if admit != nil {
+			done, admitErr := admit(ctx, info.FullMethod)
+			if admitErr != nil {
+				return nil, admitErr
+			}
+			defer func() { done(err) }()
+		}
+
+		callCtx, err := applyContextFuncs(ctx, info.FullMethod, contextFuncs)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(callCtx, req)
+	}
+}
+
+func (s *Server) streamInterceptor(admit AdmitFunc, contextFuncs []ContextFunc) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		ctx := ss.Context()
+		start := time.Now()
+		defer func() {
+			err = s.recover(ctx, recover(), err)
+			s.logCall(ctx, info.FullMethod, start, err)
+		}()
+
+		if isHealthCheck(info.FullMethod) {
+			return handler(srv, ss)
+		}
+
+		if admit != nil {
+			done, admitErr := admit(ctx, info.FullMethod)
+			if admitErr != nil {
+				return admitErr
+			}
+			defer func() { done(err) }()
+		}
+
+		callCtx, err := applyContextFuncs(ctx, info.FullMethod, contextFuncs)
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, &serverStream{ServerStream: ss, ctx: callCtx})
+	}
+}
+
+// recover - Internal status for a panic of a handler, err when there was none
+func (s *Server) recover(ctx context.Context, p interface{}, err error) error {
+	if p == nil {
+		return err
+	}
+
+	s.log.WithContext(ctx).Error("panic in grpc handler", "panic", fmt.Sprint(p), "stack", string(debug.Stack()))
+	return status.Error(codes.Internal, "Internal server error")
+}
+
+// logCall - access log entry per call
+func (s *Server) logCall(ctx context.Context, method string, start time.Time, err error) {
+	remoteAddr := ""
+	if p, ok := peer.FromContext(ctx); ok {
+		remoteAddr = p.Addr.String()
+	}
+
+	s.log.WithContext(ctx).Info("call completed",
+		"method", method,
+		"code", status.Code(err).String(),
+		"duration", time.Since(start).String(),
+		"remote_addr", remoteAddr,
+	)
+}
+
+// isHealthCheck - health checks come from load balancers and orchestrators, which carry no tenant or credentials
+func isHealthCheck(method string) bool {
+	return strings.HasPrefix(method, "/grpc.health.v1.Health/")
+}
+
+// applyContextFuncs - context of the call after every ContextFunc ran
+func applyContextFuncs(ctx context.Context, method string, contextFuncs []ContextFunc) (context.Context, error) {
+	if len(contextFuncs) == 0 {
+		return ctx, nil
+	}
+
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, method, nil)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Internal server error")
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	for key, values := range md {
+		if key == ":authority" {
+			if len(values) > 0 {
+				r.Host = values[0]
+			}
+			continue
+		}
+		for _, value := range values {
+			r.Header.Add(key, value)
+		}
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		r.RemoteAddr = p.Addr.String()
+	}
+
+	for _, fn := range contextFuncs {
+		ctx, err = fn(ctx, r)
+		if err != nil {
+			if _, ok := status.FromError(err); !ok {
+				err = status.Error(codes.Internal, "Internal server error")
+			}
+			return nil, err
+		}
+		r = r.WithContext(ctx)
+	}
+
+	return ctx, nil
+}
+
+// serverStream - stream of a call with the context derived by the ContextFuncs
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
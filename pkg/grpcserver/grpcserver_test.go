@@ -0,0 +1,238 @@
+package grpcserver_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	testpb "google.golang.org/grpc/interop/grpc_testing"
+	"google.golang.org/grpc/metadata"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"go-clean-architecture/pkg/auth"
+	"go-clean-architecture/pkg/grpcserver"
+	"go-clean-architecture/pkg/loadshed"
+	"go-clean-architecture/pkg/quota"
+	"go-clean-architecture/pkg/ratelimit"
+	"go-clean-architecture/pkg/tenant"
+	errorsutil "go-clean-architecture/utils/errors"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testService - reports the tenant and caller of EmptyCall through calls, panics in UnaryCall
+type testService struct {
+	testpb.UnimplementedTestServiceServer
+	calls chan context.Context
+}
+
+func (s *testService) EmptyCall(ctx context.Context, _ *testpb.Empty) (*testpb.Empty, error) {
+	s.calls <- ctx
+	return &testpb.Empty{}, nil
+}
+
+func (s *testService) UnaryCall(context.Context, *testpb.SimpleRequest) (*testpb.SimpleResponse, error) {
+	panic("boom")
+}
+
+// serve - connection to a server of cfg serving a test service until the test ends
+func serve(t *testing.T, cfg *grpcserver.Config, admit grpcserver.AdmitFunc, contextFuncs ...grpcserver.ContextFunc) (*grpc.ClientConn, *testService, context.CancelFunc, chan error) {
+	service := &testService{calls: make(chan context.Context, 1)}
+	server := grpcserver.New(cfg, admit, contextFuncs...)
+	testpb.RegisterTestServiceServer(server, service)
+
+	ln := bufconn.Listen(1 << 20)
+	ctx, cancel := context.WithCancel(context.Background())
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.Serve(ctx, ln)
+	}()
+
+	conn, err := grpc.Dial("api.example.com",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return ln.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		conn.Close()
+		cancel()
+	})
+
+	return conn, service, cancel, serveErr
+}
+
+func newConfig() *grpcserver.Config {
+	return &grpcserver.Config{Reflection: true, MaxRecvMsgSize: 4 << 20, ShutdownTimeout: time.Second}
+}
+
+func TestServer(t *testing.T) {
+	t.Run("when health is checked", func(t *testing.T) {
+		rejectAll := func(ctx context.Context, r *http.Request) (context.Context, error) {
+			return nil, status.Error(codes.Unauthenticated, "no")
+		}
+		conn, _, _, _ := serve(t, newConfig(), nil, rejectAll)
+
+		res, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{Service: "grpc.testing.TestService"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, healthpb.HealthCheckResponse_SERVING, res.Status)
+	})
+
+	t.Run("when services are discovered by reflection", func(t *testing.T) {
+		conn, _, _, _ := serve(t, newConfig(), nil)
+
+		stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(context.Background())
+		assert.NoError(t, err)
+		assert.NoError(t, stream.Send(&reflectionpb.ServerReflectionRequest{MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{}}))
+		res, err := stream.Recv()
+		assert.NoError(t, err)
+
+		services := []string{}
+		for _, service := range res.GetListServicesResponse().Service {
+			services = append(services, service.Name)
+		}
+		assert.Contains(t, services, "grpc.testing.TestService")
+	})
+
+	t.Run("when the tenant and caller are resolved from metadata", func(t *testing.T) {
+		tenantResolver, err := tenant.NewResolver(&tenant.Config{Resolvers: "header,subdomain", Header: "X-Tenant-ID", Domain: "example.com", Tenants: "api"}, nil)
+		assert.NoError(t, err)
+		conn, service, _, _ := serve(t, newConfig(), nil,
+			grpcserver.Tenant(tenantResolver, tenant.NewRegistry(&tenant.Config{Tenants: "api", DatabasePrefix: "tenant_"})),
+			grpcserver.Auth(auth.TrustedHeader("X-User-ID")),
+		)
+
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "x-user-id", "user-1")
+		_, err = testpb.NewTestServiceClient(conn).EmptyCall(ctx, &testpb.Empty{})
+		assert.NoError(t, err)
+
+		callCtx := <-service.calls
+		// The tenant comes from the authority api.example.com
+		assert.Equal(t, "api", tenant.ID(callCtx))
+		assert.Equal(t, "user-1", auth.FromContext(callCtx).Subject)
+	})
+
+	t.Run("when the tenant is not served", func(t *testing.T) {
+		tenantResolver, err := tenant.NewResolver(&tenant.Config{Resolvers: "header", Header: "X-Tenant-ID", Tenants: "acme"}, nil)
+		assert.NoError(t, err)
+		conn, _, _, _ := serve(t, newConfig(), nil, grpcserver.Tenant(tenantResolver, tenant.NewRegistry(&tenant.Config{Tenants: "acme"})))
+		client := testpb.NewTestServiceClient(conn)
+
+		_, err = client.EmptyCall(context.Background(), &testpb.Empty{})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+		_, err = client.EmptyCall(metadata.AppendToOutgoingContext(context.Background(), "x-tenant-id", "globex"), &testpb.Empty{})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("when the credentials are invalid", func(t *testing.T) {
+		invalid := auth.ResolverFunc(func(r *http.Request) (*auth.Principal, error) {
+			return nil, errorsutil.ErrUnauthorized
+		})
+		conn, _, _, _ := serve(t, newConfig(), nil, grpcserver.Auth(invalid))
+
+		_, err := testpb.NewTestServiceClient(conn).EmptyCall(context.Background(), &testpb.Empty{})
+
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+
+	t.Run("when a handler panics", func(t *testing.T) {
+		conn, _, _, _ := serve(t, newConfig(), nil)
+
+		_, err := testpb.NewTestServiceClient(conn).UnaryCall(context.Background(), &testpb.SimpleRequest{})
+
+		assert.Equal(t, codes.Internal, status.Code(err))
+	})
+
+	t.Run("when the context is done", func(t *testing.T) {
+		conn, _, cancel, serveErr := serve(t, newConfig(), nil)
+		health := healthpb.NewHealthClient(conn)
+		_, err := health.Check(context.Background(), &healthpb.HealthCheckRequest{})
+		assert.NoError(t, err)
+
+		cancel()
+
+		select {
+		case err := <-serveErr:
+			assert.NoError(t, err)
+		case <-time.After(2 * time.Second):
+			t.Fatal("server did not shut down")
+		}
+	})
+
+	t.Run("when the request quota is used up", func(t *testing.T) {
+		count := func(ctx context.Context, userID string) error {
+			if userID == "user-2" {
+				return &quota.ExceededError{Quota: quota.Requests, Limit: 100, RetryAfter: time.Hour}
+			}
+			return nil
+		}
+		conn, service, _, _ := serve(t, newConfig(), nil, grpcserver.Auth(auth.TrustedHeader("X-User-ID")),
+			grpcserver.Service("grpc.testing.TestService", grpcserver.Quota(count)))
+		client := testpb.NewTestServiceClient(conn)
+
+		_, err := client.EmptyCall(metadata.AppendToOutgoingContext(context.Background(), "x-user-id", "user-1"), &testpb.Empty{})
+		assert.NoError(t, err)
+		<-service.calls
+
+		var header metadata.MD
+		_, err = client.EmptyCall(metadata.AppendToOutgoingContext(context.Background(), "x-user-id", "user-2"), &testpb.Empty{}, grpc.Header(&header))
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+		assert.Equal(t, "You have used up the 100 requests of the day", status.Convert(err).Message())
+		assert.Equal(t, []string{"3600"}, header.Get("retry-after"))
+	})
+
+	t.Run("when the rate limit is exceeded", func(t *testing.T) {
+		limiter := ratelimit.New(ratelimit.NewMemoryStore(), ratelimit.ByUser(auth.UserID))
+		rules := map[string][]ratelimit.Rule{
+			"/grpc.testing.TestService/EmptyCall": {{Name: "test", Limit: &ratelimit.Limit{Requests: 1, Period: time.Minute, Burst: 1}}},
+		}
+		conn, service, _, _ := serve(t, newConfig(), nil, grpcserver.Auth(auth.TrustedHeader("X-User-ID")), grpcserver.RateLimit(limiter, rules))
+		client := testpb.NewTestServiceClient(conn)
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "x-user-id", "user-1")
+
+		_, err := client.EmptyCall(ctx, &testpb.Empty{})
+		assert.NoError(t, err)
+		<-service.calls
+
+		var header metadata.MD
+		_, err = client.EmptyCall(ctx, &testpb.Empty{}, grpc.Header(&header))
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+		assert.NotEmpty(t, header.Get("retry-after"))
+
+		// Buckets are per caller
+		_, err = client.EmptyCall(metadata.AppendToOutgoingContext(context.Background(), "x-user-id", "user-2"), &testpb.Empty{})
+		assert.NoError(t, err)
+		<-service.calls
+	})
+
+	t.Run("when overloaded", func(t *testing.T) {
+		shedder := loadshed.New(&loadshed.Config{RetryAfter: 2 * time.Second}, loadshed.StaticLimit(1), loadshed.ByMethod())
+		classify := func(method string) loadshed.Priority { return loadshed.PriorityNormal }
+		conn, service, _, _ := serve(t, newConfig(), grpcserver.Shed(shedder, classify))
+		client := testpb.NewTestServiceClient(conn)
+
+		// Another call holds the only slot
+		release, ok := shedder.Acquire(loadshed.PriorityNormal)
+		assert.True(t, ok)
+
+		var header metadata.MD
+		_, err := client.EmptyCall(context.Background(), &testpb.Empty{}, grpc.Header(&header))
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+		assert.Equal(t, []string{"2"}, header.Get("retry-after"))
+
+		release(false)
+		_, err = client.EmptyCall(context.Background(), &testpb.Empty{})
+		assert.NoError(t, err)
+		<-service.calls
+		assert.Equal(t, 0, shedder.InFlight())
+	})
+}
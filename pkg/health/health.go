@@ -0,0 +1,166 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-chi/render"
+)
+
+const (
+	StatusOK           = "ok"
+	StatusUnavailable  = "unavailable"
+	StatusShuttingDown = "shutting_down"
+)
+
+// CheckFunc - dependency check, a nil error means the dependency is healthy
+type CheckFunc func(ctx context.Context) error
+
+// Result - outcome of a single check
+type Result struct {
+	Status    string    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	Duration  string    `json:"duration"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// Report - readiness report of every registered check
+type Report struct {
+	Status string             `json:"status"`
+	Checks map[string]*Result `json:"checks"`
+}
+
+type check struct {
+	name    string
+	timeout time.Duration
+	fn      CheckFunc
+
+	mu     sync.Mutex
+	result *Result
+}
+
+// Registry - registered dependency checks and the readiness state of the process
+type Registry struct {
+	mu           sync.RWMutex
+	checks       []*check
+	cacheTTL     time.Duration
+	shuttingDown int32
+}
+
+// New - make registry, check results are reused for cacheTTL
+func New(cacheTTL time.Duration) *Registry {
+	return &Registry{
+		cacheTTL: cacheTTL,
+	}
+}
+
+// Register - register a readiness check, each run is bounded by timeout
+func (h *Registry) Register(name string, timeout time.Duration, fn CheckFunc) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.checks = append(h.checks, &check{
+		name:    name,
+		timeout: timeout,
+		fn:      fn,
+	})
+}
+
+// SetShuttingDown - mark the process as not ready, readiness stays false afterwards
+func (h *Registry) SetShuttingDown() {
+	atomic.StoreInt32(&h.shuttingDown, 1)
+}
+
+// IsShuttingDown - whether SetShuttingDown was called
+func (h *Registry) IsShuttingDown() bool {
+	return atomic.LoadInt32(&h.shuttingDown) == 1
+}
+
+// Check - run every check concurrently (or reuse cached results) and build the report
+func (h *Registry) Check(ctx context.Context) *Report {
+	h.mu.RLock()
+	checks := make([]*check, len(h.checks))
+	copy(checks, h.checks)
+	h.mu.RUnlock()
+
+	results := make([]*Result, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c *check) {
+			defer wg.Done()
+			results[i] = c.run(ctx, h.cacheTTL)
+		}(i, c)
+	}
+	wg.Wait()
+
+	report := &Report{
+		Status: StatusOK,
+		Checks: make(map[string]*Result, len(checks)),
+	}
+	for i, c := range checks {
+		report.Checks[c.name] = results[i]
+		if results[i].Status != StatusOK {
+			report.Status = StatusUnavailable
+		}
+	}
+
+	if h.IsShuttingDown() {
+		report.Status = StatusShuttingDown
+	}
+
+	return report
+}
+
+// LivenessHandler - GET /healthz, the process is alive as long as it can answer
+func (h *Registry) LivenessHandler(w http.ResponseWriter, r *http.Request) {
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &Report{
+		Status: StatusOK,
+		Checks: map[string]*Result{},
+	})
+}
+
+// ReadinessHandler - GET /readyz, 503 when a check fails or the process is shutting down
+func (h *Registry) ReadinessHandler(w http.ResponseWriter, r *http.Request) {
+	report := h.Check(r.Context())
+
+	status := http.StatusOK
+	if report.Status != StatusOK {
+		status = http.StatusServiceUnavailable
+	}
+
+	render.Status(r, status)
+	render.JSON(w, r, report)
+}
+
+func (c *check) run(ctx context.Context, cacheTTL time.Duration) *Result {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.result != nil && time.Since(c.result.CheckedAt) < cacheTTL {
+		return c.result
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := c.fn(ctx)
+
+	result := &Result{
+		Status:    StatusOK,
+		Duration:  time.Since(start).String(),
+		CheckedAt: start,
+	}
+	if err != nil {
+		result.Status = StatusUnavailable
+		result.Error = err.Error()
+	}
+	c.result = result
+
+	return result
+}
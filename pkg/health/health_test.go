@@ -0,0 +1,114 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go-clean-architecture/pkg/health"
+	errorsutil "go-clean-architecture/utils/errors"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func readiness(t *testing.T, registry *health.Registry) (int, *health.Report) {
+	req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+	rr := httptest.NewRecorder()
+
+	registry.ReadinessHandler(rr, req)
+
+	report := &health.Report{}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), report))
+
+	return rr.Code, report
+}
+
+func TestLivenessHandler(t *testing.T) {
+	registry := health.New(0)
+	registry.Register("mongodb", time.Second, func(ctx context.Context) error {
+		return errorsutil.ErrDefault
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	rr := httptest.NewRecorder()
+
+	registry.LivenessHandler(rr, req)
+
+	// Liveness does not depend on registered checks
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestReadinessHandler(t *testing.T) {
+	t.Run("when every check passes", func(t *testing.T) {
+		registry := health.New(0)
+		registry.Register("mongodb", time.Second, func(ctx context.Context) error {
+			return nil
+		})
+
+		code, report := readiness(t, registry)
+
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, health.StatusOK, report.Status)
+		assert.Equal(t, health.StatusOK, report.Checks["mongodb"].Status)
+	})
+
+	t.Run("when a check fails", func(t *testing.T) {
+		registry := health.New(0)
+		registry.Register("mongodb", time.Second, func(ctx context.Context) error {
+			return nil
+		})
+		registry.Register("cache", time.Second, func(ctx context.Context) error {
+			return errorsutil.ErrDefault
+		})
+
+		code, report := readiness(t, registry)
+
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, health.StatusUnavailable, report.Status)
+		assert.Equal(t, health.StatusOK, report.Checks["mongodb"].Status)
+		assert.Equal(t, "error", report.Checks["cache"].Error)
+	})
+
+	t.Run("when a check times out", func(t *testing.T) {
+		registry := health.New(0)
+		registry.Register("mongodb", 10*time.Millisecond, func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+
+		code, report := readiness(t, registry)
+
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, context.DeadlineExceeded.Error(), report.Checks["mongodb"].Error)
+	})
+
+	t.Run("when result is cached", func(t *testing.T) {
+		calls := 0
+		registry := health.New(time.Minute)
+		registry.Register("mongodb", time.Second, func(ctx context.Context) error {
+			calls++
+			return nil
+		})
+
+		readiness(t, registry)
+		readiness(t, registry)
+
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("when shutting down", func(t *testing.T) {
+		registry := health.New(0)
+		registry.Register("mongodb", time.Second, func(ctx context.Context) error {
+			return nil
+		})
+		registry.SetShuttingDown()
+
+		code, report := readiness(t, registry)
+
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, health.StatusShuttingDown, report.Status)
+	})
+}
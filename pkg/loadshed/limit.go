@@ -0,0 +1,124 @@
+package loadshed
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Limit - maximum number of requests served concurrently, adaptive limits adjust it from completed requests
+type Limit interface {
+	Limit() int
+	// Observe - record a request that completed after latency with inflight requests being served,
+	// dropped means it failed because of overload (503 or 504)
+	Observe(latency time.Duration, inflight int, dropped bool)
+}
+
+// StaticLimit - fixed limit
+type StaticLimit int
+
+func (l StaticLimit) Limit() int {
+	return int(l)
+}
+
+func (l StaticLimit) Observe(latency time.Duration, inflight int, dropped bool) {}
+
+// AIMDConfig - configuration of AIMDLimit
+type AIMDConfig struct {
+	Initial int
+	Min     int
+	Max     int
+	// TargetLatency - average latency above which the limit is decreased
+	TargetLatency time.Duration
+	// Window - how often the limit is adjusted
+	Window time.Duration
+	// Backoff - factor applied on decrease, e.g. 0.9
+	Backoff float64
+}
+
+// AIMDLimit - additive increase, multiplicative decrease: every window the limit grows by one while the
+// average latency stays under the target and the limit is actually used, and shrinks by Backoff as soon as
+// latency goes over the target or requests are dropped
+type AIMDLimit struct {
+	mu  sync.Mutex
+	cfg AIMDConfig
+	now func() time.Time
+
+	limit       float64
+	windowStart time.Time
+	count       int
+	total       time.Duration
+	dropped     bool
+	maxInflight int
+}
+
+// NewAIMDLimit - make adaptive limit starting at cfg.Initial
+func NewAIMDLimit(cfg AIMDConfig) *AIMDLimit {
+	if cfg.Min <= 0 {
+		cfg.Min = 1
+	}
+	if cfg.Max < cfg.Min {
+		cfg.Max = cfg.Min
+	}
+	if cfg.Initial < cfg.Min || cfg.Initial > cfg.Max {
+		cfg.Initial = cfg.Min
+	}
+	if cfg.Backoff <= 0 || cfg.Backoff >= 1 {
+		cfg.Backoff = 0.9
+	}
+
+	return &AIMDLimit{
+		cfg:         cfg,
+		now:         time.Now,
+		limit:       float64(cfg.Initial),
+		windowStart: time.Now(),
+	}
+}
+
+func (l *AIMDLimit) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return int(l.limit)
+}
+
+func (l *AIMDLimit) Observe(latency time.Duration, inflight int, dropped bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.count++
+	l.total += latency
+	l.dropped = l.dropped || dropped
+	if inflight > l.maxInflight {
+		l.maxInflight = inflight
+	}
+
+	now := l.now()
+	if now.Sub(l.windowStart) < l.cfg.Window {
+		return
+	}
+
+	average := l.total / time.Duration(l.count)
+	switch {
+	case l.dropped || average > l.cfg.TargetLatency:
+		l.limit = math.Max(float64(l.cfg.Min), math.Floor(l.limit*l.cfg.Backoff))
+	case float64(l.maxInflight)*2 >= l.limit:
+		// Only grow a limit that is actually used, an idle service says nothing about capacity
+		l.limit = math.Min(float64(l.cfg.Max), l.limit+1)
+	}
+
+	l.windowStart = now
+	l.count = 0
+	l.total = 0
+	l.dropped = false
+	l.maxInflight = 0
+}
+
+// SetClock - replace the time source, used by tests
+func (l *AIMDLimit) SetClock(now func() time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.now = now
+	l.windowStart = now()
+}
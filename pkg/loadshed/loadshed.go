@@ -0,0 +1,261 @@
+package loadshed
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+
+	"go-clean-architecture/pkg/config"
+	pkgmetrics "go-clean-architecture/pkg/metrics"
+	responseutil "go-clean-architecture/utils/response"
+)
+
+// Priority - which requests keep being served when saturated
+type Priority int
+
+const (
+	// PriorityLow - may only use part of the limit (writes)
+	PriorityLow Priority = iota
+	// PriorityNormal - may use the whole limit (reads)
+	PriorityNormal
+	// PriorityCritical - never shed (health checks)
+	PriorityCritical
+	// PriorityExempt - neither shed nor counted (long-lived streams, which would hold a slot until they end)
+	PriorityExempt
+)
+
+func (p Priority) String() string {
+	switch p {
+	case PriorityExempt:
+		return "exempt"
+	case PriorityCritical:
+		return "critical"
+	case PriorityNormal:
+		return "normal"
+	default:
+		return "low"
+	}
+}
+
+// Classifier - priority of a request
+type Classifier func(r *http.Request) Priority
+
+// ByMethod - criticalPaths are critical, safe methods are normal and everything else is low
+func ByMethod(criticalPaths ...string) Classifier {
+	critical := map[string]bool{}
+	for _, path := range criticalPaths {
+		critical[path] = true
+	}
+
+	return func(r *http.Request) Priority {
+		if critical[r.URL.Path] {
+			return PriorityCritical
+		}
+
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			return PriorityNormal
+		default:
+			return PriorityLow
+		}
+	}
+}
+
+// Exempt - exemptPaths are exempt, others are classified by classify
+func Exempt(classify Classifier, exemptPaths ...string) Classifier {
+	exempt := map[string]bool{}
+	for _, path := range exemptPaths {
+		exempt[path] = true
+	}
+
+	return func(r *http.Request) Priority {
+		if exempt[r.URL.Path] {
+			return PriorityExempt
+		}
+
+		return classify(r)
+	}
+}
+
+// Config - load shedding configuration
+type Config struct {
+	// Mode - off, static or adaptive
+	Mode string
+	// Limit - static limit, or initial limit when adaptive
+	Limit    int
+	MinLimit int
+	MaxLimit int
+	// TargetLatency - adaptive mode decreases the limit when the average latency is above it
+	TargetLatency time.Duration
+	// Window - adaptive mode adjusts the limit once per window
+	Window time.Duration
+	// LowPriorityShare - share of the limit low priority requests may use, the rest is kept for reads
+	LowPriorityShare float64
+	// RetryAfter - sent with 503 responses
+	RetryAfter time.Duration
+}
+
+// LoadConfig - read load shedding configuration from environment variables
+func LoadConfig() *Config {
+	share, err := strconv.ParseFloat(config.GetString("LOADSHED_LOW_PRIORITY_SHARE", "0.8"), 64)
+	if err != nil {
+		share = 0.8
+	}
+
+	return &Config{
+		Mode:             config.GetString("LOADSHED_MODE", "off"),
+		Limit:            config.GetInt("LOADSHED_LIMIT", 100),
+		MinLimit:         config.GetInt("LOADSHED_MIN_LIMIT", 10),
+		MaxLimit:         config.GetInt("LOADSHED_MAX_LIMIT", 1000),
+		TargetLatency:    config.GetDuration("LOADSHED_TARGET_LATENCY", 500*time.Millisecond),
+		Window:           config.GetDuration("LOADSHED_WINDOW", time.Second),
+		LowPriorityShare: share,
+		RetryAfter:       config.GetDuration("LOADSHED_RETRY_AFTER", time.Second),
+	}
+}
+
+// NewLimit - limit selected by cfg.Mode, nil when load shedding is off
+func NewLimit(cfg *Config) (Limit, error) {
+	switch strings.ToLower(cfg.Mode) {
+	case "", "off":
+		return nil, nil
+	case "static":
+		if cfg.Limit <= 0 {
+			return nil, fmt.Errorf("loadshed: limit must be positive, got %d", cfg.Limit)
+		}
+		return StaticLimit(cfg.Limit), nil
+	case "adaptive":
+		return NewAIMDLimit(AIMDConfig{
+			Initial:       cfg.Limit,
+			Min:           cfg.MinLimit,
+			Max:           cfg.MaxLimit,
+			TargetLatency: cfg.TargetLatency,
+			Window:        cfg.Window,
+		}), nil
+	default:
+		return nil, fmt.Errorf("loadshed: unsupported mode %q", cfg.Mode)
+	}
+}
+
+// Shedder - caps in-flight requests and rejects the excess with 503 instead of letting it queue
+type Shedder struct {
+	limit      Limit
+	classify   Classifier
+	lowShare   float64
+	retryAfter string
+
+	mu       sync.Mutex
+	inflight int
+}
+
+// New - make shedder enforcing limit, requests are prioritised by classify
+func New(cfg *Config, limit Limit, classify Classifier) *Shedder {
+	lowShare := cfg.LowPriorityShare
+	if lowShare <= 0 || lowShare > 1 {
+		lowShare = 1
+	}
+
+	retryAfter := int(math.Ceil(cfg.RetryAfter.Seconds()))
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+
+	pkgmetrics.SetConcurrencyLimit(limit.Limit())
+
+	return &Shedder{
+		limit:      limit,
+		classify:   classify,
+		lowShare:   lowShare,
+		retryAfter: strconv.Itoa(retryAfter),
+	}
+}
+
+// Middleware - serve the request when its priority still has capacity, otherwise respond 503 with Retry-After
+func (s *Shedder) Middleware(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		release, ok := s.Acquire(s.classify(r))
+		if !ok {
+			w.Header().Set("Retry-After", s.retryAfter)
+			responseutil.ResponseOverloaded(w, r)
+			return
+		}
+
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		defer func() {
+			status := ww.Status()
+			release(status == http.StatusServiceUnavailable || status == http.StatusGatewayTimeout)
+		}()
+		next.ServeHTTP(ww, r)
+	}
+
+	return http.HandlerFunc(fn)
+}
+
+// Acquire - reserve a slot for a request of priority, false when priority has no capacity left. release frees the
+// slot once the request completed, dropped tells whether it timed out or was refused downstream.
+// Servers other than the HTTP router, e.g. gRPC, shed with it
+func (s *Shedder) Acquire(priority Priority) (release func(dropped bool), ok bool) {
+	if priority == PriorityExempt {
+		return func(bool) {}, true
+	}
+
+	inflight, ok := s.acquire(priority)
+	if !ok {
+		pkgmetrics.ObserveShed(priority.String())
+		return nil, false
+	}
+
+	start := time.Now()
+	return func(dropped bool) {
+		s.release()
+		if priority != PriorityCritical {
+			s.limit.Observe(time.Since(start), inflight, dropped)
+			pkgmetrics.SetConcurrencyLimit(s.limit.Limit())
+		}
+	}, true
+}
+
+// RetryAfter - seconds shed requests should wait before retrying
+func (s *Shedder) RetryAfter() string {
+	return s.retryAfter
+}
+
+// acquire - reserve a slot for priority, returning the in-flight count including this request
+func (s *Shedder) acquire(priority Priority) (int, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if priority != PriorityCritical {
+		capacity := s.limit.Limit()
+		if priority == PriorityLow {
+			capacity = int(math.Max(1, math.Floor(float64(capacity)*s.lowShare)))
+		}
+		if s.inflight >= capacity {
+			return s.inflight, false
+		}
+	}
+
+	s.inflight++
+	return s.inflight, true
+}
+
+func (s *Shedder) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.inflight--
+}
+
+// InFlight - number of requests currently served
+func (s *Shedder) InFlight() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.inflight
+}
@@ -0,0 +1,133 @@
+package loadshed_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"go-clean-architecture/pkg/loadshed"
+)
+
+func TestShedder(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	cfg := &loadshed.Config{LowPriorityShare: 0.5, RetryAfter: 2 * time.Second}
+	shedder := loadshed.New(cfg, loadshed.StaticLimit(2), loadshed.Exempt(loadshed.ByMethod("/healthz"), "/todo/events"))
+	streamInFlight := -1
+	handler := shedder.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/todo/events" {
+			streamInFlight = shedder.InFlight()
+		}
+		if r.URL.Query().Get("block") != "" {
+			started <- struct{}{}
+			<-release
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	do := func(method string, target string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(method, target, nil))
+		return rr
+	}
+
+	// One read is in flight: writes have used up their share, reads have one slot left
+	done := make(chan struct{})
+	go func() {
+		do(http.MethodGet, "/todo?block=1")
+		close(done)
+	}()
+	<-started
+
+	t.Run("when low priority share is used up", func(t *testing.T) {
+		rr := do(http.MethodPost, "/todo")
+
+		assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+		assert.Equal(t, "2", rr.Header().Get("Retry-After"))
+	})
+
+	t.Run("when normal priority has capacity", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, do(http.MethodGet, "/todo").Code)
+	})
+
+	t.Run("when request is critical", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, do(http.MethodGet, "/healthz").Code)
+	})
+
+	t.Run("when request is exempt", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, do(http.MethodGet, "/todo/events").Code)
+		assert.Equal(t, 1, streamInFlight, "not counted as in flight")
+	})
+
+	close(release)
+	<-done
+
+	t.Run("when load is gone", func(t *testing.T) {
+		assert.Equal(t, 0, shedder.InFlight())
+		assert.Equal(t, http.StatusOK, do(http.MethodPost, "/todo").Code)
+	})
+}
+
+func TestAIMDLimit(t *testing.T) {
+	now := time.Now()
+	limit := loadshed.NewAIMDLimit(loadshed.AIMDConfig{
+		Initial:       10,
+		Min:           2,
+		Max:           11,
+		TargetLatency: 100 * time.Millisecond,
+		Window:        time.Second,
+		Backoff:       0.5,
+	})
+	limit.SetClock(func() time.Time { return now })
+
+	t.Run("when latency is under target", func(t *testing.T) {
+		limit.Observe(10*time.Millisecond, 6, false)
+		assert.Equal(t, 10, limit.Limit(), "adjusted once per window")
+
+		now = now.Add(time.Second)
+		limit.Observe(10*time.Millisecond, 6, false)
+		assert.Equal(t, 11, limit.Limit())
+
+		now = now.Add(time.Second)
+		limit.Observe(10*time.Millisecond, 6, false)
+		assert.Equal(t, 11, limit.Limit(), "capped at max")
+	})
+
+	t.Run("when limit is not used", func(t *testing.T) {
+		now = now.Add(time.Second)
+		limit.Observe(10*time.Millisecond, 1, false)
+		assert.Equal(t, 11, limit.Limit())
+	})
+
+	t.Run("when latency is over target", func(t *testing.T) {
+		now = now.Add(time.Second)
+		limit.Observe(300*time.Millisecond, 6, false)
+		assert.Equal(t, 5, limit.Limit())
+	})
+
+	t.Run("when requests are dropped", func(t *testing.T) {
+		now = now.Add(time.Second)
+		limit.Observe(10*time.Millisecond, 1, true)
+		assert.Equal(t, 2, limit.Limit())
+
+		now = now.Add(time.Second)
+		limit.Observe(10*time.Millisecond, 1, true)
+		assert.Equal(t, 2, limit.Limit(), "floored at min")
+	})
+}
+
+func TestNewLimit(t *testing.T) {
+	limit, err := loadshed.NewLimit(&loadshed.Config{Mode: "off"})
+	assert.NoError(t, err)
+	assert.Nil(t, limit)
+
+	limit, err = loadshed.NewLimit(&loadshed.Config{Mode: "static", Limit: 5})
+	assert.NoError(t, err)
+	assert.Equal(t, 5, limit.Limit())
+
+	_, err = loadshed.NewLimit(&loadshed.Config{Mode: "bogus"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,82 @@
+package logger
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+// LevelRequest - body of PUT /debug/log-level, an empty package changes the default level
+// and an empty level removes the package override
+type LevelRequest struct {
+	Package string `json:"package"`
+	Level   string `json:"level"`
+}
+
+// LevelResponse - current levels
+type LevelResponse struct {
+	Level    string            `json:"level"`
+	Packages map[string]string `json:"packages"`
+}
+
+// LevelHandler - GET returns the current levels, PUT changes one of them without restarting
+func LevelHandler(levels *Levels) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut:
+			req := &LevelRequest{}
+			if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+				http.Error(w, "invalid body", http.StatusBadRequest)
+				return
+			}
+
+			if req.Package != "" && req.Level == "" {
+				levels.Reset(req.Package)
+			} else if err := levels.Set(req.Package, req.Level); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			Named("pkg/logger").Info("log level changed", "package", req.Package, "level", req.Level)
+		default:
+			w.Header().Set("Allow", "GET, PUT")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		level, packages := levels.Snapshot()
+		render.JSON(w, r, &LevelResponse{
+			Level:    level,
+			Packages: packages,
+		})
+	})
+}
+
+// RequestLogger - access log entry per request written to l
+func RequestLogger(l Logger) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r)
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			l.WithContext(r.Context()).Info("request completed",
+				"method", r.Method,
+				"path", r.URL.RequestURI(),
+				"status", status,
+				"bytes", ww.BytesWritten(),
+				"duration", time.Since(start).String(),
+				"remote_addr", r.RemoteAddr,
+			)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
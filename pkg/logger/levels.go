@@ -0,0 +1,107 @@
+package logger
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Levels - default level plus per package overrides, safe for concurrent use
+type Levels struct {
+	mu       sync.RWMutex
+	level    logrus.Level
+	packages map[string]logrus.Level
+}
+
+// NewLevels - parse the default level and "package=level" pairs separated by comma
+func NewLevels(level string, packages string) (*Levels, error) {
+	levels := &Levels{
+		level:    logrus.InfoLevel,
+		packages: map[string]logrus.Level{},
+	}
+
+	if level != "" {
+		if err := levels.Set("", level); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, pair := range strings.Split(packages, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("logger: invalid package level %q", pair)
+		}
+		if err := levels.Set(strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])); err != nil {
+			return nil, err
+		}
+	}
+
+	return levels, nil
+}
+
+// Set - set level of pkg, an empty pkg sets the default level
+func (lv *Levels) Set(pkg string, level string) error {
+	parsed, err := logrus.ParseLevel(level)
+	if err != nil {
+		return fmt.Errorf("logger: %w", err)
+	}
+
+	lv.mu.Lock()
+	defer lv.mu.Unlock()
+
+	if pkg == "" {
+		lv.level = parsed
+		return nil
+	}
+	lv.packages[pkg] = parsed
+
+	return nil
+}
+
+// Reset - remove the override of pkg so it follows the default level again
+func (lv *Levels) Reset(pkg string) {
+	lv.mu.Lock()
+	defer lv.mu.Unlock()
+
+	delete(lv.packages, pkg)
+}
+
+// Level - effective level of name, the longest matching package prefix wins
+func (lv *Levels) Level(name string) logrus.Level {
+	lv.mu.RLock()
+	defer lv.mu.RUnlock()
+
+	level, matched := lv.level, -1
+	for pkg, pkgLevel := range lv.packages {
+		if (name == pkg || strings.HasPrefix(name, pkg+"/")) && len(pkg) > matched {
+			level, matched = pkgLevel, len(pkg)
+		}
+	}
+
+	return level
+}
+
+// Enabled - whether entries of level are logged for name
+func (lv *Levels) Enabled(name string, level logrus.Level) bool {
+	return level <= lv.Level(name)
+}
+
+// Snapshot - default level and per package levels as strings
+func (lv *Levels) Snapshot() (string, map[string]string) {
+	lv.mu.RLock()
+	defer lv.mu.RUnlock()
+
+	packages := make(map[string]string, len(lv.packages))
+	for pkg, level := range lv.packages {
+		packages[pkg] = level.String()
+	}
+
+	return lv.level.String(), packages
+}
@@ -1,7 +1,301 @@
 package logger
 
-import "github.com/sirupsen/logrus"
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+
+	"go-clean-architecture/pkg/config"
+	"go-clean-architecture/pkg/requestid"
+)
+
+// Logger - structured, leveled logger, fields are given as alternating keys and values
+type Logger interface {
+	Debug(msg string, keysAndValues ...interface{})
+	Info(msg string, keysAndValues ...interface{})
+	Warn(msg string, keysAndValues ...interface{})
+	Error(msg string, keysAndValues ...interface{})
+	// With - child logger always logging the given fields
+	With(keysAndValues ...interface{}) Logger
+	// Named - child logger whose level is looked up by name (e.g. todo/repository)
+	Named(name string) Logger
+	// WithContext - child logger carrying the request id of ctx
+	WithContext(ctx context.Context) Logger
+}
+
+// Config - logger configuration
+type Config struct {
+	// Level - default level (debug, info, warn, error)
+	Level string
+	// Levels - per package levels, e.g. "todo/repository=debug,pkg/server=warn"
+	Levels string
+	// Format - json or console
+	Format string
+	// Output - comma separated sinks: stdout, stderr or a file path
+	Output string
+	// MaxSize - size in bytes after which a file output is rotated, 0 disables rotation
+	MaxSize int64
+	// MaxBackups - number of rotated files kept
+	MaxBackups int
+	// Sinks - additional writers every entry is written to
+	Sinks []io.Writer
+}
+
+// LoadConfig - read logger configuration from environment variables
+func LoadConfig() *Config {
+	return &Config{
+		Level:      config.GetString("LOG_LEVEL", "info"),
+		Levels:     config.GetString("LOG_LEVELS", ""),
+		Format:     config.GetString("LOG_FORMAT", "console"),
+		Output:     config.GetString("LOG_OUTPUT", "stderr"),
+		MaxSize:    int64(config.GetInt("LOG_FILE_MAX_SIZE_MB", 100)) * 1024 * 1024,
+		MaxBackups: config.GetInt("LOG_FILE_MAX_BACKUPS", 5),
+	}
+}
+
+// StdLogger - Logger implementation backed by logrus
+type StdLogger struct {
+	base    *logrus.Logger
+	levels  *Levels
+	closers []io.Closer
+
+	name   string
+	fields logrus.Fields
+}
+
+// New - make logger from config
+func New(cfg *Config) (*StdLogger, error) {
+	levels, err := NewLevels(cfg.Level, cfg.Levels)
+	if err != nil {
+		return nil, err
+	}
+
+	base := logrus.New()
+	// Filtering happens per logger name in Levels, logrus lets everything through
+	base.SetLevel(logrus.TraceLevel)
+
+	switch strings.ToLower(cfg.Format) {
+	case "json":
+		base.SetFormatter(&logrus.JSONFormatter{})
+	case "", "console":
+		base.SetFormatter(&logrus.TextFormatter{FullTimestamp: true})
+	default:
+		return nil, fmt.Errorf("logger: unsupported format %q", cfg.Format)
+	}
+
+	l := &StdLogger{
+		base:   base,
+		levels: levels,
+		fields: logrus.Fields{},
+	}
+
+	writers := []io.Writer{}
+	for _, output := range strings.Split(cfg.Output, ",") {
+		switch output = strings.TrimSpace(output); output {
+		case "":
+		case "stdout":
+			writers = append(writers, os.Stdout)
+		case "stderr":
+			writers = append(writers, os.Stderr)
+		default:
+			file, err := NewRotatingFile(output, cfg.MaxSize, cfg.MaxBackups)
+			if err != nil {
+				l.Close() //nolint:errcheck
+				return nil, err
+			}
+			writers = append(writers, file)
+			l.closers = append(l.closers, file)
+		}
+	}
+	writers = append(writers, cfg.Sinks...)
+
+	switch len(writers) {
+	case 0:
+		base.SetOutput(os.Stderr)
+	case 1:
+		base.SetOutput(writers[0])
+	default:
+		base.SetOutput(io.MultiWriter(writers...))
+	}
+
+	return l, nil
+}
+
+// Levels - levels of this logger and its children, changeable at runtime
+func (l *StdLogger) Levels() *Levels {
+	return l.levels
+}
+
+// Close - close file outputs
+func (l *StdLogger) Close() error {
+	var firstErr error
+	for _, closer := range l.closers {
+		if err := closer.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+// Sync - flush file outputs. Standard streams are left alone, syncing them fails on pipes and terminals
+func (l *StdLogger) Sync() error {
+	for _, closer := range l.closers {
+		if syncer, ok := closer.(interface{ Sync() error }); ok {
+			if err := syncer.Sync(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (l *StdLogger) Debug(msg string, keysAndValues ...interface{}) {
+	l.log(logrus.DebugLevel, msg, keysAndValues)
+}
+
+func (l *StdLogger) Info(msg string, keysAndValues ...interface{}) {
+	l.log(logrus.InfoLevel, msg, keysAndValues)
+}
+
+func (l *StdLogger) Warn(msg string, keysAndValues ...interface{}) {
+	l.log(logrus.WarnLevel, msg, keysAndValues)
+}
+
+func (l *StdLogger) Error(msg string, keysAndValues ...interface{}) {
+	l.log(logrus.ErrorLevel, msg, keysAndValues)
+}
+
+func (l *StdLogger) With(keysAndValues ...interface{}) Logger {
+	child := l.clone()
+	for key, value := range toFields(keysAndValues) {
+		child.fields[key] = value
+	}
+
+	return child
+}
+
+func (l *StdLogger) Named(name string) Logger {
+	child := l.clone()
+	child.name = name
+
+	return child
+}
+
+func (l *StdLogger) WithContext(ctx context.Context) Logger {
+	if id := requestid.FromContext(ctx); id != "" {
+		return l.With("request_id", id)
+	}
+
+	return l
+}
+
+func (l *StdLogger) clone() *StdLogger {
+	fields := make(logrus.Fields, len(l.fields))
+	for key, value := range l.fields {
+		fields[key] = value
+	}
+
+	return &StdLogger{
+		base:   l.base,
+		levels: l.levels,
+		name:   l.name,
+		fields: fields,
+	}
+}
+
+func (l *StdLogger) log(level logrus.Level, msg string, keysAndValues []interface{}) {
+	if !l.levels.Enabled(l.name, level) {
+		return
+	}
+
+	entry := l.base.WithFields(l.fields)
+	if l.name != "" {
+		entry = entry.WithField("logger", l.name)
+	}
+	if len(keysAndValues) > 0 {
+		entry = entry.WithFields(toFields(keysAndValues))
+	}
+
+	entry.Log(level, msg)
+}
+
+// toFields - pair up keys and values, errors are logged by message and a missing value is logged as is
+func toFields(keysAndValues []interface{}) logrus.Fields {
+	fields := logrus.Fields{}
+	for i := 0; i < len(keysAndValues); i += 2 {
+		key := fmt.Sprint(keysAndValues[i])
+		if i+1 >= len(keysAndValues) {
+			fields["!BADKEY"] = key
+			break
+		}
+
+		value := keysAndValues[i+1]
+		if err, ok := value.(error); ok {
+			value = err.Error()
+		}
+		fields[key] = value
+	}
+
+	return fields
+}
+
+var (
+	defaultMu     sync.RWMutex
+	defaultLogger = mustDefault()
+)
+
+func mustDefault() *StdLogger {
+	l, err := New(&Config{Level: "info", Format: "console", Output: "stderr"})
+	if err != nil {
+		panic(err)
+	}
+
+	return l
+}
+
+// SetDefault - replace the logger used by the package level functions
+func SetDefault(l *StdLogger) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	defaultLogger = l
+}
+
+// Default - logger used by the package level functions
+func Default() *StdLogger {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+
+	return defaultLogger
+}
+
+// Named - named child of the default logger
+func Named(name string) Logger {
+	return Default().Named(name)
+}
 
 func Error(err error) {
-	logrus.Error(err)
+	Default().Error(err.Error())
+}
+
+// ErrorContext - log error with the request id of ctx
+func ErrorContext(ctx context.Context, err error) {
+	WithContext(ctx).Error(err.Error())
+}
+
+// WithContext - default logger carrying the request id of ctx
+func WithContext(ctx context.Context) Logger {
+	return Default().WithContext(ctx)
+}
+
+// Sync - flush buffered log output, called before the process exits
+func Sync() error {
+	return Default().Sync()
 }
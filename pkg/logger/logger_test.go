@@ -0,0 +1,181 @@
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go-clean-architecture/pkg/logger"
+	"go-clean-architecture/pkg/requestid"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newJSONLogger(t *testing.T, levels string) (*logger.StdLogger, *bytes.Buffer) {
+	buf := &bytes.Buffer{}
+	l, err := logger.New(&logger.Config{
+		Level:  "info",
+		Levels: levels,
+		Format: "json",
+		Sinks:  []io.Writer{buf},
+	})
+	assert.NoError(t, err)
+
+	return l, buf
+}
+
+func entries(buf *bytes.Buffer) []map[string]interface{} {
+	result := []map[string]interface{}{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		entry := map[string]interface{}{}
+		json.Unmarshal([]byte(line), &entry) //nolint:errcheck
+		result = append(result, entry)
+	}
+
+	return result
+}
+
+func TestLoggerFields(t *testing.T) {
+	l, buf := newJSONLogger(t, "")
+
+	ctx := requestid.NewContext(context.Background(), "abc")
+	l.Named("todo/repository").WithContext(ctx).With("repository", "todo").Error("database operation failed", "operation", "FindAll", "error", io.EOF)
+
+	logged := entries(buf)
+	assert.Len(t, logged, 1)
+	assert.Equal(t, "error", logged[0]["level"])
+	assert.Equal(t, "database operation failed", logged[0]["msg"])
+	assert.Equal(t, "todo/repository", logged[0]["logger"])
+	assert.Equal(t, "abc", logged[0]["request_id"])
+	assert.Equal(t, "todo", logged[0]["repository"])
+	assert.Equal(t, "FindAll", logged[0]["operation"])
+	assert.Equal(t, "EOF", logged[0]["error"])
+}
+
+func TestLoggerLevels(t *testing.T) {
+	l, buf := newJSONLogger(t, "todo=debug,todo/service=error")
+
+	l.Debug("root debug")
+	l.Named("todo/repository").Debug("repository debug")
+	l.Named("todo/service").Warn("service warn")
+	l.Named("todo/service").Error("service error")
+
+	logged := entries(buf)
+	assert.Len(t, logged, 2)
+	assert.Equal(t, "repository debug", logged[0]["msg"])
+	assert.Equal(t, "service error", logged[1]["msg"])
+
+	// Levels change at runtime
+	assert.NoError(t, l.Levels().Set("", "debug"))
+	l.Debug("root debug")
+	assert.Len(t, entries(buf), 3)
+}
+
+func TestNewLevels(t *testing.T) {
+	_, err := logger.NewLevels("verbose", "")
+	assert.Error(t, err)
+
+	_, err = logger.NewLevels("info", "todo")
+	assert.Error(t, err)
+}
+
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	rf, err := logger.NewRotatingFile(path, 10, 2)
+	assert.NoError(t, err)
+	defer rf.Close()
+
+	for _, line := range []string{"first-1\n", "second-2\n", "third-33\n", "fourth-4\n"} {
+		_, err := rf.Write([]byte(line))
+		assert.NoError(t, err)
+	}
+
+	current, _ := os.ReadFile(path)
+	backup1, _ := os.ReadFile(path + ".1")
+	backup2, _ := os.ReadFile(path + ".2")
+	_, err = os.Stat(path + ".3")
+
+	assert.Equal(t, "fourth-4\n", string(current))
+	assert.Equal(t, "third-33\n", string(backup1))
+	assert.Equal(t, "second-2\n", string(backup2))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestLoggerSync(t *testing.T) {
+	t.Run("when stderr is a pipe", func(t *testing.T) {
+		r, w, err := os.Pipe()
+		assert.NoError(t, err)
+		defer r.Close()
+		defer w.Close()
+		stderr := os.Stderr
+		os.Stderr = w
+		defer func() { os.Stderr = stderr }()
+
+		l, err := logger.New(&logger.Config{Level: "info", Output: "stderr"})
+		assert.NoError(t, err)
+
+		assert.NoError(t, l.Sync())
+		assert.NoError(t, l.Close())
+	})
+
+	t.Run("when output is a file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "app.log")
+		l, err := logger.New(&logger.Config{Level: "info", Format: "json", Output: "stderr," + path})
+		assert.NoError(t, err)
+
+		l.Info("synced")
+		assert.NoError(t, l.Sync())
+		assert.NoError(t, l.Close())
+
+		content, _ := os.ReadFile(path)
+		assert.Contains(t, string(content), "synced")
+	})
+}
+
+func TestLevelHandler(t *testing.T) {
+	levels, err := logger.NewLevels("info", "")
+	assert.NoError(t, err)
+	handler := logger.LevelHandler(levels)
+
+	t.Run("when level is changed", func(t *testing.T) {
+		body := strings.NewReader(`{"package":"todo/repository","level":"debug"}`)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPut, "/debug/log-level", body))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		res := &logger.LevelResponse{}
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), res))
+		assert.Equal(t, "info", res.Level)
+		assert.Equal(t, "debug", res.Packages["todo/repository"])
+	})
+
+	t.Run("when level is invalid", func(t *testing.T) {
+		body := strings.NewReader(`{"level":"verbose"}`)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPut, "/debug/log-level", body))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("when package override is removed", func(t *testing.T) {
+		body := strings.NewReader(`{"package":"todo/repository"}`)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPut, "/debug/log-level", body))
+
+		res := &logger.LevelResponse{}
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), res))
+		assert.Empty(t, res.Packages)
+	})
+}
@@ -0,0 +1,120 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Collectors are registered in the default registry, which also exposes the Go runtime and process metrics
+var (
+	httpRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Total number of HTTP requests by route pattern, method and status.",
+	}, []string{"method", "route", "status"})
+
+	httpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request latency by route pattern, method and status.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	httpRequestsInFlight = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "http_requests_in_flight",
+		Help: "Number of HTTP requests currently being served.",
+	})
+
+	repositoryOperationDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "repository_operation_duration_seconds",
+		Help:    "Database operation latency by repository method and result.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"repository", "method", "result"})
+
+	concurrencyLimit = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "http_concurrency_limit",
+		Help: "Current limit of concurrently served HTTP requests.",
+	})
+
+	httpRequestsShed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_shed_total",
+		Help: "Total number of HTTP requests rejected by load shedding by priority.",
+	}, []string{"priority"})
+
+	webhookDeliveryAttempts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "webhook_delivery_attempts_total",
+		Help: "Total number of webhook delivery attempts by result: succeeded, retried or dead.",
+	}, []string{"result"})
+)
+
+// Handler - expose metrics in the prometheus text format
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// Middleware - record request count, latency and in-flight requests labeled by chi route pattern
+func Middleware(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		httpRequestsInFlight.Inc()
+		defer httpRequestsInFlight.Dec()
+
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		labels := prometheus.Labels{
+			"method": r.Method,
+			"route":  RoutePattern(r),
+			"status": strconv.Itoa(status),
+		}
+		httpRequestsTotal.With(labels).Inc()
+		httpRequestDuration.With(labels).Observe(time.Since(start).Seconds())
+	}
+
+	return http.HandlerFunc(fn)
+}
+
+// RoutePattern - matched chi route pattern, unmatched requests share one label to keep cardinality bounded
+func RoutePattern(r *http.Request) string {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil || rctx.RoutePattern() == "" {
+		return "unmatched"
+	}
+
+	return rctx.RoutePattern()
+}
+
+// ObserveRepository - record the duration of a repository method, meant to be deferred with a pointer to the returned error
+func ObserveRepository(repository string, method string, start time.Time, err *error) {
+	result := "ok"
+	if err != nil && *err != nil {
+		result = "error"
+	}
+
+	repositoryOperationDuration.WithLabelValues(repository, method, result).Observe(time.Since(start).Seconds())
+}
+
+// SetConcurrencyLimit - record the current concurrency limit
+func SetConcurrencyLimit(limit int) {
+	concurrencyLimit.Set(float64(limit))
+}
+
+// ObserveShed - count a request rejected by load shedding
+func ObserveShed(priority string) {
+	httpRequestsShed.WithLabelValues(priority).Inc()
+}
+
+// ObserveWebhookAttempt - count a webhook delivery attempt
+func ObserveWebhookAttempt(result string) {
+	webhookDeliveryAttempts.WithLabelValues(result).Inc()
+}
@@ -0,0 +1,55 @@
+package metrics_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	pkgmetrics "go-clean-architecture/pkg/metrics"
+	errorsutil "go-clean-architecture/utils/errors"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func scrape(t *testing.T) string {
+	rr := httptest.NewRecorder()
+	pkgmetrics.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	return rr.Body.String()
+}
+
+func TestMiddleware(t *testing.T) {
+	router := chi.NewRouter()
+	router.Use(pkgmetrics.Middleware)
+	router.Get("/todo/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/todo/1", nil))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/unknown/path", nil))
+
+	body := scrape(t)
+
+	// Labeled by route pattern, not by raw path
+	assert.Contains(t, body, `http_requests_total{method="GET",route="/todo/{id}",status="404"} 1`)
+	assert.Contains(t, body, `http_request_duration_seconds_count{method="GET",route="/todo/{id}",status="404"} 1`)
+	assert.Contains(t, body, `http_requests_total{method="GET",route="unmatched",status="404"} 1`)
+	assert.Contains(t, body, "http_requests_in_flight 0")
+	assert.Contains(t, body, "go_goroutines")
+}
+
+func TestObserveRepository(t *testing.T) {
+	var err error
+	pkgmetrics.ObserveRepository("todo", "FindAll", time.Now(), &err)
+
+	err = errorsutil.ErrDefault
+	pkgmetrics.ObserveRepository("todo", "FindAll", time.Now(), &err)
+
+	body := scrape(t)
+
+	assert.Contains(t, body, `repository_operation_duration_seconds_count{method="FindAll",repository="todo",result="ok"} 1`)
+	assert.Contains(t, body, `repository_operation_duration_seconds_count{method="FindAll",repository="todo",result="error"} 1`)
+}
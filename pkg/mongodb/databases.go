@@ -0,0 +1,84 @@
+package mongodb
+
+import (
+	"context"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"go-clean-architecture/pkg/logger"
+	"go-clean-architecture/pkg/tenant"
+)
+
+// Provisioner - prepare a database before its first use, e.g. create indexes. Must be idempotent
+type Provisioner func(ctx context.Context, db *mongo.Database) error
+
+// Databases - database of the tenant of a context, each database is provisioned on first use
+type Databases struct {
+	client       *mongo.Client
+	defaultName  string
+	provisioners []Provisioner
+
+	mu          sync.Mutex
+	provisioned map[string]*provisioning
+	log         logger.Logger
+}
+
+type provisioning struct {
+	mu   sync.Mutex
+	done bool
+}
+
+// NewDatabases - databases of client, defaultName is used when the context carries no tenant
+func NewDatabases(client *mongo.Client, defaultName string, provisioners ...Provisioner) *Databases {
+	return &Databases{
+		client:       client,
+		defaultName:  defaultName,
+		provisioners: provisioners,
+		provisioned:  map[string]*provisioning{},
+		log:          logger.Named("pkg/mongodb"),
+	}
+}
+
+// Get - provisioned database of the tenant of ctx
+func (d *Databases) Get(ctx context.Context) (*mongo.Database, error) {
+	name := d.defaultName
+	if t := tenant.FromContext(ctx); t != nil {
+		name = t.Database
+	}
+
+	db := d.client.Database(name)
+	if err := d.provision(ctx, db); err != nil {
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// provision - run the provisioners once per database, a failure is retried by the next call
+func (d *Databases) provision(ctx context.Context, db *mongo.Database) error {
+	d.mu.Lock()
+	p, ok := d.provisioned[db.Name()]
+	if !ok {
+		p = &provisioning{}
+		d.provisioned[db.Name()] = p
+	}
+	d.mu.Unlock()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.done {
+		return nil
+	}
+
+	for _, provisioner := range d.provisioners {
+		if err := provisioner(ctx, db); err != nil {
+			d.log.WithContext(ctx).Error("provisioning database failed", "database", db.Name(), "error", err)
+			return err
+		}
+	}
+	p.done = true
+	d.log.WithContext(ctx).Info("database provisioned", "database", db.Name())
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package mongodb_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	pkgmongodb "go-clean-architecture/pkg/mongodb"
+	"go-clean-architecture/pkg/tenant"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func newClient(t *testing.T) *mongo.Client {
+	// Selecting databases and collections needs no connection
+	client, err := mongo.NewClient(options.Client().ApplyURI("mongodb://localhost:27017"))
+	assert.NoError(t, err)
+
+	return client
+}
+
+func TestDatabasesIsolateTenants(t *testing.T) {
+	databases := pkgmongodb.NewDatabases(newClient(t), "default")
+
+	acme := tenant.NewContext(context.Background(), &tenant.Tenant{ID: "acme", Database: "tenant_acme"})
+	globex := tenant.NewContext(context.Background(), &tenant.Tenant{ID: "globex", Database: "tenant_globex"})
+
+	t.Run("when context carries a tenant", func(t *testing.T) {
+		db, err := databases.Get(acme)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "tenant_acme", db.Name())
+	})
+
+	t.Run("when another tenant reads", func(t *testing.T) {
+		db, err := databases.Get(globex)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "tenant_globex", db.Name())
+	})
+
+	t.Run("when context carries no tenant", func(t *testing.T) {
+		db, err := databases.Get(context.Background())
+
+		assert.NoError(t, err)
+		assert.Equal(t, "default", db.Name())
+	})
+}
+
+func TestDatabasesProvision(t *testing.T) {
+	acme := tenant.NewContext(context.Background(), &tenant.Tenant{ID: "acme", Database: "tenant_acme"})
+
+	t.Run("when database is used for the first time", func(t *testing.T) {
+		provisioned := map[string]int{}
+		databases := pkgmongodb.NewDatabases(newClient(t), "default", func(ctx context.Context, db *mongo.Database) error {
+			provisioned[db.Name()]++
+			return nil
+		})
+
+		for i := 0; i < 3; i++ {
+			_, err := databases.Get(acme)
+			assert.NoError(t, err)
+		}
+		_, err := databases.Get(context.Background())
+		assert.NoError(t, err)
+
+		assert.Equal(t, map[string]int{"tenant_acme": 1, "default": 1}, provisioned)
+	})
+
+	t.Run("when provisioning fails", func(t *testing.T) {
+		calls := 0
+		databases := pkgmongodb.NewDatabases(newClient(t), "default", func(ctx context.Context, db *mongo.Database) error {
+			calls++
+			if calls == 1 {
+				return errors.New("no primary")
+			}
+			return nil
+		})
+
+		_, err := databases.Get(acme)
+		assert.Error(t, err)
+
+		_, err = databases.Get(acme)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, calls)
+	})
+}
@@ -7,9 +7,10 @@ import (
 
 	"go-clean-architecture/pkg/logger"
 
-	"github.com/sirupsen/logrus"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.opentelemetry.io/contrib/instrumentation/go.mongodb.org/mongo-driver/mongo/otelmongo"
 )
 
 // InitMongoDB - initialize mongo
@@ -17,7 +18,12 @@ func InitMongoDB() (context.Context, func(), *mongo.Client) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	client, err := mongo.NewClient(options.Client().ApplyURI(os.Getenv("DB_URL")))
+	// Command monitor starts a span for every mongo command
+	clientOptions := options.Client().
+		ApplyURI(os.Getenv("DB_URL")).
+		SetMonitor(otelmongo.NewMonitor())
+
+	client, err := mongo.NewClient(clientOptions)
 	if err != nil {
 		logger.Error(err)
 	}
@@ -32,7 +38,14 @@ func InitMongoDB() (context.Context, func(), *mongo.Client) {
 	if err != nil {
 		logger.Error(err)
 	}
-	logrus.Println("Database connected")
+	logger.Named("pkg/mongodb").Info("Database connected")
 
 	return ctx, cancel, client
 }
+
+// Ping - health check pinging the primary
+func Ping(client *mongo.Client) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		return client.Ping(ctx, readpref.Primary())
+	}
+}
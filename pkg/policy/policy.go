@@ -0,0 +1,164 @@
+package policy
+
+import (
+	"fmt"
+	"strings"
+
+	"go-clean-architecture/pkg/auth"
+	"go-clean-architecture/pkg/config"
+	errorsutil "go-clean-architecture/utils/errors"
+)
+
+// Action - operation on a todo
+type Action string
+
+const (
+	ActionList   Action = "list"
+	ActionRead   Action = "read"
+	ActionCreate Action = "create"
+	ActionUpdate Action = "update"
+	ActionDelete Action = "delete"
+	// ActionShare - invite, change and remove collaborators
+	ActionShare Action = "share"
+	// ActionModerate - read and delete todos of the tenant the caller neither owns nor collaborates on,
+	// e.g. to take down abuse. Never granted by "*"
+	ActionModerate Action = "moderate"
+)
+
+// Actions - actions on the todos a caller owns or collaborates on, "*" in a role grants all of them
+var Actions = []Action{ActionList, ActionRead, ActionCreate, ActionUpdate, ActionDelete, ActionShare}
+
+// Config - policy configuration
+type Config struct {
+	// Roles - ";" separated roles, each "name=action,..." or "name=*", e.g. "admin=*,moderate;editor=*;viewer=list,read".
+	// Roles grant actions, which todos they apply to is decided by the todos' owners and collaborators
+	// unless the role moderates
+	Roles string
+	// DefaultRole - role of principals that carry no roles defined here, e.g. trusted header callers or users
+	// holding only a role other settings check, like LOG_LEVEL_ROLE
+	DefaultRole string
+}
+
+// LoadConfig - read policy configuration from environment variables
+func LoadConfig() *Config {
+	return &Config{
+		Roles:       config.GetString("RBAC_ROLES", "admin=*,moderate;editor=*;viewer=list,read"),
+		DefaultRole: config.GetString("RBAC_DEFAULT_ROLE", "editor"),
+	}
+}
+
+// Defines - whether role is one of the roles of cfg, for settings that refer to roles by name
+func (cfg *Config) Defines(role string) bool {
+	roles, err := ParseRoles(cfg.Roles)
+	if err != nil {
+		return false
+	}
+
+	_, ok := roles[role]
+	return ok
+}
+
+// Policy - decide whether a principal may perform an action
+type Policy interface {
+	// Authorize - nil when principal may perform action on the todos it can access, denials return
+	// errorsutil.ErrForbidden
+	Authorize(principal *auth.Principal, action Action) error
+}
+
+// RolePolicy - policy granting the actions of the principal's roles
+type RolePolicy struct {
+	roles       map[string][]Action
+	defaultRole string
+}
+
+// New - make role policy from cfg
+func New(cfg *Config) (Policy, error) {
+	roles, err := ParseRoles(cfg.Roles)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := roles[cfg.DefaultRole]; cfg.DefaultRole != "" && !ok {
+		return nil, fmt.Errorf("policy: default role %q is not defined", cfg.DefaultRole)
+	}
+
+	return &RolePolicy{
+		roles:       roles,
+		defaultRole: cfg.DefaultRole,
+	}, nil
+}
+
+// ParseRoles - parse the roles of Config.Roles
+func ParseRoles(s string) (map[string][]Action, error) {
+	roles := map[string][]Action{}
+	for _, def := range strings.Split(s, ";") {
+		def = strings.TrimSpace(def)
+		if def == "" {
+			continue
+		}
+
+		name, list, ok := strings.Cut(def, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("policy: invalid role %q, expected name=action,...", def)
+		}
+
+		actions := []Action{}
+		for _, action := range strings.Split(list, ",") {
+			action = strings.TrimSpace(action)
+			if action == "" {
+				continue
+			}
+			if action == "*" {
+				actions = append(actions, Actions...)
+				continue
+			}
+
+			if !isAction(Action(action)) && Action(action) != ActionModerate {
+				return nil, fmt.Errorf("policy: invalid action %q of role %q", action, name)
+			}
+			actions = append(actions, Action(action))
+		}
+
+		roles[name] = actions
+	}
+
+	return roles, nil
+}
+
+// Authorize - allowed when any role of principal grants action, principals without a defined role
+// get the default role
+func (p *RolePolicy) Authorize(principal *auth.Principal, action Action) error {
+	if principal == nil {
+		return errorsutil.ErrForbidden
+	}
+
+	roles := []string{}
+	for _, role := range principal.Roles {
+		if _, ok := p.roles[role]; ok {
+			roles = append(roles, role)
+		}
+	}
+	if len(roles) == 0 && p.defaultRole != "" {
+		roles = []string{p.defaultRole}
+	}
+
+	for _, role := range roles {
+		for _, granted := range p.roles[role] {
+			if granted == action {
+				return nil
+			}
+		}
+	}
+
+	return errorsutil.ErrForbidden
+}
+
+func isAction(action Action) bool {
+	for _, a := range Actions {
+		if a == action {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,98 @@
+package policy_test
+
+import (
+	"testing"
+
+	"go-clean-architecture/pkg/auth"
+	"go-clean-architecture/pkg/policy"
+	errorsutil "go-clean-architecture/utils/errors"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const roles = "admin=*,moderate;editor=*;author=list,read,create;viewer=list,read"
+
+func TestAuthorize(t *testing.T) {
+	p, err := policy.New(&policy.Config{Roles: roles, DefaultRole: "viewer"})
+	assert.NoError(t, err)
+
+	principal := func(roles ...string) *auth.Principal {
+		return &auth.Principal{Subject: "user-1", Roles: roles}
+	}
+
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		action    policy.Action
+		allowed   bool
+	}{
+		{"admin deletes", principal("admin"), policy.ActionDelete, true},
+		{"admin moderates", principal("admin"), policy.ActionModerate, true},
+
+		{"editor lists", principal("editor"), policy.ActionList, true},
+		{"editor deletes", principal("editor"), policy.ActionDelete, true},
+		{"editor shares", principal("editor"), policy.ActionShare, true},
+		{"editor moderates", principal("editor"), policy.ActionModerate, false},
+
+		{"author creates", principal("author"), policy.ActionCreate, true},
+		{"author updates", principal("author"), policy.ActionUpdate, false},
+
+		{"viewer lists", principal("viewer"), policy.ActionList, true},
+		{"viewer reads", principal("viewer"), policy.ActionRead, true},
+		{"viewer creates", principal("viewer"), policy.ActionCreate, false},
+		{"viewer updates", principal("viewer"), policy.ActionUpdate, false},
+		{"viewer deletes", principal("viewer"), policy.ActionDelete, false},
+
+		{"viewer and editor creates", principal("viewer", "editor"), policy.ActionCreate, true},
+		{"no roles gets the default role", principal(), policy.ActionRead, true},
+		{"no roles gets only the default role", principal(), policy.ActionCreate, false},
+		{"unknown role gets the default role", principal("owner"), policy.ActionList, true},
+		{"unknown role gets only the default role", principal("owner"), policy.ActionCreate, false},
+		{"unknown role keeps the defined roles", principal("owner", "editor"), policy.ActionCreate, true},
+		{"anonymous", nil, policy.ActionList, false},
+	}
+
+	for _, tt := range tests {
+		t.Run("when "+tt.name, func(t *testing.T) {
+			err := p.Authorize(tt.principal, tt.action)
+
+			if tt.allowed {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, errorsutil.ErrForbidden)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     *policy.Config
+		wantErr bool
+	}{
+		{"roles are valid", &policy.Config{Roles: roles, DefaultRole: "editor"}, false},
+		{"there is no default role", &policy.Config{Roles: roles}, false},
+		{"default role is not defined", &policy.Config{Roles: roles, DefaultRole: "owner"}, true},
+		{"action is unknown", &policy.Config{Roles: "editor=list,archive"}, true},
+		{"action is qualified", &policy.Config{Roles: "editor=delete:own"}, true},
+		{"role has no name", &policy.Config{Roles: "=list"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run("when "+tt.name, func(t *testing.T) {
+			_, err := policy.New(tt.cfg)
+
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func TestConfigDefines(t *testing.T) {
+	cfg := &policy.Config{Roles: roles}
+
+	assert.True(t, cfg.Defines("admin"))
+	assert.True(t, cfg.Defines("viewer"))
+	assert.False(t, cfg.Defines("owner"))
+	assert.False(t, (&policy.Config{Roles: "admin=archive"}).Defines("admin"))
+}
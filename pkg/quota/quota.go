@@ -0,0 +1,100 @@
+package quota
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go-clean-architecture/pkg/tenant"
+	errorsutil "go-clean-architecture/utils/errors"
+)
+
+// Scope - who shares a quota
+type Scope string
+
+const (
+	// ScopeUser - every user has their own quota (default)
+	ScopeUser Scope = "user"
+	// ScopeTenant - the users of a tenant share one quota
+	ScopeTenant Scope = "tenant"
+)
+
+// Quotas enforced, named in ExceededError and usage reports
+const (
+	Todos           = "todos"
+	DescriptionSize = "description_size"
+	Requests        = "requests"
+)
+
+// Limits - quotas of a tenant, zero means unlimited
+type Limits struct {
+	Scope Scope
+	// MaxTodos - todos stored per scope
+	MaxTodos int
+	// MaxDescriptionSize - bytes of a todo description
+	MaxDescriptionSize int
+	// RequestsPerDay - todo API requests per scope and UTC day
+	RequestsPerDay int
+}
+
+// LoadLimits - limits of the tenant of ctx, QUOTA_<NAME> overridden per tenant by TENANT_<ID>_QUOTA_<NAME>
+func LoadLimits(ctx context.Context) *Limits {
+	scope := ScopeUser
+	if Scope(strings.ToLower(tenant.GetString(ctx, "QUOTA_SCOPE", string(ScopeUser)))) == ScopeTenant {
+		scope = ScopeTenant
+	}
+
+	return &Limits{
+		Scope:              scope,
+		MaxTodos:           tenant.GetInt(ctx, "QUOTA_MAX_TODOS", 0),
+		MaxDescriptionSize: tenant.GetInt(ctx, "QUOTA_MAX_DESCRIPTION_SIZE", 0),
+		RequestsPerDay:     tenant.GetInt(ctx, "QUOTA_REQUESTS_PER_DAY", 0),
+	}
+}
+
+// Subject - whose usage userID counts towards, userID itself or empty for the whole tenant. Every
+// tenant has its own database, so the tenant id is not part of the subject
+func (l *Limits) Subject(userID string) string {
+	if l.Scope == ScopeTenant {
+		return ""
+	}
+
+	return userID
+}
+
+// ExceededError - a quota is used up, errors.Is matches errorsutil.ErrQuotaExceeded
+type ExceededError struct {
+	Quota string
+	Limit int
+	// RetryAfter - wait until the quota resets, zero when it only frees up as usage goes down
+	RetryAfter time.Duration
+}
+
+func (e *ExceededError) Error() string {
+	return fmt.Sprintf("%s quota of %d exceeded", e.Quota, e.Limit)
+}
+
+// Message - explanation of the exceeded quota for API responses
+func (e *ExceededError) Message() string {
+	switch e.Quota {
+	case Todos:
+		return fmt.Sprintf("You have reached the limit of %d items", e.Limit)
+	case DescriptionSize:
+		return fmt.Sprintf("Description is larger than the limit of %d bytes", e.Limit)
+	case Requests:
+		return fmt.Sprintf("You have used up the %d requests of the day", e.Limit)
+	}
+
+	return e.Error()
+}
+
+func (e *ExceededError) Is(target error) bool {
+	return target == errorsutil.ErrQuotaExceeded
+}
+
+// Day - UTC day of t, the period of daily quotas
+func Day(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,49 @@
+package quota_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go-clean-architecture/pkg/quota"
+	"go-clean-architecture/pkg/tenant"
+	errorsutil "go-clean-architecture/utils/errors"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadLimits(t *testing.T) {
+	t.Setenv("QUOTA_MAX_TODOS", "10")
+	t.Setenv("QUOTA_REQUESTS_PER_DAY", "100")
+	t.Setenv("TENANT_ACME_CORP_QUOTA_MAX_TODOS", "1000")
+	t.Setenv("TENANT_ACME_CORP_QUOTA_SCOPE", "Tenant")
+
+	t.Run("when no tenant", func(t *testing.T) {
+		limits := quota.LoadLimits(context.Background())
+
+		assert.Equal(t, &quota.Limits{Scope: quota.ScopeUser, MaxTodos: 10, RequestsPerDay: 100}, limits)
+		assert.Equal(t, "user-1", limits.Subject("user-1"))
+	})
+
+	t.Run("when the tenant overrides limits", func(t *testing.T) {
+		ctx := tenant.NewContext(context.Background(), &tenant.Tenant{ID: "acme-corp"})
+		limits := quota.LoadLimits(ctx)
+
+		assert.Equal(t, &quota.Limits{Scope: quota.ScopeTenant, MaxTodos: 1000, RequestsPerDay: 100}, limits)
+		assert.Equal(t, "", limits.Subject("user-1"))
+	})
+}
+
+func TestExceededError(t *testing.T) {
+	var err error = &quota.ExceededError{Quota: quota.Requests, Limit: 100}
+
+	assert.True(t, errors.Is(err, errorsutil.ErrQuotaExceeded))
+	assert.Equal(t, "requests quota of 100 exceeded", err.Error())
+}
+
+func TestDay(t *testing.T) {
+	jakarta := time.FixedZone("WIB", 7*60*60)
+
+	assert.Equal(t, time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC), quota.Day(time.Date(2026, 10, 18, 5, 0, 0, 0, jakarta)))
+}
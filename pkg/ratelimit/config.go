@@ -0,0 +1,76 @@
+package ratelimit
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+
+	"go-clean-architecture/pkg/config"
+)
+
+// Config - rate limiter configuration
+type Config struct {
+	// Store - memory or redis
+	Store string
+	// RedisURL - redis://[:password@]host:port/db, used by the redis store
+	RedisURL string
+	// RedisPrefix - prefix of bucket keys in Redis
+	RedisPrefix string
+	// Key - comma separated client identifiers tried in order: user, apikey, ip
+	Key string
+	// APIKeyHeader - header carrying the API key
+	APIKeyHeader string
+}
+
+// LoadConfig - read rate limiter configuration from environment variables
+func LoadConfig() *Config {
+	return &Config{
+		Store:        config.GetString("RATE_LIMIT_STORE", "memory"),
+		RedisURL:     config.GetString("RATE_LIMIT_REDIS_URL", "redis://localhost:6379/0"),
+		RedisPrefix:  config.GetString("RATE_LIMIT_REDIS_PREFIX", "ratelimit:"),
+		Key:          config.GetString("RATE_LIMIT_KEY", "user,apikey,ip"),
+		APIKeyHeader: config.GetString("RATE_LIMIT_API_KEY_HEADER", "X-API-Key"),
+	}
+}
+
+// NewStore - make the store selected by cfg, the returned close func releases its connections
+func NewStore(cfg *Config) (Store, func() error, error) {
+	switch strings.ToLower(cfg.Store) {
+	case "", "memory":
+		return NewMemoryStore(), func() error { return nil }, nil
+	case "redis":
+		opts, err := redis.ParseURL(cfg.RedisURL)
+		if err != nil {
+			return nil, nil, fmt.Errorf("ratelimit: %w", err)
+		}
+		client := redis.NewClient(opts)
+
+		return NewRedisStore(client, cfg.RedisPrefix), client.Close, nil
+	default:
+		return nil, nil, fmt.Errorf("ratelimit: unsupported store %q", cfg.Store)
+	}
+}
+
+// NewKeyFunc - key func of cfg.Key, userID resolves the authenticated user for "user"
+func NewKeyFunc(cfg *Config, userID func(r *http.Request) string) (KeyFunc, error) {
+	keys := []KeyFunc{}
+	for _, name := range strings.Split(cfg.Key, ",") {
+		switch name = strings.ToLower(strings.TrimSpace(name)); name {
+		case "":
+		case "ip":
+			keys = append(keys, ByIP)
+		case "apikey":
+			keys = append(keys, ByAPIKey(cfg.APIKeyHeader))
+		case "user":
+			if userID != nil {
+				keys = append(keys, ByUser(userID))
+			}
+		default:
+			return nil, fmt.Errorf("ratelimit: unsupported key %q", name)
+		}
+	}
+
+	return First(keys...), nil
+}
@@ -0,0 +1,68 @@
+package ratelimit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// KeyFunc - identify the client of a request, an empty key skips rate limiting
+type KeyFunc func(r *http.Request) string
+
+// ByIP - client IP taken from the connection, put middleware.RealIP in front of the limiter
+// only when the service runs behind a trusted proxy
+func ByIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if host == "" {
+		return ""
+	}
+
+	return "ip:" + host
+}
+
+// ByAPIKey - API key sent in header or as a bearer token, stored hashed so the store never holds secrets
+func ByAPIKey(header string) KeyFunc {
+	return func(r *http.Request) string {
+		key := r.Header.Get(header)
+		if key == "" {
+			if auth := r.Header.Get("Authorization"); len(auth) > 7 && strings.EqualFold(auth[:7], "bearer ") {
+				key = strings.TrimSpace(auth[7:])
+			}
+		}
+		if key == "" {
+			return ""
+		}
+
+		sum := sha256.Sum256([]byte(key))
+		return "key:" + hex.EncodeToString(sum[:16])
+	}
+}
+
+// ByUser - authenticated user returned by userID, e.g. the subject of the request principal
+func ByUser(userID func(r *http.Request) string) KeyFunc {
+	return func(r *http.Request) string {
+		if id := userID(r); id != "" {
+			return "user:" + id
+		}
+
+		return ""
+	}
+}
+
+// First - key of the first func returning one, e.g. First(ByUser(...), ByAPIKey("X-API-Key"), ByIP)
+func First(keys ...KeyFunc) KeyFunc {
+	return func(r *http.Request) string {
+		for _, key := range keys {
+			if k := key(r); k != "" {
+				return k
+			}
+		}
+
+		return ""
+	}
+}
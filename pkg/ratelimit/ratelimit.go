@@ -0,0 +1,252 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-clean-architecture/pkg/logger"
+	responseutil "go-clean-architecture/utils/response"
+)
+
+// Limit - token bucket refilled with Requests tokens every Period, holding at most Burst tokens
+type Limit struct {
+	Requests int
+	Period   time.Duration
+	Burst    int
+}
+
+// ParseLimit - parse "requests/period" with an optional ":burst" suffix, e.g. "100/1m" or "10/1s:20".
+// Only "off" returns nil (no limit), anything else that does not parse is an error
+func ParseLimit(value string) (*Limit, error) {
+	value = strings.TrimSpace(value)
+	if strings.EqualFold(value, "off") {
+		return nil, nil
+	}
+
+	rate, burst := value, ""
+	if i := strings.Index(value, ":"); i >= 0 {
+		rate, burst = value[:i], value[i+1:]
+	}
+
+	parts := strings.SplitN(rate, "/", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("ratelimit: invalid limit %q, expected requests/period", value)
+	}
+
+	requests, err := strconv.Atoi(parts[0])
+	if err != nil || requests <= 0 {
+		return nil, fmt.Errorf("ratelimit: invalid requests in limit %q", value)
+	}
+
+	period, err := parsePeriod(parts[1])
+	if err != nil || period <= 0 {
+		return nil, fmt.Errorf("ratelimit: invalid period in limit %q", value)
+	}
+
+	limit := &Limit{Requests: requests, Period: period, Burst: requests}
+	if burst != "" {
+		limit.Burst, err = strconv.Atoi(burst)
+		if err != nil || limit.Burst <= 0 {
+			return nil, fmt.Errorf("ratelimit: invalid burst in limit %q", value)
+		}
+	}
+
+	return limit, nil
+}
+
+// parsePeriod - duration where a bare unit means one of it ("s", "m", "h")
+func parsePeriod(value string) (time.Duration, error) {
+	switch value {
+	case "s":
+		return time.Second, nil
+	case "m":
+		return time.Minute, nil
+	case "h":
+		return time.Hour, nil
+	}
+
+	return time.ParseDuration(value)
+}
+
+// rate - tokens added per second
+func (l *Limit) rate() float64 {
+	return float64(l.Requests) / l.Period.Seconds()
+}
+
+// Result - outcome of taking a token from a bucket
+type Result struct {
+	Allowed bool
+	// Limit - bucket capacity
+	Limit int
+	// Remaining - whole tokens left after this request
+	Remaining int
+	// RetryAfter - wait until the next token is available, zero when allowed
+	RetryAfter time.Duration
+	// Reset - wait until the bucket is full again
+	Reset time.Duration
+}
+
+// newResult - result of a bucket holding tokens after the request was (or was not) allowed
+func newResult(limit *Limit, allowed bool, tokens float64) *Result {
+	rate := limit.rate()
+	result := &Result{
+		Allowed:   allowed,
+		Limit:     limit.Burst,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     seconds((float64(limit.Burst) - tokens) / rate),
+	}
+	if !allowed {
+		result.RetryAfter = seconds((1 - tokens) / rate)
+	}
+
+	return result
+}
+
+func seconds(s float64) time.Duration {
+	if s <= 0 {
+		return 0
+	}
+
+	return time.Duration(s * float64(time.Second))
+}
+
+// Store - token buckets keyed by client, shared by every limiter using it
+type Store interface {
+	// Take - take one token from the bucket of key
+	Take(ctx context.Context, key string, limit *Limit) (*Result, error)
+	// Refund - give back a token taken from the bucket of key for a request that was not served
+	Refund(ctx context.Context, key string, limit *Limit) error
+}
+
+// Rule - limit applied to the requests of a route group, requests of rules with a different
+// Name never share a bucket
+type Rule struct {
+	Name  string
+	Limit *Limit
+	// Methods - HTTP methods the rule applies to, all methods when empty
+	Methods []string
+}
+
+func (rule *Rule) matches(method string) bool {
+	if len(rule.Methods) == 0 {
+		return true
+	}
+
+	for _, m := range rule.Methods {
+		if strings.EqualFold(m, method) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Limiter - rate limiting middleware factory
+type Limiter struct {
+	store Store
+	key   KeyFunc
+	log   logger.Logger
+}
+
+// New - make limiter taking tokens from store for the client identified by key
+func New(store Store, key KeyFunc) *Limiter {
+	return &Limiter{
+		store: store,
+		key:   key,
+		log:   logger.Named("pkg/ratelimit"),
+	}
+}
+
+// Middleware - enforce rules, a request has to be allowed by every matching rule.
+// RateLimit-* headers describe the most restrictive rule, rejected requests get 429 with Retry-After.
+// Store failures let the request through so an unavailable store does not take the API down
+func (l *Limiter) Middleware(rules ...Rule) func(next http.Handler) http.Handler {
+	active := []Rule{}
+	for _, rule := range rules {
+		if rule.Limit != nil {
+			active = append(active, rule)
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		if len(active) == 0 {
+			return next
+		}
+
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			result := l.Take(r, active...)
+			if result != nil {
+				setHeaders(w, result)
+				if !result.Allowed {
+					w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+					responseutil.ResponseTooManyRequests(w, r)
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// Take - take a token for r from every rule matching it, the result of the most restrictive rule.
+// A denied request gives back the tokens the rules before the denying one took.
+// nil when the client of r is unknown or no rule took a token
+func (l *Limiter) Take(r *http.Request, rules ...Rule) *Result {
+	client := l.key(r)
+	if client == "" {
+		return nil
+	}
+
+	var tightest *Result
+	taken := []*Rule{}
+	for i := range rules {
+		rule := &rules[i]
+		if rule.Limit == nil || !rule.matches(r.Method) {
+			continue
+		}
+
+		result, err := l.store.Take(r.Context(), rule.Name+":"+client, rule.Limit)
+		if err != nil {
+			l.log.WithContext(r.Context()).Warn("rate limit store failed", "rule", rule.Name, "error", err)
+			continue
+		}
+
+		if !result.Allowed {
+			l.refund(r.Context(), client, taken)
+			return result
+		}
+		if tightest == nil || result.Remaining < tightest.Remaining {
+			tightest = result
+		}
+		taken = append(taken, rule)
+	}
+
+	return tightest
+}
+
+// refund - give back the tokens rules took for client
+func (l *Limiter) refund(ctx context.Context, client string, rules []*Rule) {
+	for _, rule := range rules {
+		if err := l.store.Refund(ctx, rule.Name+":"+client, rule.Limit); err != nil {
+			l.log.WithContext(ctx).Warn("rate limit store failed", "rule", rule.Name, "error", err)
+		}
+	}
+}
+
+// setHeaders - RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset (seconds) of the IETF draft
+func setHeaders(w http.ResponseWriter, result *Result) {
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
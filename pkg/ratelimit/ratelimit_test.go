@@ -0,0 +1,194 @@
+package ratelimit_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+
+	"go-clean-architecture/pkg/ratelimit"
+)
+
+func TestParseLimit(t *testing.T) {
+	limit, err := ratelimit.ParseLimit("100/1m")
+	assert.NoError(t, err)
+	assert.Equal(t, &ratelimit.Limit{Requests: 100, Period: time.Minute, Burst: 100}, limit)
+
+	limit, err = ratelimit.ParseLimit("10/s:20")
+	assert.NoError(t, err)
+	assert.Equal(t, &ratelimit.Limit{Requests: 10, Period: time.Second, Burst: 20}, limit)
+
+	limit, err = ratelimit.ParseLimit("off")
+	assert.NoError(t, err)
+	assert.Nil(t, limit)
+
+	for _, value := range []string{"", "100", "0/1m", "10/forever", "300/1x", "10/s:x"} {
+		_, err = ratelimit.ParseLimit(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	now := time.Now()
+	store := ratelimit.NewMemoryStore()
+	store.SetClock(func() time.Time { return now })
+	limit := &ratelimit.Limit{Requests: 2, Period: time.Second, Burst: 2}
+
+	for i := 0; i < 2; i++ {
+		result, err := store.Take(context.Background(), "ip:1", limit)
+		assert.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, 1-i, result.Remaining)
+	}
+
+	result, _ := store.Take(context.Background(), "ip:1", limit)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 500*time.Millisecond, result.RetryAfter)
+
+	// Other clients have their own bucket
+	result, _ = store.Take(context.Background(), "ip:2", limit)
+	assert.True(t, result.Allowed)
+
+	// Tokens are refilled over time
+	now = now.Add(500 * time.Millisecond)
+	result, _ = store.Take(context.Background(), "ip:1", limit)
+	assert.True(t, result.Allowed)
+}
+
+func TestRedisStore(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer client.Close()
+
+	// Two stores on the same server behave like two instances of the service
+	first := ratelimit.NewRedisStore(client, "ratelimit:")
+	second := ratelimit.NewRedisStore(client, "ratelimit:")
+	limit := &ratelimit.Limit{Requests: 2, Period: time.Hour, Burst: 2}
+
+	result, err := first.Take(context.Background(), "ip:1", limit)
+	assert.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 1, result.Remaining)
+
+	result, err = second.Take(context.Background(), "ip:1", limit)
+	assert.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+
+	result, err = first.Take(context.Background(), "ip:1", limit)
+	assert.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.True(t, result.RetryAfter > 0)
+	assert.True(t, server.Exists("ratelimit:ip:1"))
+}
+
+func TestRedisStoreRefund(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer client.Close()
+
+	store := ratelimit.NewRedisStore(client, "ratelimit:")
+	limit := &ratelimit.Limit{Requests: 1, Period: time.Hour, Burst: 1}
+
+	result, err := store.Take(context.Background(), "ip:1", limit)
+	assert.NoError(t, err)
+	assert.True(t, result.Allowed)
+
+	assert.NoError(t, store.Refund(context.Background(), "ip:1", limit))
+	assert.NoError(t, store.Refund(context.Background(), "ip:1", limit))
+
+	// Refunds never fill the bucket over its burst
+	result, err = store.Take(context.Background(), "ip:1", limit)
+	assert.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+
+	// Buckets that expired are full, there is nothing to give back
+	assert.NoError(t, store.Refund(context.Background(), "ip:2", limit))
+	assert.False(t, server.Exists("ratelimit:ip:2"))
+}
+
+func TestLimiterTake(t *testing.T) {
+	t.Run("when a later rule denies, earlier rules keep their tokens", func(t *testing.T) {
+		limiter := ratelimit.New(ratelimit.NewMemoryStore(), ratelimit.ByIP)
+		loose := ratelimit.Rule{Name: "loose", Limit: &ratelimit.Limit{Requests: 2, Period: time.Minute, Burst: 2}}
+		strict := ratelimit.Rule{Name: "strict", Limit: &ratelimit.Limit{Requests: 1, Period: time.Minute, Burst: 1}}
+		req := httptest.NewRequest(http.MethodGet, "/todo", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+
+		result := limiter.Take(req, loose, strict)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, 0, result.Remaining)
+
+		for i := 0; i < 3; i++ {
+			result = limiter.Take(req, loose, strict)
+			assert.False(t, result.Allowed)
+		}
+
+		// Only the first request took a token of loose
+		result = limiter.Take(req, loose)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, 0, result.Remaining)
+	})
+}
+
+func TestMiddleware(t *testing.T) {
+	limiter := ratelimit.New(ratelimit.NewMemoryStore(), ratelimit.First(ratelimit.ByAPIKey("X-API-Key"), ratelimit.ByIP))
+	handler := limiter.Middleware(
+		ratelimit.Rule{Name: "read", Methods: []string{http.MethodGet}, Limit: &ratelimit.Limit{Requests: 2, Period: time.Minute, Burst: 2}},
+		ratelimit.Rule{Name: "write", Methods: []string{http.MethodPost}, Limit: &ratelimit.Limit{Requests: 1, Period: time.Minute, Burst: 1}},
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	do := func(method string, remoteAddr string, apiKey string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/todo", nil)
+		req.RemoteAddr = remoteAddr
+		if apiKey != "" {
+			req.Header.Set("X-API-Key", apiKey)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("when limit is not reached", func(t *testing.T) {
+		rr := do(http.MethodGet, "10.0.0.1:1234", "")
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "2", rr.Header().Get("RateLimit-Limit"))
+		assert.Equal(t, "1", rr.Header().Get("RateLimit-Remaining"))
+		assert.Equal(t, "30", rr.Header().Get("RateLimit-Reset"))
+	})
+
+	t.Run("when limit is reached", func(t *testing.T) {
+		do(http.MethodGet, "10.0.0.1:1234", "")
+		rr := do(http.MethodGet, "10.0.0.1:5678", "")
+
+		assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+		assert.Equal(t, "30", rr.Header().Get("Retry-After"))
+		assert.Equal(t, "0", rr.Header().Get("RateLimit-Remaining"))
+		assert.Contains(t, rr.Body.String(), `"code":429`)
+	})
+
+	t.Run("when route group has its own limit", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, do(http.MethodPost, "10.0.0.1:1234", "").Code)
+		assert.Equal(t, http.StatusTooManyRequests, do(http.MethodPost, "10.0.0.1:1234", "").Code)
+	})
+
+	t.Run("when client uses an api key", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, do(http.MethodGet, "10.0.0.1:1234", "secret").Code)
+	})
+
+	t.Run("when no rule matches", func(t *testing.T) {
+		rr := do(http.MethodDelete, "10.0.0.1:1234", "")
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Empty(t, rr.Header().Get("RateLimit-Limit"))
+	})
+}
@@ -0,0 +1,87 @@
+package ratelimit
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// takeScript - atomic token bucket stored as a hash {tokens, ts}, the clock is the Redis server's
+// so instances with skewed clocks agree. Returns {allowed, tokens as string}
+var takeScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local time = redis.call("TIME")
+local now = tonumber(time[1]) + tonumber(time[2]) / 1000000
+
+local state = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil or ts == nil then
+	tokens = burst
+	ts = now
+end
+
+if now > ts then
+	tokens = math.min(burst, tokens + (now - ts) * rate)
+	ts = now
+end
+
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "ts", tostring(ts))
+redis.call("PEXPIRE", KEYS[1], math.ceil((burst - tokens) / rate * 1000) + 1000)
+
+return {allowed, tostring(tokens)}
+`)
+
+// refundScript - put a token back into the bucket of takeScript, an expired bucket was full already
+var refundScript = redis.NewScript(`
+local burst = tonumber(ARGV[1])
+local tokens = tonumber(redis.call("HGET", KEYS[1], "tokens"))
+if tokens == nil then
+	return 0
+end
+
+redis.call("HSET", KEYS[1], "tokens", tostring(math.min(burst, tokens + 1)))
+return 1
+`)
+
+// RedisStore - Store shared by every instance talking to the same Redis compatible server
+type RedisStore struct {
+	client redis.Scripter
+	prefix string
+}
+
+// NewRedisStore - make store keeping buckets under prefix, client may be a single node, sentinel or cluster client
+func NewRedisStore(client redis.Scripter, prefix string) *RedisStore {
+	return &RedisStore{
+		client: client,
+		prefix: prefix,
+	}
+}
+
+func (s *RedisStore) Take(ctx context.Context, key string, limit *Limit) (*Result, error) {
+	values, err := takeScript.Run(ctx, s.client, []string{s.prefix + key}, limit.rate(), limit.Burst).Slice()
+	if err != nil {
+		return nil, err
+	}
+
+	allowed, _ := values[0].(int64)
+	tokensStr, _ := values[1].(string)
+	tokens, err := strconv.ParseFloat(tokensStr, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	return newResult(limit, allowed == 1, tokens), nil
+}
+
+func (s *RedisStore) Refund(ctx context.Context, key string, limit *Limit) error {
+	return refundScript.Run(ctx, s.client, []string{s.prefix + key}, limit.Burst).Err()
+}
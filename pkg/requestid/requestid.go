@@ -0,0 +1,89 @@
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Header - request and response header carrying the request id
+const Header = "X-Request-ID"
+
+// maxLength - incoming ids longer than this are replaced
+const maxLength = 128
+
+// Middleware - accept the incoming X-Request-ID or generate one, store it in the
+// request context (where chi's middleware.Logger also finds it) and echo it in the response
+func Middleware(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(Header)
+		if !valid(id) {
+			id = New()
+		}
+
+		w.Header().Set(Header, id)
+		ctx := context.WithValue(r.Context(), middleware.RequestIDKey, id)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	}
+
+	return http.HandlerFunc(fn)
+}
+
+// New - generate a random request id
+func New() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+
+	return hex.EncodeToString(b)
+}
+
+// FromContext - request id of ctx, empty when there is none
+func FromContext(ctx context.Context) string {
+	return middleware.GetReqID(ctx)
+}
+
+// NewContext - copy of ctx carrying id, for work started outside of an http request
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, middleware.RequestIDKey, id)
+}
+
+// Transport - forward the request id of the request context on outgoing requests
+func Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		if id := FromContext(r.Context()); id != "" && r.Header.Get(Header) == "" {
+			r = r.Clone(r.Context())
+			r.Header.Set(Header, id)
+		}
+		return base.RoundTrip(r)
+	})
+}
+
+type roundTripperFunc func(r *http.Request) (*http.Response, error)
+
+func (fn roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return fn(r)
+}
+
+// valid - only non empty, reasonably short, printable ASCII ids are accepted
+func valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,71 @@
+package requestid_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go-clean-architecture/pkg/requestid"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func serve(req *http.Request) (*httptest.ResponseRecorder, string) {
+	var id string
+	handler := requestid.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id = requestid.FromContext(r.Context())
+	}))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	return rr, id
+}
+
+func TestMiddleware(t *testing.T) {
+	t.Run("when request id is generated", func(t *testing.T) {
+		rr, id := serve(httptest.NewRequest(http.MethodGet, "/todo", nil))
+
+		assert.Len(t, id, 32)
+		assert.Equal(t, id, rr.Header().Get(requestid.Header))
+	})
+
+	t.Run("when request id is accepted", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/todo", nil)
+		req.Header.Set(requestid.Header, "gateway-123")
+
+		rr, id := serve(req)
+
+		assert.Equal(t, "gateway-123", id)
+		assert.Equal(t, "gateway-123", rr.Header().Get(requestid.Header))
+	})
+
+	t.Run("when request id is invalid", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/todo", nil)
+		req.Header.Set(requestid.Header, strings.Repeat("a", 200))
+
+		_, id := serve(req)
+
+		assert.Len(t, id, 32)
+	})
+}
+
+func TestTransport(t *testing.T) {
+	var received string
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Get(requestid.Header)
+	}))
+	defer receiver.Close()
+
+	ctx := requestid.NewContext(httptest.NewRequest(http.MethodGet, "/", nil).Context(), "abc")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, receiver.URL, nil)
+	assert.NoError(t, err)
+
+	client := &http.Client{Transport: requestid.Transport(nil)}
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, "abc", received)
+}
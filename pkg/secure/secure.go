@@ -0,0 +1,74 @@
+package secure
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-clean-architecture/pkg/config"
+)
+
+// Config - security headers added to every response, an empty value leaves its header out
+type Config struct {
+	// HSTSMaxAge - Strict-Transport-Security max-age, sent on HTTPS requests only, zero disables it
+	HSTSMaxAge            time.Duration
+	HSTSIncludeSubdomains bool
+	HSTSPreload           bool
+	// FrameOptions - X-Frame-Options, DENY or SAMEORIGIN
+	FrameOptions          string
+	ContentSecurityPolicy string
+	ReferrerPolicy        string
+}
+
+// LoadConfig - read security header configuration from environment variables
+func LoadConfig() *Config {
+	return &Config{
+		HSTSMaxAge:            config.GetDuration("SECURITY_HSTS_MAX_AGE", 365*24*time.Hour),
+		HSTSIncludeSubdomains: config.GetBool("SECURITY_HSTS_INCLUDE_SUBDOMAINS", true),
+		HSTSPreload:           config.GetBool("SECURITY_HSTS_PRELOAD", false),
+		FrameOptions:          config.GetString("SECURITY_FRAME_OPTIONS", "DENY"),
+		// The API serves no documents, nothing needs to load or frame its responses
+		ContentSecurityPolicy: config.GetString("SECURITY_CONTENT_SECURITY_POLICY", "default-src 'none'; frame-ancestors 'none'"),
+		ReferrerPolicy:        config.GetString("SECURITY_REFERRER_POLICY", "no-referrer"),
+	}
+}
+
+// Headers - middleware setting the security headers of cfg before the response is written,
+// handlers may still override them
+func Headers(cfg *Config) func(next http.Handler) http.Handler {
+	hsts := ""
+	if cfg.HSTSMaxAge > 0 {
+		hsts = "max-age=" + strconv.Itoa(int(cfg.HSTSMaxAge.Seconds()))
+		if cfg.HSTSIncludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+		if cfg.HSTSPreload {
+			hsts += "; preload"
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			h := w.Header()
+			h.Set("X-Content-Type-Options", "nosniff")
+			if cfg.FrameOptions != "" {
+				h.Set("X-Frame-Options", cfg.FrameOptions)
+			}
+			if cfg.ContentSecurityPolicy != "" {
+				h.Set("Content-Security-Policy", cfg.ContentSecurityPolicy)
+			}
+			if cfg.ReferrerPolicy != "" {
+				h.Set("Referrer-Policy", cfg.ReferrerPolicy)
+			}
+			// Browsers ignore HSTS received over plain HTTP, TLS may end at a proxy in front of the server
+			if hsts != "" && (r.TLS != nil || strings.EqualFold(r.Header.Get("X-Forwarded-Proto"), "https")) {
+				h.Set("Strict-Transport-Security", hsts)
+			}
+
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
@@ -0,0 +1,73 @@
+package secure_test
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go-clean-architecture/pkg/secure"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func serve(cfg *secure.Config, req *http.Request) *httptest.ResponseRecorder {
+	handler := secure.Headers(cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	return rr
+}
+
+func TestHeaders(t *testing.T) {
+	cfg := &secure.Config{
+		HSTSMaxAge:            365 * 24 * time.Hour,
+		HSTSIncludeSubdomains: true,
+		FrameOptions:          "DENY",
+		ContentSecurityPolicy: "default-src 'none'",
+		ReferrerPolicy:        "no-referrer",
+	}
+
+	t.Run("when served over HTTPS", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/todo", nil)
+		req.TLS = &tls.ConnectionState{}
+
+		rr := serve(cfg, req)
+
+		assert.Equal(t, "max-age=31536000; includeSubDomains", rr.Header().Get("Strict-Transport-Security"))
+		assert.Equal(t, "nosniff", rr.Header().Get("X-Content-Type-Options"))
+		assert.Equal(t, "DENY", rr.Header().Get("X-Frame-Options"))
+		assert.Equal(t, "default-src 'none'", rr.Header().Get("Content-Security-Policy"))
+		assert.Equal(t, "no-referrer", rr.Header().Get("Referrer-Policy"))
+	})
+
+	t.Run("when TLS ends at a proxy", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/todo", nil)
+		req.Header.Set("X-Forwarded-Proto", "https")
+
+		rr := serve(cfg, req)
+
+		assert.NotEmpty(t, rr.Header().Get("Strict-Transport-Security"))
+	})
+
+	t.Run("when served over HTTP", func(t *testing.T) {
+		rr := serve(cfg, httptest.NewRequest(http.MethodGet, "/todo", nil))
+
+		assert.Empty(t, rr.Header().Get("Strict-Transport-Security"))
+		assert.Equal(t, "nosniff", rr.Header().Get("X-Content-Type-Options"))
+	})
+
+	t.Run("when headers are disabled", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/todo", nil)
+		req.TLS = &tls.ConnectionState{}
+
+		rr := serve(&secure.Config{}, req)
+
+		assert.Empty(t, rr.Header().Get("Strict-Transport-Security"))
+		assert.Empty(t, rr.Header().Get("X-Frame-Options"))
+		assert.Empty(t, rr.Header().Get("Content-Security-Policy"))
+		assert.Empty(t, rr.Header().Get("Referrer-Policy"))
+	})
+}
@@ -0,0 +1,220 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"go-clean-architecture/pkg/config"
+	"go-clean-architecture/pkg/logger"
+)
+
+// Config - http server configuration
+type Config struct {
+	Addr              string
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	MaxHeaderBytes    int
+	ShutdownTimeout   time.Duration
+	ShutdownDelay     time.Duration
+	TLS               TLSConfig
+}
+
+// LoadConfig - read server configuration from environment variables
+func LoadConfig() *Config {
+	return &Config{
+		Addr:              fmt.Sprintf("%s%s", ":", config.GetString("PORT", "5555")),
+		ReadTimeout:       config.GetDuration("SERVER_READ_TIMEOUT", 15*time.Second),
+		ReadHeaderTimeout: config.GetDuration("SERVER_READ_HEADER_TIMEOUT", 5*time.Second),
+		WriteTimeout:      config.GetDuration("SERVER_WRITE_TIMEOUT", 15*time.Second),
+		IdleTimeout:       config.GetDuration("SERVER_IDLE_TIMEOUT", 60*time.Second),
+		MaxHeaderBytes:    config.GetInt("SERVER_MAX_HEADER_BYTES", http.DefaultMaxHeaderBytes),
+		ShutdownTimeout:   config.GetDuration("SERVER_SHUTDOWN_TIMEOUT", 30*time.Second),
+		ShutdownDelay:     config.GetDuration("SERVER_SHUTDOWN_DELAY", 0),
+		TLS:               loadTLSConfig(),
+	}
+}
+
+// ShutdownFunc - called once the server stopped accepting requests
+type ShutdownFunc func(ctx context.Context) error
+
+type Server struct {
+	httpServer      *http.Server
+	tls             TLSConfig
+	redirectServer  *http.Server
+	shutdownTimeout time.Duration
+	shutdownDelay   time.Duration
+	onShutdownStart []func()
+	onShutdown      []ShutdownFunc
+	log             logger.Logger
+}
+
+// New - make server from config
+func New(cfg *Config, handler http.Handler) *Server {
+	s := &Server{
+		httpServer: &http.Server{
+			Addr:              cfg.Addr,
+			Handler:           handler,
+			ReadTimeout:       cfg.ReadTimeout,
+			ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+			WriteTimeout:      cfg.WriteTimeout,
+			IdleTimeout:       cfg.IdleTimeout,
+			MaxHeaderBytes:    cfg.MaxHeaderBytes,
+		},
+		tls:             cfg.TLS,
+		shutdownTimeout: cfg.ShutdownTimeout,
+		shutdownDelay:   cfg.ShutdownDelay,
+		log:             logger.Named("pkg/server"),
+	}
+
+	if !cfg.TLS.HTTP2 {
+		// A non-nil map keeps net/http from enabling HTTP/2
+		s.httpServer.TLSNextProto = map[string]func(*http.Server, *tls.Conn, http.Handler){}
+	}
+
+	return s
+}
+
+// OnShutdownStart - register fn to run as soon as shutdown starts, before requests stop being accepted
+func (s *Server) OnShutdownStart(fn func()) {
+	s.onShutdownStart = append(s.onShutdownStart, fn)
+}
+
+// OnShutdown - register fn to run after in-flight requests are drained, in registration order
+func (s *Server) OnShutdown(fn ShutdownFunc) {
+	s.onShutdown = append(s.onShutdown, fn)
+}
+
+// Run - listen on the configured address until ctx is done, then shutdown gracefully
+func (s *Server) Run(ctx context.Context) error {
+	ln, err := net.Listen("tcp", s.httpServer.Addr)
+	if err != nil {
+		return err
+	}
+
+	return s.Serve(ctx, ln)
+}
+
+// Serve - accept connections on ln until ctx is done, then shutdown gracefully. With TLS configured the
+// connections are TLS and the certificate is reloaded while serving
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	serve := s.httpServer.Serve
+	if s.tls.Enabled() {
+		if err := s.setupTLS(ctx, ln); err != nil {
+			ln.Close()
+			return err
+		}
+		serve = func(ln net.Listener) error {
+			return s.httpServer.ServeTLS(ln, "", "")
+		}
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		s.log.Info("Listening", "addr", ln.Addr().String(), "tls", s.tls.Enabled())
+		serveErr <- serve(ln)
+	}()
+
+	select {
+	case err := <-serveErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	case <-ctx.Done():
+	}
+
+	return s.Shutdown()
+}
+
+// setupTLS - load the certificate reloaded until ctx is done and start the HTTPS redirect listener
+func (s *Server) setupTLS(ctx context.Context, ln net.Listener) error {
+	certificates, err := newCertificateReloader(s.tls.CertFile, s.tls.KeyFile)
+	if err != nil {
+		return err
+	}
+
+	s.httpServer.TLSConfig, err = newTLSConfig(&s.tls, certificates)
+	if err != nil {
+		return err
+	}
+
+	if s.tls.ReloadInterval > 0 {
+		go certificates.watch(ctx, s.tls.ReloadInterval)
+	}
+
+	if s.tls.RedirectAddr == "" {
+		return nil
+	}
+
+	redirectLn, err := net.Listen("tcp", s.tls.RedirectAddr)
+	if err != nil {
+		return err
+	}
+	_, httpsPort, _ := net.SplitHostPort(ln.Addr().String())
+	s.redirectServer = &http.Server{
+		Handler:           RedirectHandler(httpsPort),
+		ReadTimeout:       s.httpServer.ReadTimeout,
+		ReadHeaderTimeout: s.httpServer.ReadHeaderTimeout,
+		WriteTimeout:      s.httpServer.WriteTimeout,
+		IdleTimeout:       s.httpServer.IdleTimeout,
+		MaxHeaderBytes:    s.httpServer.MaxHeaderBytes,
+	}
+	go func() {
+		s.log.Info("Redirecting to HTTPS", "addr", redirectLn.Addr().String())
+		if err := s.redirectServer.Serve(redirectLn); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.log.Error("redirect listener failed", "error", err)
+		}
+	}()
+
+	return nil
+}
+
+// Shutdown - run shutdown start hooks, wait for the shutdown delay, stop accepting requests, drain in-flight ones within the shutdown timeout, then run shutdown hooks
+func (s *Server) Shutdown() error {
+	s.log.Info("Shutting down server")
+
+	for _, fn := range s.onShutdownStart {
+		fn()
+	}
+
+	// Give load balancers time to notice the instance is not ready anymore
+	time.Sleep(s.shutdownDelay)
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+	defer cancel()
+
+	// Hooks always run so resources are released even if draining timed out,
+	// the first error is returned and the rest are logged
+	var firstErr error
+	record := func(err error) {
+		if firstErr == nil {
+			firstErr = err
+			return
+		}
+		s.log.Error("shutdown failed", "error", err)
+	}
+
+	if s.redirectServer != nil {
+		if err := s.redirectServer.Shutdown(ctx); err != nil {
+			record(fmt.Errorf("server: stop redirect listener: %w", err))
+		}
+	}
+	if err := s.httpServer.Shutdown(ctx); err != nil {
+		record(fmt.Errorf("server: drain requests: %w", err))
+	}
+
+	for _, fn := range s.onShutdown {
+		if err := fn(ctx); err != nil {
+			record(err)
+		}
+	}
+
+	return firstErr
+}
@@ -0,0 +1,124 @@
+package server_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"go-clean-architecture/pkg/server"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newConfig(shutdownTimeout time.Duration) *server.Config {
+	return &server.Config{
+		ReadTimeout:       time.Second,
+		ReadHeaderTimeout: time.Second,
+		WriteTimeout:      5 * time.Second,
+		IdleTimeout:       time.Second,
+		MaxHeaderBytes:    http.DefaultMaxHeaderBytes,
+		ShutdownTimeout:   shutdownTimeout,
+	}
+}
+
+func TestServerShutdown(t *testing.T) {
+	t.Run("when in-flight request is drained", func(t *testing.T) {
+		started := make(chan struct{})
+		release := make(chan struct{})
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			<-release
+			w.Write([]byte("done")) //nolint:errcheck
+		})
+
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		assert.NoError(t, err)
+
+		hooks := []string{}
+		srv := server.New(newConfig(5*time.Second), handler)
+		srv.OnShutdown(func(ctx context.Context) error {
+			hooks = append(hooks, "database")
+			return nil
+		})
+		srv.OnShutdown(func(ctx context.Context) error {
+			hooks = append(hooks, "logger")
+			return nil
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		serveErr := make(chan error, 1)
+		go func() {
+			serveErr <- srv.Serve(ctx, ln)
+		}()
+
+		respBody := make(chan string, 1)
+		go func() {
+			resp, err := http.Get("http://" + ln.Addr().String())
+			if err != nil {
+				respBody <- err.Error()
+				return
+			}
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+			respBody <- string(body)
+		}()
+
+		<-started
+		cancel()
+
+		// Shutdown waits for the in-flight request
+		select {
+		case <-serveErr:
+			t.Fatal("server stopped before in-flight request finished")
+		case <-time.After(100 * time.Millisecond):
+		}
+
+		close(release)
+
+		assert.Equal(t, "done", <-respBody)
+		assert.NoError(t, <-serveErr)
+		assert.Equal(t, []string{"database", "logger"}, hooks)
+
+		// New connections are refused after shutdown
+		_, err = http.Get("http://" + ln.Addr().String())
+		assert.Error(t, err)
+	})
+
+	t.Run("when drain deadline is exceeded", func(t *testing.T) {
+		started := make(chan struct{})
+		release := make(chan struct{})
+		defer close(release)
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			<-release
+		})
+
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		assert.NoError(t, err)
+
+		hookCalled := false
+		srv := server.New(newConfig(50*time.Millisecond), handler)
+		srv.OnShutdown(func(ctx context.Context) error {
+			hookCalled = true
+			return nil
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		serveErr := make(chan error, 1)
+		go func() {
+			serveErr <- srv.Serve(ctx, ln)
+		}()
+
+		go http.Get("http://" + ln.Addr().String()) //nolint:errcheck
+
+		<-started
+		cancel()
+
+		err = <-serveErr
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.True(t, hookCalled)
+	})
+}
@@ -0,0 +1,244 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"go-clean-architecture/pkg/config"
+	"go-clean-architecture/pkg/logger"
+)
+
+// TLSConfig - TLS termination configuration, the server speaks plain HTTP without CertFile
+type TLSConfig struct {
+	CertFile string
+	KeyFile  string
+	// MinVersion - lowest protocol version accepted, "1.0" to "1.3"
+	MinVersion string
+	// CipherSuites - names of the TLS 1.2 cipher suites offered, e.g. TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256.
+	// Empty uses the Go defaults, TLS 1.3 suites are not configurable
+	CipherSuites []string
+	// ClientCAFile - PEM bundle of the CAs client certificates are verified against
+	ClientCAFile string
+	// ClientAuth - none, request, require, verify-if-given or require-and-verify
+	ClientAuth string
+	// ReloadInterval - how often the certificate and key files are checked for a rotated pair, zero disables it
+	ReloadInterval time.Duration
+	// HTTP2 - negotiate HTTP/2 over TLS
+	HTTP2 bool
+	// RedirectAddr - address of a plain HTTP listener redirecting every request to HTTPS, empty disables it
+	RedirectAddr string
+}
+
+// loadTLSConfig - read TLS configuration from environment variables
+func loadTLSConfig() TLSConfig {
+	suites := []string{}
+	for _, suite := range strings.Split(config.GetString("SERVER_TLS_CIPHER_SUITES", ""), ",") {
+		if suite = strings.TrimSpace(suite); suite != "" {
+			suites = append(suites, suite)
+		}
+	}
+
+	return TLSConfig{
+		CertFile:       config.GetString("SERVER_TLS_CERT_FILE", ""),
+		KeyFile:        config.GetString("SERVER_TLS_KEY_FILE", ""),
+		MinVersion:     config.GetString("SERVER_TLS_MIN_VERSION", "1.2"),
+		CipherSuites:   suites,
+		ClientCAFile:   config.GetString("SERVER_TLS_CLIENT_CA_FILE", ""),
+		ClientAuth:     config.GetString("SERVER_TLS_CLIENT_AUTH", "none"),
+		ReloadInterval: config.GetDuration("SERVER_TLS_RELOAD_INTERVAL", time.Minute),
+		HTTP2:          config.GetBool("SERVER_HTTP2", true),
+		RedirectAddr:   config.GetString("SERVER_TLS_REDIRECT_ADDR", ""),
+	}
+}
+
+// Enabled - whether the server terminates TLS
+func (cfg *TLSConfig) Enabled() bool {
+	return cfg.CertFile != ""
+}
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+var clientAuthTypes = map[string]tls.ClientAuthType{
+	"none":               tls.NoClientCert,
+	"request":            tls.RequestClientCert,
+	"require":            tls.RequireAnyClientCert,
+	"verify-if-given":    tls.VerifyClientCertIfGiven,
+	"require-and-verify": tls.RequireAndVerifyClientCert,
+}
+
+// newTLSConfig - crypto/tls configuration of cfg serving the current certificate of certificates
+func newTLSConfig(cfg *TLSConfig, certificates *certificateReloader) (*tls.Config, error) {
+	minVersion, ok := tlsVersions[cfg.MinVersion]
+	if !ok {
+		return nil, fmt.Errorf("server: unknown TLS version %q", cfg.MinVersion)
+	}
+
+	clientAuth, ok := clientAuthTypes[strings.ToLower(cfg.ClientAuth)]
+	if !ok {
+		return nil, fmt.Errorf("server: unknown TLS client auth %q", cfg.ClientAuth)
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:     minVersion,
+		ClientAuth:     clientAuth,
+		GetCertificate: certificates.GetCertificate,
+	}
+
+	if len(cfg.CipherSuites) > 0 {
+		// Only the suites Go considers secure can be selected
+		ids := map[string]uint16{}
+		for _, suite := range tls.CipherSuites() {
+			ids[suite.Name] = suite.ID
+		}
+		for _, name := range cfg.CipherSuites {
+			id, ok := ids[name]
+			if !ok {
+				return nil, fmt.Errorf("server: unknown or insecure cipher suite %q", name)
+			}
+			tlsConfig.CipherSuites = append(tlsConfig.CipherSuites, id)
+		}
+	}
+
+	if cfg.ClientCAFile != "" {
+		pem, err := os.ReadFile(cfg.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("server: read client CA: %w", err)
+		}
+		tlsConfig.ClientCAs = x509.NewCertPool()
+		if !tlsConfig.ClientCAs.AppendCertsFromPEM(pem) {
+			return nil, errors.New("server: no certificate in client CA file " + cfg.ClientCAFile)
+		}
+	} else if clientAuth == tls.VerifyClientCertIfGiven || clientAuth == tls.RequireAndVerifyClientCert {
+		return nil, errors.New("server: client certificates cannot be verified without a client CA file")
+	}
+
+	return tlsConfig, nil
+}
+
+// certificateReloader - serve a certificate and key pair, replaced when the files change. Rotation tools
+// rarely write both files at once, a pair that does not load keeps the previous certificate until it does
+type certificateReloader struct {
+	certFile string
+	keyFile  string
+
+	mu          sync.RWMutex
+	certificate *tls.Certificate
+	certPEM     []byte
+	keyPEM      []byte
+
+	log logger.Logger
+}
+
+func newCertificateReloader(certFile string, keyFile string) (*certificateReloader, error) {
+	c := &certificateReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+		log:      logger.Named("pkg/server"),
+	}
+
+	if _, err := c.reload(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// GetCertificate - current certificate, tls.Config.GetCertificate
+func (c *certificateReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.certificate, nil
+}
+
+// reload - load the pair when the files changed, whether it was replaced
+func (c *certificateReloader) reload() (bool, error) {
+	certPEM, err := os.ReadFile(c.certFile)
+	if err != nil {
+		return false, fmt.Errorf("server: read certificate: %w", err)
+	}
+	keyPEM, err := os.ReadFile(c.keyFile)
+	if err != nil {
+		return false, fmt.Errorf("server: read key: %w", err)
+	}
+
+	c.mu.RLock()
+	unchanged := bytes.Equal(certPEM, c.certPEM) && bytes.Equal(keyPEM, c.keyPEM)
+	c.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	certificate, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return false, fmt.Errorf("server: load certificate: %w", err)
+	}
+
+	c.mu.Lock()
+	c.certificate, c.certPEM, c.keyPEM = &certificate, certPEM, keyPEM
+	c.mu.Unlock()
+
+	return true, nil
+}
+
+// watch - reload every interval until ctx is done
+func (c *certificateReloader) watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reloaded, err := c.reload()
+			if err != nil {
+				c.log.Error("certificate reload failed, serving the previous certificate", "error", err)
+				continue
+			}
+			if reloaded {
+				c.log.Info("Certificate reloaded", "cert_file", c.certFile)
+			}
+		}
+	}
+}
+
+// RedirectHandler - redirect every request to the same URL over HTTPS on httpsPort, permanently
+func RedirectHandler(httpsPort string) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if httpsPort != "" && httpsPort != "443" {
+			host = net.JoinHostPort(host, httpsPort)
+		} else if strings.Contains(host, ":") {
+			host = "[" + host + "]"
+		}
+
+		// 308 keeps the method and body of writes, older clients understand 301 on reads
+		code := http.StatusPermanentRedirect
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			code = http.StatusMovedPermanently
+		}
+
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), code)
+	}
+
+	return http.HandlerFunc(fn)
+}
@@ -0,0 +1,240 @@
+package server_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go-clean-architecture/pkg/server"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// certificate - self-signed certificate of commonName, valid for 127.0.0.1
+type certificate struct {
+	x509    *x509.Certificate
+	certPEM []byte
+	keyPEM  []byte
+}
+
+func newCertificate(t *testing.T, commonName string) *certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+	parsed, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+
+	return &certificate{
+		x509:    parsed,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+// write - write the certificate and key to dir, returning their paths
+func (c *certificate) write(t *testing.T, dir string) (string, string) {
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	assert.NoError(t, os.WriteFile(certFile, c.certPEM, 0o600))
+	assert.NoError(t, os.WriteFile(keyFile, c.keyPEM, 0o600))
+
+	return certFile, keyFile
+}
+
+// serveTLS - serve cfg on a local listener until the test ends
+func serveTLS(t *testing.T, cfg *server.Config) (string, chan error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Proto)) //nolint:errcheck
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.New(cfg, handler).Serve(ctx, ln)
+	}()
+	t.Cleanup(cancel)
+
+	return "https://" + ln.Addr().String(), serveErr
+}
+
+func newClient(roots *x509.CertPool, certificates ...tls.Certificate) *http.Client {
+	return &http.Client{
+		Timeout: 5 * time.Second,
+		Transport: &http.Transport{
+			TLSClientConfig:   &tls.Config{RootCAs: roots, Certificates: certificates},
+			ForceAttemptHTTP2: true,
+			DisableKeepAlives: true,
+		},
+	}
+}
+
+func pool(certificates ...*certificate) *x509.CertPool {
+	roots := x509.NewCertPool()
+	for _, c := range certificates {
+		roots.AddCert(c.x509)
+	}
+
+	return roots
+}
+
+func TestServerTLS(t *testing.T) {
+	t.Run("when HTTP/2 is negotiated", func(t *testing.T) {
+		cert := newCertificate(t, "server")
+		cfg := newConfig(time.Second)
+		cfg.TLS.CertFile, cfg.TLS.KeyFile = cert.write(t, t.TempDir())
+		cfg.TLS.MinVersion, cfg.TLS.ClientAuth, cfg.TLS.HTTP2 = "1.2", "none", true
+
+		url, _ := serveTLS(t, cfg)
+
+		resp, err := newClient(pool(cert)).Get(url)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, "HTTP/2.0", resp.Proto)
+	})
+
+	t.Run("when HTTP/2 is disabled", func(t *testing.T) {
+		cert := newCertificate(t, "server")
+		cfg := newConfig(time.Second)
+		cfg.TLS.CertFile, cfg.TLS.KeyFile = cert.write(t, t.TempDir())
+		cfg.TLS.MinVersion, cfg.TLS.ClientAuth = "1.2", "none"
+
+		url, _ := serveTLS(t, cfg)
+
+		resp, err := newClient(pool(cert)).Get(url)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, "HTTP/1.1", resp.Proto)
+	})
+
+	t.Run("when the certificate is rotated", func(t *testing.T) {
+		dir := t.TempDir()
+		before, after := newCertificate(t, "before"), newCertificate(t, "after")
+		cfg := newConfig(time.Second)
+		cfg.TLS.CertFile, cfg.TLS.KeyFile = before.write(t, dir)
+		cfg.TLS.MinVersion, cfg.TLS.ClientAuth = "1.2", "none"
+		cfg.TLS.ReloadInterval = 10 * time.Millisecond
+
+		url, _ := serveTLS(t, cfg)
+		client := newClient(pool(before, after))
+
+		resp, err := client.Get(url)
+		assert.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, "before", resp.TLS.PeerCertificates[0].Subject.CommonName)
+
+		after.write(t, dir)
+
+		assert.Eventually(t, func() bool {
+			resp, err := client.Get(url)
+			if err != nil {
+				return false
+			}
+			resp.Body.Close()
+			return resp.TLS.PeerCertificates[0].Subject.CommonName == "after"
+		}, 2*time.Second, 20*time.Millisecond)
+	})
+
+	t.Run("when client certificates are required", func(t *testing.T) {
+		serverCert, clientCert := newCertificate(t, "server"), newCertificate(t, "client")
+		dir := t.TempDir()
+		cfg := newConfig(time.Second)
+		cfg.TLS.CertFile, cfg.TLS.KeyFile = serverCert.write(t, dir)
+		cfg.TLS.ClientCAFile = filepath.Join(dir, "ca.crt")
+		assert.NoError(t, os.WriteFile(cfg.TLS.ClientCAFile, clientCert.certPEM, 0o600))
+		cfg.TLS.MinVersion, cfg.TLS.ClientAuth = "1.3", "require-and-verify"
+
+		url, _ := serveTLS(t, cfg)
+
+		_, err := newClient(pool(serverCert)).Get(url)
+		assert.Error(t, err)
+
+		pair, err := tls.X509KeyPair(clientCert.certPEM, clientCert.keyPEM)
+		assert.NoError(t, err)
+		resp, err := newClient(pool(serverCert), pair).Get(url)
+		assert.NoError(t, err)
+		resp.Body.Close()
+	})
+
+	t.Run("when the configuration is invalid", func(t *testing.T) {
+		cert := newCertificate(t, "server")
+		cfg := newConfig(time.Second)
+		cfg.TLS.CertFile, cfg.TLS.KeyFile = cert.write(t, t.TempDir())
+
+		for _, invalid := range []func(*server.TLSConfig){
+			func(c *server.TLSConfig) { c.MinVersion, c.ClientAuth = "1.4", "none" },
+			func(c *server.TLSConfig) { c.MinVersion, c.ClientAuth = "1.2", "always" },
+			func(c *server.TLSConfig) { c.MinVersion, c.ClientAuth = "1.2", "require-and-verify" },
+			func(c *server.TLSConfig) {
+				c.MinVersion, c.ClientAuth, c.CipherSuites = "1.2", "none", []string{"TLS_RSA_WITH_RC4_128_SHA"}
+			},
+			func(c *server.TLSConfig) { c.MinVersion, c.ClientAuth, c.KeyFile = "1.2", "none", c.CertFile },
+		} {
+			invalidCfg := *cfg
+			invalid(&invalidCfg.TLS)
+
+			_, serveErr := serveTLS(t, &invalidCfg)
+
+			select {
+			case err := <-serveErr:
+				assert.Error(t, err)
+			case <-time.After(2 * time.Second):
+				t.Fatal("server started with an invalid configuration")
+			}
+		}
+	})
+}
+
+func TestRedirectHandler(t *testing.T) {
+	cases := []struct {
+		name     string
+		method   string
+		target   string
+		port     string
+		code     int
+		location string
+	}{
+		{"when reading on the default port", http.MethodGet, "http://api.example.com/todo?page=2", "443", http.StatusMovedPermanently, "https://api.example.com/todo?page=2"},
+		{"when writing on another port", http.MethodPost, "http://api.example.com:8080/todo", "8443", http.StatusPermanentRedirect, "https://api.example.com:8443/todo"},
+		{"when the host is an IPv6 address", http.MethodGet, "http://[::1]:8080/", "443", http.StatusMovedPermanently, "https://[::1]/"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			server.RedirectHandler(c.port).ServeHTTP(rr, httptest.NewRequest(c.method, c.target, nil))
+
+			assert.Equal(t, c.code, rr.Code)
+			assert.Equal(t, c.location, rr.Header().Get("Location"))
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package tenant
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"go-clean-architecture/pkg/auth"
+	"go-clean-architecture/pkg/config"
+)
+
+// Resolver - resolve the tenant id of a request, empty when the request does not name one this way
+type Resolver interface {
+	Resolve(r *http.Request) (string, error)
+}
+
+// ResolverFunc - adapter to use a plain func as Resolver
+type ResolverFunc func(r *http.Request) (string, error)
+
+func (f ResolverFunc) Resolve(r *http.Request) (string, error) {
+	return f(r)
+}
+
+// Header - tenant id sent in header, e.g. X-Tenant-ID
+func Header(header string) Resolver {
+	return ResolverFunc(func(r *http.Request) (string, error) {
+		return strings.TrimSpace(r.Header.Get(header)), nil
+	})
+}
+
+// Subdomain - tenant id of the "<tenant>.<domain>" host
+func Subdomain(domain string) Resolver {
+	suffix := "." + strings.ToLower(strings.TrimPrefix(domain, "."))
+
+	return ResolverFunc(func(r *http.Request) (string, error) {
+		host := strings.ToLower(r.Host)
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+
+		sub := strings.TrimSuffix(host, suffix)
+		if sub == host || sub == "" || strings.Contains(sub, ".") {
+			return "", nil
+		}
+
+		return sub, nil
+	})
+}
+
+// Claim - tenant id of the "tid" claim of the bearer access token, verify checks the signature only so
+// the tenant can be resolved before any tenant database is touched
+func Claim(verify func(token string) (*auth.Principal, error)) Resolver {
+	return ResolverFunc(func(r *http.Request) (string, error) {
+		token := auth.BearerToken(r)
+		if token == "" || strings.HasPrefix(token, auth.APIKeyPrefix) {
+			return "", nil
+		}
+
+		principal, err := verify(token)
+		if err != nil {
+			// Left to auth.Middleware to answer 401
+			return "", nil
+		}
+
+		return principal.TenantID, nil
+	})
+}
+
+// Chain - first tenant id resolved by resolvers
+func Chain(resolvers ...Resolver) Resolver {
+	return ResolverFunc(func(r *http.Request) (string, error) {
+		for _, resolver := range resolvers {
+			id, err := resolver.Resolve(r)
+			if err != nil || id != "" {
+				return id, err
+			}
+		}
+
+		return "", nil
+	})
+}
+
+// Config - tenancy configuration
+type Config struct {
+	// Resolvers - comma separated tenant resolvers tried in order: header, subdomain, claim. Empty disables tenancy
+	Resolvers string
+	// Header - header of the header resolver
+	Header string
+	// Domain - parent domain of the subdomain resolver, e.g. api.example.com
+	Domain string
+	// Tenants - comma separated tenant ids served, required when tenancy is enabled
+	Tenants string
+	// DatabasePrefix - database of a tenant is DatabasePrefix + id unless TENANT_<ID>_DB_NAME is set
+	DatabasePrefix string
+}
+
+// LoadConfig - read tenancy configuration from environment variables
+func LoadConfig() *Config {
+	return &Config{
+		Resolvers:      config.GetString("TENANT_RESOLVERS", ""),
+		Header:         config.GetString("TENANT_HEADER", "X-Tenant-ID"),
+		Domain:         config.GetString("TENANT_DOMAIN", ""),
+		Tenants:        config.GetString("TENANTS", ""),
+		DatabasePrefix: config.GetString("TENANT_DB_PREFIX", "tenant_"),
+	}
+}
+
+// Enabled - whether requests are served per tenant
+func (cfg *Config) Enabled() bool {
+	return strings.TrimSpace(cfg.Resolvers) != ""
+}
+
+// NewResolver - chain of cfg.Resolvers, verify checks access tokens for the claim resolver
+func NewResolver(cfg *Config, verify func(token string) (*auth.Principal, error)) (Resolver, error) {
+	if cfg.Enabled() && NewRegistry(cfg).Tenants() == nil {
+		return nil, fmt.Errorf("tenant: TENANT_RESOLVERS needs TENANTS to list the tenants served")
+	}
+
+	resolvers := []Resolver{}
+	for _, name := range strings.Split(cfg.Resolvers, ",") {
+		switch name = strings.ToLower(strings.TrimSpace(name)); name {
+		case "":
+		case "header":
+			resolvers = append(resolvers, Header(cfg.Header))
+		case "subdomain":
+			if cfg.Domain == "" {
+				return nil, fmt.Errorf("tenant: subdomain resolver needs TENANT_DOMAIN")
+			}
+			resolvers = append(resolvers, Subdomain(cfg.Domain))
+		case "claim":
+			resolvers = append(resolvers, Claim(verify))
+		default:
+			return nil, fmt.Errorf("tenant: unsupported resolver %q", name)
+		}
+	}
+
+	return Chain(resolvers...), nil
+}
@@ -0,0 +1,153 @@
+package tenant
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"go-clean-architecture/pkg/config"
+	responseutil "go-clean-architecture/utils/response"
+)
+
+// Tenant - customer whose data lives in its own database
+type Tenant struct {
+	ID string
+	// Database - Mongo database holding the data of the tenant
+	Database string
+}
+
+type contextKey struct{}
+
+// NewContext - copy of ctx carrying t
+func NewContext(ctx context.Context, t *Tenant) context.Context {
+	return context.WithValue(ctx, contextKey{}, t)
+}
+
+// FromContext - tenant of ctx, nil when tenancy is disabled
+func FromContext(ctx context.Context) *Tenant {
+	t, _ := ctx.Value(contextKey{}).(*Tenant)
+	return t
+}
+
+// ID - id of the tenant of ctx, empty when tenancy is disabled
+func ID(ctx context.Context) string {
+	if t := FromContext(ctx); t != nil {
+		return t.ID
+	}
+
+	return ""
+}
+
+// validID - tenant ids end up in database names, keep them to a safe alphabet
+var validID = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,31}$`)
+
+// Registry - tenants that may be served
+type Registry struct {
+	allowed        map[string]bool
+	databasePrefix string
+}
+
+// NewRegistry - registry of the tenants listed in cfg.Tenants
+func NewRegistry(cfg *Config) *Registry {
+	allowed := map[string]bool{}
+	for _, id := range strings.Split(cfg.Tenants, ",") {
+		if id = strings.ToLower(strings.TrimSpace(id)); id != "" {
+			allowed[id] = true
+		}
+	}
+
+	return &Registry{
+		allowed:        allowed,
+		databasePrefix: cfg.DatabasePrefix,
+	}
+}
+
+// Lookup - tenant of id, nil when it is not listed. Ids come from unauthenticated requests, serving
+// unlisted ones would let anyone create databases
+func (reg *Registry) Lookup(id string) *Tenant {
+	id = strings.ToLower(id)
+	if !validID.MatchString(id) || !reg.allowed[id] {
+		return nil
+	}
+
+	return &Tenant{
+		ID:       id,
+		Database: config.GetString(settingKey(id, "DB_NAME"), reg.databasePrefix+id),
+	}
+}
+
+// Tenants - every tenant served in id order, nil when none is listed
+func (reg *Registry) Tenants() []*Tenant {
+	if len(reg.allowed) == 0 {
+		return nil
+	}
+
+	ids := make([]string, 0, len(reg.allowed))
+	for id := range reg.allowed {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	tenants := make([]*Tenant, 0, len(ids))
+	for _, id := range ids {
+		if t := reg.Lookup(id); t != nil {
+			tenants = append(tenants, t)
+		}
+	}
+
+	return tenants
+}
+
+// Middleware - put the tenant resolved by resolver into the request context, requests without a
+// tenant are rejected with 400 and unknown tenants with 404
+func Middleware(resolver Resolver, registry *Registry) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			id, err := resolver.Resolve(r)
+			if err != nil {
+				responseutil.ResponseBadRequest(w, r, "Invalid tenant")
+				return
+			}
+			if id == "" {
+				responseutil.ResponseBadRequest(w, r, "Tenant required")
+				return
+			}
+
+			t := registry.Lookup(id)
+			if t == nil {
+				responseutil.ResponseNotFound(w, r, "Tenant not found")
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), t)))
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// GetString - per-tenant configuration, TENANT_<ID>_<KEY> of the tenant of ctx with a fallback to <KEY>
+func GetString(ctx context.Context, key string, defaultValue string) string {
+	defaultValue = config.GetString(key, defaultValue)
+	if id := ID(ctx); id != "" {
+		return config.GetString(settingKey(id, key), defaultValue)
+	}
+
+	return defaultValue
+}
+
+// GetInt - per-tenant configuration as int, see GetString
+func GetInt(ctx context.Context, key string, defaultValue int) int {
+	defaultValue = config.GetInt(key, defaultValue)
+	if id := ID(ctx); id != "" {
+		return config.GetInt(settingKey(id, key), defaultValue)
+	}
+
+	return defaultValue
+}
+
+func settingKey(id string, key string) string {
+	return "TENANT_" + strings.ToUpper(strings.ReplaceAll(id, "-", "_")) + "_" + key
+}
@@ -0,0 +1,158 @@
+package tenant_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"go-clean-architecture/pkg/auth"
+	"go-clean-architecture/pkg/tenant"
+	errorsutil "go-clean-architecture/utils/errors"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware(t *testing.T) {
+	cfg := &tenant.Config{Resolvers: "header,subdomain", Header: "X-Tenant-ID", Domain: "api.example.com", Tenants: "acme,globex", DatabasePrefix: "tenant_"}
+	resolver, err := tenant.NewResolver(cfg, nil)
+	assert.NoError(t, err)
+
+	handler := tenant.Middleware(resolver, tenant.NewRegistry(cfg))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(tenant.FromContext(r.Context()).Database)) //nolint:errcheck
+	}))
+
+	do := func(host string, header string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/todo", nil)
+		req.Host = host
+		if header != "" {
+			req.Header.Set("X-Tenant-ID", header)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("when tenant is sent in the header", func(t *testing.T) {
+		rr := do("api.example.com", "acme")
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "tenant_acme", rr.Body.String())
+	})
+
+	t.Run("when tenant is the subdomain", func(t *testing.T) {
+		rr := do("globex.api.example.com:8080", "")
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "tenant_globex", rr.Body.String())
+	})
+
+	t.Run("when tenant database is configured", func(t *testing.T) {
+		os.Setenv("TENANT_ACME_DB_NAME", "acme-production")
+		defer os.Unsetenv("TENANT_ACME_DB_NAME")
+
+		assert.Equal(t, "acme-production", do("api.example.com", "acme").Body.String())
+	})
+
+	t.Run("when tenant is missing", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, do("api.example.com", "").Code)
+	})
+
+	t.Run("when tenant is not served", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, do("api.example.com", "initech").Code)
+	})
+
+	t.Run("when tenant id is not a valid database name", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, do("api.example.com", "acme/../admin").Code)
+	})
+}
+
+func TestClaim(t *testing.T) {
+	resolver := tenant.Claim(func(token string) (*auth.Principal, error) {
+		if token != "valid" {
+			return nil, errorsutil.ErrUnauthorized
+		}
+		return &auth.Principal{Subject: "user-1", TenantID: "acme"}, nil
+	})
+
+	do := func(authorization string) string {
+		req := httptest.NewRequest(http.MethodGet, "/todo", nil)
+		req.Header.Set("Authorization", authorization)
+		id, err := resolver.Resolve(req)
+		assert.NoError(t, err)
+		return id
+	}
+
+	t.Run("when token is valid", func(t *testing.T) {
+		assert.Equal(t, "acme", do("Bearer valid"))
+	})
+
+	t.Run("when token is invalid", func(t *testing.T) {
+		assert.Equal(t, "", do("Bearer invalid"))
+	})
+
+	t.Run("when token is an API key", func(t *testing.T) {
+		assert.Equal(t, "", do("Bearer "+auth.APIKeyPrefix+"key"))
+	})
+}
+
+func TestGetString(t *testing.T) {
+	os.Setenv("MAX_TODOS", "100")
+	os.Setenv("TENANT_ACME_CORP_MAX_TODOS", "1000")
+	defer os.Unsetenv("MAX_TODOS")
+	defer os.Unsetenv("TENANT_ACME_CORP_MAX_TODOS")
+
+	acme := tenant.NewContext(context.Background(), &tenant.Tenant{ID: "acme-corp"})
+	globex := tenant.NewContext(context.Background(), &tenant.Tenant{ID: "globex"})
+
+	t.Run("when tenant overrides the setting", func(t *testing.T) {
+		assert.Equal(t, 1000, tenant.GetInt(acme, "MAX_TODOS", 10))
+	})
+
+	t.Run("when tenant does not override the setting", func(t *testing.T) {
+		assert.Equal(t, "100", tenant.GetString(globex, "MAX_TODOS", "10"))
+	})
+
+	t.Run("when tenancy is disabled", func(t *testing.T) {
+		assert.Equal(t, 100, tenant.GetInt(context.Background(), "MAX_TODOS", 10))
+	})
+}
+
+func TestRegistryTenants(t *testing.T) {
+	t.Run("when tenants are listed", func(t *testing.T) {
+		registry := tenant.NewRegistry(&tenant.Config{Tenants: "globex, Acme", DatabasePrefix: "tenant_"})
+
+		assert.Equal(t, []*tenant.Tenant{
+			{ID: "acme", Database: "tenant_acme"},
+			{ID: "globex", Database: "tenant_globex"},
+		}, registry.Tenants())
+	})
+
+	t.Run("when no tenant is listed", func(t *testing.T) {
+		registry := tenant.NewRegistry(&tenant.Config{DatabasePrefix: "tenant_"})
+
+		assert.Nil(t, registry.Tenants())
+		assert.Nil(t, registry.Lookup("acme"))
+	})
+}
+
+func TestNewResolver(t *testing.T) {
+	t.Run("when tenants are listed", func(t *testing.T) {
+		_, err := tenant.NewResolver(&tenant.Config{Resolvers: "header", Header: "X-Tenant-ID", Tenants: "acme"}, nil)
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("when no tenant is listed", func(t *testing.T) {
+		_, err := tenant.NewResolver(&tenant.Config{Resolvers: "header", Header: "X-Tenant-ID"}, nil)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("when tenancy is disabled", func(t *testing.T) {
+		_, err := tenant.NewResolver(&tenant.Config{}, nil)
+
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,87 @@
+package tracing_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	pkgtracing "go-clean-architecture/pkg/tracing"
+	errorsutil "go-clean-architecture/utils/errors"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func newRecorder() *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	return recorder
+}
+
+func TestMiddleware(t *testing.T) {
+	recorder := newRecorder()
+
+	router := chi.NewRouter()
+	router.Use(pkgtracing.Middleware)
+	router.Get("/todo/{id}", func(w http.ResponseWriter, r *http.Request) {
+		_, span := pkgtracing.Start(r.Context(), "todo.Service/GetByID")
+		span.End()
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/todo/1", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 2)
+
+	child, server := spans[0], spans[1]
+	assert.Equal(t, "GET /todo/{id}", server.Name())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", server.SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", server.Parent().SpanID().String())
+	assert.Equal(t, server.SpanContext().SpanID(), child.Parent().SpanID())
+}
+
+func TestEnd(t *testing.T) {
+	recorder := newRecorder()
+
+	err := errorsutil.ErrDefault
+	_, span := pkgtracing.Start(context.Background(), "todo.Service/Delete")
+	pkgtracing.End(span, &err)
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Len(t, spans[0].Events(), 1)
+}
+
+func TestTransport(t *testing.T) {
+	newRecorder()
+
+	var traceparent string
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+	}))
+	defer receiver.Close()
+
+	ctx, span := pkgtracing.Start(context.Background(), "outgoing")
+	defer span.End()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, receiver.URL, nil)
+	assert.NoError(t, err)
+
+	client := &http.Client{Transport: pkgtracing.Transport(nil)}
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Contains(t, traceparent, span.SpanContext().TraceID().String())
+}
@@ -2,6 +2,7 @@ package validator
 
 import (
 	"fmt"
+	"net/url"
 	"regexp"
 	"strconv"
 
@@ -49,6 +50,10 @@ func ValidatonError(err error) CommonError {
 			res.Errors[field] = fmt.Sprintf("%v is not a valid email address", v.Value())
 		case "username":
 			res.Errors[field] = fmt.Sprintf("%v is not a valid username", v.Value())
+		case "httpurl":
+			res.Errors[field] = fmt.Sprintf("%v must be an http or https URL", field)
+		case "oneof":
+			res.Errors[field] = fmt.Sprintf("%v must be one of %v", field, v.Param())
 		}
 	}
 
@@ -61,6 +66,7 @@ func ValidateStruct(i interface{}) error {
 	validate.RegisterValidation("sgte", GreaterThanEqual)
 	validate.RegisterValidation("slte", LessThanEqual)
 	validate.RegisterValidation("username", Username)
+	validate.RegisterValidation("httpurl", HTTPURL)
 
 	err := validate.Struct(i)
 	if err != nil {
@@ -151,3 +157,18 @@ func Username(fl validator.FieldLevel) bool {
 	var regex = regexp.MustCompile(`^[A-Za-z0-9]+(?:[_-][A-Za-z0-9]+)*$`)
 	return regex.MatchString(fl.Field().String())
 }
+
+// HTTPURL - absolute http or https URL with a host
+func HTTPURL(fl validator.FieldLevel) bool {
+	// If empty skip
+	if fl.Field().String() == "" {
+		return true
+	}
+
+	u, err := url.Parse(fl.Field().String())
+	if err != nil {
+		return false
+	}
+
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
@@ -0,0 +1,341 @@
+package clidelivery
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"go-clean-architecture/todo/models"
+)
+
+type CLIHandler interface {
+	// Run - run the command of args, e.g. ["get", "<id>", "-o", "json"]
+	Run(ctx context.Context, args []string) error
+	List(ctx context.Context, args []string) error
+	Search(ctx context.Context, args []string) error
+	Get(ctx context.Context, args []string) error
+	Create(ctx context.Context, args []string) error
+	Update(ctx context.Context, args []string) error
+	Delete(ctx context.Context, args []string) error
+}
+
+// UsageError - command line that cannot be run, the usage of the command has been printed
+type UsageError struct {
+	Err error
+}
+
+func (e *UsageError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *UsageError) Unwrap() error {
+	return e.Err
+}
+
+// Config - command line configuration
+type Config struct {
+	// Output - default output format: table, json or yaml
+	Output string
+	// Stdin - read by "-f -"
+	Stdin io.Reader
+	// Stdout - results are written to it, usage and errors are left to the caller
+	Stdout io.Writer
+	// Stderr - usage of commands
+	Stderr io.Writer
+}
+
+type CLIHandlerImpl struct {
+	client Client
+	cfg    Config
+}
+
+// New - make command line handler
+func New(client Client, cfg *Config) CLIHandler {
+	h := &CLIHandlerImpl{
+		client: client,
+		cfg:    *cfg,
+	}
+	if h.cfg.Output == "" {
+		h.cfg.Output = OutputTable
+	}
+	if h.cfg.Stdin == nil {
+		h.cfg.Stdin = os.Stdin
+	}
+	if h.cfg.Stdout == nil {
+		h.cfg.Stdout = os.Stdout
+	}
+	if h.cfg.Stderr == nil {
+		h.cfg.Stderr = os.Stderr
+	}
+
+	return h
+}
+
+// Commands - usage of every command
+const Commands = `Commands:
+  list    [-page n] [-per-page n]                          list todos
+  search  [-page n] [-per-page n] <keywords>               list todos matching keywords
+  get     <id>                                             show todo
+  create  [-title s] [-description s] [-f file|-]          create todo
+  update  [-title s] [-description s] [-f file|-] <id>     replace title and description of todo
+  delete  <id>                                             delete todo
+
+Every command takes -o table|json|yaml. Bodies read with -f are JSON objects
+with "title" and "description", flags override their fields.
+`
+
+// Run - run the command of args
+func (h *CLIHandlerImpl) Run(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(h.cfg.Stderr, Commands)
+		return &UsageError{Err: errors.New("missing command")}
+	}
+
+	commands := map[string]func(context.Context, []string) error{
+		"list":   h.List,
+		"search": h.Search,
+		"get":    h.Get,
+		"create": h.Create,
+		"update": h.Update,
+		"delete": h.Delete,
+	}
+	switch args[0] {
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(h.cfg.Stdout, Commands)
+		return nil
+	}
+
+	command, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprint(h.cfg.Stderr, Commands)
+		return &UsageError{Err: fmt.Errorf("unknown command %q", args[0])}
+	}
+
+	return command(ctx, args[1:])
+}
+
+// List - list todos
+func (h *CLIHandlerImpl) List(ctx context.Context, args []string) error {
+	return h.list(ctx, "list", args, false)
+}
+
+// Search - list todos matching the keywords
+func (h *CLIHandlerImpl) Search(ctx context.Context, args []string) error {
+	return h.list(ctx, "search", args, true)
+}
+
+func (h *CLIHandlerImpl) list(ctx context.Context, name string, args []string, search bool) error {
+	flags, output := h.flagSet(name)
+	page := flags.Int("page", 0, "page, starting at 1")
+	perPage := flags.Int("per-page", 0, "todos per page, at most 100")
+
+	keywords, err := parse(flags, args)
+	if err != nil {
+		return err
+	}
+	if search && len(keywords) == 0 {
+		return h.usage(flags, errors.New("missing keywords"))
+	}
+	if !search && len(keywords) > 0 {
+		return h.usage(flags, fmt.Errorf("unexpected argument %q", keywords[0]))
+	}
+
+	result, err := h.client.List(ctx, strings.Join(keywords, " "), *page, *perPage)
+	if err != nil {
+		return err
+	}
+
+	return writeList(h.cfg.Stdout, *output, result)
+}
+
+// Get - show todo by id
+func (h *CLIHandlerImpl) Get(ctx context.Context, args []string) error {
+	flags, output := h.flagSet("get")
+
+	id, err := h.id(flags, args)
+	if err != nil {
+		return err
+	}
+
+	result, err := h.client.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return writeTodo(h.cfg.Stdout, *output, result)
+}
+
+// Create - create todo from flags or a JSON body
+func (h *CLIHandlerImpl) Create(ctx context.Context, args []string) error {
+	flags, output := h.flagSet("create")
+	body := h.bodyFlags(flags)
+
+	rest, err := parse(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) > 0 {
+		return h.usage(flags, fmt.Errorf("unexpected argument %q", rest[0]))
+	}
+
+	value, err := body()
+	if err != nil {
+		return err
+	}
+
+	result, err := h.client.Create(ctx, value)
+	if err != nil {
+		return err
+	}
+
+	return writeTodo(h.cfg.Stdout, *output, result)
+}
+
+// Update - replace title and description of todo by id from flags or a JSON body
+func (h *CLIHandlerImpl) Update(ctx context.Context, args []string) error {
+	flags, output := h.flagSet("update")
+	body := h.bodyFlags(flags)
+
+	id, err := h.id(flags, args)
+	if err != nil {
+		return err
+	}
+
+	value, err := body()
+	if err != nil {
+		return err
+	}
+
+	result, err := h.client.Update(ctx, id, value)
+	if err != nil {
+		return err
+	}
+
+	return writeTodo(h.cfg.Stdout, *output, result)
+}
+
+// Delete - delete todo by id
+func (h *CLIHandlerImpl) Delete(ctx context.Context, args []string) error {
+	flags, output := h.flagSet("delete")
+
+	id, err := h.id(flags, args)
+	if err != nil {
+		return err
+	}
+
+	if err := h.client.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	return writeDeleted(h.cfg.Stdout, *output, id)
+}
+
+// flagSet - flags of command name, with the output format every command takes
+func (h *CLIHandlerImpl) flagSet(name string) (*flag.FlagSet, *string) {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(h.cfg.Stderr)
+	output := flags.String("o", h.cfg.Output, "output format: table, json or yaml")
+
+	return flags, output
+}
+
+// bodyFlags - define the body flags of create and update, the returned func reads the body once they are parsed
+func (h *CLIHandlerImpl) bodyFlags(flags *flag.FlagSet) func() (*models.TodoRequest, error) {
+	file := flags.String("f", "", "JSON body, - reads it from stdin")
+	title := flags.String("title", "", "title")
+	description := flags.String("description", "", "description")
+
+	return func() (*models.TodoRequest, error) {
+		value := &models.TodoRequest{}
+		if *file != "" {
+			if err := h.readBody(*file, value); err != nil {
+				return nil, err
+			}
+		}
+
+		// Only flags given on the command line override the body
+		flags.Visit(func(f *flag.Flag) {
+			switch f.Name {
+			case "title":
+				value.Title = *title
+			case "description":
+				value.Description = *description
+			}
+		})
+
+		return value, nil
+	}
+}
+
+// readBody - decode the JSON object of file, or of stdin when file is -
+func (h *CLIHandlerImpl) readBody(file string, value *models.TodoRequest) error {
+	reader := h.cfg.Stdin
+	if file != "-" {
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		reader = f
+	}
+
+	decoder := json.NewDecoder(reader)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(value); err != nil {
+		return fmt.Errorf("reading body of %s: %w", file, err)
+	}
+
+	return nil
+}
+
+// id - the single id argument of args
+func (h *CLIHandlerImpl) id(flags *flag.FlagSet, args []string) (string, error) {
+	rest, err := parse(flags, args)
+	if err != nil {
+		return "", err
+	}
+	if len(rest) != 1 {
+		return "", h.usage(flags, errors.New("expected a single id"))
+	}
+
+	return rest[0], nil
+}
+
+func (h *CLIHandlerImpl) usage(flags *flag.FlagSet, err error) error {
+	fmt.Fprintf(h.cfg.Stderr, "%s: %v\n", flags.Name(), err)
+	flags.Usage()
+
+	return &UsageError{Err: err}
+}
+
+// parse - parse flags placed anywhere in args, e.g. "get <id> -o json", returning the other arguments.
+// Arguments after "--" are never parsed as flags
+func parse(flags *flag.FlagSet, args []string) ([]string, error) {
+	positional := []string{}
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, &UsageError{Err: err}
+		}
+		if !isOutput(flags.Lookup("o").Value.String()) {
+			err := fmt.Errorf("unsupported output %q", flags.Lookup("o").Value.String())
+			fmt.Fprintf(flags.Output(), "%s: %v\n", flags.Name(), err)
+			return nil, &UsageError{Err: err}
+		}
+
+		rest := flags.Args()
+		if len(rest) == 0 {
+			return positional, nil
+		}
+		if len(args) > len(rest) && args[len(args)-len(rest)-1] == "--" {
+			return append(positional, rest...), nil
+		}
+
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
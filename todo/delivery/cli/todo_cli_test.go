@@ -0,0 +1,343 @@
+package clidelivery_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"go-clean-architecture/pkg/auth"
+	pkgvalidator "go-clean-architecture/pkg/validator"
+	clidelivery "go-clean-architecture/todo/delivery/cli"
+	httpdelivery "go-clean-architecture/todo/delivery/http"
+	mockservice "go-clean-architecture/todo/mocks/service"
+	"go-clean-architecture/todo/models"
+	errorsutil "go-clean-architecture/utils/errors"
+)
+
+var todoID = primitive.NewObjectID()
+
+func todo() *models.Todo {
+	updatedAt := time.Date(2022, 10, 1, 9, 30, 0, 0, time.UTC)
+
+	return &models.Todo{
+		ID:          todoID,
+		Title:       "Buy milk",
+		Description: "Two litres",
+		OwnerID:     "owner-1",
+		CreatedAt:   updatedAt,
+		UpdatedAt:   updatedAt,
+	}
+}
+
+// run - run args with the service client acting as owner-1
+func run(service *mockservice.Service, stdin string, args ...string) (string, error) {
+	client := clidelivery.NewServiceClient(service, &auth.Principal{Subject: "owner-1"})
+
+	return runClient(client, stdin, args...)
+}
+
+func runClient(client clidelivery.Client, stdin string, args ...string) (string, error) {
+	stdout := &bytes.Buffer{}
+	cli := clidelivery.New(client, &clidelivery.Config{
+		Stdin:  strings.NewReader(stdin),
+		Stdout: stdout,
+		Stderr: &bytes.Buffer{},
+	})
+
+	err := cli.Run(context.Background(), args)
+
+	return stdout.String(), err
+}
+
+// ownerContext - context of calls made as owner-1
+func ownerContext() interface{} {
+	return mock.MatchedBy(func(ctx context.Context) bool {
+		return auth.FromContext(ctx) != nil && auth.FromContext(ctx).Subject == "owner-1"
+	})
+}
+
+func TestTodoCLIList(t *testing.T) {
+	pkgvalidator.New()
+
+	t.Run("success when todos are listed as a table", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+		mockService.On("GetAll", ownerContext(), "owner-1", "", 10, 0).Return([]*models.Todo{todo()}, 1, nil)
+
+		out, err := run(mockService, "", "list")
+
+		assert.NoError(t, err)
+		lines := strings.Split(out, "\n")
+		assert.Equal(t, []string{"ID", "TITLE", "DESCRIPTION", "OWNER", "UPDATED"}, strings.Fields(lines[0]))
+		assert.Equal(t, todoID.Hex()+"  Buy milk  Two litres   owner-1  2022-10-01T09:30:00Z", lines[1])
+		assert.Contains(t, out, "Page 1 of 1, 1 todos")
+	})
+
+	t.Run("success when todos are searched as json", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+		mockService.On("GetAll", ownerContext(), "owner-1", "buy milk", 5, 5).Return([]*models.Todo{todo()}, 6, nil)
+
+		out, err := run(mockService, "", "search", "buy", "-o", "json", "milk", "-page", "2", "-per-page", "5")
+
+		assert.NoError(t, err)
+		assert.Contains(t, out, `"title": "Buy milk"`)
+		assert.Contains(t, out, `"page_count": 2`)
+	})
+
+	t.Run("success when todos are listed as yaml", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+		mockService.On("GetAll", ownerContext(), "owner-1", "", 10, 0).Return([]*models.Todo{todo()}, 1, nil)
+
+		out, err := run(mockService, "", "list", "-o", "yaml")
+
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(out, "data:\n  - id: "+todoID.Hex()+"\n    title: Buy milk\n    description: Two litres\n"), out)
+		assert.Contains(t, out, "meta:\n  per_page: 10\n  page: 1\n")
+	})
+
+	t.Run("when the page is invalid", func(t *testing.T) {
+		out, err := run(new(mockservice.Service), "", "list", "-per-page", "101")
+
+		apiErr := &clidelivery.APIError{}
+		assert.True(t, errors.As(err, &apiErr))
+		assert.Equal(t, http.StatusBadRequest, apiErr.Status)
+		assert.Contains(t, err.Error(), "per_page must less than equal 100")
+		assert.Empty(t, out)
+	})
+
+	t.Run("when search has no keywords", func(t *testing.T) {
+		_, err := run(new(mockservice.Service), "", "search")
+
+		usageErr := &clidelivery.UsageError{}
+		assert.True(t, errors.As(err, &usageErr))
+	})
+
+	t.Run("when the output format is unsupported", func(t *testing.T) {
+		_, err := run(new(mockservice.Service), "", "list", "-o", "xml")
+
+		usageErr := &clidelivery.UsageError{}
+		assert.True(t, errors.As(err, &usageErr))
+	})
+}
+
+func TestTodoCLIGet(t *testing.T) {
+	t.Run("success when the todo is found", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+		mockService.On("GetByID", ownerContext(), "owner-1", todoID.Hex()).Return(todo(), nil)
+
+		out, err := run(mockService, "", "get", todoID.Hex(), "-o", "json")
+
+		assert.NoError(t, err)
+		assert.Contains(t, out, `"id": "`+todoID.Hex()+`"`)
+	})
+
+	t.Run("when the todo is not found", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+		mockService.On("GetByID", ownerContext(), "owner-1", "missing").Return(nil, errorsutil.ErrNotFound)
+
+		_, err := run(mockService, "", "get", "missing")
+
+		assert.EqualError(t, err, "Item not found")
+	})
+
+	t.Run("when the todo is forbidden", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+		mockService.On("GetByID", ownerContext(), "owner-1", "other").Return(nil, errorsutil.ErrForbidden)
+
+		_, err := run(mockService, "", "get", "other")
+
+		assert.EqualError(t, err, "You are not allowed to read this item")
+	})
+
+	t.Run("when the id is missing", func(t *testing.T) {
+		_, err := run(new(mockservice.Service), "", "get")
+
+		usageErr := &clidelivery.UsageError{}
+		assert.True(t, errors.As(err, &usageErr))
+	})
+}
+
+func TestTodoCLICreate(t *testing.T) {
+	pkgvalidator.New()
+
+	t.Run("success when the body is read from stdin and overridden by flags", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+		mockService.On("Create", ownerContext(), &models.Todo{Title: "Buy oat milk", Description: "Two litres", OwnerID: "owner-1"}).Return(todo(), nil)
+
+		out, err := run(mockService, `{"title": "Buy milk", "description": "Two litres"}`, "create", "-f", "-", "-title", "Buy oat milk")
+
+		assert.NoError(t, err)
+		assert.Contains(t, out, todoID.Hex())
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("when the body has an unknown field", func(t *testing.T) {
+		_, err := run(new(mockservice.Service), `{"name": "Buy milk"}`, "create", "-f", "-")
+
+		assert.ErrorContains(t, err, `unknown field "name"`)
+	})
+
+	t.Run("when the title is missing", func(t *testing.T) {
+		_, err := run(new(mockservice.Service), "", "create", "-description", "Two litres")
+
+		apiErr := &clidelivery.APIError{}
+		assert.True(t, errors.As(err, &apiErr))
+		assert.Equal(t, "title is required", apiErr.Errors["title"])
+	})
+}
+
+func TestTodoCLIUpdateDelete(t *testing.T) {
+	pkgvalidator.New()
+
+	t.Run("success when the todo is updated", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+		mockService.On("Update", ownerContext(), "owner-1", todoID.Hex(), &models.Todo{Title: "Buy milk", Description: "Two litres"}).Return(todo(), nil)
+
+		out, err := run(mockService, "", "update", todoID.Hex(), "-title", "Buy milk", "-description", "Two litres", "-o", "yaml")
+
+		assert.NoError(t, err)
+		assert.Contains(t, out, "title: Buy milk\n")
+	})
+
+	t.Run("success when the todo is deleted", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+		mockService.On("Delete", ownerContext(), "owner-1", todoID.Hex()).Return(nil)
+
+		out, err := run(mockService, "", "delete", todoID.Hex())
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Deleted "+todoID.Hex()+"\n", out)
+	})
+
+	t.Run("when the command is unknown", func(t *testing.T) {
+		_, err := run(new(mockservice.Service), "", "archive")
+
+		usageErr := &clidelivery.UsageError{}
+		assert.True(t, errors.As(err, &usageErr))
+	})
+
+	t.Run("when help is asked for", func(t *testing.T) {
+		_, err := run(new(mockservice.Service), "", "delete", "-h")
+
+		assert.True(t, errors.Is(err, flag.ErrHelp))
+	})
+}
+
+// newServer - REST API of service, requests are made by owner-1 when they carry its token
+func newServer(t *testing.T, service *mockservice.Service) *httptest.Server {
+	router := chi.NewRouter()
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if auth.BearerToken(r) == "token-1" && r.Header.Get("X-Tenant-ID") == "acme" {
+				r = r.WithContext(auth.NewContext(r.Context(), &auth.Principal{Subject: "owner-1"}))
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+	httpdelivery.New(service).RegisterRoutes(router)
+
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestTodoCLIServer(t *testing.T) {
+	pkgvalidator.New()
+
+	client := func(server *httptest.Server, token string) clidelivery.Client {
+		return clidelivery.NewHTTPClient(&clidelivery.HTTPConfig{
+			Server: server.URL + "/",
+			Token:  token,
+			Tenant: "acme",
+		}, server.Client())
+	}
+
+	t.Run("success when todos are searched", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+		mockService.On("GetAll", mock.Anything, "owner-1", "buy milk", 5, 5).Return([]*models.Todo{todo()}, 6, nil)
+		server := newServer(t, mockService)
+
+		out, err := runClient(client(server, "token-1"), "", "search", "buy milk", "-page", "2", "-per-page", "5")
+
+		assert.NoError(t, err)
+		assert.Contains(t, out, todoID.Hex()+"  Buy milk")
+		assert.Contains(t, out, "Page 2 of 2, 6 todos")
+	})
+
+	t.Run("success when a todo is created", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+		mockService.On("Create", mock.Anything, &models.Todo{Title: "Buy milk", Description: "Two litres", OwnerID: "owner-1"}).Return(todo(), nil)
+		server := newServer(t, mockService)
+
+		out, err := runClient(client(server, "token-1"), `{"title": "Buy milk", "description": "Two litres"}`, "create", "-f", "-", "-o", "json")
+
+		assert.NoError(t, err)
+		assert.Contains(t, out, `"owner_id": "owner-1"`)
+	})
+
+	t.Run("success when a todo is updated", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+		mockService.On("Update", mock.Anything, "owner-1", todoID.Hex(), &models.Todo{Title: "Buy milk", Description: "Two litres"}).Return(todo(), nil)
+		mockService.On("GetByID", mock.Anything, "owner-1", todoID.Hex()).Return(todo(), nil)
+		server := newServer(t, mockService)
+
+		out, err := runClient(client(server, "token-1"), "", "update", todoID.Hex(), "-title", "Buy milk", "-description", "Two litres")
+
+		assert.NoError(t, err)
+		assert.Contains(t, out, "Buy milk")
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("success when a todo is deleted", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+		mockService.On("Delete", mock.Anything, "owner-1", todoID.Hex()).Return(nil)
+		server := newServer(t, mockService)
+
+		out, err := runClient(client(server, "token-1"), "", "delete", todoID.Hex(), "-o", "json")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "{\n  \"id\": \""+todoID.Hex()+"\"\n}\n", out)
+	})
+
+	t.Run("when the body is invalid", func(t *testing.T) {
+		server := newServer(t, new(mockservice.Service))
+
+		_, err := runClient(client(server, "token-1"), "", "create", "-title", "Buy milk")
+
+		apiErr := &clidelivery.APIError{}
+		assert.True(t, errors.As(err, &apiErr))
+		assert.Equal(t, http.StatusBadRequest, apiErr.Status)
+		assert.Equal(t, "description is required", apiErr.Errors["description"])
+	})
+
+	t.Run("when the todo is not found", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+		mockService.On("GetByID", mock.Anything, "owner-1", "missing").Return(nil, errorsutil.ErrNotFound)
+		server := newServer(t, mockService)
+
+		_, err := runClient(client(server, "token-1"), "", "get", "missing")
+
+		assert.EqualError(t, err, "Item not found")
+	})
+
+	t.Run("when the token is invalid", func(t *testing.T) {
+		server := newServer(t, new(mockservice.Service))
+
+		_, err := runClient(client(server, "wrong"), "", "list")
+
+		apiErr := &clidelivery.APIError{}
+		assert.True(t, errors.As(err, &apiErr))
+		assert.Equal(t, http.StatusUnauthorized, apiErr.Status)
+	})
+}
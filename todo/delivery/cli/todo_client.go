@@ -0,0 +1,367 @@
+package clidelivery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+
+	"go-clean-architecture/pkg/auth"
+	"go-clean-architecture/pkg/quota"
+	pkgvalidator "go-clean-architecture/pkg/validator"
+	"go-clean-architecture/todo/models"
+	todoservice "go-clean-architecture/todo/service"
+	errorsutil "go-clean-architecture/utils/errors"
+	paginationutil "go-clean-architecture/utils/pagination"
+	responseutil "go-clean-architecture/utils/response"
+)
+
+// Client - todo operations of the command line, served by a running server or directly by the service
+type Client interface {
+	List(ctx context.Context, keyword string, page int, perPage int) (*List, error)
+	Get(ctx context.Context, id string) (*models.Todo, error)
+	Create(ctx context.Context, value *models.TodoRequest) (*models.Todo, error)
+	Update(ctx context.Context, id string, value *models.TodoRequest) (*models.Todo, error)
+	Delete(ctx context.Context, id string) error
+}
+
+// List - page of todos, shaped like the list responses of the REST API
+type List struct {
+	Data []*models.Todo     `json:"data"`
+	Meta *responseutil.Meta `json:"meta"`
+}
+
+// APIError - failed operation, with the status and message the REST API responds with
+type APIError struct {
+	Status  int
+	Message string
+	Errors  map[string]interface{}
+}
+
+func (e *APIError) Error() string {
+	if len(e.Errors) == 0 {
+		return e.Message
+	}
+
+	fields := make([]string, 0, len(e.Errors))
+	for field := range e.Errors {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	lines := []string{e.Message}
+	for _, field := range fields {
+		lines = append(lines, fmt.Sprintf("  %s: %v", field, e.Errors[field]))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+type ServiceClientImpl struct {
+	service   todoservice.Service
+	principal *auth.Principal
+}
+
+// NewServiceClient - make client calling service as principal, without a server in between
+func NewServiceClient(service todoservice.Service, principal *auth.Principal) Client {
+	return &ServiceClientImpl{
+		service:   service,
+		principal: principal,
+	}
+}
+
+// List - page of the todos matching keyword, validated like the query of GET /todo
+func (c *ServiceClientImpl) List(ctx context.Context, keyword string, page int, perPage int) (*List, error) {
+	err := pkgvalidator.ValidateStruct(&models.TodoListRequest{
+		Keywords: &models.SearchForm{
+			Keywords: keyword,
+		},
+		Page:    optionalInt(page),
+		PerPage: optionalInt(perPage),
+	})
+	if err != nil {
+		return nil, serviceError(err, "")
+	}
+
+	currentPage := paginationutil.CurrentPage(page)
+	perPage = paginationutil.PerPage(perPage)
+	offset := paginationutil.Offset(currentPage, perPage)
+
+	results, totalData, err := c.service.GetAll(c.context(ctx), c.principal.Subject, keyword, perPage, offset)
+	if err != nil {
+		return nil, serviceError(err, "list items")
+	}
+
+	return &List{
+		Data: results,
+		Meta: &responseutil.Meta{
+			PerPage:     perPage,
+			CurrentPage: currentPage,
+			TotalPage:   paginationutil.TotalPage(totalData, perPage),
+			TotalData:   totalData,
+		},
+	}, nil
+}
+
+// Get - todo by id
+func (c *ServiceClientImpl) Get(ctx context.Context, id string) (*models.Todo, error) {
+	result, err := c.service.GetByID(c.context(ctx), c.principal.Subject, id)
+	if err != nil {
+		return nil, serviceError(err, "read this item")
+	}
+
+	return result, nil
+}
+
+// Create - create todo owned by the principal
+func (c *ServiceClientImpl) Create(ctx context.Context, value *models.TodoRequest) (*models.Todo, error) {
+	if err := pkgvalidator.ValidateStruct(value); err != nil {
+		return nil, serviceError(err, "")
+	}
+
+	result, err := c.service.Create(c.context(ctx), &models.Todo{
+		Title:       value.Title,
+		Description: value.Description,
+		OwnerID:     c.principal.Subject,
+	})
+	if err != nil {
+		return nil, serviceError(err, "create items")
+	}
+
+	return result, nil
+}
+
+// Update - replace title and description of todo by id
+func (c *ServiceClientImpl) Update(ctx context.Context, id string, value *models.TodoRequest) (*models.Todo, error) {
+	if err := pkgvalidator.ValidateStruct(value); err != nil {
+		return nil, serviceError(err, "")
+	}
+
+	result, err := c.service.Update(c.context(ctx), c.principal.Subject, id, &models.Todo{
+		Title:       value.Title,
+		Description: value.Description,
+	})
+	if err != nil {
+		return nil, serviceError(err, "update this item")
+	}
+
+	return result, nil
+}
+
+// Delete - delete todo by id
+func (c *ServiceClientImpl) Delete(ctx context.Context, id string) error {
+	if err := c.service.Delete(c.context(ctx), c.principal.Subject, id); err != nil {
+		return serviceError(err, "delete this item")
+	}
+
+	return nil
+}
+
+// context - ctx carrying the principal, as auth.Middleware would
+func (c *ServiceClientImpl) context(ctx context.Context) context.Context {
+	return auth.NewContext(ctx, c.principal)
+}
+
+// serviceError - err as the REST API would respond with it, action completes "You are not allowed to"
+func serviceError(err error, action string) error {
+	validationErrors := validator.ValidationErrors{}
+	if errors.As(err, &validationErrors) {
+		return &APIError{
+			Status:  http.StatusBadRequest,
+			Message: "Validation errors in your request",
+			Errors:  pkgvalidator.ValidatonError(validationErrors).Errors,
+		}
+	}
+	if errors.Is(err, errorsutil.ErrForbidden) {
+		return &APIError{Status: http.StatusForbidden, Message: "You are not allowed to " + action}
+	}
+	exceeded := &quota.ExceededError{}
+	if errors.As(err, &exceeded) {
+		status := http.StatusForbidden
+		if exceeded.RetryAfter > 0 {
+			status = http.StatusTooManyRequests
+		}
+		return &APIError{Status: status, Message: exceeded.Message()}
+	}
+	if err.Error() == "not found" {
+		return &APIError{Status: http.StatusNotFound, Message: "Item not found"}
+	}
+
+	return err
+}
+
+// optionalInt - query string of n, empty when it is unset
+func optionalInt(n int) string {
+	if n == 0 {
+		return ""
+	}
+
+	return strconv.Itoa(n)
+}
+
+// HTTPConfig - server the client talks to
+type HTTPConfig struct {
+	// Server - base URL of the server, e.g. http://localhost:8080
+	Server string
+	// Token - access token or API key, both are sent as "Authorization: Bearer"
+	Token string
+	// Tenant - tenant id sent in TenantHeader, empty when tenancy is disabled
+	Tenant       string
+	TenantHeader string
+}
+
+type HTTPClientImpl struct {
+	cfg    HTTPConfig
+	client *http.Client
+}
+
+// NewHTTPClient - make client of the REST API of a running server
+func NewHTTPClient(cfg *HTTPConfig, client *http.Client) Client {
+	c := &HTTPClientImpl{
+		cfg:    *cfg,
+		client: client,
+	}
+	c.cfg.Server = strings.TrimSuffix(c.cfg.Server, "/")
+	if c.cfg.TenantHeader == "" {
+		c.cfg.TenantHeader = "X-Tenant-ID"
+	}
+
+	return c
+}
+
+// List - GET /todo
+func (c *HTTPClientImpl) List(ctx context.Context, keyword string, page int, perPage int) (*List, error) {
+	query := url.Values{}
+	if keyword != "" {
+		query.Set("q", keyword)
+	}
+	if page != 0 {
+		query.Set("page", strconv.Itoa(page))
+	}
+	if perPage != 0 {
+		query.Set("per_page", strconv.Itoa(perPage))
+	}
+
+	path := "/todo"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	result := &List{}
+	if err := c.do(ctx, http.MethodGet, path, nil, result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Get - GET /todo/{id}
+func (c *HTTPClientImpl) Get(ctx context.Context, id string) (*models.Todo, error) {
+	result := &models.Todo{}
+	if err := c.do(ctx, http.MethodGet, "/todo/"+url.PathEscape(id), nil, &envelope{Data: result}); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Create - POST /todo
+func (c *HTTPClientImpl) Create(ctx context.Context, value *models.TodoRequest) (*models.Todo, error) {
+	result := &models.Todo{}
+	if err := c.do(ctx, http.MethodPost, "/todo", value, &envelope{Data: result}); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Update - PUT /todo/{id}, which responds with the id only, followed by GET /todo/{id}
+func (c *HTTPClientImpl) Update(ctx context.Context, id string, value *models.TodoRequest) (*models.Todo, error) {
+	if err := c.do(ctx, http.MethodPut, "/todo/"+url.PathEscape(id), value, nil); err != nil {
+		return nil, err
+	}
+
+	return c.Get(ctx, id)
+}
+
+// Delete - DELETE /todo/{id}
+func (c *HTTPClientImpl) Delete(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/todo/"+url.PathEscape(id), nil, nil)
+}
+
+// envelope - body of successful responses
+type envelope struct {
+	Data interface{} `json:"data"`
+}
+
+// errorBody - body of error responses, in the default or the problem format
+type errorBody struct {
+	Message string                 `json:"message"`
+	Title   string                 `json:"title"`
+	Detail  string                 `json:"detail"`
+	Errors  map[string]interface{} `json:"errors"`
+}
+
+// do - send body as JSON and decode the response into out, an error response is returned as *APIError
+func (c *HTTPClientImpl) do(ctx context.Context, method string, path string, body interface{}, out interface{}) error {
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.cfg.Server+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.cfg.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.cfg.Token)
+	}
+	if c.cfg.Tenant != "" {
+		req.Header.Set(c.cfg.TenantHeader, c.cfg.Tenant)
+	}
+
+	res, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= http.StatusBadRequest {
+		e := &errorBody{}
+		if err := json.NewDecoder(res.Body).Decode(e); err != nil {
+			return &APIError{Status: res.StatusCode, Message: http.StatusText(res.StatusCode)}
+		}
+		message := e.Message
+		if message == "" {
+			message = e.Title
+		}
+		if e.Detail != "" {
+			message += ": " + e.Detail
+		}
+		return &APIError{Status: res.StatusCode, Message: message, Errors: e.Errors}
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(res.Body).Decode(out); err != nil {
+		return fmt.Errorf("decoding response of %s %s: %w", method, path, err)
+	}
+
+	return nil
+}
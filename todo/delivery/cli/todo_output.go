@@ -0,0 +1,120 @@
+package clidelivery
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"go-clean-architecture/todo/models"
+)
+
+// Output formats
+const (
+	OutputTable = "table"
+	OutputJSON  = "json"
+	OutputYAML  = "yaml"
+)
+
+func isOutput(output string) bool {
+	switch output {
+	case OutputTable, OutputJSON, OutputYAML:
+		return true
+	default:
+		return false
+	}
+}
+
+// writeList - page of todos as a table with a page summary, or as the list response of the REST API
+func writeList(w io.Writer, output string, list *List) error {
+	if output != OutputTable {
+		return writeValue(w, output, list)
+	}
+
+	tw := todoTable(w)
+	for _, todo := range list.Data {
+		todoRow(tw, todo)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if list.Meta != nil {
+		_, err := fmt.Fprintf(w, "\nPage %d of %d, %d todos\n", list.Meta.CurrentPage, list.Meta.TotalPage, list.Meta.TotalData)
+		return err
+	}
+
+	return nil
+}
+
+// writeTodo - todo as a table, or as the data of the REST API responses
+func writeTodo(w io.Writer, output string, todo *models.Todo) error {
+	if output != OutputTable {
+		return writeValue(w, output, todo)
+	}
+
+	tw := todoTable(w)
+	todoRow(tw, todo)
+
+	return tw.Flush()
+}
+
+// writeDeleted - id of the deleted todo
+func writeDeleted(w io.Writer, output string, id string) error {
+	if output != OutputTable {
+		return writeValue(w, output, map[string]string{"id": id})
+	}
+
+	_, err := fmt.Fprintf(w, "Deleted %s\n", id)
+	return err
+}
+
+func todoTable(w io.Writer) *tabwriter.Writer {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tTITLE\tDESCRIPTION\tOWNER\tUPDATED")
+
+	return tw
+}
+
+func todoRow(tw *tabwriter.Writer, todo *models.Todo) {
+	fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", todo.ID.Hex(), todo.Title, todo.Description, todo.OwnerID, todo.UpdatedAt.Format(time.RFC3339))
+}
+
+// writeValue - v as indented JSON, or as YAML with the field names and order of its JSON
+func writeValue(w io.Writer, output string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if output == OutputJSON {
+		_, err = fmt.Fprintf(w, "%s\n", data)
+		return err
+	}
+
+	// JSON is YAML, decoding it into a node keeps the order of its fields
+	node := &yaml.Node{}
+	if err := yaml.Unmarshal(data, node); err != nil {
+		return err
+	}
+	blockStyle(node)
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(node); err != nil {
+		return err
+	}
+
+	return encoder.Close()
+}
+
+// blockStyle - drop the flow style and quotes node was decoded with from JSON
+func blockStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
@@ -0,0 +1,531 @@
+package graphqldelivery
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+
+	"go-clean-architecture/pkg/auth"
+	"go-clean-architecture/pkg/logger"
+	"go-clean-architecture/pkg/quota"
+	pkgvalidator "go-clean-architecture/pkg/validator"
+	"go-clean-architecture/todo/models"
+	todoservice "go-clean-architecture/todo/service"
+	errorsutil "go-clean-architecture/utils/errors"
+	paginationutil "go-clean-architecture/utils/pagination"
+	requestutil "go-clean-architecture/utils/request"
+	responseutil "go-clean-architecture/utils/response"
+)
+
+type GraphQLHandler interface {
+	RegisterRoutes(router chi.Router)
+	ServeHTTP(w http.ResponseWriter, r *http.Request)
+}
+
+// maxBodySize - limit of GraphQL request bodies, queries are small but carry the mutation inputs
+const maxBodySize = 64 << 10
+
+type GraphQLHandlerImpl struct {
+	service todoservice.Service
+	schema  graphql.Schema
+	limits  Limits
+	log     logger.Logger
+}
+
+// New - make graphql handler serving queries within limits
+func New(service todoservice.Service, limits Limits) (GraphQLHandler, error) {
+	h := &GraphQLHandlerImpl{
+		service: service,
+		limits:  limits,
+		log:     logger.Named("todo/delivery/graphql"),
+	}
+
+	schema, err := h.newSchema()
+	if err != nil {
+		return nil, err
+	}
+	h.schema = schema
+
+	return h, nil
+}
+
+// RegisterRoutes - like the REST routes every operation requires a principal resolved by auth.Middleware,
+// API keys need todo:read for queries and todo:write for mutations
+func (h *GraphQLHandlerImpl) RegisterRoutes(router chi.Router) {
+	router.Group(func(r chi.Router) {
+		r.Use(auth.Require)
+		r.Use(requestutil.MaxBodySize(maxBodySize))
+
+		r.Get("/graphql", h.ServeHTTP)
+		r.Post("/graphql", h.ServeHTTP)
+	})
+}
+
+// Request - GraphQL over HTTP request, sent as the JSON body of a POST or the query parameters of a GET
+type Request struct {
+	Query         string                 `json:"query" form:"query"`
+	OperationName string                 `json:"operationName" form:"operationName"`
+	Variables     map[string]interface{} `json:"variables" form:"variables"`
+	// Extensions - protocol extensions like persisted queries, accepted and ignored
+	Extensions map[string]interface{} `json:"extensions" form:"extensions"`
+}
+
+// ServeHTTP - execute a GraphQL request, errors of a well-formed request are part of the 200 response
+func (h *GraphQLHandlerImpl) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	req := &Request{}
+	if r.Method == http.MethodGet {
+		query := r.URL.Query()
+		req.Query, req.OperationName = query.Get("query"), query.Get("operationName")
+		if variables := query.Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+				responseutil.ResponseBadRequest(w, r, "Variables must be a JSON object")
+				return
+			}
+		}
+	} else if err := requestutil.Decode(r, req); err != nil {
+		responseutil.ResponseBindError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, h.execute(r.Context(), req, r.Method == http.MethodGet))
+}
+
+// execute - parse, validate, check the limits of and run req. Mutations are refused on readOnly requests
+// so that a cross-site GET cannot change data
+func (h *GraphQLHandlerImpl) execute(ctx context.Context, req *Request, readOnly bool) *graphql.Result {
+	if strings.TrimSpace(req.Query) == "" {
+		return errorResult(&gqlError{message: "Query is required", code: responseutil.CodeBadRequest})
+	}
+
+	doc, err := parser.Parse(parser.ParseParams{Source: source.NewSource(&source.Source{
+		Body: []byte(req.Query),
+		Name: "GraphQL request",
+	})})
+	if err != nil {
+		return &graphql.Result{Errors: gqlerrors.FormatErrors(err)}
+	}
+
+	validation := graphql.ValidateDocument(&h.schema, doc, nil)
+	if !validation.IsValid {
+		return &graphql.Result{Errors: validation.Errors}
+	}
+
+	if err := h.limits.check(doc, req.Variables); err != nil {
+		return errorResult(err)
+	}
+
+	if readOnly {
+		if operation := selectOperation(doc, req.OperationName); operation != nil && operation.Operation != ast.OperationTypeQuery {
+			return errorResult(&gqlError{message: "Only queries can be sent with GET", code: responseutil.CodeBadRequest})
+		}
+	}
+
+	return graphql.Execute(graphql.ExecuteParams{
+		Schema:        h.schema,
+		AST:           doc,
+		OperationName: req.OperationName,
+		Args:          req.Variables,
+		Context:       ctx,
+	})
+}
+
+// selectOperation - operation of doc named name, the only one when name is empty
+func selectOperation(doc *ast.Document, name string) *ast.OperationDefinition {
+	var selected *ast.OperationDefinition
+	for _, definition := range doc.Definitions {
+		operation, ok := definition.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		if name == "" || (operation.Name != nil && operation.Name.Value == name) {
+			if selected != nil && name == "" {
+				return nil
+			}
+			selected = operation
+		}
+	}
+
+	return selected
+}
+
+func errorResult(err error) *graphql.Result {
+	return &graphql.Result{Errors: []gqlerrors.FormattedError{gqlerrors.FormatError(gqlerrors.NewError(err.Error(), nil, "", nil, nil, err))}}
+}
+
+// gqlError - error of a resolver, its code and details are reported in the extensions of the GraphQL error
+type gqlError struct {
+	message string
+	code    string
+	details map[string]interface{}
+}
+
+func (e *gqlError) Error() string {
+	return e.message
+}
+
+// Extensions - gqlerrors.ExtendedError
+func (e *gqlError) Extensions() map[string]interface{} {
+	extensions := map[string]interface{}{"code": e.code}
+	for key, value := range e.details {
+		extensions[key] = value
+	}
+
+	return extensions
+}
+
+// resolverError - GraphQL error of an error of the service, action names what the caller tried for forbidden errors
+func (h *GraphQLHandlerImpl) resolverError(ctx context.Context, err error, action string) error {
+	var validationErrors validator.ValidationErrors
+	exceeded := &quota.ExceededError{}
+
+	switch {
+	case errors.As(err, &validationErrors):
+		return &gqlError{
+			message: "Validation errors in your request",
+			code:    responseutil.CodeValidation,
+			details: map[string]interface{}{"errors": pkgvalidator.ValidatonError(validationErrors).Errors},
+		}
+	case errors.Is(err, errorsutil.ErrForbidden):
+		return &gqlError{message: "You are not allowed to " + action, code: responseutil.CodeForbidden}
+	case errors.Is(err, errorsutil.ErrNotFound):
+		return &gqlError{message: "Item not found", code: responseutil.CodeNotFound}
+	case errors.As(err, &exceeded):
+		details := map[string]interface{}{"quota": exceeded.Quota, "limit": exceeded.Limit}
+		if exceeded.RetryAfter > 0 {
+			details["retryAfter"] = int(math.Ceil(exceeded.RetryAfter.Seconds()))
+		}
+		return &gqlError{message: exceeded.Message(), code: responseutil.CodeQuotaExceeded, details: details}
+	}
+
+	h.log.WithContext(ctx).Error("graphql resolver failed", "error", err)
+	return &gqlError{message: "There is something error", code: responseutil.CodeInternal}
+}
+
+// caller - user id of the principal of ctx when it was granted scope
+func caller(ctx context.Context, scope string) (string, error) {
+	principal := auth.FromContext(ctx)
+	if principal == nil {
+		return "", &gqlError{message: "Authentication required", code: responseutil.CodeUnauthorized}
+	}
+	if !principal.HasScope(scope) {
+		return "", &gqlError{message: "API key lacks the " + scope + " scope", code: responseutil.CodeForbidden}
+	}
+
+	return principal.Subject, nil
+}
+
+// newSchema - queries and mutations of the todo service
+func (h *GraphQLHandlerImpl) newSchema() (graphql.Schema, error) {
+	collaboratorType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Collaborator",
+		Description: "User a todo is shared with",
+		Fields: graphql.Fields{
+			"userId":     &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"permission": &graphql.Field{Type: graphql.NewNonNull(graphql.String), Description: "view, edit or owner"},
+		},
+	})
+
+	todoType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Todo",
+		Fields: graphql.Fields{
+			"id":            &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+			"title":         &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"description":   &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"ownerId":       &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"collaborators": &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(collaboratorType)))},
+			"createdAt":     &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime)},
+			"updatedAt":     &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime)},
+		},
+	})
+
+	todoEdgeType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "TodoEdge",
+		Fields: graphql.Fields{
+			"cursor": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"node":   &graphql.Field{Type: graphql.NewNonNull(todoType)},
+		},
+	})
+
+	pageInfoType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "PageInfo",
+		Fields: graphql.Fields{
+			"hasNextPage":     &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+			"hasPreviousPage": &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+			"startCursor":     &graphql.Field{Type: graphql.String},
+			"endCursor":       &graphql.Field{Type: graphql.String},
+		},
+	})
+
+	todoConnectionType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "TodoConnection",
+		Fields: graphql.Fields{
+			"edges":      &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(todoEdgeType)))},
+			"nodes":      &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(todoType)))},
+			"pageInfo":   &graphql.Field{Type: graphql.NewNonNull(pageInfoType)},
+			"totalCount": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		},
+	})
+
+	todoInputType := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "TodoInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"title":       &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"description": &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+		},
+	})
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"todo": &graphql.Field{
+				Type:        todoType,
+				Description: "Todo by id",
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				},
+				Resolve: h.resolveTodo,
+			},
+			"todos": &graphql.Field{
+				Type:        graphql.NewNonNull(todoConnectionType),
+				Description: "Todos of the caller, including todos shared with them",
+				Args: graphql.FieldConfigArgument{
+					"search": &graphql.ArgumentConfig{Type: graphql.String, Description: "Keywords searched in title and description"},
+					"first":  &graphql.ArgumentConfig{Type: graphql.Int, Description: "Todos per page, 1 to 100, 10 by default"},
+					"after":  &graphql.ArgumentConfig{Type: graphql.String, Description: "Cursor of the todo the page starts after"},
+				},
+				Resolve: h.resolveTodos,
+			},
+		},
+	})
+
+	mutation := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"createTodo": &graphql.Field{
+				Type: graphql.NewNonNull(todoType),
+				Args: graphql.FieldConfigArgument{
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(todoInputType)},
+				},
+				Resolve: h.resolveCreateTodo,
+			},
+			"updateTodo": &graphql.Field{
+				Type: graphql.NewNonNull(todoType),
+				Args: graphql.FieldConfigArgument{
+					"id":    &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(todoInputType)},
+				},
+				Resolve: h.resolveUpdateTodo,
+			},
+			"deleteTodo": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.ID),
+				Description: "Delete a todo, returns its id",
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				},
+				Resolve: h.resolveDeleteTodo,
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: query, Mutation: mutation})
+}
+
+// resolveTodo - get todo by id resolver, null with a not_found error when there is none
+func (h *GraphQLHandlerImpl) resolveTodo(p graphql.ResolveParams) (interface{}, error) {
+	userID, err := caller(p.Context, auth.ScopeTodoRead)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := h.service.GetByID(p.Context, userID, p.Args["id"].(string))
+	if err != nil {
+		return nil, h.resolverError(p.Context, err, "read this item")
+	}
+
+	return toGraphQL(result), nil
+}
+
+// resolveTodos - get all todo resolver, a page of the todos connection
+func (h *GraphQLHandlerImpl) resolveTodos(p graphql.ResolveParams) (interface{}, error) {
+	userID, err := caller(p.Context, auth.ScopeTodoRead)
+	if err != nil {
+		return nil, err
+	}
+
+	search, _ := p.Args["search"].(string)
+	form := &models.TodoListRequest{Keywords: &models.SearchForm{Keywords: search}}
+	if first, ok := p.Args["first"].(int); ok {
+		form.PerPage = strconv.Itoa(first)
+	}
+	if err := pkgvalidator.ValidateStruct(form); err != nil {
+		return nil, h.resolverError(p.Context, err, "list items")
+	}
+
+	offset := 0
+	if after, ok := p.Args["after"].(string); ok && after != "" {
+		if offset, err = decodeCursor(after); err != nil {
+			return nil, &gqlError{
+				message: "Validation errors in your request",
+				code:    responseutil.CodeValidation,
+				details: map[string]interface{}{"errors": map[string]interface{}{"after": "after is not a valid cursor"}},
+			}
+		}
+	}
+
+	perPage, _ := strconv.Atoi(form.PerPage)
+	perPage = paginationutil.PerPage(perPage)
+
+	results, total, err := h.service.GetAll(p.Context, userID, search, perPage, offset)
+	if err != nil {
+		return nil, h.resolverError(p.Context, err, "list items")
+	}
+
+	edges := make([]map[string]interface{}, 0, len(results))
+	nodes := make([]map[string]interface{}, 0, len(results))
+	for i, todo := range results {
+		node := toGraphQL(todo)
+		edges = append(edges, map[string]interface{}{"cursor": encodeCursor(offset + i + 1), "node": node})
+		nodes = append(nodes, node)
+	}
+
+	pageInfo := map[string]interface{}{
+		"hasNextPage":     offset+len(results) < total,
+		"hasPreviousPage": offset > 0,
+	}
+	if len(edges) > 0 {
+		pageInfo["startCursor"] = edges[0]["cursor"]
+		pageInfo["endCursor"] = edges[len(edges)-1]["cursor"]
+	}
+
+	return map[string]interface{}{
+		"edges":      edges,
+		"nodes":      nodes,
+		"pageInfo":   pageInfo,
+		"totalCount": total,
+	}, nil
+}
+
+// resolveCreateTodo - create todo resolver
+func (h *GraphQLHandlerImpl) resolveCreateTodo(p graphql.ResolveParams) (interface{}, error) {
+	userID, err := caller(p.Context, auth.ScopeTodoWrite)
+	if err != nil {
+		return nil, err
+	}
+
+	data := todoInput(p.Args["input"])
+	if err := pkgvalidator.ValidateStruct(data); err != nil {
+		return nil, h.resolverError(p.Context, err, "create items")
+	}
+
+	result, err := h.service.Create(p.Context, &models.Todo{
+		Title:       data.Title,
+		Description: data.Description,
+		OwnerID:     userID,
+	})
+	if err != nil {
+		return nil, h.resolverError(p.Context, err, "create items")
+	}
+
+	return toGraphQL(result), nil
+}
+
+// resolveUpdateTodo - update todo by id resolver
+func (h *GraphQLHandlerImpl) resolveUpdateTodo(p graphql.ResolveParams) (interface{}, error) {
+	userID, err := caller(p.Context, auth.ScopeTodoWrite)
+	if err != nil {
+		return nil, err
+	}
+
+	data := todoInput(p.Args["input"])
+	if err := pkgvalidator.ValidateStruct(data); err != nil {
+		return nil, h.resolverError(p.Context, err, "update this item")
+	}
+
+	result, err := h.service.Update(p.Context, userID, p.Args["id"].(string), &models.Todo{
+		Title:       data.Title,
+		Description: data.Description,
+	})
+	if err != nil {
+		return nil, h.resolverError(p.Context, err, "update this item")
+	}
+
+	return toGraphQL(result), nil
+}
+
+// resolveDeleteTodo - delete todo by id resolver
+func (h *GraphQLHandlerImpl) resolveDeleteTodo(p graphql.ResolveParams) (interface{}, error) {
+	userID, err := caller(p.Context, auth.ScopeTodoWrite)
+	if err != nil {
+		return nil, err
+	}
+
+	id := p.Args["id"].(string)
+	if err := h.service.Delete(p.Context, userID, id); err != nil {
+		return nil, h.resolverError(p.Context, err, "delete this item")
+	}
+
+	return id, nil
+}
+
+// todoInput - todo request of a TodoInput argument
+func todoInput(arg interface{}) *models.TodoRequest {
+	input, _ := arg.(map[string]interface{})
+	title, _ := input["title"].(string)
+	description, _ := input["description"].(string)
+
+	return &models.TodoRequest{Title: title, Description: description}
+}
+
+// toGraphQL - Todo object of todo
+func toGraphQL(todo *models.Todo) map[string]interface{} {
+	collaborators := make([]map[string]interface{}, 0, len(todo.Collaborators))
+	for _, c := range todo.Collaborators {
+		collaborators = append(collaborators, map[string]interface{}{"userId": c.UserID, "permission": c.Permission})
+	}
+
+	return map[string]interface{}{
+		"id":            todo.ID.Hex(),
+		"title":         todo.Title,
+		"description":   todo.Description,
+		"ownerId":       todo.OwnerID,
+		"collaborators": collaborators,
+		"createdAt":     todo.CreatedAt,
+		"updatedAt":     todo.UpdatedAt,
+	}
+}
+
+// Cursors are opaque to clients, they encode the position of a todo in the list
+const cursorPrefix = "todo:"
+
+func encodeCursor(position int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(cursorPrefix + strconv.Itoa(position)))
+}
+
+// decodeCursor - position a cursor encodes, the offset of the page after it
+func decodeCursor(cursor string) (int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || !strings.HasPrefix(string(data), cursorPrefix) {
+		return 0, errors.New("invalid cursor")
+	}
+
+	position, err := strconv.Atoi(strings.TrimPrefix(string(data), cursorPrefix))
+	if err != nil || position < 0 {
+		return 0, errors.New("invalid cursor")
+	}
+
+	return position, nil
+}
@@ -0,0 +1,162 @@
+package graphqldelivery
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql/language/ast"
+
+	paginationutil "go-clean-architecture/utils/pagination"
+)
+
+// Limits - bounds of the queries accepted, zero disables a bound
+type Limits struct {
+	// MaxDepth - deepest nesting of fields, todos { edges { node { id } } } is 4 deep
+	MaxDepth int
+	// MaxComplexity - every field resolved costs 1, fields below the edges and nodes of a connection
+	// cost once per todo of the page requested by its first argument
+	MaxComplexity int
+}
+
+// LimitError - query over one of the Limits
+type LimitError struct {
+	// Limit - depth or complexity
+	Limit string
+	Max   int
+	Value int
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("Query %s of %d exceeds the limit of %d", e.Limit, e.Value, e.Max)
+}
+
+// Extensions - gqlerrors.ExtendedError
+func (e *LimitError) Extensions() map[string]interface{} {
+	return map[string]interface{}{
+		"code":  "query_too_" + map[string]string{"depth": "deep", "complexity": "complex"}[e.Limit],
+		"limit": e.Max,
+		"value": e.Value,
+	}
+}
+
+// check - error when an operation of doc is over the limits. Introspection is bounded by the schema
+// itself and left out, so tools can load the schema whatever the limits
+func (l Limits) check(doc *ast.Document, variables map[string]interface{}) error {
+	m := &measure{
+		fragments: map[string]*ast.FragmentDefinition{},
+		variables: variables,
+	}
+	for _, definition := range doc.Definitions {
+		if fragment, ok := definition.(*ast.FragmentDefinition); ok {
+			m.fragments[fragment.Name.Value] = fragment
+		}
+	}
+
+	for _, definition := range doc.Definitions {
+		operation, ok := definition.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+
+		depth, complexity := m.selectionSet(operation.SelectionSet, map[string]bool{})
+		if l.MaxDepth > 0 && depth > l.MaxDepth {
+			return &LimitError{Limit: "depth", Max: l.MaxDepth, Value: depth}
+		}
+		if l.MaxComplexity > 0 && complexity > l.MaxComplexity {
+			return &LimitError{Limit: "complexity", Max: l.MaxComplexity, Value: complexity}
+		}
+	}
+
+	return nil
+}
+
+type measure struct {
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+}
+
+// selectionSet - depth and complexity of set, spreads lists the fragments spread on the way here
+func (m *measure) selectionSet(set *ast.SelectionSet, spreads map[string]bool) (int, int) {
+	if set == nil {
+		return 0, 0
+	}
+
+	depth, complexity := 0, 0
+	for _, selection := range set.Selections {
+		var d, c int
+
+		switch selection := selection.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(selection.Name.Value, "__") {
+				continue
+			}
+			d, c = m.field(selection, spreads)
+		case *ast.InlineFragment:
+			d, c = m.selectionSet(selection.SelectionSet, spreads)
+		case *ast.FragmentSpread:
+			name := selection.Name.Value
+			fragment, ok := m.fragments[name]
+			// Cycles are rejected by validation, do not follow them here
+			if !ok || spreads[name] {
+				continue
+			}
+			spreads[name] = true
+			d, c = m.selectionSet(fragment.SelectionSet, spreads)
+			delete(spreads, name)
+		}
+
+		if d > depth {
+			depth = d
+		}
+		complexity += c
+	}
+
+	return depth, complexity
+}
+
+// field - depth and complexity of field and its selections
+func (m *measure) field(field *ast.Field, spreads map[string]bool) (int, int) {
+	if field.SelectionSet == nil {
+		return 1, 1
+	}
+
+	pageSize := m.first(field)
+
+	depth, complexity := 0, 0
+	for _, selection := range field.SelectionSet.Selections {
+		d, c := m.selectionSet(&ast.SelectionSet{Selections: []ast.Selection{selection}}, spreads)
+		if child, ok := selection.(*ast.Field); ok && (child.Name.Value == "edges" || child.Name.Value == "nodes") {
+			c *= pageSize
+		}
+
+		if d > depth {
+			depth = d
+		}
+		complexity += c
+	}
+
+	return depth + 1, complexity + 1
+}
+
+// first - page size requested by the first argument of field, the default page size without one
+func (m *measure) first(field *ast.Field) int {
+	for _, argument := range field.Arguments {
+		if argument.Name.Value != "first" {
+			continue
+		}
+
+		switch value := argument.Value.(type) {
+		case *ast.IntValue:
+			first, _ := strconv.Atoi(value.Value)
+			return paginationutil.PerPage(first)
+		case *ast.Variable:
+			// Variables decoded from JSON are numbers
+			if first, ok := m.variables[value.Name.Value].(float64); ok {
+				return paginationutil.PerPage(int(first))
+			}
+		}
+	}
+
+	return paginationutil.PerPage(0)
+}
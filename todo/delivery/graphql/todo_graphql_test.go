@@ -0,0 +1,348 @@
+package graphqldelivery_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"go-clean-architecture/pkg/auth"
+	"go-clean-architecture/pkg/quota"
+	pkgvalidator "go-clean-architecture/pkg/validator"
+	tododelivery "go-clean-architecture/todo/delivery/graphql"
+	mockservice "go-clean-architecture/todo/mocks/service"
+	"go-clean-architecture/todo/models"
+	errorsutil "go-clean-architecture/utils/errors"
+)
+
+type graphQLResponse struct {
+	Data   map[string]interface{} `json:"data"`
+	Errors []struct {
+		Message    string                 `json:"message"`
+		Extensions map[string]interface{} `json:"extensions"`
+	} `json:"errors"`
+}
+
+// newRouter - router serving the graphql handler to owner-1, an API key holding scopes when any are given
+func newRouter(t *testing.T, service *mockservice.Service, limits tododelivery.Limits, scopes ...string) http.Handler {
+	pkgvalidator.New()
+
+	handler, err := tododelivery.New(service, limits)
+	assert.NoError(t, err)
+
+	router := chi.NewRouter()
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal := &auth.Principal{Subject: "owner-1"}
+			if len(scopes) > 0 {
+				principal.Scopes = scopes
+			}
+			next.ServeHTTP(w, r.WithContext(auth.NewContext(r.Context(), principal)))
+		})
+	})
+	handler.RegisterRoutes(router)
+
+	return router
+}
+
+func post(t *testing.T, router http.Handler, query string, variables map[string]interface{}) (*httptest.ResponseRecorder, *graphQLResponse) {
+	body, _ := json.Marshal(map[string]interface{}{"query": query, "variables": variables})
+	req := httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	return serve(t, router, req)
+}
+
+func serve(t *testing.T, router http.Handler, req *http.Request) (*httptest.ResponseRecorder, *graphQLResponse) {
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	res := &graphQLResponse{}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), res))
+
+	return rec, res
+}
+
+func TestTodoGraphQLTodo(t *testing.T) {
+	id := primitive.NewObjectID()
+	createdAt := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("when the todo is found", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+		router := newRouter(t, mockService, tododelivery.Limits{})
+
+		mockService.On("GetByID", mock.Anything, "owner-1", id.Hex()).Return(&models.Todo{
+			ID:            id,
+			Title:         "Buy milk",
+			OwnerID:       "owner-1",
+			Collaborators: []*models.Collaborator{{UserID: "user-2", Permission: models.PermissionView}},
+			CreatedAt:     createdAt,
+			UpdatedAt:     createdAt,
+		}, nil)
+
+		rec, res := post(t, router, `query($id: ID!) { todo(id: $id) { id title collaborators { userId permission } createdAt } }`,
+			map[string]interface{}{"id": id.Hex()})
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Empty(t, res.Errors)
+		todo := res.Data["todo"].(map[string]interface{})
+		assert.Equal(t, id.Hex(), todo["id"])
+		assert.Equal(t, "Buy milk", todo["title"])
+		assert.Equal(t, "2022-10-01T12:00:00Z", todo["createdAt"])
+		assert.Equal(t, []interface{}{map[string]interface{}{"userId": "user-2", "permission": "view"}}, todo["collaborators"])
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("when the todo is not found", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+		router := newRouter(t, mockService, tododelivery.Limits{})
+
+		mockService.On("GetByID", mock.Anything, "owner-1", "1").Return(nil, errorsutil.ErrNotFound)
+
+		_, res := post(t, router, `{ todo(id: "1") { id } }`, nil)
+
+		assert.Nil(t, res.Data["todo"])
+		assert.Equal(t, "not_found", res.Errors[0].Extensions["code"])
+	})
+
+	t.Run("when the service fails", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+		router := newRouter(t, mockService, tododelivery.Limits{})
+
+		mockService.On("GetByID", mock.Anything, "owner-1", "1").Return(nil, errors.New("connection refused"))
+
+		_, res := post(t, router, `{ todo(id: "1") { id } }`, nil)
+
+		assert.Equal(t, "internal_error", res.Errors[0].Extensions["code"])
+		assert.NotContains(t, res.Errors[0].Message, "connection refused")
+	})
+
+	t.Run("when it is sent with GET", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+		router := newRouter(t, mockService, tododelivery.Limits{})
+
+		mockService.On("GetByID", mock.Anything, "owner-1", "1").Return(&models.Todo{Title: "Buy milk"}, nil)
+
+		query := url.Values{"query": {`query($id: ID!) { todo(id: $id) { title } }`}, "variables": {`{"id":"1"}`}}
+		rec, res := serve(t, router, httptest.NewRequest(http.MethodGet, "/graphql?"+query.Encode(), nil))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "Buy milk", res.Data["todo"].(map[string]interface{})["title"])
+	})
+}
+
+func TestTodoGraphQLTodos(t *testing.T) {
+	const query = `query($search: String, $first: Int, $after: String) {
+		todos(search: $search, first: $first, after: $after) {
+			totalCount
+			edges { cursor node { title } }
+			pageInfo { hasNextPage hasPreviousPage endCursor }
+		}
+	}`
+
+	t.Run("when pages are followed by their cursor", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+		router := newRouter(t, mockService, tododelivery.Limits{})
+
+		mockService.On("GetAll", mock.Anything, "owner-1", "milk", 2, 0).
+			Return([]*models.Todo{{Title: "Buy milk"}, {Title: "Drink milk"}}, 3, nil).Once()
+		mockService.On("GetAll", mock.Anything, "owner-1", "milk", 2, 2).
+			Return([]*models.Todo{{Title: "Spill milk"}}, 3, nil).Once()
+
+		_, first := post(t, router, query, map[string]interface{}{"search": "milk", "first": 2})
+
+		assert.Empty(t, first.Errors)
+		todos := first.Data["todos"].(map[string]interface{})
+		assert.Equal(t, float64(3), todos["totalCount"])
+		assert.Len(t, todos["edges"], 2)
+		pageInfo := todos["pageInfo"].(map[string]interface{})
+		assert.Equal(t, true, pageInfo["hasNextPage"])
+		assert.Equal(t, false, pageInfo["hasPreviousPage"])
+
+		_, second := post(t, router, query, map[string]interface{}{"search": "milk", "first": 2, "after": pageInfo["endCursor"]})
+
+		assert.Empty(t, second.Errors)
+		todos = second.Data["todos"].(map[string]interface{})
+		edges := todos["edges"].([]interface{})
+		assert.Equal(t, "Spill milk", edges[0].(map[string]interface{})["node"].(map[string]interface{})["title"])
+		pageInfo = todos["pageInfo"].(map[string]interface{})
+		assert.Equal(t, false, pageInfo["hasNextPage"])
+		assert.Equal(t, true, pageInfo["hasPreviousPage"])
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("when the page size is out of range", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+		router := newRouter(t, mockService, tododelivery.Limits{})
+
+		_, res := post(t, router, query, map[string]interface{}{"first": 500})
+
+		assert.Equal(t, "validation_error", res.Errors[0].Extensions["code"])
+		assert.Contains(t, res.Errors[0].Extensions["errors"], "per_page")
+		mockService.AssertNotCalled(t, "GetAll", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("when the cursor is invalid", func(t *testing.T) {
+		router := newRouter(t, new(mockservice.Service), tododelivery.Limits{})
+
+		_, res := post(t, router, query, map[string]interface{}{"after": "not-a-cursor"})
+
+		assert.Equal(t, "validation_error", res.Errors[0].Extensions["code"])
+		assert.Contains(t, res.Errors[0].Extensions["errors"], "after")
+	})
+}
+
+func TestTodoGraphQLCreateTodo(t *testing.T) {
+	const mutation = `mutation($input: TodoInput!) { createTodo(input: $input) { title ownerId } }`
+
+	t.Run("when the todo is created", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+		router := newRouter(t, mockService, tododelivery.Limits{})
+
+		mockService.On("Create", mock.Anything, &models.Todo{Title: "title", Description: "description", OwnerID: "owner-1"}).
+			Return(&models.Todo{Title: "title", Description: "description", OwnerID: "owner-1"}, nil)
+
+		_, res := post(t, router, mutation, map[string]interface{}{"input": map[string]interface{}{"title": "title", "description": "description"}})
+
+		assert.Empty(t, res.Errors)
+		assert.Equal(t, "owner-1", res.Data["createTodo"].(map[string]interface{})["ownerId"])
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("when the input is invalid", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+		router := newRouter(t, mockService, tododelivery.Limits{})
+
+		_, res := post(t, router, mutation, map[string]interface{}{"input": map[string]interface{}{"title": "title", "description": ""}})
+
+		assert.Equal(t, "Validation errors in your request", res.Errors[0].Message)
+		assert.Equal(t, "validation_error", res.Errors[0].Extensions["code"])
+		assert.Equal(t, map[string]interface{}{"description": "description is required"}, res.Errors[0].Extensions["errors"])
+		mockService.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("when the API key lacks the write scope", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+		router := newRouter(t, mockService, tododelivery.Limits{}, auth.ScopeTodoRead)
+
+		_, res := post(t, router, mutation, map[string]interface{}{"input": map[string]interface{}{"title": "title", "description": "description"}})
+
+		assert.Equal(t, "forbidden", res.Errors[0].Extensions["code"])
+		mockService.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("when the request quota is used up", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+		router := newRouter(t, mockService, tododelivery.Limits{})
+
+		mockService.On("Create", mock.Anything, mock.Anything).Return(nil, &quota.ExceededError{Quota: quota.Requests, Limit: 10, RetryAfter: time.Hour})
+
+		_, res := post(t, router, mutation, map[string]interface{}{"input": map[string]interface{}{"title": "title", "description": "description"}})
+
+		assert.Equal(t, "quota_exceeded", res.Errors[0].Extensions["code"])
+		assert.Equal(t, float64(3600), res.Errors[0].Extensions["retryAfter"])
+	})
+
+	t.Run("when it is sent with GET", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+		router := newRouter(t, mockService, tododelivery.Limits{})
+
+		query := url.Values{"query": {`mutation { createTodo(input: {title: "title", description: "description"}) { title } }`}}
+		_, res := serve(t, router, httptest.NewRequest(http.MethodGet, "/graphql?"+query.Encode(), nil))
+
+		assert.Equal(t, "bad_request", res.Errors[0].Extensions["code"])
+		mockService.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+}
+
+func TestTodoGraphQLUpdateTodo(t *testing.T) {
+	t.Run("when the caller may not edit the todo", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+		router := newRouter(t, mockService, tododelivery.Limits{})
+
+		mockService.On("Update", mock.Anything, "owner-1", "1", &models.Todo{Title: "title", Description: "description"}).
+			Return(nil, errorsutil.ErrForbidden)
+
+		_, res := post(t, router, `mutation { updateTodo(id: "1", input: {title: "title", description: "description"}) { title } }`, nil)
+
+		assert.Equal(t, "You are not allowed to update this item", res.Errors[0].Message)
+		assert.Equal(t, "forbidden", res.Errors[0].Extensions["code"])
+	})
+}
+
+func TestTodoGraphQLDeleteTodo(t *testing.T) {
+	t.Run("when the todo is deleted", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+		router := newRouter(t, mockService, tododelivery.Limits{})
+
+		mockService.On("Delete", mock.Anything, "owner-1", "1").Return(nil)
+
+		_, res := post(t, router, `mutation { deleteTodo(id: "1") }`, nil)
+
+		assert.Empty(t, res.Errors)
+		assert.Equal(t, "1", res.Data["deleteTodo"])
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestTodoGraphQLLimits(t *testing.T) {
+	t.Run("when the query is too deep", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+		router := newRouter(t, mockService, tododelivery.Limits{MaxDepth: 3})
+
+		_, res := post(t, router, `{ todos { edges { node { collaborators { userId } } } } }`, nil)
+
+		assert.Equal(t, "query_too_deep", res.Errors[0].Extensions["code"])
+		assert.Equal(t, float64(3), res.Errors[0].Extensions["limit"])
+		mockService.AssertNotCalled(t, "GetAll", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("when a large page makes the query too complex", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+		router := newRouter(t, mockService, tododelivery.Limits{MaxComplexity: 100})
+
+		_, res := post(t, router, `query($first: Int) { todos(first: $first) { nodes { id title } } }`, map[string]interface{}{"first": 100})
+
+		assert.Equal(t, "query_too_complex", res.Errors[0].Extensions["code"])
+		mockService.AssertNotCalled(t, "GetAll", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("when the schema is introspected", func(t *testing.T) {
+		router := newRouter(t, new(mockservice.Service), tododelivery.Limits{MaxDepth: 2, MaxComplexity: 10})
+
+		_, res := post(t, router, `{ __schema { types { name fields { name type { name ofType { name } } } } } }`, nil)
+
+		assert.Empty(t, res.Errors)
+		assert.NotEmpty(t, res.Data["__schema"])
+	})
+}
+
+func TestTodoGraphQLRequest(t *testing.T) {
+	t.Run("when the query does not parse", func(t *testing.T) {
+		router := newRouter(t, new(mockservice.Service), tododelivery.Limits{})
+
+		rec, res := post(t, router, `{ todo(id: "1") {`, nil)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.NotEmpty(t, res.Errors)
+	})
+
+	t.Run("when the variables are not JSON", func(t *testing.T) {
+		router := newRouter(t, new(mockservice.Service), tododelivery.Limits{})
+
+		query := url.Values{"query": {`{ todo(id: "1") { id } }`}, "variables": {`{`}}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/graphql?"+query.Encode(), nil))
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
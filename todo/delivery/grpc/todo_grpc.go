@@ -0,0 +1,303 @@
+package grpcdelivery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/runtime/protoiface"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"go-clean-architecture/pkg/auth"
+	"go-clean-architecture/pkg/logger"
+	"go-clean-architecture/pkg/quota"
+	pkgvalidator "go-clean-architecture/pkg/validator"
+	"go-clean-architecture/todo/delivery/grpc/todopb"
+	"go-clean-architecture/todo/models"
+	todoservice "go-clean-architecture/todo/service"
+	errorsutil "go-clean-architecture/utils/errors"
+	paginationutil "go-clean-architecture/utils/pagination"
+)
+
+type GRPCHandler interface {
+	todopb.TodoServiceServer
+	Register(registrar grpc.ServiceRegistrar)
+}
+
+type GRPCHandlerImpl struct {
+	todopb.UnimplementedTodoServiceServer
+	service todoservice.Service
+	log     logger.Logger
+}
+
+// New - make grpc handler
+func New(service todoservice.Service) GRPCHandler {
+	return &GRPCHandlerImpl{
+		service: service,
+		log:     logger.Named("todo/delivery/grpc"),
+	}
+}
+
+// Register - register the todo service on registrar. Like the HTTP routes every call requires a principal
+// put into the context by grpcserver.Auth, API keys additionally need the scope of the call
+func (h *GRPCHandlerImpl) Register(registrar grpc.ServiceRegistrar) {
+	todopb.RegisterTodoServiceServer(registrar, h)
+}
+
+// List - get all todo grpc handler
+func (h *GRPCHandlerImpl) List(ctx context.Context, req *todopb.ListTodosRequest) (*todopb.ListTodosResponse, error) {
+	userID, err := caller(ctx, auth.ScopeTodoRead)
+	if err != nil {
+		return nil, err
+	}
+
+	// Zero is unset in proto3, it selects the default like a missing query parameter
+	form := &models.TodoListRequest{Keywords: &models.SearchForm{Keywords: req.Query}}
+	if req.Page != 0 {
+		form.Page = strconv.Itoa(int(req.Page))
+	}
+	if req.PerPage != 0 {
+		form.PerPage = strconv.Itoa(int(req.PerPage))
+	}
+	if err := pkgvalidator.ValidateStruct(form); err != nil {
+		return nil, h.statusError(ctx, err, "list items")
+	}
+
+	currentPage := paginationutil.CurrentPage(int(req.Page))
+	perPage := paginationutil.PerPage(int(req.PerPage))
+	offset := paginationutil.Offset(currentPage, perPage)
+
+	results, totalData, err := h.service.GetAll(ctx, userID, req.Query, perPage, offset)
+	if err != nil {
+		return nil, h.statusError(ctx, err, "list items")
+	}
+
+	res := &todopb.ListTodosResponse{
+		Todos:      make([]*todopb.Todo, 0, len(results)),
+		Page:       int32(currentPage),
+		PerPage:    int32(perPage),
+		TotalPages: int32(paginationutil.TotalPage(totalData, perPage)),
+		Total:      int32(totalData),
+	}
+	for _, todo := range results {
+		res.Todos = append(res.Todos, toProto(todo))
+	}
+
+	return res, nil
+}
+
+// Get - get todo by id grpc handler
+func (h *GRPCHandlerImpl) Get(ctx context.Context, req *todopb.GetTodoRequest) (*todopb.Todo, error) {
+	userID, err := caller(ctx, auth.ScopeTodoRead)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := h.service.GetByID(ctx, userID, req.Id)
+	if err != nil {
+		return nil, h.statusError(ctx, err, "read this item")
+	}
+
+	return toProto(result), nil
+}
+
+// Create - create todo grpc handler
+func (h *GRPCHandlerImpl) Create(ctx context.Context, req *todopb.CreateTodoRequest) (*todopb.Todo, error) {
+	userID, err := caller(ctx, auth.ScopeTodoWrite)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := pkgvalidator.ValidateStruct(&models.TodoRequest{Title: req.Title, Description: req.Description}); err != nil {
+		return nil, h.statusError(ctx, err, "create items")
+	}
+
+	result, err := h.service.Create(ctx, &models.Todo{
+		Title:       req.Title,
+		Description: req.Description,
+		OwnerID:     userID,
+	})
+	if err != nil {
+		return nil, h.statusError(ctx, err, "create items")
+	}
+
+	return toProto(result), nil
+}
+
+// Update - update todo by id grpc handler
+func (h *GRPCHandlerImpl) Update(ctx context.Context, req *todopb.UpdateTodoRequest) (*todopb.Todo, error) {
+	userID, err := caller(ctx, auth.ScopeTodoWrite)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := pkgvalidator.ValidateStruct(&models.TodoRequest{Title: req.Title, Description: req.Description}); err != nil {
+		return nil, h.statusError(ctx, err, "update this item")
+	}
+
+	result, err := h.service.Update(ctx, userID, req.Id, &models.Todo{
+		Title:       req.Title,
+		Description: req.Description,
+	})
+	if err != nil {
+		return nil, h.statusError(ctx, err, "update this item")
+	}
+
+	return toProto(result), nil
+}
+
+// Delete - delete todo by id grpc handler
+func (h *GRPCHandlerImpl) Delete(ctx context.Context, req *todopb.DeleteTodoRequest) (*todopb.DeleteTodoResponse, error) {
+	userID, err := caller(ctx, auth.ScopeTodoWrite)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.service.Delete(ctx, userID, req.Id); err != nil {
+		return nil, h.statusError(ctx, err, "delete this item")
+	}
+
+	return &todopb.DeleteTodoResponse{Id: req.Id}, nil
+}
+
+// Watch - stream todo events grpc handler, ends with Aborted when the client cannot keep up
+func (h *GRPCHandlerImpl) Watch(req *todopb.WatchTodosRequest, stream todopb.TodoService_WatchServer) error {
+	ctx := stream.Context()
+	userID, err := caller(ctx, auth.ScopeTodoRead)
+	if err != nil {
+		return err
+	}
+
+	events, err := h.service.Watch(ctx, userID, req.LastEventId)
+	if err != nil {
+		return h.statusError(ctx, err, "list items")
+	}
+
+	for event := range events {
+		if err := stream.Send(eventToProto(event)); err != nil {
+			return err
+		}
+	}
+
+	if ctx.Err() != nil {
+		return status.FromContextError(ctx.Err()).Err()
+	}
+
+	return status.Error(codes.Aborted, "Too far behind on events, watch again")
+}
+
+// caller - user id of the principal of ctx when it was granted scope
+func caller(ctx context.Context, scope string) (string, error) {
+	principal := auth.FromContext(ctx)
+	if principal == nil {
+		return "", status.Error(codes.Unauthenticated, "Authentication required")
+	}
+	if !principal.HasScope(scope) {
+		return "", status.Error(codes.PermissionDenied, "API key lacks the "+scope+" scope")
+	}
+
+	return principal.Subject, nil
+}
+
+// statusError - grpc status of an error of the service, action names what the caller tried for PermissionDenied
+func (h *GRPCHandlerImpl) statusError(ctx context.Context, err error, action string) error {
+	var validationErrors validator.ValidationErrors
+	exceeded := &quota.ExceededError{}
+
+	switch {
+	case errors.As(err, &validationErrors):
+		return invalidArgument(pkgvalidator.ValidatonError(validationErrors).Errors)
+	case errors.Is(err, errorsutil.ErrForbidden):
+		return status.Error(codes.PermissionDenied, "You are not allowed to "+action)
+	case errors.Is(err, errorsutil.ErrNotFound):
+		return status.Error(codes.NotFound, "Item not found")
+	case errors.Is(err, errorsutil.ErrAlreadyExists):
+		return status.Error(codes.AlreadyExists, "Item already exists")
+	case errors.As(err, &exceeded):
+		details := []protoiface.MessageV1{&errdetails.QuotaFailure{
+			Violations: []*errdetails.QuotaFailure_Violation{{Subject: exceeded.Quota, Description: exceeded.Error()}},
+		}}
+		if exceeded.RetryAfter > 0 {
+			details = append(details, &errdetails.RetryInfo{RetryDelay: durationpb.New(exceeded.RetryAfter)})
+		}
+		return withDetails(status.New(codes.ResourceExhausted, exceeded.Message()), details...)
+	}
+
+	h.log.WithContext(ctx).Error("grpc call failed", "error", err)
+	return status.Error(codes.Internal, "There is something error")
+}
+
+// invalidArgument - InvalidArgument status listing the invalid fields of errs
+func invalidArgument(errs map[string]interface{}) error {
+	fields := make([]string, 0, len(errs))
+	for field := range errs {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	badRequest := &errdetails.BadRequest{}
+	for _, field := range fields {
+		badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       field,
+			Description: fmt.Sprint(errs[field]),
+		})
+	}
+
+	return withDetails(status.New(codes.InvalidArgument, "Validation errors in your request"), badRequest)
+}
+
+// withDetails - st with details attached, details are only lost when they fail to marshal
+func withDetails(st *status.Status, details ...protoiface.MessageV1) error {
+	if detailed, err := st.WithDetails(details...); err == nil {
+		return detailed.Err()
+	}
+
+	return st.Err()
+}
+
+// toProto - protobuf message of todo
+func toProto(todo *models.Todo) *todopb.Todo {
+	res := &todopb.Todo{
+		Id:            todo.ID.Hex(),
+		Title:         todo.Title,
+		Description:   todo.Description,
+		OwnerId:       todo.OwnerID,
+		Collaborators: make([]*todopb.Collaborator, 0, len(todo.Collaborators)),
+		CreatedAt:     timestamppb.New(todo.CreatedAt),
+		UpdatedAt:     timestamppb.New(todo.UpdatedAt),
+	}
+	for _, c := range todo.Collaborators {
+		res.Collaborators = append(res.Collaborators, &todopb.Collaborator{UserId: c.UserID, Permission: c.Permission})
+	}
+
+	return res
+}
+
+var eventTypes = map[string]todopb.TodoEvent_Type{
+	models.EventCreated: todopb.TodoEvent_TYPE_CREATED,
+	models.EventUpdated: todopb.TodoEvent_TYPE_UPDATED,
+	models.EventDeleted: todopb.TodoEvent_TYPE_DELETED,
+	models.EventReset:   todopb.TodoEvent_TYPE_RESET,
+}
+
+// eventToProto - protobuf message of event
+func eventToProto(event *models.Event) *todopb.TodoEvent {
+	res := &todopb.TodoEvent{
+		Id:         event.ID,
+		Type:       eventTypes[event.Type],
+		OccurredAt: timestamppb.New(event.OccurredAt),
+	}
+	if event.Todo != nil {
+		res.Todo = toProto(event.Todo)
+	}
+
+	return res
+}
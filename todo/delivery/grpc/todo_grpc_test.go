@@ -0,0 +1,305 @@
+package grpcdelivery_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"go-clean-architecture/pkg/auth"
+	"go-clean-architecture/pkg/grpcserver"
+	"go-clean-architecture/pkg/quota"
+	pkgvalidator "go-clean-architecture/pkg/validator"
+	tododelivery "go-clean-architecture/todo/delivery/grpc"
+	"go-clean-architecture/todo/delivery/grpc/todopb"
+	mockservice "go-clean-architecture/todo/mocks/service"
+	"go-clean-architecture/todo/models"
+	errorsutil "go-clean-architecture/utils/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// resolver - principal named by the "user" metadata, API keys carry the scopes of "scopes"
+var resolver = auth.ResolverFunc(func(r *http.Request) (*auth.Principal, error) {
+	user := r.Header.Get("user")
+	if user == "" {
+		return nil, nil
+	}
+
+	principal := &auth.Principal{Subject: user}
+	if scopes := r.Header.Get("scopes"); scopes != "" {
+		principal.Scopes = strings.Split(scopes, ",")
+	}
+
+	return principal, nil
+})
+
+// newClient - todo client of a server running handler over an in-memory connection
+func newClient(t *testing.T, service *mockservice.Service) todopb.TodoServiceClient {
+	pkgvalidator.New()
+
+	server := grpcserver.New(&grpcserver.Config{MaxRecvMsgSize: 4 << 20, ShutdownTimeout: time.Second}, nil, grpcserver.Auth(resolver))
+	tododelivery.New(service).Register(server)
+
+	ln := bufconn.Listen(1 << 20)
+	ctx, cancel := context.WithCancel(context.Background())
+	go server.Serve(ctx, ln) //nolint:errcheck
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return ln.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		conn.Close()
+		cancel()
+	})
+
+	return todopb.NewTodoServiceClient(conn)
+}
+
+func asUser(scopes ...string) context.Context {
+	md := metadata.Pairs("user", "owner-1")
+	if len(scopes) > 0 {
+		md.Set("scopes", strings.Join(scopes, ","))
+	}
+
+	return metadata.NewOutgoingContext(context.Background(), md)
+}
+
+func TestTodoGRPCList(t *testing.T) {
+	t.Run("when the page is listed", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+		client := newClient(t, mockService)
+
+		mockService.On("GetAll", mock.Anything, "owner-1", "milk", 5, 5).Return([]*models.Todo{{Title: "Buy milk", OwnerID: "owner-1"}}, 11, nil)
+
+		res, err := client.List(asUser(), &todopb.ListTodosRequest{Query: "milk", Page: 2, PerPage: 5})
+
+		assert.NoError(t, err)
+		assert.Len(t, res.Todos, 1)
+		assert.Equal(t, "Buy milk", res.Todos[0].Title)
+		assert.Equal(t, int32(2), res.Page)
+		assert.Equal(t, int32(3), res.TotalPages)
+		assert.Equal(t, int32(11), res.Total)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("when the caller is anonymous", func(t *testing.T) {
+		client := newClient(t, new(mockservice.Service))
+
+		_, err := client.List(context.Background(), &todopb.ListTodosRequest{})
+
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+
+	t.Run("when the page size is out of range", func(t *testing.T) {
+		client := newClient(t, new(mockservice.Service))
+
+		_, err := client.List(asUser(), &todopb.ListTodosRequest{PerPage: 500})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		badRequest := detail[*errdetails.BadRequest](t, err)
+		assert.Equal(t, "per_page", badRequest.FieldViolations[0].Field)
+	})
+}
+
+func TestTodoGRPCGet(t *testing.T) {
+	t.Run("when the todo is not found", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+		client := newClient(t, mockService)
+
+		mockService.On("GetByID", mock.Anything, "owner-1", "1").Return(nil, errorsutil.ErrNotFound)
+
+		_, err := client.Get(asUser(), &todopb.GetTodoRequest{Id: "1"})
+
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("when the service fails", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+		client := newClient(t, mockService)
+
+		mockService.On("GetByID", mock.Anything, "owner-1", "1").Return(nil, errors.New("connection refused"))
+
+		_, err := client.Get(asUser(), &todopb.GetTodoRequest{Id: "1"})
+
+		assert.Equal(t, codes.Internal, status.Code(err))
+		assert.NotContains(t, status.Convert(err).Message(), "connection refused")
+	})
+}
+
+func TestTodoGRPCCreate(t *testing.T) {
+	t.Run("when the todo is created", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+		client := newClient(t, mockService)
+
+		mockService.On("Create", mock.Anything, &models.Todo{Title: "title", Description: "description", OwnerID: "owner-1"}).
+			Return(&models.Todo{Title: "title", Description: "description", OwnerID: "owner-1"}, nil)
+
+		res, err := client.Create(asUser(auth.ScopeTodoWrite), &todopb.CreateTodoRequest{Title: "title", Description: "description"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "owner-1", res.OwnerId)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("when the request is invalid", func(t *testing.T) {
+		client := newClient(t, new(mockservice.Service))
+
+		_, err := client.Create(asUser(), &todopb.CreateTodoRequest{Title: "title"})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		badRequest := detail[*errdetails.BadRequest](t, err)
+		assert.Equal(t, "description", badRequest.FieldViolations[0].Field)
+		assert.Equal(t, "description is required", badRequest.FieldViolations[0].Description)
+	})
+
+	t.Run("when the API key lacks the write scope", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+		client := newClient(t, mockService)
+
+		_, err := client.Create(asUser(auth.ScopeTodoRead), &todopb.CreateTodoRequest{Title: "title", Description: "description"})
+
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+		mockService.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("when the request quota is used up", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+		client := newClient(t, mockService)
+
+		mockService.On("Create", mock.Anything, mock.Anything).Return(nil, &quota.ExceededError{Quota: quota.Requests, Limit: 10, RetryAfter: time.Hour})
+
+		_, err := client.Create(asUser(), &todopb.CreateTodoRequest{Title: "title", Description: "description"})
+
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+		assert.Equal(t, quota.Requests, detail[*errdetails.QuotaFailure](t, err).Violations[0].Subject)
+		assert.Equal(t, time.Hour, detail[*errdetails.RetryInfo](t, err).RetryDelay.AsDuration())
+	})
+}
+
+func TestTodoGRPCUpdate(t *testing.T) {
+	t.Run("when the todo is updated", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+		client := newClient(t, mockService)
+
+		mockService.On("Update", mock.Anything, "owner-1", "1", &models.Todo{Title: "title", Description: "description"}).
+			Return(&models.Todo{Title: "title", Description: "description"}, nil)
+
+		res, err := client.Update(asUser(), &todopb.UpdateTodoRequest{Id: "1", Title: "title", Description: "description"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "title", res.Title)
+	})
+
+	t.Run("when the caller may not edit the todo", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+		client := newClient(t, mockService)
+
+		mockService.On("Update", mock.Anything, "owner-1", "1", mock.Anything).Return(nil, errorsutil.ErrForbidden)
+
+		_, err := client.Update(asUser(), &todopb.UpdateTodoRequest{Id: "1", Title: "title", Description: "description"})
+
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+		assert.Equal(t, "You are not allowed to update this item", status.Convert(err).Message())
+	})
+}
+
+func TestTodoGRPCDelete(t *testing.T) {
+	t.Run("when the todo is deleted", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+		client := newClient(t, mockService)
+
+		mockService.On("Delete", mock.Anything, "owner-1", "1").Return(nil)
+
+		res, err := client.Delete(asUser(), &todopb.DeleteTodoRequest{Id: "1"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "1", res.Id)
+	})
+}
+
+func TestTodoGRPCWatch(t *testing.T) {
+	t.Run("when events are streamed", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+		client := newClient(t, mockService)
+
+		events := make(chan *models.Event, 2)
+		events <- &models.Event{ID: "1", Type: models.EventCreated, Todo: &models.Todo{Title: "title"}}
+		events <- &models.Event{ID: "2", Type: models.EventDeleted, Todo: &models.Todo{Title: "title"}}
+		close(events)
+		mockService.On("Watch", mock.Anything, "owner-1", "").Return((<-chan *models.Event)(events), nil)
+
+		stream, err := client.Watch(asUser(), &todopb.WatchTodosRequest{})
+		assert.NoError(t, err)
+
+		event, err := stream.Recv()
+		assert.NoError(t, err)
+		assert.Equal(t, todopb.TodoEvent_TYPE_CREATED, event.Type)
+		event, err = stream.Recv()
+		assert.NoError(t, err)
+		assert.Equal(t, todopb.TodoEvent_TYPE_DELETED, event.Type)
+
+		// The service closed the channel while the call was live, the client fell behind
+		_, err = stream.Recv()
+		assert.Equal(t, codes.Aborted, status.Code(err))
+	})
+
+	t.Run("when the watch resumes after events too old to replay", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+		client := newClient(t, mockService)
+
+		events := make(chan *models.Event, 1)
+		events <- &models.Event{ID: "9", Type: models.EventReset}
+		close(events)
+		mockService.On("Watch", mock.Anything, "owner-1", "1").Return((<-chan *models.Event)(events), nil)
+
+		stream, err := client.Watch(asUser(), &todopb.WatchTodosRequest{LastEventId: "1"})
+		assert.NoError(t, err)
+
+		event, err := stream.Recv()
+		assert.NoError(t, err)
+		assert.Equal(t, todopb.TodoEvent_TYPE_RESET, event.Type)
+		assert.Nil(t, event.Todo)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("when listing is forbidden", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+		client := newClient(t, mockService)
+
+		mockService.On("Watch", mock.Anything, "owner-1", "").Return((<-chan *models.Event)(nil), errorsutil.ErrForbidden)
+
+		stream, err := client.Watch(asUser(), &todopb.WatchTodosRequest{})
+		assert.NoError(t, err)
+
+		_, err = stream.Recv()
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+}
+
+// detail - detail of type T attached to the status of err
+func detail[T any](t *testing.T, err error) T {
+	for _, d := range status.Convert(err).Details() {
+		if v, ok := d.(T); ok {
+			return v
+		}
+	}
+
+	var zero T
+	t.Fatalf("status %v has no %T detail", err, zero)
+	return zero
+}
@@ -1,28 +1,38 @@
 package httpdelivery
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
+	"go-clean-architecture/pkg/auth"
+	"go-clean-architecture/pkg/quota"
 	pkgvalidator "go-clean-architecture/pkg/validator"
 	"go-clean-architecture/todo/models"
 	todoservice "go-clean-architecture/todo/service"
+	errorsutil "go-clean-architecture/utils/errors"
 	paginationutil "go-clean-architecture/utils/pagination"
+	requestutil "go-clean-architecture/utils/request"
 	responseutil "go-clean-architecture/utils/response"
 
 	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/render"
 )
 
 type HTTPHandler interface {
-	RegisterRoutes(router *chi.Mux)
+	RegisterRoutes(router chi.Router)
 	GetAll(w http.ResponseWriter, r *http.Request)
 	GetByID(w http.ResponseWriter, r *http.Request)
 	Create(w http.ResponseWriter, r *http.Request)
 	Update(w http.ResponseWriter, r *http.Request)
 	Delete(w http.ResponseWriter, r *http.Request)
+	AddCollaborator(w http.ResponseWriter, r *http.Request)
+	UpdateCollaborator(w http.ResponseWriter, r *http.Request)
+	RemoveCollaborator(w http.ResponseWriter, r *http.Request)
 }
 
+// maxBodySize - limit of todo request bodies, a title and description fit with room to spare
+const maxBodySize = 64 << 10
+
 type HTTPHandlerImpl struct {
 	service todoservice.Service
 }
@@ -34,12 +44,36 @@ func New(service todoservice.Service) HTTPHandler {
 	}
 }
 
-func (h *HTTPHandlerImpl) RegisterRoutes(router *chi.Mux) {
-	router.Get("/todo", h.GetAll)
-	router.Get("/todo/{id}", h.GetByID)
-	router.Post("/todo", h.Create)
-	router.Put("/todo/{id}", h.Update)
-	router.Delete("/todo/{id}", h.Delete)
+// RegisterRoutes - todos belong to the caller, so every route requires a principal resolved by auth.Middleware
+// and API keys additionally need the scope of the route
+func (h *HTTPHandlerImpl) RegisterRoutes(router chi.Router) {
+	router.Group(func(router chi.Router) {
+		router.Use(auth.Require)
+
+		router.With(
+			auth.RequireScope(auth.ScopeTodoRead),
+			responseutil.Negotiate(responseutil.ListFormats...),
+		).Get("/todo", h.GetAll)
+
+		router.Group(func(r chi.Router) {
+			r.Use(responseutil.Negotiate(responseutil.Formats...))
+
+			r.With(auth.RequireScope(auth.ScopeTodoRead)).Get("/todo/{id}", h.GetByID)
+
+			r.Group(func(r chi.Router) {
+				r.Use(auth.RequireScope(auth.ScopeTodoWrite))
+				r.Use(requestutil.MaxBodySize(maxBodySize))
+
+				r.Post("/todo", h.Create)
+				r.Put("/todo/{id}", h.Update)
+				r.Delete("/todo/{id}", h.Delete)
+
+				r.Post("/todo/{id}/collaborators", h.AddCollaborator)
+				r.Put("/todo/{id}/collaborators/{userId}", h.UpdateCollaborator)
+				r.Delete("/todo/{id}/collaborators/{userId}", h.RemoveCollaborator)
+			})
+		})
+	})
 }
 
 // GetAll - get all todo http handler
@@ -67,8 +101,12 @@ func (h *HTTPHandlerImpl) GetAll(w http.ResponseWriter, r *http.Request) {
 	perPage := paginationutil.PerPage(perPageQuery)
 	offset := paginationutil.Offset(currentPage, perPage)
 
-	results, totalData, err := h.service.GetAll(qQuery, perPage, offset)
+	results, totalData, err := h.service.GetAll(r.Context(), auth.UserID(r), qQuery, perPage, offset)
 	if err != nil {
+		if errors.Is(err, errorsutil.ErrForbidden) {
+			responseutil.ResponseForbidden(w, r, "You are not allowed to list items")
+			return
+		}
 		responseutil.ResponseError(w, r, err)
 		return
 	}
@@ -91,8 +129,12 @@ func (h *HTTPHandlerImpl) GetByID(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 
 	// Get detail
-	result, err := h.service.GetByID(id)
+	result, err := h.service.GetByID(r.Context(), auth.UserID(r), id)
 	if err != nil {
+		if errors.Is(err, errorsutil.ErrForbidden) {
+			responseutil.ResponseForbidden(w, r, "You are not allowed to read this item")
+			return
+		}
 		if err.Error() == "not found" {
 			responseutil.ResponseNotFound(w, r, "Item not found")
 			return
@@ -111,21 +153,26 @@ func (h *HTTPHandlerImpl) GetByID(w http.ResponseWriter, r *http.Request) {
 // Create - create todo http handler
 func (h *HTTPHandlerImpl) Create(w http.ResponseWriter, r *http.Request) {
 	data := &models.TodoRequest{}
-	if err := render.Bind(r, data); err != nil {
-		if err.Error() == "EOF" {
-			responseutil.ResponseBodyError(w, r, err)
-			return
-		}
-
-		responseutil.ResponseErrorValidation(w, r, err)
+	if err := requestutil.Bind(r, data); err != nil {
+		responseutil.ResponseBindError(w, r, err)
 		return
 	}
 
-	result, err := h.service.Create(&models.Todo{
+	result, err := h.service.Create(r.Context(), &models.Todo{
 		Title:       data.Title,
 		Description: data.Description,
+		OwnerID:     auth.UserID(r),
 	})
 	if err != nil {
+		if errors.Is(err, errorsutil.ErrForbidden) {
+			responseutil.ResponseForbidden(w, r, "You are not allowed to create items")
+			return
+		}
+		exceeded := &quota.ExceededError{}
+		if errors.As(err, &exceeded) {
+			responseutil.ResponseQuotaExceeded(w, r, exceeded.Message(), exceeded.RetryAfter)
+			return
+		}
 		responseutil.ResponseError(w, r, err)
 		return
 	}
@@ -141,23 +188,27 @@ func (h *HTTPHandlerImpl) Update(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 
 	data := &models.TodoRequest{}
-	if err := render.Bind(r, data); err != nil {
-		if err.Error() == "EOF" {
-			responseutil.ResponseBodyError(w, r, err)
-			return
-		}
-
-		responseutil.ResponseErrorValidation(w, r, err)
+	if err := requestutil.Bind(r, data); err != nil {
+		responseutil.ResponseBindError(w, r, err)
 		return
 	}
 
 	// Edit data
-	_, err := h.service.Update(id, &models.Todo{
+	_, err := h.service.Update(r.Context(), auth.UserID(r), id, &models.Todo{
 		Title:       data.Title,
 		Description: data.Description,
 	})
 
 	if err != nil {
+		if errors.Is(err, errorsutil.ErrForbidden) {
+			responseutil.ResponseForbidden(w, r, "You are not allowed to update this item")
+			return
+		}
+		exceeded := &quota.ExceededError{}
+		if errors.As(err, &exceeded) {
+			responseutil.ResponseQuotaExceeded(w, r, exceeded.Message(), exceeded.RetryAfter)
+			return
+		}
 		if err.Error() == "not found" {
 			responseutil.ResponseNotFound(w, r, "Item not found")
 			return
@@ -180,8 +231,12 @@ func (h *HTTPHandlerImpl) Delete(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 
 	// Delete record
-	err := h.service.Delete(id)
+	err := h.service.Delete(r.Context(), auth.UserID(r), id)
 	if err != nil {
+		if errors.Is(err, errorsutil.ErrForbidden) {
+			responseutil.ResponseForbidden(w, r, "You are not allowed to delete this item")
+			return
+		}
 		if err.Error() == "not found" {
 			responseutil.ResponseNotFound(w, r, "Item not found")
 			return
@@ -197,3 +252,86 @@ func (h *HTTPHandlerImpl) Delete(w http.ResponseWriter, r *http.Request) {
 		},
 	})
 }
+
+// AddCollaborator - share todo by id http handler
+func (h *HTTPHandlerImpl) AddCollaborator(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	data := &models.CollaboratorRequest{}
+	if err := requestutil.Bind(r, data); err != nil {
+		responseutil.ResponseBindError(w, r, err)
+		return
+	}
+
+	collaborator := &models.Collaborator{
+		UserID:     data.UserID,
+		Permission: data.Permission,
+	}
+	err := h.service.AddCollaborator(r.Context(), auth.UserID(r), id, collaborator)
+	if err != nil {
+		h.responseCollaboratorError(w, r, err)
+		return
+	}
+
+	responseutil.ResponseCreated(w, r, &responseutil.ResponseSuccess{
+		Data: collaborator,
+	})
+}
+
+// UpdateCollaborator - change collaborator permission http handler
+func (h *HTTPHandlerImpl) UpdateCollaborator(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	data := &models.CollaboratorPermissionRequest{}
+	if err := requestutil.Bind(r, data); err != nil {
+		responseutil.ResponseBindError(w, r, err)
+		return
+	}
+
+	collaborator := &models.Collaborator{
+		UserID:     chi.URLParam(r, "userId"),
+		Permission: data.Permission,
+	}
+	err := h.service.UpdateCollaborator(r.Context(), auth.UserID(r), id, collaborator)
+	if err != nil {
+		h.responseCollaboratorError(w, r, err)
+		return
+	}
+
+	responseutil.ResponseOK(w, r, &responseutil.ResponseSuccess{
+		Data: collaborator,
+	})
+}
+
+// RemoveCollaborator - unshare todo http handler
+func (h *HTTPHandlerImpl) RemoveCollaborator(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	userID := chi.URLParam(r, "userId")
+
+	err := h.service.RemoveCollaborator(r.Context(), auth.UserID(r), id, userID)
+	if err != nil {
+		h.responseCollaboratorError(w, r, err)
+		return
+	}
+
+	responseutil.ResponseOK(w, r, &responseutil.ResponseSuccess{
+		Data: responseutil.H{
+			"id":      id,
+			"user_id": userID,
+		},
+	})
+}
+
+// responseCollaboratorError - send response for an error of a collaborator operation
+func (h *HTTPHandlerImpl) responseCollaboratorError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, errorsutil.ErrForbidden):
+		responseutil.ResponseForbidden(w, r, "You are not allowed to share this item")
+	case errors.Is(err, errorsutil.ErrNotFound):
+		responseutil.ResponseNotFound(w, r, "Item or collaborator not found")
+	case errors.Is(err, errorsutil.ErrAlreadyExists):
+		responseutil.ResponseConflict(w, r, "Item is already shared with this user")
+	default:
+		responseutil.ResponseError(w, r, err)
+	}
+}
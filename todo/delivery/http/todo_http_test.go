@@ -5,8 +5,12 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
 
+	"go-clean-architecture/pkg/auth"
+	"go-clean-architecture/pkg/quota"
 	pkgvalidator "go-clean-architecture/pkg/validator"
 	tododelivery "go-clean-architecture/todo/delivery/http"
 	errorsutil "go-clean-architecture/utils/errors"
@@ -18,6 +22,7 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/vmihailenco/msgpack/v5"
 )
 
 var WhenError400EOF string = "when return 400 bad request (error EOF)"
@@ -33,7 +38,7 @@ func TestNewTodoHTTPHandler(t *testing.T) {
 
 	mockService := new(mockservice.Service)
 
-	mockService.On("Create", mock.AnythingOfType("*models.Todo")).Return(&models.Todo{}, nil)
+	mockService.On("Create", mock.Anything, mock.AnythingOfType("*models.Todo")).Return(&models.Todo{}, nil)
 
 	handler := tododelivery.New(mockService)
 	handler.RegisterRoutes(chi.NewMux())
@@ -74,7 +79,7 @@ func TestTodoGetAll(t *testing.T) {
 
 		req.Header.Set("Content-Type", "application/json")
 
-		mockService.On("GetAll", mock.AnythingOfType("string"), mock.AnythingOfType("int"), mock.AnythingOfType("int")).Return(nil, 1, errorsutil.ErrDefault)
+		mockService.On("GetAll", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.AnythingOfType("int"), mock.AnythingOfType("int")).Return(nil, 1, errorsutil.ErrDefault)
 
 		todoHandler := tododelivery.New(mockService)
 
@@ -102,7 +107,7 @@ func TestTodoGetAll(t *testing.T) {
 
 		req.Header.Set("Content-Type", "application/json")
 
-		mockService.On("GetAll", mock.AnythingOfType("string"), mock.AnythingOfType("int"), mock.AnythingOfType("int")).Return(mockListTodo, 1, nil)
+		mockService.On("GetAll", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.AnythingOfType("int"), mock.AnythingOfType("int")).Return(mockListTodo, 1, nil)
 
 		todoHandler := tododelivery.New(mockService)
 
@@ -141,6 +146,39 @@ func TestTodoCreate(t *testing.T) {
 		// Check the status code is what expected
 		assert.Equal(t, http.StatusBadRequest, rr.Code)
 	})
+	t.Run("when return 400 bad request with the error code of the body error", func(t *testing.T) {
+		pkgvalidator.New()
+
+		cases := map[string]string{
+			"":                  "empty_body",
+			`{"title":}`:        "malformed_body",
+			`{"title":1}`:       "invalid_type",
+			`{"titel":"lorem"}`: "unknown_field",
+		}
+		for body, code := range cases {
+			mockService := new(mockservice.Service)
+
+			req, err := http.NewRequest(http.MethodPost, "/api/v1/todo", strings.NewReader(body))
+			assert.NoError(t, err)
+
+			req.Header.Set("Content-Type", "application/json")
+
+			todoHandler := tododelivery.New(mockService)
+
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(todoHandler.Create)
+
+			handler.ServeHTTP(rr, req)
+
+			// The envelope (default error format) tells the errors apart by error_code
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+			res := map[string]interface{}{}
+			assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &res))
+			assert.Equal(t, float64(http.StatusBadRequest), res["code"])
+			assert.Equal(t, code, res["error_code"], body)
+			mockService.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+		}
+	})
 	t.Run("when return 400 bad request (error validation) ", func(t *testing.T) {
 		pkgvalidator.New()
 
@@ -183,7 +221,7 @@ func TestTodoCreate(t *testing.T) {
 
 		req.Header.Set("Content-Type", "application/json")
 
-		mockService.On("Create", mock.AnythingOfType("*models.Todo")).Return(nil, errorsutil.ErrDefault)
+		mockService.On("Create", mock.Anything, mock.AnythingOfType("*models.Todo")).Return(nil, errorsutil.ErrDefault)
 
 		todoHandler := tododelivery.New(mockService)
 
@@ -198,6 +236,111 @@ func TestTodoCreate(t *testing.T) {
 		// Check if the mock called
 		mockService.AssertExpectations(t)
 	})
+	t.Run("when return 403 forbidden (todo quota exceeded)", func(t *testing.T) {
+		pkgvalidator.New()
+
+		mockService := new(mockservice.Service)
+
+		body, _ := json.Marshal(map[string]interface{}{
+			"title":       "lorem ipsum",
+			"description": "desc",
+		})
+
+		req, err := http.NewRequest(http.MethodPost, "/api/v1/todo", bytes.NewReader(body))
+		assert.NoError(t, err)
+
+		req.Header.Set("Content-Type", "application/json")
+
+		mockService.On("Create", mock.Anything, mock.AnythingOfType("*models.Todo")).Return(nil, &quota.ExceededError{Quota: quota.Todos, Limit: 10})
+
+		todoHandler := tododelivery.New(mockService)
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(todoHandler.Create)
+
+		handler.ServeHTTP(rr, req)
+
+		// Check the status code is what expected
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+		assert.Contains(t, rr.Body.String(), "limit of 10 items")
+	})
+	t.Run("when return 415 unsupported media type", func(t *testing.T) {
+		pkgvalidator.New()
+
+		mockService := new(mockservice.Service)
+
+		req, err := http.NewRequest(http.MethodPost, "/api/v1/todo", bytes.NewReader([]byte("<todo/>")))
+		assert.NoError(t, err)
+
+		req.Header.Set("Content-Type", "application/xml")
+
+		todoHandler := tododelivery.New(mockService)
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(todoHandler.Create)
+
+		handler.ServeHTTP(rr, req)
+
+		// Check the status code is what expected
+		assert.Equal(t, http.StatusUnsupportedMediaType, rr.Code)
+	})
+	t.Run("when return 201 created (form body)", func(t *testing.T) {
+		pkgvalidator.New()
+
+		mockService := new(mockservice.Service)
+
+		body := url.Values{"title": {"lorem ipsum"}, "description": {"desc"}}.Encode()
+
+		req, err := http.NewRequest(http.MethodPost, "/api/v1/todo", strings.NewReader(body))
+		assert.NoError(t, err)
+
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		mockService.On("Create", mock.Anything, &models.Todo{Title: "lorem ipsum", Description: "desc"}).Return(&models.Todo{}, nil)
+
+		todoHandler := tododelivery.New(mockService)
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(todoHandler.Create)
+
+		handler.ServeHTTP(rr, req)
+
+		// Check the status code is what expected
+		assert.Equal(t, http.StatusCreated, rr.Code)
+
+		// Check if the mock called
+		mockService.AssertExpectations(t)
+	})
+	t.Run("when return 201 created (msgpack body)", func(t *testing.T) {
+		pkgvalidator.New()
+
+		mockService := new(mockservice.Service)
+
+		body, _ := msgpack.Marshal(map[string]interface{}{
+			"title":       "lorem ipsum",
+			"description": "desc",
+		})
+
+		req, err := http.NewRequest(http.MethodPost, "/api/v1/todo", bytes.NewReader(body))
+		assert.NoError(t, err)
+
+		req.Header.Set("Content-Type", "application/msgpack")
+
+		mockService.On("Create", mock.Anything, &models.Todo{Title: "lorem ipsum", Description: "desc"}).Return(&models.Todo{}, nil)
+
+		todoHandler := tododelivery.New(mockService)
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(todoHandler.Create)
+
+		handler.ServeHTTP(rr, req)
+
+		// Check the status code is what expected
+		assert.Equal(t, http.StatusCreated, rr.Code)
+
+		// Check if the mock called
+		mockService.AssertExpectations(t)
+	})
 	t.Run("when return 201 created", func(t *testing.T) {
 		pkgvalidator.New()
 
@@ -214,7 +357,7 @@ func TestTodoCreate(t *testing.T) {
 
 		req.Header.Set("Content-Type", "application/json")
 
-		mockService.On("Create", mock.AnythingOfType("*models.Todo")).Return(&models.Todo{}, nil)
+		mockService.On("Create", mock.Anything, mock.AnythingOfType("*models.Todo")).Return(&models.Todo{}, nil)
 
 		todoHandler := tododelivery.New(mockService)
 
@@ -243,7 +386,7 @@ func TestTodoGetByID(t *testing.T) {
 
 		req.Header.Set("Content-Type", "application/json")
 
-		mockService.On("GetByID", mock.AnythingOfType("string")).Return(nil, errorsutil.ErrNotFound)
+		mockService.On("GetByID", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(nil, errorsutil.ErrNotFound)
 
 		todoHandler := tododelivery.New(mockService)
 
@@ -268,7 +411,7 @@ func TestTodoGetByID(t *testing.T) {
 
 		req.Header.Set("Content-Type", "application/json")
 
-		mockService.On("GetByID", mock.AnythingOfType("string")).Return(nil, errorsutil.ErrDefault)
+		mockService.On("GetByID", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(nil, errorsutil.ErrDefault)
 
 		todoHandler := tododelivery.New(mockService)
 
@@ -293,7 +436,7 @@ func TestTodoGetByID(t *testing.T) {
 
 		req.Header.Set("Content-Type", "application/json")
 
-		mockService.On("GetByID", mock.AnythingOfType("string")).Return(&models.Todo{}, nil)
+		mockService.On("GetByID", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(&models.Todo{}, nil)
 
 		todoHandler := tododelivery.New(mockService)
 
@@ -322,7 +465,7 @@ func TestTodoUpdate(t *testing.T) {
 
 		req.Header.Set("Content-Type", "application/json")
 
-		mockService.On("Update", mock.AnythingOfType("string"), mock.AnythingOfType("*models.Todo")).Return(&models.Todo{}, nil)
+		mockService.On("Update", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.AnythingOfType("*models.Todo")).Return(&models.Todo{}, nil)
 
 		todoHandler := tododelivery.New(mockService)
 
@@ -379,7 +522,7 @@ func TestTodoUpdate(t *testing.T) {
 
 		req.Header.Set("Content-Type", "application/json")
 
-		mockService.On("Update", mock.AnythingOfType("string"), mock.AnythingOfType("*models.Todo")).Return(nil, errorsutil.ErrNotFound)
+		mockService.On("Update", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.AnythingOfType("*models.Todo")).Return(nil, errorsutil.ErrNotFound)
 
 		todoHandler := tododelivery.New(mockService)
 
@@ -410,7 +553,7 @@ func TestTodoUpdate(t *testing.T) {
 
 		req.Header.Set("Content-Type", "application/json")
 
-		mockService.On("Update", mock.AnythingOfType("string"), mock.AnythingOfType("*models.Todo")).Return(nil, errorsutil.ErrDefault)
+		mockService.On("Update", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.AnythingOfType("*models.Todo")).Return(nil, errorsutil.ErrDefault)
 
 		todoHandler := tododelivery.New(mockService)
 
@@ -441,7 +584,7 @@ func TestTodoUpdate(t *testing.T) {
 
 		req.Header.Set("Content-Type", "application/json")
 
-		mockService.On("Update", mock.AnythingOfType("string"), mock.AnythingOfType("*models.Todo")).Return(&models.Todo{}, nil)
+		mockService.On("Update", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.AnythingOfType("*models.Todo")).Return(&models.Todo{}, nil)
 
 		todoHandler := tododelivery.New(mockService)
 
@@ -468,7 +611,7 @@ func TestTodoDelete(t *testing.T) {
 		assert.NoError(t, err)
 		req.Header.Set("Content-Type", "application/json")
 
-		mockService.On("Delete", mock.AnythingOfType("string")).Return(errorsutil.ErrNotFound)
+		mockService.On("Delete", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(errorsutil.ErrNotFound)
 
 		todoHandler := tododelivery.New(mockService)
 
@@ -487,7 +630,7 @@ func TestTodoDelete(t *testing.T) {
 		assert.NoError(t, err)
 		req.Header.Set("Content-Type", "application/json")
 
-		mockService.On("Delete", mock.AnythingOfType("string")).Return(errorsutil.ErrDefault)
+		mockService.On("Delete", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(errorsutil.ErrDefault)
 
 		todoHandler := tododelivery.New(mockService)
 
@@ -511,7 +654,7 @@ func TestTodoDelete(t *testing.T) {
 
 		req.Header.Set("Content-Type", "application/json")
 
-		mockService.On("Delete", mock.AnythingOfType("string")).Return(nil)
+		mockService.On("Delete", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(nil)
 
 		todoHandler := tododelivery.New(mockService)
 
@@ -527,3 +670,188 @@ func TestTodoDelete(t *testing.T) {
 		mockService.AssertExpectations(t)
 	})
 }
+
+// TestTodoOwnership - todos are created for and looked up as the caller
+func TestTodoOwnership(t *testing.T) {
+	t.Run("when return 401 unauthorized (anonymous)", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+
+		router := chi.NewMux()
+		tododelivery.New(mockService).RegisterRoutes(router)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/todo", nil))
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		mockService.AssertExpectations(t)
+	})
+	t.Run(WhenSuccess201Created, func(t *testing.T) {
+		pkgvalidator.New()
+
+		mockService := new(mockservice.Service)
+
+		req, err := http.NewRequest(http.MethodPost, "/api/v1/todo", strings.NewReader(`{"title":"lorem ipsum","description":"desc"}`))
+		assert.NoError(t, err)
+
+		req.Header.Set("Content-Type", "application/json")
+		req = req.WithContext(auth.NewContext(req.Context(), &auth.Principal{Subject: "owner-1"}))
+
+		mockService.On("Create", mock.Anything, &models.Todo{Title: "lorem ipsum", Description: "desc", OwnerID: "owner-1"}).Return(&models.Todo{}, nil)
+
+		rr := httptest.NewRecorder()
+		http.HandlerFunc(tododelivery.New(mockService).Create).ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		mockService.AssertExpectations(t)
+	})
+	t.Run("when return 404 not found (todo of another owner)", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+
+		router := chi.NewMux()
+		tododelivery.New(mockService).RegisterRoutes(router)
+
+		req := httptest.NewRequest(http.MethodGet, "/todo/1", nil)
+		req = req.WithContext(auth.NewContext(req.Context(), &auth.Principal{Subject: "owner-2"}))
+
+		mockService.On("GetByID", mock.Anything, "owner-2", "1").Return(nil, errorsutil.ErrNotFound)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		mockService.AssertExpectations(t)
+	})
+}
+
+// TestTodoScopes - API keys only reach the routes their scopes allow
+func TestTodoScopes(t *testing.T) {
+	readOnly := &auth.Principal{Subject: "owner-1", KeyID: "key-1", Scopes: []string{auth.ScopeTodoRead}}
+
+	t.Run("when return 403 forbidden (key without todo:write)", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+
+		router := chi.NewMux()
+		tododelivery.New(mockService).RegisterRoutes(router)
+
+		req := httptest.NewRequest(http.MethodDelete, "/todo/1", nil)
+		req = req.WithContext(auth.NewContext(req.Context(), readOnly))
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+		mockService.AssertExpectations(t)
+	})
+	t.Run(WhenSuccess200OK, func(t *testing.T) {
+		mockService := new(mockservice.Service)
+
+		router := chi.NewMux()
+		tododelivery.New(mockService).RegisterRoutes(router)
+
+		req := httptest.NewRequest(http.MethodGet, "/todo/1", nil)
+		req = req.WithContext(auth.NewContext(req.Context(), readOnly))
+
+		mockService.On("GetByID", mock.Anything, "owner-1", "1").Return(&models.Todo{}, nil)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		mockService.AssertExpectations(t)
+	})
+}
+
+// TestTodoForbidden - operations denied by the policy answer 403
+func TestTodoForbidden(t *testing.T) {
+	t.Run("when return 403 forbidden", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+
+		router := chi.NewMux()
+		tododelivery.New(mockService).RegisterRoutes(router)
+
+		req := httptest.NewRequest(http.MethodDelete, "/todo/1", nil)
+		req = req.WithContext(auth.NewContext(req.Context(), &auth.Principal{Subject: "owner-1", Roles: []string{"viewer"}}))
+
+		mockService.On("Delete", mock.Anything, "owner-1", "1").Return(errorsutil.ErrForbidden)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+		mockService.AssertExpectations(t)
+	})
+}
+
+// TestTodoCollaborators - invite, change and remove collaborators
+func TestTodoCollaborators(t *testing.T) {
+	serve := func(mockService *mockservice.Service, method string, target string, body string) *httptest.ResponseRecorder {
+		router := chi.NewMux()
+		tododelivery.New(mockService).RegisterRoutes(router)
+
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req = req.WithContext(auth.NewContext(req.Context(), &auth.Principal{Subject: "owner-1"}))
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("when return 400 bad request (unknown permission)", func(t *testing.T) {
+		pkgvalidator.New()
+		mockService := new(mockservice.Service)
+
+		rr := serve(mockService, http.MethodPost, "/todo/1/collaborators", `{"user_id":"user-2","permission":"admin"}`)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockService.AssertExpectations(t)
+	})
+	t.Run(WhenSuccess201Created, func(t *testing.T) {
+		pkgvalidator.New()
+		mockService := new(mockservice.Service)
+		mockService.On("AddCollaborator", mock.Anything, "owner-1", "1", &models.Collaborator{UserID: "user-2", Permission: "edit"}).Return(nil)
+
+		rr := serve(mockService, http.MethodPost, "/todo/1/collaborators", `{"user_id":"user-2","permission":"edit"}`)
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		mockService.AssertExpectations(t)
+	})
+	t.Run("when return 409 conflict (already shared)", func(t *testing.T) {
+		pkgvalidator.New()
+		mockService := new(mockservice.Service)
+		mockService.On("AddCollaborator", mock.Anything, "owner-1", "1", mock.AnythingOfType("*models.Collaborator")).Return(errorsutil.ErrAlreadyExists)
+
+		rr := serve(mockService, http.MethodPost, "/todo/1/collaborators", `{"user_id":"user-2","permission":"edit"}`)
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+		mockService.AssertExpectations(t)
+	})
+	t.Run(WhenSuccess200OK, func(t *testing.T) {
+		pkgvalidator.New()
+		mockService := new(mockservice.Service)
+		mockService.On("UpdateCollaborator", mock.Anything, "owner-1", "1", &models.Collaborator{UserID: "user-2", Permission: "view"}).Return(nil)
+
+		rr := serve(mockService, http.MethodPut, "/todo/1/collaborators/user-2", `{"permission":"view"}`)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		mockService.AssertExpectations(t)
+	})
+	t.Run("when return 403 forbidden (not an owner)", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+		mockService.On("RemoveCollaborator", mock.Anything, "owner-1", "1", "user-2").Return(errorsutil.ErrForbidden)
+
+		rr := serve(mockService, http.MethodDelete, "/todo/1/collaborators/user-2", "")
+
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+		mockService.AssertExpectations(t)
+	})
+	t.Run("when return 404 not found (not a collaborator)", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+		mockService.On("RemoveCollaborator", mock.Anything, "owner-1", "1", "user-2").Return(errorsutil.ErrNotFound)
+
+		rr := serve(mockService, http.MethodDelete, "/todo/1/collaborators/user-2", "")
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		mockService.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,305 @@
+package streamdelivery
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"golang.org/x/net/websocket"
+
+	"go-clean-architecture/pkg/auth"
+	"go-clean-architecture/pkg/config"
+	"go-clean-architecture/pkg/logger"
+	"go-clean-architecture/todo/models"
+	todoservice "go-clean-architecture/todo/service"
+	errorsutil "go-clean-architecture/utils/errors"
+	responseutil "go-clean-architecture/utils/response"
+)
+
+type StreamHandler interface {
+	RegisterRoutes(router chi.Router)
+	Events(w http.ResponseWriter, r *http.Request)
+	WebSocket(w http.ResponseWriter, r *http.Request)
+	// Shutdown - end every stream and refuse new ones, clients resume on another instance with their last event id
+	Shutdown()
+}
+
+// Config - live change feed configuration
+type Config struct {
+	// Heartbeat - interval of the comments (SSE) and pings (WebSocket) keeping idle connections open
+	Heartbeat time.Duration
+	// Retry - reconnection delay suggested to SSE clients, also the Retry-After of rejected connections
+	Retry time.Duration
+	// WriteTimeout - a client not reading an event or heartbeat for this long is disconnected
+	WriteTimeout time.Duration
+	// MaxConnections - open streams of this instance, 0 is unlimited
+	MaxConnections int
+}
+
+// LoadConfig - read live change feed configuration from environment variables
+func LoadConfig() *Config {
+	return &Config{
+		Heartbeat:      config.GetDuration("EVENTS_HEARTBEAT", 15*time.Second),
+		Retry:          config.GetDuration("EVENTS_RETRY", 3*time.Second),
+		WriteTimeout:   config.GetDuration("EVENTS_WRITE_TIMEOUT", 10*time.Second),
+		MaxConnections: config.GetInt("EVENTS_MAX_CONNECTIONS", 1000),
+	}
+}
+
+type StreamHandlerImpl struct {
+	service     todoservice.Service
+	cfg         Config
+	connections int64
+	done        chan struct{}
+	closeOnce   sync.Once
+	log         logger.Logger
+}
+
+// New - make stream handler
+func New(service todoservice.Service, cfg *Config) StreamHandler {
+	h := &StreamHandlerImpl{
+		service: service,
+		cfg:     *cfg,
+		done:    make(chan struct{}),
+		log:     logger.Named("todo/delivery/stream"),
+	}
+	if h.cfg.Heartbeat <= 0 {
+		h.cfg.Heartbeat = 15 * time.Second
+	}
+	if h.cfg.WriteTimeout <= 0 {
+		h.cfg.WriteTimeout = 10 * time.Second
+	}
+
+	return h
+}
+
+// RegisterRoutes - the feed only carries todos the caller can see, API keys need todo:read
+func (h *StreamHandlerImpl) RegisterRoutes(router chi.Router) {
+	router.Group(func(r chi.Router) {
+		r.Use(auth.Require)
+		r.Use(auth.RequireScope(auth.ScopeTodoRead))
+
+		r.Get("/todo/events", h.Events)
+		r.Get("/todo/events/ws", h.WebSocket)
+	})
+}
+
+// Events - todo events as Server-Sent Events. A client that falls behind is disconnected and resumes with
+// Last-Event-ID, which replays the events it missed
+func (h *StreamHandlerImpl) Events(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		responseutil.ResponseError(w, r, errors.New("response writer cannot flush"))
+		return
+	}
+
+	events, ok := h.watch(w, r)
+	if !ok {
+		return
+	}
+	defer h.release()
+
+	header := w.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	// Proxies like nginx would otherwise buffer the stream
+	header.Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	write := func(message string) bool {
+		setWriteDeadline(w, time.Now().Add(h.cfg.WriteTimeout))
+		if _, err := fmt.Fprint(w, message); err != nil {
+			return false
+		}
+		flusher.Flush()
+		return true
+	}
+
+	if !write(fmt.Sprintf("retry: %d\n\n", h.cfg.Retry.Milliseconds())) {
+		return
+	}
+
+	heartbeat := time.NewTicker(h.cfg.Heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case event, open := <-events:
+			if !open {
+				return
+			}
+			data, err := json.Marshal(event)
+			if err != nil {
+				h.log.WithContext(r.Context()).Error("encoding event failed", "error", err)
+				return
+			}
+			if !write(fmt.Sprintf("id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)) {
+				return
+			}
+		case <-heartbeat.C:
+			if !write(": heartbeat\n\n") {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		case <-h.done:
+			return
+		}
+	}
+}
+
+// WebSocket - todo events as JSON text messages of a WebSocket, resumed after the id of the Last-Event-ID
+// header or the last_event_id query parameter. Messages of the client are ignored
+func (h *StreamHandlerImpl) WebSocket(w http.ResponseWriter, r *http.Request) {
+	if _, ok := w.(http.Hijacker); !ok {
+		responseutil.ResponseBadRequest(w, r, "WebSocket needs an HTTP/1.1 connection")
+		return
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	events, ok := h.watch(w, r.WithContext(ctx))
+	if !ok {
+		return
+	}
+	defer h.release()
+
+	server := websocket.Server{
+		// Callers authenticate with headers rather than cookies, pages of other origins gain nothing
+		Handshake: func(*websocket.Config, *http.Request) error { return nil },
+		Handler: func(conn *websocket.Conn) {
+			h.serveWebSocket(ctx, cancel, conn, events)
+		},
+	}
+	server.ServeHTTP(w, r)
+}
+
+// serveWebSocket - send events to conn until ctx is done, events is closed or the client goes away
+func (h *StreamHandlerImpl) serveWebSocket(ctx context.Context, cancel context.CancelFunc, conn *websocket.Conn, events <-chan *models.Event) {
+	defer conn.Close()
+
+	// The deadlines of the server timeouts stay on the hijacked connection
+	conn.SetReadDeadline(time.Time{}) //nolint:errcheck
+	go func() {
+		// Reading answers pings and notices the client closing the connection
+		defer cancel()
+		buf := make([]byte, 512)
+		for {
+			if _, err := conn.Read(buf); err != nil {
+				return
+			}
+		}
+	}()
+
+	heartbeat := time.NewTicker(h.cfg.Heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case event, open := <-events:
+			if !open {
+				return
+			}
+			conn.SetWriteDeadline(time.Now().Add(h.cfg.WriteTimeout)) //nolint:errcheck
+			if err := websocket.JSON.Send(conn, event); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			conn.SetWriteDeadline(time.Now().Add(h.cfg.WriteTimeout)) //nolint:errcheck
+			conn.PayloadType = websocket.PingFrame
+			_, err := conn.Write(nil)
+			conn.PayloadType = websocket.TextFrame
+			if err != nil {
+				return
+			}
+		case <-ctx.Done():
+			return
+		case <-h.done:
+			return
+		}
+	}
+}
+
+// watch - events of the caller of r after the last event it received, or false once the rejection is sent.
+// The connection slot taken must be given back with release
+func (h *StreamHandlerImpl) watch(w http.ResponseWriter, r *http.Request) (<-chan *models.Event, bool) {
+	if h.closed() || !h.acquire() {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Max(1, math.Ceil(h.cfg.Retry.Seconds())))))
+		responseutil.ResponseOverloaded(w, r)
+		return nil, false
+	}
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+
+	events, err := h.service.Watch(r.Context(), auth.UserID(r), lastEventID)
+	if err != nil {
+		h.release()
+		if errors.Is(err, errorsutil.ErrForbidden) {
+			responseutil.ResponseForbidden(w, r, "You are not allowed to list items")
+			return nil, false
+		}
+		responseutil.ResponseError(w, r, err)
+		return nil, false
+	}
+
+	return events, true
+}
+
+// Shutdown - shutdown streams
+func (h *StreamHandlerImpl) Shutdown() {
+	h.closeOnce.Do(func() {
+		close(h.done)
+	})
+}
+
+func (h *StreamHandlerImpl) closed() bool {
+	select {
+	case <-h.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// acquire - take a connection slot, false when every slot is taken
+func (h *StreamHandlerImpl) acquire() bool {
+	connections := atomic.AddInt64(&h.connections, 1)
+	if h.cfg.MaxConnections > 0 && connections > int64(h.cfg.MaxConnections) {
+		atomic.AddInt64(&h.connections, -1)
+		return false
+	}
+
+	return true
+}
+
+func (h *StreamHandlerImpl) release() {
+	atomic.AddInt64(&h.connections, -1)
+}
+
+// setWriteDeadline - move the write deadline of the connection of w, the write timeout of the server would
+// otherwise end every stream. Writers that cannot set it end their stream there and the client reconnects
+func setWriteDeadline(w http.ResponseWriter, deadline time.Time) {
+	for {
+		switch rw := w.(type) {
+		case interface{ SetWriteDeadline(time.Time) error }:
+			rw.SetWriteDeadline(deadline) //nolint:errcheck
+			return
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = rw.Unwrap()
+		default:
+			return
+		}
+	}
+}
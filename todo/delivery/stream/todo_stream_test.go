@@ -0,0 +1,257 @@
+package streamdelivery_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/net/websocket"
+
+	"go-clean-architecture/pkg/auth"
+	tododelivery "go-clean-architecture/todo/delivery/stream"
+	mockservice "go-clean-architecture/todo/mocks/service"
+	"go-clean-architecture/todo/models"
+	errorsutil "go-clean-architecture/utils/errors"
+)
+
+// newServer - started server of the stream handler
+func newServer(t *testing.T, service *mockservice.Service, cfg *tododelivery.Config) (*httptest.Server, tododelivery.StreamHandler) {
+	server, handler := newUnstartedServer(t, service, cfg)
+	server.Start()
+
+	return server, handler
+}
+
+// newUnstartedServer - server of the stream handler, requests carrying a "user" header are made by that user
+func newUnstartedServer(t *testing.T, service *mockservice.Service, cfg *tododelivery.Config) (*httptest.Server, tododelivery.StreamHandler) {
+	handler := tododelivery.New(service, cfg)
+
+	router := chi.NewRouter()
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if user := r.Header.Get("user"); user != "" {
+				r = r.WithContext(auth.NewContext(r.Context(), &auth.Principal{Subject: user}))
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+	handler.RegisterRoutes(router)
+
+	server := httptest.NewUnstartedServer(router)
+	t.Cleanup(server.Close)
+
+	return server, handler
+}
+
+func subscribe(t *testing.T, server *httptest.Server, lastEventID string) *http.Response {
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/todo/events", nil)
+	req.Header.Set("user", "owner-1")
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	t.Cleanup(func() { res.Body.Close() })
+
+	return res
+}
+
+// readEvent - lines of the next event or comment of an event stream
+func readEvent(t *testing.T, reader *bufio.Reader) []string {
+	lines := []string{}
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			assert.NoError(t, err)
+			return lines
+		}
+		if line == "\n" {
+			return lines
+		}
+		lines = append(lines, strings.TrimSuffix(line, "\n"))
+	}
+}
+
+func TestTodoStreamEvents(t *testing.T) {
+	t.Run("when events are streamed after the last event received", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+		server, _ := newServer(t, mockService, &tododelivery.Config{Retry: 2 * time.Second})
+
+		events := make(chan *models.Event, 2)
+		events <- &models.Event{ID: "a-6", Type: models.EventCreated, Todo: &models.Todo{Title: "Buy milk"}}
+		events <- &models.Event{ID: "a-7", Type: models.EventReset}
+		close(events)
+		mockService.On("Watch", mock.Anything, "owner-1", "a-5").Return((<-chan *models.Event)(events), nil)
+
+		res := subscribe(t, server, "a-5")
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+		assert.Equal(t, "no-cache", res.Header.Get("Cache-Control"))
+
+		reader := bufio.NewReader(res.Body)
+		assert.Equal(t, []string{"retry: 2000"}, readEvent(t, reader))
+
+		created := readEvent(t, reader)
+		assert.Equal(t, []string{"id: a-6", "event: todo.created"}, created[:2])
+		data := &models.Event{}
+		assert.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(created[2], "data: ")), data))
+		assert.Equal(t, "Buy milk", data.Todo.Title)
+
+		assert.Equal(t, []string{"id: a-7", "event: todo.reset"}, readEvent(t, reader)[:2])
+
+		// The service closed the channel, the client fell behind and reconnects
+		_, err := reader.ReadString('\n')
+		assert.Error(t, err)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("when the stream is idle", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+		server, _ := newServer(t, mockService, &tododelivery.Config{Heartbeat: 10 * time.Millisecond})
+
+		mockService.On("Watch", mock.Anything, "owner-1", "").Return((<-chan *models.Event)(make(chan *models.Event)), nil)
+
+		reader := bufio.NewReader(subscribe(t, server, "").Body)
+		readEvent(t, reader)
+
+		assert.Equal(t, []string{": heartbeat"}, readEvent(t, reader))
+	})
+
+	t.Run("when the stream outlives the write timeout of the server", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+		server, _ := newUnstartedServer(t, mockService, &tododelivery.Config{Heartbeat: 20 * time.Millisecond})
+		server.Config.WriteTimeout = 50 * time.Millisecond
+		server.Start()
+
+		mockService.On("Watch", mock.Anything, "owner-1", "").Return((<-chan *models.Event)(make(chan *models.Event)), nil)
+
+		reader := bufio.NewReader(subscribe(t, server, "").Body)
+		readEvent(t, reader)
+		for i := 0; i < 10; i++ {
+			assert.Equal(t, []string{": heartbeat"}, readEvent(t, reader))
+		}
+	})
+
+	t.Run("when listing is forbidden", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+		server, _ := newServer(t, mockService, &tododelivery.Config{})
+
+		mockService.On("Watch", mock.Anything, "owner-1", "").Return((<-chan *models.Event)(nil), errorsutil.ErrForbidden)
+
+		assert.Equal(t, http.StatusForbidden, subscribe(t, server, "").StatusCode)
+	})
+
+	t.Run("when the caller is anonymous", func(t *testing.T) {
+		server, _ := newServer(t, new(mockservice.Service), &tododelivery.Config{})
+
+		res, err := http.Get(server.URL + "/todo/events")
+		assert.NoError(t, err)
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	})
+
+	t.Run("when every connection is taken", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+		server, _ := newServer(t, mockService, &tododelivery.Config{MaxConnections: 1, Retry: 3 * time.Second})
+
+		mockService.On("Watch", mock.Anything, "owner-1", "").Return((<-chan *models.Event)(make(chan *models.Event)), nil)
+
+		first := subscribe(t, server, "")
+		assert.Equal(t, http.StatusOK, first.StatusCode)
+
+		second := subscribe(t, server, "")
+		assert.Equal(t, http.StatusServiceUnavailable, second.StatusCode)
+		assert.Equal(t, "3", second.Header.Get("Retry-After"))
+	})
+
+	t.Run("when the server shuts down", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+		server, handler := newServer(t, mockService, &tododelivery.Config{})
+
+		mockService.On("Watch", mock.Anything, "owner-1", "").Return((<-chan *models.Event)(make(chan *models.Event)), nil)
+
+		reader := bufio.NewReader(subscribe(t, server, "").Body)
+		readEvent(t, reader)
+		handler.Shutdown()
+
+		_, err := reader.ReadString('\n')
+		assert.Error(t, err)
+		assert.Equal(t, http.StatusServiceUnavailable, subscribe(t, server, "").StatusCode)
+	})
+}
+
+func TestTodoStreamWebSocket(t *testing.T) {
+	dial := func(t *testing.T, server *httptest.Server, query string) *websocket.Conn {
+		cfg, err := websocket.NewConfig("ws"+strings.TrimPrefix(server.URL, "http")+"/todo/events/ws"+query, server.URL)
+		assert.NoError(t, err)
+		cfg.Header.Set("user", "owner-1")
+
+		conn, err := websocket.DialConfig(cfg)
+		assert.NoError(t, err)
+		t.Cleanup(func() { conn.Close() })
+
+		return conn
+	}
+
+	t.Run("when events are sent after the last event received", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+		server, _ := newServer(t, mockService, &tododelivery.Config{Heartbeat: 10 * time.Millisecond})
+
+		events := make(chan *models.Event, 1)
+		events <- &models.Event{ID: "a-6", Type: models.EventUpdated, Todo: &models.Todo{Title: "Buy milk"}}
+		mockService.On("Watch", mock.Anything, "owner-1", "a-5").Return((<-chan *models.Event)(events), nil)
+
+		conn := dial(t, server, "?last_event_id=a-5")
+
+		event := &models.Event{}
+		assert.NoError(t, websocket.JSON.Receive(conn, event))
+		assert.Equal(t, "a-6", event.ID)
+		assert.Equal(t, models.EventUpdated, event.Type)
+		assert.Equal(t, "Buy milk", event.Todo.Title)
+
+		// Heartbeat pings are answered by the client and never surface as messages
+		time.Sleep(50 * time.Millisecond)
+		events <- &models.Event{ID: "a-7", Type: models.EventDeleted, Todo: &models.Todo{}}
+		assert.NoError(t, websocket.JSON.Receive(conn, event))
+		assert.Equal(t, "a-7", event.ID)
+	})
+
+	t.Run("when the events end", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+		server, _ := newServer(t, mockService, &tododelivery.Config{})
+
+		events := make(chan *models.Event)
+		close(events)
+		mockService.On("Watch", mock.Anything, "owner-1", "").Return((<-chan *models.Event)(events), nil)
+
+		conn := dial(t, server, "")
+
+		var message string
+		assert.Error(t, websocket.Message.Receive(conn, &message))
+	})
+
+	t.Run("when the request is not an upgrade", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+		server, _ := newServer(t, mockService, &tododelivery.Config{})
+
+		mockService.On("Watch", mock.Anything, "owner-1", "").Return((<-chan *models.Event)(make(chan *models.Event)), nil)
+
+		req, _ := http.NewRequest(http.MethodGet, server.URL+"/todo/events/ws", nil)
+		req.Header.Set("user", "owner-1")
+		res, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	})
+}
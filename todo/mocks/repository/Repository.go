@@ -3,6 +3,8 @@
 package mocks
 
 import (
+	context "context"
+
 	models "go-clean-architecture/todo/models"
 
 	mock "github.com/stretchr/testify/mock"
@@ -13,20 +15,76 @@ type Repository struct {
 	mock.Mock
 }
 
-// CountFindAll provides a mock function with given fields: keyword
-func (_m *Repository) CountFindAll(keyword string) (int, error) {
-	ret := _m.Called(keyword)
+// AddCollaborator provides a mock function with given fields: ctx, userID, id, value
+func (_m *Repository) AddCollaborator(ctx context.Context, userID string, id string, value *models.Collaborator) error {
+	ret := _m.Called(ctx, userID, id, value)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, *models.Collaborator) error); ok {
+		r0 = rf(ctx, userID, id, value)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Changes provides a mock function with given fields: ctx
+func (_m *Repository) Changes(ctx context.Context) (<-chan *models.Event, error) {
+	ret := _m.Called(ctx)
+
+	var r0 <-chan *models.Event
+	if rf, ok := ret.Get(0).(func(context.Context) <-chan *models.Event); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(<-chan *models.Event)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CountFindAll provides a mock function with given fields: ctx, userID, keyword
+func (_m *Repository) CountFindAll(ctx context.Context, userID string, keyword string) (int, error) {
+	ret := _m.Called(ctx, userID, keyword)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) int); ok {
+		r0 = rf(ctx, userID, keyword)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, userID, keyword)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CountFindByID provides a mock function with given fields: ctx, userID, id
+func (_m *Repository) CountFindByID(ctx context.Context, userID string, id string) (int, error) {
+	ret := _m.Called(ctx, userID, id)
 
 	var r0 int
-	if rf, ok := ret.Get(0).(func(string) int); ok {
-		r0 = rf(keyword)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) int); ok {
+		r0 = rf(ctx, userID, id)
 	} else {
 		r0 = ret.Get(0).(int)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(keyword)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, userID, id)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -34,20 +92,20 @@ func (_m *Repository) CountFindAll(keyword string) (int, error) {
 	return r0, r1
 }
 
-// CountFindByID provides a mock function with given fields: id
-func (_m *Repository) CountFindByID(id string) (int, error) {
-	ret := _m.Called(id)
+// CountOwned provides a mock function with given fields: ctx, ownerID
+func (_m *Repository) CountOwned(ctx context.Context, ownerID string) (int, error) {
+	ret := _m.Called(ctx, ownerID)
 
 	var r0 int
-	if rf, ok := ret.Get(0).(func(string) int); ok {
-		r0 = rf(id)
+	if rf, ok := ret.Get(0).(func(context.Context, string) int); ok {
+		r0 = rf(ctx, ownerID)
 	} else {
 		r0 = ret.Get(0).(int)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(id)
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, ownerID)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -55,13 +113,13 @@ func (_m *Repository) CountFindByID(id string) (int, error) {
 	return r0, r1
 }
 
-// Delete provides a mock function with given fields: id
-func (_m *Repository) Delete(id string) error {
-	ret := _m.Called(id)
+// Delete provides a mock function with given fields: ctx, userID, id
+func (_m *Repository) Delete(ctx context.Context, userID string, id string) error {
+	ret := _m.Called(ctx, userID, id)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string) error); ok {
-		r0 = rf(id)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, userID, id)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -69,13 +127,27 @@ func (_m *Repository) Delete(id string) error {
 	return r0
 }
 
-// FindAll provides a mock function with given fields: keyword, limit, offset
-func (_m *Repository) FindAll(keyword string, limit int, offset int) ([]*models.Todo, error) {
-	ret := _m.Called(keyword, limit, offset)
+// DeleteAny provides a mock function with given fields: ctx, id
+func (_m *Repository) DeleteAny(ctx context.Context, id string) error {
+	ret := _m.Called(ctx, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// FindAll provides a mock function with given fields: ctx, userID, keyword, limit, offset
+func (_m *Repository) FindAll(ctx context.Context, userID string, keyword string, limit int, offset int) ([]*models.Todo, error) {
+	ret := _m.Called(ctx, userID, keyword, limit, offset)
 
 	var r0 []*models.Todo
-	if rf, ok := ret.Get(0).(func(string, int, int) []*models.Todo); ok {
-		r0 = rf(keyword, limit, offset)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int, int) []*models.Todo); ok {
+		r0 = rf(ctx, userID, keyword, limit, offset)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]*models.Todo)
@@ -83,8 +155,8 @@ func (_m *Repository) FindAll(keyword string, limit int, offset int) ([]*models.
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string, int, int) error); ok {
-		r1 = rf(keyword, limit, offset)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, int, int) error); ok {
+		r1 = rf(ctx, userID, keyword, limit, offset)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -92,13 +164,13 @@ func (_m *Repository) FindAll(keyword string, limit int, offset int) ([]*models.
 	return r0, r1
 }
 
-// FindById provides a mock function with given fields: id
-func (_m *Repository) FindById(id string) (*models.Todo, error) {
-	ret := _m.Called(id)
+// FindAnyById provides a mock function with given fields: ctx, id
+func (_m *Repository) FindAnyById(ctx context.Context, id string) (*models.Todo, error) {
+	ret := _m.Called(ctx, id)
 
 	var r0 *models.Todo
-	if rf, ok := ret.Get(0).(func(string) *models.Todo); ok {
-		r0 = rf(id)
+	if rf, ok := ret.Get(0).(func(context.Context, string) *models.Todo); ok {
+		r0 = rf(ctx, id)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*models.Todo)
@@ -106,8 +178,8 @@ func (_m *Repository) FindById(id string) (*models.Todo, error) {
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(id)
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -115,13 +187,13 @@ func (_m *Repository) FindById(id string) (*models.Todo, error) {
 	return r0, r1
 }
 
-// Store provides a mock function with given fields: value
-func (_m *Repository) Store(value *models.Todo) (*models.Todo, error) {
-	ret := _m.Called(value)
+// FindById provides a mock function with given fields: ctx, userID, id
+func (_m *Repository) FindById(ctx context.Context, userID string, id string) (*models.Todo, error) {
+	ret := _m.Called(ctx, userID, id)
 
 	var r0 *models.Todo
-	if rf, ok := ret.Get(0).(func(*models.Todo) *models.Todo); ok {
-		r0 = rf(value)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *models.Todo); ok {
+		r0 = rf(ctx, userID, id)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*models.Todo)
@@ -129,8 +201,8 @@ func (_m *Repository) Store(value *models.Todo) (*models.Todo, error) {
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(*models.Todo) error); ok {
-		r1 = rf(value)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, userID, id)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -138,13 +210,27 @@ func (_m *Repository) Store(value *models.Todo) (*models.Todo, error) {
 	return r0, r1
 }
 
-// Update provides a mock function with given fields: id, value
-func (_m *Repository) Update(id string, value *models.Todo) (*models.Todo, error) {
-	ret := _m.Called(id, value)
+// RemoveCollaborator provides a mock function with given fields: ctx, userID, id, collaboratorID
+func (_m *Repository) RemoveCollaborator(ctx context.Context, userID string, id string, collaboratorID string) error {
+	ret := _m.Called(ctx, userID, id, collaboratorID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) error); ok {
+		r0 = rf(ctx, userID, id, collaboratorID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Store provides a mock function with given fields: ctx, value
+func (_m *Repository) Store(ctx context.Context, value *models.Todo) (*models.Todo, error) {
+	ret := _m.Called(ctx, value)
 
 	var r0 *models.Todo
-	if rf, ok := ret.Get(0).(func(string, *models.Todo) *models.Todo); ok {
-		r0 = rf(id, value)
+	if rf, ok := ret.Get(0).(func(context.Context, *models.Todo) *models.Todo); ok {
+		r0 = rf(ctx, value)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*models.Todo)
@@ -152,11 +238,48 @@ func (_m *Repository) Update(id string, value *models.Todo) (*models.Todo, error
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string, *models.Todo) error); ok {
-		r1 = rf(id, value)
+	if rf, ok := ret.Get(1).(func(context.Context, *models.Todo) error); ok {
+		r1 = rf(ctx, value)
 	} else {
 		r1 = ret.Error(1)
 	}
 
 	return r0, r1
 }
+
+// Update provides a mock function with given fields: ctx, userID, id, value
+func (_m *Repository) Update(ctx context.Context, userID string, id string, value *models.Todo) (*models.Todo, error) {
+	ret := _m.Called(ctx, userID, id, value)
+
+	var r0 *models.Todo
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, *models.Todo) *models.Todo); ok {
+		r0 = rf(ctx, userID, id, value)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Todo)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, *models.Todo) error); ok {
+		r1 = rf(ctx, userID, id, value)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateCollaborator provides a mock function with given fields: ctx, userID, id, value
+func (_m *Repository) UpdateCollaborator(ctx context.Context, userID string, id string, value *models.Collaborator) error {
+	ret := _m.Called(ctx, userID, id, value)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, *models.Collaborator) error); ok {
+		r0 = rf(ctx, userID, id, value)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
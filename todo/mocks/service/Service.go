@@ -3,6 +3,8 @@
 package mocks
 
 import (
+	context "context"
+
 	models "go-clean-architecture/todo/models"
 
 	mock "github.com/stretchr/testify/mock"
@@ -13,13 +15,27 @@ type Service struct {
 	mock.Mock
 }
 
-// Create provides a mock function with given fields: value
-func (_m *Service) Create(value *models.Todo) (*models.Todo, error) {
-	ret := _m.Called(value)
+// AddCollaborator provides a mock function with given fields: ctx, userID, id, value
+func (_m *Service) AddCollaborator(ctx context.Context, userID string, id string, value *models.Collaborator) error {
+	ret := _m.Called(ctx, userID, id, value)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, *models.Collaborator) error); ok {
+		r0 = rf(ctx, userID, id, value)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Create provides a mock function with given fields: ctx, value
+func (_m *Service) Create(ctx context.Context, value *models.Todo) (*models.Todo, error) {
+	ret := _m.Called(ctx, value)
 
 	var r0 *models.Todo
-	if rf, ok := ret.Get(0).(func(*models.Todo) *models.Todo); ok {
-		r0 = rf(value)
+	if rf, ok := ret.Get(0).(func(context.Context, *models.Todo) *models.Todo); ok {
+		r0 = rf(ctx, value)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*models.Todo)
@@ -27,8 +43,8 @@ func (_m *Service) Create(value *models.Todo) (*models.Todo, error) {
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(*models.Todo) error); ok {
-		r1 = rf(value)
+	if rf, ok := ret.Get(1).(func(context.Context, *models.Todo) error); ok {
+		r1 = rf(ctx, value)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -36,13 +52,13 @@ func (_m *Service) Create(value *models.Todo) (*models.Todo, error) {
 	return r0, r1
 }
 
-// Delete provides a mock function with given fields: id
-func (_m *Service) Delete(id string) error {
-	ret := _m.Called(id)
+// Delete provides a mock function with given fields: ctx, userID, id
+func (_m *Service) Delete(ctx context.Context, userID string, id string) error {
+	ret := _m.Called(ctx, userID, id)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string) error); ok {
-		r0 = rf(id)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, userID, id)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -50,13 +66,13 @@ func (_m *Service) Delete(id string) error {
 	return r0
 }
 
-// GetAll provides a mock function with given fields: keyword, limit, offset
-func (_m *Service) GetAll(keyword string, limit int, offset int) ([]*models.Todo, int, error) {
-	ret := _m.Called(keyword, limit, offset)
+// GetAll provides a mock function with given fields: ctx, userID, keyword, limit, offset
+func (_m *Service) GetAll(ctx context.Context, userID string, keyword string, limit int, offset int) ([]*models.Todo, int, error) {
+	ret := _m.Called(ctx, userID, keyword, limit, offset)
 
 	var r0 []*models.Todo
-	if rf, ok := ret.Get(0).(func(string, int, int) []*models.Todo); ok {
-		r0 = rf(keyword, limit, offset)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int, int) []*models.Todo); ok {
+		r0 = rf(ctx, userID, keyword, limit, offset)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]*models.Todo)
@@ -64,15 +80,15 @@ func (_m *Service) GetAll(keyword string, limit int, offset int) ([]*models.Todo
 	}
 
 	var r1 int
-	if rf, ok := ret.Get(1).(func(string, int, int) int); ok {
-		r1 = rf(keyword, limit, offset)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, int, int) int); ok {
+		r1 = rf(ctx, userID, keyword, limit, offset)
 	} else {
 		r1 = ret.Get(1).(int)
 	}
 
 	var r2 error
-	if rf, ok := ret.Get(2).(func(string, int, int) error); ok {
-		r2 = rf(keyword, limit, offset)
+	if rf, ok := ret.Get(2).(func(context.Context, string, string, int, int) error); ok {
+		r2 = rf(ctx, userID, keyword, limit, offset)
 	} else {
 		r2 = ret.Error(2)
 	}
@@ -80,13 +96,13 @@ func (_m *Service) GetAll(keyword string, limit int, offset int) ([]*models.Todo
 	return r0, r1, r2
 }
 
-// GetByID provides a mock function with given fields: id
-func (_m *Service) GetByID(id string) (*models.Todo, error) {
-	ret := _m.Called(id)
+// GetByID provides a mock function with given fields: ctx, userID, id
+func (_m *Service) GetByID(ctx context.Context, userID string, id string) (*models.Todo, error) {
+	ret := _m.Called(ctx, userID, id)
 
 	var r0 *models.Todo
-	if rf, ok := ret.Get(0).(func(string) *models.Todo); ok {
-		r0 = rf(id)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *models.Todo); ok {
+		r0 = rf(ctx, userID, id)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*models.Todo)
@@ -94,8 +110,8 @@ func (_m *Service) GetByID(id string) (*models.Todo, error) {
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(id)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, userID, id)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -103,13 +119,27 @@ func (_m *Service) GetByID(id string) (*models.Todo, error) {
 	return r0, r1
 }
 
-// Update provides a mock function with given fields: id, value
-func (_m *Service) Update(id string, value *models.Todo) (*models.Todo, error) {
-	ret := _m.Called(id, value)
+// RemoveCollaborator provides a mock function with given fields: ctx, userID, id, collaboratorID
+func (_m *Service) RemoveCollaborator(ctx context.Context, userID string, id string, collaboratorID string) error {
+	ret := _m.Called(ctx, userID, id, collaboratorID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) error); ok {
+		r0 = rf(ctx, userID, id, collaboratorID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Update provides a mock function with given fields: ctx, userID, id, value
+func (_m *Service) Update(ctx context.Context, userID string, id string, value *models.Todo) (*models.Todo, error) {
+	ret := _m.Called(ctx, userID, id, value)
 
 	var r0 *models.Todo
-	if rf, ok := ret.Get(0).(func(string, *models.Todo) *models.Todo); ok {
-		r0 = rf(id, value)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, *models.Todo) *models.Todo); ok {
+		r0 = rf(ctx, userID, id, value)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*models.Todo)
@@ -117,8 +147,43 @@ func (_m *Service) Update(id string, value *models.Todo) (*models.Todo, error) {
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string, *models.Todo) error); ok {
-		r1 = rf(id, value)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, *models.Todo) error); ok {
+		r1 = rf(ctx, userID, id, value)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateCollaborator provides a mock function with given fields: ctx, userID, id, value
+func (_m *Service) UpdateCollaborator(ctx context.Context, userID string, id string, value *models.Collaborator) error {
+	ret := _m.Called(ctx, userID, id, value)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, *models.Collaborator) error); ok {
+		r0 = rf(ctx, userID, id, value)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Watch provides a mock function with given fields: ctx, userID, lastEventID
+func (_m *Service) Watch(ctx context.Context, userID string, lastEventID string) (<-chan *models.Event, error) {
+	ret := _m.Called(ctx, userID, lastEventID)
+
+	var r0 <-chan *models.Event
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) <-chan *models.Event); ok {
+		r0 = rf(ctx, userID, lastEventID)
+	} else {
+		r0 = ret.Get(0).(<-chan *models.Event)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, userID, lastEventID)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// Types of todo events
+const (
+	EventCreated = "todo.created"
+	EventUpdated = "todo.updated"
+	EventDeleted = "todo.deleted"
+	// EventReset - events were missed, e.g. a Last-Event-ID too old to replay, the receiver should reload its todos
+	EventReset = "todo.reset"
+)
+
+// Event - change of a todo
+type Event struct {
+	// ID - assigned by the broker, increasing in publishing order and unique to the broker instance
+	ID   string `json:"id" xml:"id"`
+	Type string `json:"type" xml:"type"`
+	// Todo - todo after the change, as it was before for deletions, nil for resets
+	Todo *Todo `json:"todo" xml:"todo"`
+	// TenantID - tenant the todo belongs to, empty when tenancy is disabled
+	TenantID   string    `json:"-" xml:"-"`
+	OccurredAt time.Time `json:"occurred_at" xml:"occurred_at"`
+}
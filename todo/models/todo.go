@@ -10,11 +10,84 @@ import (
 
 // Todo - todo model
 type Todo struct {
-	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
-	Title       string             `json:"title" bson:"title"`
-	Description string             `json:"description" bson:"description"`
-	CreatedAt   time.Time          `json:"created_at" bson:"createdAt"`
-	UpdatedAt   time.Time          `json:"updated_at" bson:"updatedAt"`
+	ID          primitive.ObjectID `json:"id" xml:"id" bson:"_id,omitempty"`
+	Title       string             `json:"title" xml:"title" bson:"title"`
+	Description string             `json:"description" xml:"description" bson:"description"`
+	OwnerID     string             `json:"owner_id" xml:"owner_id" bson:"ownerId"`
+	// Collaborators - users the todo is shared with
+	Collaborators []*Collaborator `json:"collaborators" xml:"collaborators>collaborator" bson:"collaborators,omitempty"`
+	CreatedAt     time.Time       `json:"created_at" xml:"created_at" bson:"createdAt"`
+	UpdatedAt     time.Time       `json:"updated_at" xml:"updated_at" bson:"updatedAt"`
+}
+
+// Permission levels of collaborators, each level includes the ones before it
+const (
+	PermissionView  = "view"
+	PermissionEdit  = "edit"
+	PermissionOwner = "owner"
+)
+
+var permissionLevels = []string{PermissionView, PermissionEdit, PermissionOwner}
+
+// PermissionsFrom - permission and every level above it
+func PermissionsFrom(permission string) []string {
+	for i, level := range permissionLevels {
+		if level == permission {
+			return permissionLevels[i:]
+		}
+	}
+
+	return nil
+}
+
+// Collaborator - user a todo is shared with
+type Collaborator struct {
+	UserID     string `json:"user_id" xml:"user_id" bson:"userId"`
+	Permission string `json:"permission" xml:"permission" bson:"permission"`
+}
+
+// PermissionOf - permission of userID on the todo, owner for its owner and empty when it is not shared with userID
+func (t *Todo) PermissionOf(userID string) string {
+	if t.OwnerID == userID {
+		return PermissionOwner
+	}
+
+	for _, c := range t.Collaborators {
+		if c.UserID == userID {
+			return c.Permission
+		}
+	}
+
+	return ""
+}
+
+// HasPermission - whether userID has at least permission on the todo
+func (t *Todo) HasPermission(userID string, permission string) bool {
+	granted := t.PermissionOf(userID)
+	for _, level := range PermissionsFrom(permission) {
+		if level == granted {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CSVHeader - csv column names of todo
+func (t *Todo) CSVHeader() []string {
+	return []string{"id", "title", "description", "owner_id", "created_at", "updated_at"}
+}
+
+// CSVRecord - csv row of todo
+func (t *Todo) CSVRecord() []string {
+	return []string{
+		t.ID.Hex(),
+		t.Title,
+		t.Description,
+		t.OwnerID,
+		t.CreatedAt.Format(time.RFC3339),
+		t.UpdatedAt.Format(time.RFC3339),
+	}
 }
 
 // TodoRequest - todo request
@@ -27,6 +100,25 @@ func (tr *TodoRequest) Bind(r *http.Request) error {
 	return pkgvalidator.ValidateStruct(tr)
 }
 
+// CollaboratorRequest - invite collaborator request
+type CollaboratorRequest struct {
+	UserID     string `form:"user_id" json:"user_id" validate:"required,max=255"`
+	Permission string `form:"permission" json:"permission" validate:"required,oneof=view edit owner"`
+}
+
+func (cr *CollaboratorRequest) Bind(r *http.Request) error {
+	return pkgvalidator.ValidateStruct(cr)
+}
+
+// CollaboratorPermissionRequest - change collaborator permission request
+type CollaboratorPermissionRequest struct {
+	Permission string `form:"permission" json:"permission" validate:"required,oneof=view edit owner"`
+}
+
+func (cr *CollaboratorPermissionRequest) Bind(r *http.Request) error {
+	return pkgvalidator.ValidateStruct(cr)
+}
+
 // TodoListRequest - form for list validation
 type TodoListRequest struct {
 	Keywords *SearchForm
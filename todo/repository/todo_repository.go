@@ -2,7 +2,7 @@ package repository
 
 import (
 	"context"
-	"os"
+	"errors"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -10,35 +10,89 @@ import (
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 
+	"go-clean-architecture/pkg/logger"
+	pkgmongodb "go-clean-architecture/pkg/mongodb"
+	"go-clean-architecture/pkg/tenant"
 	"go-clean-architecture/todo/models"
 	errorsutil "go-clean-architecture/utils/errors"
 	timeutil "go-clean-architecture/utils/time"
 )
 
 type Repository interface {
-	FindAll(keyword string, limit int, offset int) ([]*models.Todo, error)
-	CountFindAll(keyword string) (int, error)
-	FindById(id string) (*models.Todo, error)
-	CountFindByID(id string) (int, error)
-	Store(value *models.Todo) (*models.Todo, error)
-	Update(id string, value *models.Todo) (*models.Todo, error)
-	Delete(id string) error
+	// Every query is scoped to todos userID owns or that are shared with userID, reads need the view
+	// permission, Update edit and Delete and collaborator changes owner. Other todos behave as if they did not exist
+	FindAll(ctx context.Context, userID string, keyword string, limit int, offset int) ([]*models.Todo, error)
+	CountFindAll(ctx context.Context, userID string, keyword string) (int, error)
+	FindById(ctx context.Context, userID string, id string) (*models.Todo, error)
+	CountFindByID(ctx context.Context, userID string, id string) (int, error)
+	// CountOwned - todos ownerID owns, shared ones excluded, every todo of the database when ownerID is empty
+	CountOwned(ctx context.Context, ownerID string) (int, error)
+	Store(ctx context.Context, value *models.Todo) (*models.Todo, error)
+	Update(ctx context.Context, userID string, id string, value *models.Todo) (*models.Todo, error)
+	Delete(ctx context.Context, userID string, id string) error
+	// FindAnyById and DeleteAny - todo by id whoever owns it, for moderators only
+	FindAnyById(ctx context.Context, id string) (*models.Todo, error)
+	DeleteAny(ctx context.Context, id string) error
+	// AddCollaborator - share the todo with a user that is neither its owner nor a collaborator yet
+	AddCollaborator(ctx context.Context, userID string, id string, value *models.Collaborator) error
+	// UpdateCollaborator - change the permission of an existing collaborator
+	UpdateCollaborator(ctx context.Context, userID string, id string, value *models.Collaborator) error
+	RemoveCollaborator(ctx context.Context, userID string, id string, collaboratorID string) error
+	// Changes - events of the writes to the todos of the tenant of ctx made by any instance, needs a replica set.
+	// Deletions are only seen when the collection records pre-images. The channel is closed once ctx is done or
+	// the change stream fails, the error is logged
+	Changes(ctx context.Context) (<-chan *models.Event, error)
 }
 
 type RepositoryImpl struct {
-	client *mongo.Client
+	databases *pkgmongodb.Databases
+	log       logger.Logger
 }
 
 // New will create an object that represent the Repository interface
-func New(client *mongo.Client) Repository {
+func New(databases *pkgmongodb.Databases) Repository {
 	return &RepositoryImpl{
-		client: client,
+		databases: databases,
+		log:       logger.Named("todo/repository"),
 	}
 }
 
+// Indexes - create the owner and collaborator indexes of the access filter, provisions every database
+func Indexes(ctx context.Context, db *mongo.Database) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	_, err := db.Collection("todo").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "ownerId", Value: 1}}},
+		{Keys: bson.D{{Key: "collaborators.userId", Value: 1}}},
+	})
+	return err
+}
+
+// collection - todo collection of the database of the tenant of ctx
+func (r *RepositoryImpl) collection(ctx context.Context) (*mongo.Collection, error) {
+	db, err := r.databases.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return db.Collection("todo"), nil
+}
+
+// accessFilter - todos userID owns or that are shared with userID with at least permission
+func accessFilter(userID string, permission string) bson.M {
+	return bson.M{"$or": bson.A{
+		bson.M{"ownerId": userID},
+		bson.M{"collaborators": bson.M{"$elemMatch": bson.M{
+			"userId":     userID,
+			"permission": bson.M{"$in": models.PermissionsFrom(permission)},
+		}}},
+	}}
+}
+
 // FindAll - find all todo
-func (r *RepositoryImpl) FindAll(keyword string, limit int, offset int) ([]*models.Todo, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (r *RepositoryImpl) FindAll(ctx context.Context, userID string, keyword string, limit int, offset int) ([]*models.Todo, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	var results []*models.Todo
@@ -48,20 +102,27 @@ func (r *RepositoryImpl) FindAll(keyword string, limit int, offset int) ([]*mode
 	findOptions.SetLimit(int64(limit))
 	findOptions.SetSkip(int64(offset))
 
-	collection := r.client.Database(os.Getenv("DB_NAME")).Collection("todo")
-	cur, err := collection.Find(ctx, bson.M{"title": bson.M{"$regex": keyword, "$options": "i"}}, findOptions)
+	collection, err := r.collection(ctx)
+	if err != nil {
+		return []*models.Todo{}, err
+	}
+	filter := accessFilter(userID, models.PermissionView)
+	filter["title"] = bson.M{"$regex": keyword, "$options": "i"}
+	cur, err := collection.Find(ctx, filter, findOptions)
 	if err != nil {
+		r.logError(ctx, "FindAll", err)
 		return []*models.Todo{}, err
 	}
 
 	// Finding multiple documents returns a cursor
 	// Iterating through the cursor allows us to decode documents one at a time
-	for cur.Next(context.TODO()) {
+	for cur.Next(ctx) {
 
 		// create a value into which the single document can be decoded
 		var elem models.Todo
 		err := cur.Decode(&elem)
 		if err != nil {
+			r.logError(ctx, "FindAll", err)
 			return []*models.Todo{}, err
 		}
 
@@ -69,24 +130,31 @@ func (r *RepositoryImpl) FindAll(keyword string, limit int, offset int) ([]*mode
 	}
 
 	if err := cur.Err(); err != nil {
+		r.logError(ctx, "FindAll", err)
 		return []*models.Todo{}, err
 	}
 
 	// Close the cursor once finished
-	cur.Close(context.TODO())
+	cur.Close(ctx)
 
 	return results, nil
 }
 
 // CountFindAll - count find all todo
-func (r *RepositoryImpl) CountFindAll(keyword string) (int, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (r *RepositoryImpl) CountFindAll(ctx context.Context, userID string, keyword string) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	collection := r.client.Database(os.Getenv("DB_NAME")).Collection("todo")
+	collection, err := r.collection(ctx)
+	if err != nil {
+		return 0, err
+	}
 
-	total, err := collection.CountDocuments(ctx, bson.M{"title": bson.M{"$regex": keyword, "$options": "i"}})
+	filter := accessFilter(userID, models.PermissionView)
+	filter["title"] = bson.M{"$regex": keyword, "$options": "i"}
+	total, err := collection.CountDocuments(ctx, filter)
 	if err != nil {
+		r.logError(ctx, "CountFindAll", err)
 		return int(total), err
 	}
 
@@ -94,8 +162,8 @@ func (r *RepositoryImpl) CountFindAll(keyword string) (int, error) {
 }
 
 // FindById - find todo by id
-func (r *RepositoryImpl) FindById(id string) (*models.Todo, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (r *RepositoryImpl) FindById(ctx context.Context, userID string, id string) (*models.Todo, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	docID, err := primitive.ObjectIDFromHex(id)
@@ -103,15 +171,22 @@ func (r *RepositoryImpl) FindById(id string) (*models.Todo, error) {
 		return nil, errorsutil.ErrNotFound
 	}
 
-	collection := r.client.Database(os.Getenv("DB_NAME")).Collection("todo")
+	collection, err := r.collection(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := accessFilter(userID, models.PermissionView)
+	filter["_id"] = docID
 
 	result := &models.Todo{}
-	err = collection.FindOne(ctx, bson.M{"_id": docID}).Decode(&result)
+	err = collection.FindOne(ctx, filter).Decode(&result)
 	if err != nil {
 		if err.Error() == "mongo: no documents in result" {
 			return result, errorsutil.ErrNotFound
 		}
 
+		r.logError(ctx, "FindById", err)
 		return result, err
 	}
 
@@ -119,8 +194,8 @@ func (r *RepositoryImpl) FindById(id string) (*models.Todo, error) {
 }
 
 // CountFindByID - find count todo by id
-func (r *RepositoryImpl) CountFindByID(id string) (int, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (r *RepositoryImpl) CountFindByID(ctx context.Context, userID string, id string) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	docID, err := primitive.ObjectIDFromHex(id)
@@ -128,9 +203,15 @@ func (r *RepositoryImpl) CountFindByID(id string) (int, error) {
 		return 0, errorsutil.ErrNotFound
 	}
 
-	collection := r.client.Database(os.Getenv("DB_NAME")).Collection("todo")
-	total, err := collection.CountDocuments(ctx, bson.M{"_id": docID})
+	collection, err := r.collection(ctx)
+	if err != nil {
+		return 0, err
+	}
+	filter := accessFilter(userID, models.PermissionView)
+	filter["_id"] = docID
+	total, err := collection.CountDocuments(ctx, filter)
 	if err != nil {
+		r.logError(ctx, "CountFindByID", err)
 		return 0, err
 	}
 
@@ -141,21 +222,49 @@ func (r *RepositoryImpl) CountFindByID(id string) (int, error) {
 	return int(total), nil
 }
 
+// CountOwned - count todo of owner
+func (r *RepositoryImpl) CountOwned(ctx context.Context, ownerID string) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	collection, err := r.collection(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	filter := bson.M{}
+	if ownerID != "" {
+		filter["ownerId"] = ownerID
+	}
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		r.logError(ctx, "CountOwned", err)
+		return int(total), err
+	}
+
+	return int(total), nil
+}
+
 // Store - store todo
-func (r *RepositoryImpl) Store(value *models.Todo) (*models.Todo, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (r *RepositoryImpl) Store(ctx context.Context, value *models.Todo) (*models.Todo, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	collection := r.client.Database(os.Getenv("DB_NAME")).Collection("todo")
+	collection, err := r.collection(ctx)
+	if err != nil {
+		return &models.Todo{}, err
+	}
 
 	timeNow := timeutil.GetTimeNow()
 	res, err := collection.InsertOne(ctx, bson.M{
 		"title":       value.Title,
 		"description": value.Description,
+		"ownerId":     value.OwnerID,
 		"createdAt":   timeNow,
 		"updatedAt":   timeNow,
 	})
 	if err != nil {
+		r.logError(ctx, "Store", err)
 		return &models.Todo{}, err
 	}
 
@@ -163,6 +272,7 @@ func (r *RepositoryImpl) Store(value *models.Todo) (*models.Todo, error) {
 		ID:          res.InsertedID.(primitive.ObjectID),
 		Title:       value.Title,
 		Description: value.Description,
+		OwnerID:     value.OwnerID,
 		CreatedAt:   timeNow,
 		UpdatedAt:   timeNow,
 	}
@@ -171,8 +281,8 @@ func (r *RepositoryImpl) Store(value *models.Todo) (*models.Todo, error) {
 }
 
 // Update - update todo by id
-func (r *RepositoryImpl) Update(id string, value *models.Todo) (*models.Todo, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (r *RepositoryImpl) Update(ctx context.Context, userID string, id string, value *models.Todo) (*models.Todo, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	docID, err := primitive.ObjectIDFromHex(id)
@@ -180,7 +290,10 @@ func (r *RepositoryImpl) Update(id string, value *models.Todo) (*models.Todo, er
 		return nil, errorsutil.ErrNotFound
 	}
 
-	collection := r.client.Database(os.Getenv("DB_NAME")).Collection("todo")
+	collection, err := r.collection(ctx)
+	if err != nil {
+		return nil, err
+	}
 
 	timeNow := timeutil.GetTimeNow()
 	bsonValue := bson.D{
@@ -188,11 +301,18 @@ func (r *RepositoryImpl) Update(id string, value *models.Todo) (*models.Todo, er
 		{Key: "description", Value: value.Description},
 		{Key: "updatedAt", Value: timeNow},
 	}
-	_, err = collection.UpdateOne(ctx, bson.M{"_id": docID}, bson.D{{Key: "$set", Value: bsonValue}})
+	filter := accessFilter(userID, models.PermissionEdit)
+	filter["_id"] = docID
+	res, err := collection.UpdateOne(ctx, filter, bson.D{{Key: "$set", Value: bsonValue}})
 	if err != nil {
+		r.logError(ctx, "Update", err)
 		return nil, err
 	}
 
+	if res.MatchedCount <= 0 {
+		return nil, errorsutil.ErrNotFound
+	}
+
 	result := &models.Todo{
 		ID: docID,
 	}
@@ -201,11 +321,73 @@ func (r *RepositoryImpl) Update(id string, value *models.Todo) (*models.Todo, er
 }
 
 // Delete - delete todo by id
-func (r *RepositoryImpl) Delete(id string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (r *RepositoryImpl) Delete(ctx context.Context, userID string, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	collection, err := r.collection(ctx)
+	if err != nil {
+		return err
+	}
+
+	docID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errorsutil.ErrNotFound
+	}
+
+	filter := accessFilter(userID, models.PermissionOwner)
+	filter["_id"] = docID
+	result, err := collection.DeleteOne(ctx, filter)
+	if err != nil {
+		r.logError(ctx, "Delete", err)
+		return err
+	}
+
+	if result.DeletedCount <= 0 {
+		return errorsutil.ErrNotFound
+	}
+
+	return nil
+}
+
+// FindAnyById - find todo by id without the access filter
+func (r *RepositoryImpl) FindAnyById(ctx context.Context, id string) (*models.Todo, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	docID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errorsutil.ErrNotFound
+	}
+
+	collection, err := r.collection(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.Todo{}
+	err = collection.FindOne(ctx, bson.M{"_id": docID}).Decode(&result)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return result, errorsutil.ErrNotFound
+		}
+
+		r.logError(ctx, "FindAnyById", err)
+		return result, err
+	}
+
+	return result, nil
+}
+
+// DeleteAny - delete todo by id without the access filter
+func (r *RepositoryImpl) DeleteAny(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	collection := r.client.Database(os.Getenv("DB_NAME")).Collection("todo")
+	collection, err := r.collection(ctx)
+	if err != nil {
+		return err
+	}
 
 	docID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
@@ -214,6 +396,7 @@ func (r *RepositoryImpl) Delete(id string) error {
 
 	result, err := collection.DeleteOne(ctx, bson.M{"_id": docID})
 	if err != nil {
+		r.logError(ctx, "DeleteAny", err)
 		return err
 	}
 
@@ -223,3 +406,188 @@ func (r *RepositoryImpl) Delete(id string) error {
 
 	return nil
 }
+
+// AddCollaborator - add collaborator to todo by id
+func (r *RepositoryImpl) AddCollaborator(ctx context.Context, userID string, id string, value *models.Collaborator) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	docID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errorsutil.ErrNotFound
+	}
+
+	collection, err := r.collection(ctx)
+	if err != nil {
+		return err
+	}
+
+	filter := accessFilter(userID, models.PermissionOwner)
+	filter["_id"] = docID
+	filter["ownerId"] = bson.M{"$ne": value.UserID}
+	filter["collaborators.userId"] = bson.M{"$ne": value.UserID}
+	res, err := collection.UpdateOne(ctx, filter, bson.M{
+		"$push": bson.M{"collaborators": value},
+		"$set":  bson.M{"updatedAt": timeutil.GetTimeNow()},
+	})
+	if err != nil {
+		r.logError(ctx, "AddCollaborator", err)
+		return err
+	}
+
+	if res.MatchedCount <= 0 {
+		return errorsutil.ErrNotFound
+	}
+
+	return nil
+}
+
+// UpdateCollaborator - change collaborator permission of todo by id
+func (r *RepositoryImpl) UpdateCollaborator(ctx context.Context, userID string, id string, value *models.Collaborator) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	docID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errorsutil.ErrNotFound
+	}
+
+	collection, err := r.collection(ctx)
+	if err != nil {
+		return err
+	}
+
+	filter := accessFilter(userID, models.PermissionOwner)
+	filter["_id"] = docID
+	filter["collaborators.userId"] = value.UserID
+	// The caller may be a collaborator too, so the element is picked by an array filter and not by "$"
+	res, err := collection.UpdateOne(ctx, filter, bson.M{
+		"$set": bson.M{"collaborators.$[c].permission": value.Permission, "updatedAt": timeutil.GetTimeNow()},
+	}, options.Update().SetArrayFilters(options.ArrayFilters{
+		Filters: []interface{}{bson.M{"c.userId": value.UserID}},
+	}))
+	if err != nil {
+		r.logError(ctx, "UpdateCollaborator", err)
+		return err
+	}
+
+	if res.MatchedCount <= 0 {
+		return errorsutil.ErrNotFound
+	}
+
+	return nil
+}
+
+// RemoveCollaborator - remove collaborator from todo by id
+func (r *RepositoryImpl) RemoveCollaborator(ctx context.Context, userID string, id string, collaboratorID string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	docID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errorsutil.ErrNotFound
+	}
+
+	collection, err := r.collection(ctx)
+	if err != nil {
+		return err
+	}
+
+	filter := accessFilter(userID, models.PermissionOwner)
+	filter["_id"] = docID
+	filter["collaborators.userId"] = collaboratorID
+	res, err := collection.UpdateOne(ctx, filter, bson.M{
+		"$pull": bson.M{"collaborators": bson.M{"userId": collaboratorID}},
+		"$set":  bson.M{"updatedAt": timeutil.GetTimeNow()},
+	})
+	if err != nil {
+		r.logError(ctx, "RemoveCollaborator", err)
+		return err
+	}
+
+	if res.MatchedCount <= 0 {
+		return errorsutil.ErrNotFound
+	}
+
+	return nil
+}
+
+// change - change stream event of the todo collection
+type change struct {
+	OperationType            string              `bson:"operationType"`
+	ClusterTime              primitive.Timestamp `bson:"clusterTime"`
+	FullDocument             *models.Todo        `bson:"fullDocument"`
+	FullDocumentBeforeChange *models.Todo        `bson:"fullDocumentBeforeChange"`
+}
+
+// Changes - watch the todo collection
+func (r *RepositoryImpl) Changes(ctx context.Context) (<-chan *models.Event, error) {
+	collection, err := r.collection(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"operationType": bson.M{"$in": bson.A{"insert", "update", "replace", "delete"}}}}},
+	}
+	stream, err := collection.Watch(ctx, pipeline, options.ChangeStream().
+		SetFullDocument(options.UpdateLookup).
+		SetFullDocumentBeforeChange(options.WhenAvailable))
+	if err != nil {
+		r.logError(ctx, "Changes", err)
+		return nil, err
+	}
+
+	events := make(chan *models.Event)
+	tenantID := tenant.ID(ctx)
+
+	go func() {
+		defer close(events)
+		defer stream.Close(context.Background())
+
+		for stream.Next(ctx) {
+			var c change
+			if err := stream.Decode(&c); err != nil {
+				r.logError(ctx, "Changes", err)
+				return
+			}
+
+			event := &models.Event{
+				Todo:       c.FullDocument,
+				TenantID:   tenantID,
+				OccurredAt: time.Unix(int64(c.ClusterTime.T), 0).UTC(),
+			}
+			switch c.OperationType {
+			case "insert":
+				event.Type = models.EventCreated
+			case "delete":
+				event.Type = models.EventDeleted
+				event.Todo = c.FullDocumentBeforeChange
+			default:
+				event.Type = models.EventUpdated
+			}
+			// Updates of a todo deleted since and deletions without a pre-image cannot be checked against
+			// the collaborators of the todo, nobody is told about them
+			if event.Todo == nil {
+				continue
+			}
+
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		if err := stream.Err(); err != nil && ctx.Err() == nil {
+			r.logError(ctx, "Changes", err)
+		}
+	}()
+
+	return events, nil
+}
+
+// logError - log a database error with the request context of ctx
+func (r *RepositoryImpl) logError(ctx context.Context, operation string, err error) {
+	r.log.WithContext(ctx).Error("database operation failed", "operation", operation, "error", err)
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	pkgmetrics "go-clean-architecture/pkg/metrics"
+	"go-clean-architecture/todo/models"
+)
+
+type MetricsRepository struct {
+	next Repository
+}
+
+// WithMetrics will wrap a Repository and record the latency of every method
+func WithMetrics(next Repository) Repository {
+	return &MetricsRepository{
+		next: next,
+	}
+}
+
+// FindAll - find all todo
+func (r *MetricsRepository) FindAll(ctx context.Context, userID string, keyword string, limit int, offset int) (res []*models.Todo, err error) {
+	defer pkgmetrics.ObserveRepository("todo", "FindAll", time.Now(), &err)
+
+	return r.next.FindAll(ctx, userID, keyword, limit, offset)
+}
+
+// CountFindAll - count find all todo
+func (r *MetricsRepository) CountFindAll(ctx context.Context, userID string, keyword string) (total int, err error) {
+	defer pkgmetrics.ObserveRepository("todo", "CountFindAll", time.Now(), &err)
+
+	return r.next.CountFindAll(ctx, userID, keyword)
+}
+
+// FindById - find todo by id
+func (r *MetricsRepository) FindById(ctx context.Context, userID string, id string) (res *models.Todo, err error) {
+	defer pkgmetrics.ObserveRepository("todo", "FindById", time.Now(), &err)
+
+	return r.next.FindById(ctx, userID, id)
+}
+
+// CountFindByID - find count todo by id
+func (r *MetricsRepository) CountFindByID(ctx context.Context, userID string, id string) (total int, err error) {
+	defer pkgmetrics.ObserveRepository("todo", "CountFindByID", time.Now(), &err)
+
+	return r.next.CountFindByID(ctx, userID, id)
+}
+
+// CountOwned - count todo of owner
+func (r *MetricsRepository) CountOwned(ctx context.Context, ownerID string) (total int, err error) {
+	defer pkgmetrics.ObserveRepository("todo", "CountOwned", time.Now(), &err)
+
+	return r.next.CountOwned(ctx, ownerID)
+}
+
+// Store - store todo
+func (r *MetricsRepository) Store(ctx context.Context, value *models.Todo) (res *models.Todo, err error) {
+	defer pkgmetrics.ObserveRepository("todo", "Store", time.Now(), &err)
+
+	return r.next.Store(ctx, value)
+}
+
+// Update - update todo by id
+func (r *MetricsRepository) Update(ctx context.Context, userID string, id string, value *models.Todo) (res *models.Todo, err error) {
+	defer pkgmetrics.ObserveRepository("todo", "Update", time.Now(), &err)
+
+	return r.next.Update(ctx, userID, id, value)
+}
+
+// Delete - delete todo by id
+func (r *MetricsRepository) Delete(ctx context.Context, userID string, id string) (err error) {
+	defer pkgmetrics.ObserveRepository("todo", "Delete", time.Now(), &err)
+
+	return r.next.Delete(ctx, userID, id)
+}
+
+// FindAnyById - find todo by id without the access filter
+func (r *MetricsRepository) FindAnyById(ctx context.Context, id string) (res *models.Todo, err error) {
+	defer pkgmetrics.ObserveRepository("todo", "FindAnyById", time.Now(), &err)
+
+	return r.next.FindAnyById(ctx, id)
+}
+
+// DeleteAny - delete todo by id without the access filter
+func (r *MetricsRepository) DeleteAny(ctx context.Context, id string) (err error) {
+	defer pkgmetrics.ObserveRepository("todo", "DeleteAny", time.Now(), &err)
+
+	return r.next.DeleteAny(ctx, id)
+}
+
+// AddCollaborator - add collaborator to todo by id
+func (r *MetricsRepository) AddCollaborator(ctx context.Context, userID string, id string, value *models.Collaborator) (err error) {
+	defer pkgmetrics.ObserveRepository("todo", "AddCollaborator", time.Now(), &err)
+
+	return r.next.AddCollaborator(ctx, userID, id, value)
+}
+
+// UpdateCollaborator - change collaborator permission of todo by id
+func (r *MetricsRepository) UpdateCollaborator(ctx context.Context, userID string, id string, value *models.Collaborator) (err error) {
+	defer pkgmetrics.ObserveRepository("todo", "UpdateCollaborator", time.Now(), &err)
+
+	return r.next.UpdateCollaborator(ctx, userID, id, value)
+}
+
+// RemoveCollaborator - remove collaborator from todo by id
+func (r *MetricsRepository) RemoveCollaborator(ctx context.Context, userID string, id string, collaboratorID string) (err error) {
+	defer pkgmetrics.ObserveRepository("todo", "RemoveCollaborator", time.Now(), &err)
+
+	return r.next.RemoveCollaborator(ctx, userID, id, collaboratorID)
+}
+
+// Changes - watch todo changes, only starting the change stream is observed
+func (r *MetricsRepository) Changes(ctx context.Context) (res <-chan *models.Event, err error) {
+	defer pkgmetrics.ObserveRepository("todo", "Changes", time.Now(), &err)
+
+	return r.next.Changes(ctx)
+}
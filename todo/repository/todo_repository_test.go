@@ -3,8 +3,11 @@ package repository_test
 import (
 	"context"
 	"flag"
+	pkgmongodb "go-clean-architecture/pkg/mongodb"
+	"go-clean-architecture/pkg/tenant"
 	"go-clean-architecture/todo/models"
 	"go-clean-architecture/todo/repository"
+	errorsutil "go-clean-architecture/utils/errors"
 	"log"
 	"os"
 	"testing"
@@ -12,6 +15,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -44,7 +48,7 @@ func TestTodoFindAll(t *testing.T) {
 	assert.NoError(t, err)
 	defer client.Disconnect(ctx)
 
-	repo := repository.New(client)
+	repo := repository.New(pkgmongodb.NewDatabases(client, "test"))
 
 	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
 	defer mt.Close()
@@ -75,6 +79,100 @@ func TestTodoFindAll(t *testing.T) {
 		)
 		mt.AddMockResponses(find, getMore, killCursors)
 
-		repo.FindAll("", 10, 0)
+		repo.FindAll(context.Background(), "owner-1", "", 10, 0)
+	})
+}
+
+func TestTodoFindByIdShared(t *testing.T) {
+	os.Setenv("DB_NAME", "test")
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("when query includes todos shared with the caller", func(mt *mtest.T) {
+		repo := repository.New(pkgmongodb.NewDatabases(mt.Client, "test"))
+		mt.AddMockResponses(mtest.CreateCursorResponse(1, "test.todo", mtest.FirstBatch, bson.D{
+			{Key: "_id", Value: primitive.NewObjectID()},
+			{Key: "ownerId", Value: "owner-1"},
+			{Key: "collaborators", Value: bson.A{bson.D{{Key: "userId", Value: "user-2"}, {Key: "permission", Value: "view"}}}},
+		}))
+
+		result, err := repo.FindById(context.Background(), "user-2", primitive.NewObjectID().Hex())
+
+		assert.NoError(mt, err)
+		assert.Equal(mt, models.PermissionView, result.PermissionOf("user-2"))
+
+		filter := mt.GetStartedEvent().Command.Lookup("filter").Document()
+		_, err = filter.LookupErr("$or")
+		assert.NoError(mt, err)
+	})
+}
+
+func TestTodoFindAnyById(t *testing.T) {
+	os.Setenv("DB_NAME", "test")
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("when query ignores owners and collaborators", func(mt *mtest.T) {
+		repo := repository.New(pkgmongodb.NewDatabases(mt.Client, "test"))
+		mt.AddMockResponses(mtest.CreateCursorResponse(1, "test.todo", mtest.FirstBatch, bson.D{
+			{Key: "_id", Value: primitive.NewObjectID()},
+			{Key: "ownerId", Value: "owner-1"},
+		}))
+
+		result, err := repo.FindAnyById(context.Background(), primitive.NewObjectID().Hex())
+
+		assert.NoError(mt, err)
+		assert.Equal(mt, "owner-1", result.OwnerID)
+
+		filter := mt.GetStartedEvent().Command.Lookup("filter").Document()
+		_, err = filter.LookupErr("$or")
+		assert.Error(mt, err)
+	})
+}
+
+func TestTodoAddCollaborator(t *testing.T) {
+	os.Setenv("DB_NAME", "test")
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("when todo is not found or not owned", func(mt *mtest.T) {
+		repo := repository.New(pkgmongodb.NewDatabases(mt.Client, "test"))
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 0}, {Key: "nModified", Value: 0}})
+
+		err := repo.AddCollaborator(context.Background(), "user-2", primitive.NewObjectID().Hex(), &models.Collaborator{UserID: "user-3", Permission: models.PermissionView})
+
+		assert.ErrorIs(mt, err, errorsutil.ErrNotFound)
+	})
+
+	mt.Run("when success", func(mt *mtest.T) {
+		repo := repository.New(pkgmongodb.NewDatabases(mt.Client, "test"))
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}, {Key: "nModified", Value: 1}})
+
+		err := repo.AddCollaborator(context.Background(), "owner-1", primitive.NewObjectID().Hex(), &models.Collaborator{UserID: "user-3", Permission: models.PermissionView})
+
+		assert.NoError(mt, err)
+	})
+}
+
+// TestTodoCrossTenant - the same caller and id never reach the database of another tenant
+func TestTodoCrossTenant(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("when tenants read the same id", func(mt *mtest.T) {
+		repo := repository.New(pkgmongodb.NewDatabases(mt.Client, "test"))
+		id := primitive.NewObjectID().Hex()
+
+		for _, t := range []*tenant.Tenant{{ID: "acme", Database: "tenant_acme"}, {ID: "globex", Database: "tenant_globex"}} {
+			mt.AddMockResponses(mtest.CreateCursorResponse(0, t.Database+".todo", mtest.FirstBatch))
+
+			_, err := repo.FindById(tenant.NewContext(context.Background(), t), "owner-1", id)
+
+			assert.ErrorIs(mt, err, errorsutil.ErrNotFound)
+			assert.Equal(mt, t.Database, mt.GetStartedEvent().DatabaseName)
+		}
 	})
 }
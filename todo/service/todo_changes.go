@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"go-clean-architecture/pkg/logger"
+	"go-clean-architecture/pkg/tenant"
+	todorepository "go-clean-architecture/todo/repository"
+)
+
+// ChangeFeed - publish the changes seen by the change streams of the repository, so that the subscribers of
+// every instance hear about the writes of every instance
+type ChangeFeed interface {
+	// Run - follow the changes of the tenant of ctx until ctx is done, restarting the change stream after failures
+	Run(ctx context.Context)
+}
+
+// Restart delays of a failed change stream
+const (
+	changeFeedMinBackoff = time.Second
+	changeFeedMaxBackoff = 30 * time.Second
+)
+
+type ChangeFeedImpl struct {
+	repository todorepository.Repository
+	events     Broker
+	log        logger.Logger
+}
+
+// NewChangeFeed - make change feed publishing to events, the service should then be given ReadOnly(events)
+func NewChangeFeed(repository todorepository.Repository, events Broker) ChangeFeed {
+	return &ChangeFeedImpl{
+		repository: repository,
+		events:     events,
+		log:        logger.Named("todo/service"),
+	}
+}
+
+// Run - run change feed
+func (f *ChangeFeedImpl) Run(ctx context.Context) {
+	backoff := changeFeedMinBackoff
+
+	for ctx.Err() == nil {
+		changes, err := f.repository.Changes(ctx)
+		if err == nil {
+			f.log.WithContext(ctx).Info("change feed started", "tenant", tenant.ID(ctx))
+			for event := range changes {
+				f.events.Publish(event)
+				backoff = changeFeedMinBackoff
+			}
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		// Subscribers miss the changes made until the stream is back, they are not told
+		f.log.WithContext(ctx).Warn("change feed stopped, restarting", "tenant", tenant.ID(ctx), "backoff", backoff.String())
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return
+		}
+		if backoff *= 2; backoff > changeFeedMaxBackoff {
+			backoff = changeFeedMaxBackoff
+		}
+	}
+}
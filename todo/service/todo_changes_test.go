@@ -0,0 +1,78 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	mockrepository "go-clean-architecture/todo/mocks/repository"
+	"go-clean-architecture/todo/models"
+	todoservice "go-clean-architecture/todo/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// changes - closed change stream of events
+func changes(events ...*models.Event) <-chan *models.Event {
+	ch := make(chan *models.Event, len(events))
+	for _, event := range events {
+		ch <- event
+	}
+	close(ch)
+
+	return ch
+}
+
+func TestChangeFeed(t *testing.T) {
+	t.Run("success when changes are published", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		broker := todoservice.NewBroker(0)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		mockRepository.On("Changes", mock.Anything).
+			Return(changes(&models.Event{Type: models.EventCreated, Todo: &models.Todo{OwnerID: DefaultOwnerID}}), nil).Once()
+		mockRepository.On("Changes", mock.Anything).Return((<-chan *models.Event)(nil), errors.New("no replica set")).Maybe()
+
+		events := broker.Subscribe(ctx, "", 1)
+		done := make(chan struct{})
+		go func() {
+			todoservice.NewChangeFeed(mockRepository, broker).Run(ctx)
+			close(done)
+		}()
+
+		event := receive(events)
+		assert.Equal(t, models.EventCreated, event.Type)
+		assert.NotEmpty(t, event.ID)
+
+		cancel()
+		<-done
+	})
+
+	t.Run("success when a failed change stream is restarted", func(t *testing.T) {
+		if testing.Short() {
+			t.Skip("waits for the restart backoff")
+		}
+
+		mockRepository := new(mockrepository.Repository)
+		broker := todoservice.NewBroker(0)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		mockRepository.On("Changes", mock.Anything).Return((<-chan *models.Event)(nil), errors.New("connection refused")).Once()
+		mockRepository.On("Changes", mock.Anything).Return(changes(&models.Event{Type: models.EventDeleted}), nil).Once()
+		mockRepository.On("Changes", mock.Anything).Return((<-chan *models.Event)(nil), errors.New("connection refused")).Maybe()
+
+		events := broker.Subscribe(ctx, "", 1)
+		go todoservice.NewChangeFeed(mockRepository, broker).Run(ctx)
+
+		select {
+		case event := <-events:
+			assert.Equal(t, models.EventDeleted, event.Type)
+		case <-time.After(5 * time.Second):
+			t.Fatal("change stream was not restarted")
+		}
+	})
+}
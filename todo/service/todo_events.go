@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go-clean-architecture/todo/models"
+)
+
+// Broker - fan out todo events to the subscribers of this process
+type Broker interface {
+	// Publish - assign the next id to event and hand it to every subscriber, never blocks
+	Publish(event *models.Event)
+	// Subscribe - events published after lastEventID followed by the ones published from now on, only the
+	// latter when lastEventID is empty. An id the broker no longer holds starts the subscription with a reset
+	// event. The channel is closed once ctx is done or when the subscriber falls more than buffer events behind
+	Subscribe(ctx context.Context, lastEventID string, buffer int) <-chan *models.Event
+}
+
+type BrokerImpl struct {
+	mu sync.Mutex
+	// epoch - prefix of the ids of this broker, ids of another instance or an earlier run are never replayed
+	epoch       string
+	sequence    uint64
+	history     []*models.Event
+	size        int
+	subscribers map[chan *models.Event]struct{}
+}
+
+// NewBroker - make in-process broker keeping the last history events for subscribers resuming after an id
+func NewBroker(history int) Broker {
+	return &BrokerImpl{
+		epoch:       strconv.FormatInt(time.Now().UnixNano(), 36),
+		size:        history,
+		subscribers: map[chan *models.Event]struct{}{},
+	}
+}
+
+// Publish - publish todo event
+func (b *BrokerImpl) Publish(event *models.Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.sequence++
+	event.ID = b.epoch + "-" + strconv.FormatUint(b.sequence, 10)
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now().UTC()
+	}
+
+	if b.size > 0 {
+		if len(b.history) == b.size {
+			b.history = b.history[1:]
+		}
+		b.history = append(b.history, event)
+	}
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+			// A subscriber that cannot keep up is dropped rather than slowing down every write
+			b.remove(ch)
+		}
+	}
+}
+
+// Subscribe - subscribe to todo events
+func (b *BrokerImpl) Subscribe(ctx context.Context, lastEventID string, buffer int) <-chan *models.Event {
+	b.mu.Lock()
+
+	// Replayed events are queued under the lock, nothing published meanwhile is lost or sent twice
+	replay := b.replay(lastEventID)
+	ch := make(chan *models.Event, len(replay)+buffer)
+	for _, event := range replay {
+		ch <- event
+	}
+	b.subscribers[ch] = struct{}{}
+	b.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.remove(ch)
+	}()
+
+	return ch
+}
+
+// replay - events after lastEventID, a reset event when they are no longer held, b.mu must be held
+func (b *BrokerImpl) replay(lastEventID string) []*models.Event {
+	if lastEventID == "" {
+		return nil
+	}
+
+	sequence, ok := b.parseID(lastEventID)
+	if !ok || sequence > b.sequence {
+		return []*models.Event{b.reset()}
+	}
+
+	// history holds the events sequence-len(history)+1 to b.sequence, every event after lastEventID
+	// must still be there
+	missed := int(b.sequence - sequence)
+	if missed > len(b.history) {
+		return []*models.Event{b.reset()}
+	}
+
+	return append([]*models.Event{}, b.history[len(b.history)-missed:]...)
+}
+
+// reset - reset event carrying the id of the latest event, resuming after it replays what follows
+func (b *BrokerImpl) reset() *models.Event {
+	return &models.Event{
+		ID:         b.epoch + "-" + strconv.FormatUint(b.sequence, 10),
+		Type:       models.EventReset,
+		OccurredAt: time.Now().UTC(),
+	}
+}
+
+// parseID - sequence of an id of this broker
+func (b *BrokerImpl) parseID(id string) (uint64, bool) {
+	epoch, sequence, found := strings.Cut(id, "-")
+	if !found || epoch != b.epoch {
+		return 0, false
+	}
+
+	n, err := strconv.ParseUint(sequence, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return n, true
+}
+
+// remove - close ch unless it was removed already, b.mu must be held
+func (b *BrokerImpl) remove(ch chan *models.Event) {
+	if _, ok := b.subscribers[ch]; ok {
+		delete(b.subscribers, ch)
+		close(ch)
+	}
+}
+
+// readOnlyBroker - broker whose events are published by another source
+type readOnlyBroker struct {
+	Broker
+}
+
+// ReadOnly - broker subscribing to broker and ignoring Publish, for the service when events come from the
+// change feed so that its writes are not published twice
+func ReadOnly(broker Broker) Broker {
+	return &readOnlyBroker{Broker: broker}
+}
+
+// Publish - events are published by the change feed
+func (b *readOnlyBroker) Publish(event *models.Event) {}
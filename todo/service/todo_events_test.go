@@ -0,0 +1,136 @@
+package service_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go-clean-architecture/todo/models"
+	todoservice "go-clean-architecture/todo/service"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBroker(t *testing.T) {
+	t.Run("success when every subscriber receives events in order", func(t *testing.T) {
+		broker := todoservice.NewBroker(0)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		first, second := broker.Subscribe(ctx, "", 2), broker.Subscribe(ctx, "", 2)
+		broker.Publish(&models.Event{Type: models.EventCreated})
+		broker.Publish(&models.Event{Type: models.EventDeleted})
+
+		for _, events := range []<-chan *models.Event{first, second} {
+			event := <-events
+			assert.True(t, strings.HasSuffix(event.ID, "-1"))
+			assert.False(t, event.OccurredAt.IsZero())
+			assert.True(t, strings.HasSuffix((<-events).ID, "-2"))
+		}
+	})
+
+	t.Run("success when a subscriber falling behind is dropped", func(t *testing.T) {
+		broker := todoservice.NewBroker(0)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		slow, fast := broker.Subscribe(ctx, "", 1), broker.Subscribe(ctx, "", 3)
+		for i := 0; i < 3; i++ {
+			broker.Publish(&models.Event{Type: models.EventUpdated})
+		}
+
+		assert.True(t, strings.HasSuffix((<-slow).ID, "-1"))
+		_, open := <-slow
+		assert.False(t, open)
+		assert.Len(t, fast, 3)
+	})
+
+	t.Run("success when the subscription ends with its context", func(t *testing.T) {
+		broker := todoservice.NewBroker(0)
+		ctx, cancel := context.WithCancel(context.Background())
+
+		events := broker.Subscribe(ctx, "", 1)
+		cancel()
+
+		_, open := <-events
+		assert.False(t, open)
+		broker.Publish(&models.Event{Type: models.EventCreated})
+	})
+
+	t.Run("success when resuming replays the events after the last one received", func(t *testing.T) {
+		broker := todoservice.NewBroker(3)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		published := []*models.Event{}
+		for i := 0; i < 4; i++ {
+			event := &models.Event{Type: models.EventUpdated}
+			broker.Publish(event)
+			published = append(published, event)
+		}
+
+		events := broker.Subscribe(ctx, published[1].ID, 1)
+		broker.Publish(&models.Event{Type: models.EventDeleted})
+
+		assert.Equal(t, published[2].ID, (<-events).ID)
+		assert.Equal(t, published[3].ID, (<-events).ID)
+		assert.Equal(t, models.EventDeleted, (<-events).Type)
+	})
+
+	t.Run("success when resuming after the latest event replays nothing", func(t *testing.T) {
+		broker := todoservice.NewBroker(3)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		latest := &models.Event{Type: models.EventCreated}
+		broker.Publish(latest)
+
+		assert.Len(t, broker.Subscribe(ctx, latest.ID, 1), 0)
+	})
+
+	t.Run("success when the last event received is no longer held", func(t *testing.T) {
+		broker := todoservice.NewBroker(2)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		first := &models.Event{Type: models.EventCreated}
+		broker.Publish(first)
+		for i := 0; i < 3; i++ {
+			broker.Publish(&models.Event{Type: models.EventUpdated})
+		}
+
+		events := broker.Subscribe(ctx, first.ID, 1)
+
+		reset := <-events
+		assert.Equal(t, models.EventReset, reset.Type)
+		assert.Nil(t, reset.Todo)
+		assert.True(t, strings.HasSuffix(reset.ID, "-4"))
+		assert.Len(t, events, 0)
+	})
+
+	t.Run("success when the last event is from another broker", func(t *testing.T) {
+		broker := todoservice.NewBroker(2)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		events := broker.Subscribe(ctx, "abc-1", 1)
+
+		assert.Equal(t, models.EventReset, (<-events).Type)
+	})
+}
+
+func TestReadOnlyBroker(t *testing.T) {
+	t.Run("success when publishing is left to the other source", func(t *testing.T) {
+		broker := todoservice.NewBroker(2)
+		readOnly := todoservice.ReadOnly(broker)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		events := readOnly.Subscribe(ctx, "", 2)
+		readOnly.Publish(&models.Event{Type: models.EventCreated})
+		broker.Publish(&models.Event{Type: models.EventDeleted})
+
+		assert.Equal(t, models.EventDeleted, (<-events).Type)
+		assert.Len(t, events, 0)
+	})
+}
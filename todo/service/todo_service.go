@@ -1,39 +1,71 @@
 package service
 
 import (
+	"context"
+	"time"
+
+	"go-clean-architecture/pkg/auth"
+	"go-clean-architecture/pkg/policy"
+	"go-clean-architecture/pkg/tenant"
 	"go-clean-architecture/todo/models"
 	todorepository "go-clean-architecture/todo/repository"
+	usageservice "go-clean-architecture/usage/service"
+	errorsutil "go-clean-architecture/utils/errors"
 )
 
 // Service represent the todo service
 type Service interface {
-	GetAll(keyword string, limit int, offset int) ([]*models.Todo, int, error)
-	GetByID(id string) (*models.Todo, error)
-	Create(value *models.Todo) (*models.Todo, error)
-	Update(id string, value *models.Todo) (*models.Todo, error)
-	Delete(id string) error
+	// Todos are scoped to userID, the caller, and include todos shared with them. Create uses value.OwnerID.
+	// Callers allowed to moderate get and delete any todo of the tenant by id
+	GetAll(ctx context.Context, userID string, keyword string, limit int, offset int) ([]*models.Todo, int, error)
+	GetByID(ctx context.Context, userID string, id string) (*models.Todo, error)
+	Create(ctx context.Context, value *models.Todo) (*models.Todo, error)
+	Update(ctx context.Context, userID string, id string, value *models.Todo) (*models.Todo, error)
+	Delete(ctx context.Context, userID string, id string) error
+	// Collaborators are managed by the owner and collaborators with the owner permission
+	AddCollaborator(ctx context.Context, userID string, id string, value *models.Collaborator) error
+	UpdateCollaborator(ctx context.Context, userID string, id string, value *models.Collaborator) error
+	RemoveCollaborator(ctx context.Context, userID string, id string, collaboratorID string) error
+	// Watch - events of the todos userID can see published after lastEventID, or from now on when it is
+	// empty. The channel is closed once ctx is done or when the receiver falls behind
+	Watch(ctx context.Context, userID string, lastEventID string) (<-chan *models.Event, error)
 }
 
+// watchBuffer - events a watcher may fall behind before it is dropped
+const watchBuffer = 64
+
 type ServiceImpl struct {
 	repository todorepository.Repository
+	policy     policy.Policy
+	usage      usageservice.Service
+	events     Broker
 }
 
-// New will create new an ServiceImpl object representation of Service interface
-func New(repository todorepository.Repository) Service {
+// New will create new an ServiceImpl object representation of Service interface,
+// every operation is authorized by policy for the principal in ctx, writes are held to the quotas of usage
+// and published to events
+func New(repository todorepository.Repository, policy policy.Policy, usage usageservice.Service, events Broker) Service {
 	return &ServiceImpl{
 		repository: repository,
+		policy:     policy,
+		usage:      usage,
+		events:     events,
 	}
 }
 
 // GetAll - get all todo service
-func (s *ServiceImpl) GetAll(keyword string, limit int, offset int) ([]*models.Todo, int, error) {
-	res, err := s.repository.FindAll(keyword, limit, offset)
+func (s *ServiceImpl) GetAll(ctx context.Context, userID string, keyword string, limit int, offset int) ([]*models.Todo, int, error) {
+	if err := s.policy.Authorize(auth.FromContext(ctx), policy.ActionList); err != nil {
+		return nil, 0, err
+	}
+
+	res, err := s.repository.FindAll(ctx, userID, keyword, limit, offset)
 	if err != nil {
 		return nil, 0, err
 	}
 
 	// Count total
-	total, err := s.repository.CountFindAll(keyword)
+	total, err := s.repository.CountFindAll(ctx, userID, keyword)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -42,36 +74,59 @@ func (s *ServiceImpl) GetAll(keyword string, limit int, offset int) ([]*models.T
 }
 
 // GetByID - get todo by id service
-func (s *ServiceImpl) GetByID(id string) (*models.Todo, error) {
-	res, err := s.repository.FindById(id)
+func (s *ServiceImpl) GetByID(ctx context.Context, userID string, id string) (*models.Todo, error) {
+	res, _, err := s.find(ctx, userID, id)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := s.policy.Authorize(auth.FromContext(ctx), policy.ActionRead); err != nil {
+		return nil, err
+	}
+
 	return res, nil
 }
 
 // Create - creating todo service
-func (r *ServiceImpl) Create(value *models.Todo) (*models.Todo, error) {
-	res, err := r.repository.Store(&models.Todo{
+func (r *ServiceImpl) Create(ctx context.Context, value *models.Todo) (*models.Todo, error) {
+	if err := r.policy.Authorize(auth.FromContext(ctx), policy.ActionCreate); err != nil {
+		return nil, err
+	}
+	if err := r.usage.CheckTodos(ctx, value.OwnerID, []*models.Todo{value}); err != nil {
+		return nil, err
+	}
+
+	res, err := r.repository.Store(ctx, &models.Todo{
 		Title:       value.Title,
 		Description: value.Description,
+		OwnerID:     value.OwnerID,
 	})
 	if err != nil {
 		return nil, err
 	}
+	r.publish(ctx, models.EventCreated, res)
 
 	return res, nil
 }
 
 // Update - update todo service
-func (r *ServiceImpl) Update(id string, value *models.Todo) (*models.Todo, error) {
-	_, err := r.repository.CountFindByID(id)
+func (r *ServiceImpl) Update(ctx context.Context, userID string, id string, value *models.Todo) (*models.Todo, error) {
+	current, err := r.repository.FindById(ctx, userID, id)
 	if err != nil {
 		return nil, err
 	}
 
-	_, err = r.repository.Update(id, &models.Todo{
+	if !current.HasPermission(userID, models.PermissionEdit) {
+		return nil, errorsutil.ErrForbidden
+	}
+	if err := r.policy.Authorize(auth.FromContext(ctx), policy.ActionUpdate); err != nil {
+		return nil, err
+	}
+	if err := r.usage.CheckDescription(ctx, value.Description); err != nil {
+		return nil, err
+	}
+
+	_, err = r.repository.Update(ctx, userID, id, &models.Todo{
 		Title:       value.Title,
 		Description: value.Description,
 	})
@@ -79,15 +134,183 @@ func (r *ServiceImpl) Update(id string, value *models.Todo) (*models.Todo, error
 		return nil, err
 	}
 
-	return nil, nil
+	updated := *current
+	updated.Title, updated.Description, updated.UpdatedAt = value.Title, value.Description, time.Now()
+	r.publish(ctx, models.EventUpdated, &updated)
+
+	return &updated, nil
 }
 
 // Delete - delete todo service
-func (r *ServiceImpl) Delete(id string) error {
-	err := r.repository.Delete(id)
+func (r *ServiceImpl) Delete(ctx context.Context, userID string, id string) error {
+	current, moderated, err := r.find(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+
+	if !moderated && !current.HasPermission(userID, models.PermissionOwner) {
+		return errorsutil.ErrForbidden
+	}
+	if err := r.policy.Authorize(auth.FromContext(ctx), policy.ActionDelete); err != nil {
+		return err
+	}
+
+	if moderated {
+		err = r.repository.DeleteAny(ctx, id)
+	} else {
+		err = r.repository.Delete(ctx, userID, id)
+	}
+	if err != nil {
+		return err
+	}
+	r.publish(ctx, models.EventDeleted, current)
+
+	return nil
+}
+
+// AddCollaborator - share todo service
+func (r *ServiceImpl) AddCollaborator(ctx context.Context, userID string, id string, value *models.Collaborator) error {
+	current, err := r.authorizeShare(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+
+	if current.PermissionOf(value.UserID) != "" {
+		return errorsutil.ErrAlreadyExists
+	}
+
+	collaborator := &models.Collaborator{
+		UserID:     value.UserID,
+		Permission: value.Permission,
+	}
+	if err := r.repository.AddCollaborator(ctx, userID, id, collaborator); err != nil {
+		return err
+	}
+
+	updated := *current
+	updated.Collaborators = append(append([]*models.Collaborator{}, current.Collaborators...), collaborator)
+	r.publish(ctx, models.EventUpdated, &updated)
+
+	return nil
+}
+
+// UpdateCollaborator - change collaborator permission service
+func (r *ServiceImpl) UpdateCollaborator(ctx context.Context, userID string, id string, value *models.Collaborator) error {
+	current, err := r.authorizeShare(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+
+	collaborator := &models.Collaborator{
+		UserID:     value.UserID,
+		Permission: value.Permission,
+	}
+	if err := r.repository.UpdateCollaborator(ctx, userID, id, collaborator); err != nil {
+		return err
+	}
+
+	updated := *current
+	updated.Collaborators = []*models.Collaborator{}
+	for _, c := range current.Collaborators {
+		if c.UserID == collaborator.UserID {
+			c = collaborator
+		}
+		updated.Collaborators = append(updated.Collaborators, c)
+	}
+	r.publish(ctx, models.EventUpdated, &updated)
+
+	return nil
+}
+
+// RemoveCollaborator - unshare todo service
+func (r *ServiceImpl) RemoveCollaborator(ctx context.Context, userID string, id string, collaboratorID string) error {
+	current, err := r.authorizeShare(ctx, userID, id)
 	if err != nil {
 		return err
 	}
 
+	if err := r.repository.RemoveCollaborator(ctx, userID, id, collaboratorID); err != nil {
+		return err
+	}
+
+	updated := *current
+	updated.Collaborators = []*models.Collaborator{}
+	for _, c := range current.Collaborators {
+		if c.UserID != collaboratorID {
+			updated.Collaborators = append(updated.Collaborators, c)
+		}
+	}
+	r.publish(ctx, models.EventUpdated, &updated)
+
 	return nil
 }
+
+// Watch - watch todo events service
+func (s *ServiceImpl) Watch(ctx context.Context, userID string, lastEventID string) (<-chan *models.Event, error) {
+	if err := s.policy.Authorize(auth.FromContext(ctx), policy.ActionList); err != nil {
+		return nil, err
+	}
+
+	tenantID := tenant.ID(ctx)
+	events := s.events.Subscribe(ctx, lastEventID, watchBuffer)
+	visible := make(chan *models.Event)
+
+	go func() {
+		// Closing events (ctx done or too far behind) ends the watch, a slow receiver
+		// blocks here until the broker drops it
+		defer close(visible)
+
+		for event := range events {
+			// Resets are made for this subscription only
+			if event.Type != models.EventReset &&
+				(event.TenantID != tenantID || !event.Todo.HasPermission(userID, models.PermissionView)) {
+				continue
+			}
+
+			select {
+			case visible <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return visible, nil
+}
+
+// publish - publish a change of todo in the tenant of ctx
+func (r *ServiceImpl) publish(ctx context.Context, eventType string, todo *models.Todo) {
+	r.events.Publish(&models.Event{
+		Type:     eventType,
+		Todo:     todo,
+		TenantID: tenant.ID(ctx),
+	})
+}
+
+// find - todo by id userID can view, any todo of the tenant when the principal in ctx may moderate
+func (r *ServiceImpl) find(ctx context.Context, userID string, id string) (res *models.Todo, moderated bool, err error) {
+	if r.policy.Authorize(auth.FromContext(ctx), policy.ActionModerate) == nil {
+		res, err = r.repository.FindAnyById(ctx, id)
+		return res, true, err
+	}
+
+	res, err = r.repository.FindById(ctx, userID, id)
+	return res, false, err
+}
+
+// authorizeShare - todo by id when userID may manage its collaborators
+func (r *ServiceImpl) authorizeShare(ctx context.Context, userID string, id string) (*models.Todo, error) {
+	current, err := r.repository.FindById(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !current.HasPermission(userID, models.PermissionOwner) {
+		return nil, errorsutil.ErrForbidden
+	}
+	if err := r.policy.Authorize(auth.FromContext(ctx), policy.ActionShare); err != nil {
+		return nil, err
+	}
+
+	return current, nil
+}
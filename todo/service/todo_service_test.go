@@ -1,17 +1,46 @@
 package service_test
 
 import (
+	"context"
+	"go-clean-architecture/pkg/auth"
+	"go-clean-architecture/pkg/policy"
+	"go-clean-architecture/pkg/quota"
+	"go-clean-architecture/pkg/tenant"
 	mockrepository "go-clean-architecture/todo/mocks/repository"
 	"go-clean-architecture/todo/models"
 	todoservice "go-clean-architecture/todo/service"
+	mockusage "go-clean-architecture/usage/mocks/service"
 	errorsutil "go-clean-architecture/utils/errors"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
 
 var DefaultID string = "1"
+var DefaultOwnerID string = "owner-1"
+
+func newPolicy(t *testing.T) policy.Policy {
+	p, err := policy.New(&policy.Config{Roles: "admin=*,moderate;editor=*;viewer=list,read", DefaultRole: "editor"})
+	assert.NoError(t, err)
+
+	return p
+}
+
+// noQuota - usage service that never rejects
+func noQuota() *mockusage.Service {
+	usage := new(mockusage.Service)
+	usage.On("CheckTodos", mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	usage.On("CheckDescription", mock.Anything, mock.Anything).Return(nil).Maybe()
+
+	return usage
+}
+
+func ownerContext(roles ...string) context.Context {
+	return auth.NewContext(context.Background(), &auth.Principal{Subject: DefaultOwnerID, Roles: roles})
+}
 
 func TestTodoGetAll(t *testing.T) {
 	t.Run("success when find all", func(t *testing.T) {
@@ -19,12 +48,12 @@ func TestTodoGetAll(t *testing.T) {
 		mockList = append(mockList, &models.Todo{})
 
 		mockRepository := new(mockrepository.Repository)
-		service := todoservice.New(mockRepository)
+		service := todoservice.New(mockRepository, newPolicy(t), noQuota(), todoservice.NewBroker(16))
 
-		mockRepository.On("FindAll", mock.AnythingOfType("string"), mock.AnythingOfType("int"), mock.AnythingOfType("int")).Return(mockList, nil)
-		mockRepository.On("CountFindAll", mock.AnythingOfType("string")).Return(10, nil)
+		mockRepository.On("FindAll", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.AnythingOfType("int"), mock.AnythingOfType("int")).Return(mockList, nil)
+		mockRepository.On("CountFindAll", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(10, nil)
 
-		results, count, err := service.GetAll("keyword", 10, 0)
+		results, count, err := service.GetAll(ownerContext(), DefaultOwnerID, "keyword", 10, 0)
 
 		assert.NoError(t, err)
 		assert.Equal(t, count, 10)
@@ -33,11 +62,11 @@ func TestTodoGetAll(t *testing.T) {
 
 	t.Run("error when find all", func(t *testing.T) {
 		mockRepository := new(mockrepository.Repository)
-		service := todoservice.New(mockRepository)
+		service := todoservice.New(mockRepository, newPolicy(t), noQuota(), todoservice.NewBroker(16))
 
-		mockRepository.On("FindAll", mock.AnythingOfType("string"), mock.AnythingOfType("int"), mock.AnythingOfType("int")).Return(nil, errorsutil.ErrDefault)
-		mockRepository.On("CountFindAll", mock.AnythingOfType("string")).Return(10, nil)
-		results, count, err := service.GetAll("keyword", 10, 0)
+		mockRepository.On("FindAll", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.AnythingOfType("int"), mock.AnythingOfType("int")).Return(nil, errorsutil.ErrDefault)
+		mockRepository.On("CountFindAll", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(10, nil)
+		results, count, err := service.GetAll(ownerContext(), DefaultOwnerID, "keyword", 10, 0)
 
 		assert.Nil(t, results)
 		assert.Equal(t, 0, count)
@@ -46,12 +75,12 @@ func TestTodoGetAll(t *testing.T) {
 
 	t.Run("error when count find all", func(t *testing.T) {
 		mockRepository := new(mockrepository.Repository)
-		service := todoservice.New(mockRepository)
+		service := todoservice.New(mockRepository, newPolicy(t), noQuota(), todoservice.NewBroker(16))
 
-		mockRepository.On("FindAll", mock.AnythingOfType("string"), mock.AnythingOfType("int"), mock.AnythingOfType("int")).Return(nil, nil)
-		mockRepository.On("CountFindAll", mock.AnythingOfType("string")).Return(10, errorsutil.ErrDefault)
+		mockRepository.On("FindAll", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.AnythingOfType("int"), mock.AnythingOfType("int")).Return(nil, nil)
+		mockRepository.On("CountFindAll", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(10, errorsutil.ErrDefault)
 
-		results, count, err := service.GetAll("keyword", 10, 0)
+		results, count, err := service.GetAll(ownerContext(), DefaultOwnerID, "keyword", 10, 0)
 
 		assert.Nil(t, results)
 		assert.Equal(t, 0, count)
@@ -64,11 +93,11 @@ func TestTodoGetByID(t *testing.T) {
 		var mockTodo = &models.Todo{}
 
 		mockRepository := new(mockrepository.Repository)
-		service := todoservice.New(mockRepository)
+		service := todoservice.New(mockRepository, newPolicy(t), noQuota(), todoservice.NewBroker(16))
 
-		mockRepository.On("FindById", mock.AnythingOfType("string")).Return(mockTodo, nil)
+		mockRepository.On("FindById", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(mockTodo, nil)
 
-		result, err := service.GetByID(DefaultID)
+		result, err := service.GetByID(ownerContext(), DefaultOwnerID, DefaultID)
 
 		assert.NoError(t, err)
 		assert.Equal(t, mockTodo, result)
@@ -76,10 +105,10 @@ func TestTodoGetByID(t *testing.T) {
 
 	t.Run("error when find by id", func(t *testing.T) {
 		mockRepository := new(mockrepository.Repository)
-		service := todoservice.New(mockRepository)
+		service := todoservice.New(mockRepository, newPolicy(t), noQuota(), todoservice.NewBroker(16))
 
-		mockRepository.On("FindById", mock.AnythingOfType("string")).Return(nil, errorsutil.ErrDefault)
-		result, err := service.GetByID(DefaultID)
+		mockRepository.On("FindById", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(nil, errorsutil.ErrDefault)
+		result, err := service.GetByID(ownerContext(), DefaultOwnerID, DefaultID)
 
 		assert.Nil(t, result)
 		assert.Error(t, err)
@@ -91,26 +120,53 @@ func TestTodoCreate(t *testing.T) {
 		var mockTodo = &models.Todo{}
 
 		mockRepository := new(mockrepository.Repository)
-		service := todoservice.New(mockRepository)
+		service := todoservice.New(mockRepository, newPolicy(t), noQuota(), todoservice.NewBroker(16))
 
-		mockRepository.On("Store", mock.AnythingOfType("*models.Todo")).Return(mockTodo, nil)
+		mockRepository.On("Store", mock.Anything, mock.AnythingOfType("*models.Todo")).Return(mockTodo, nil)
 
-		result, err := service.Create(&models.Todo{})
+		result, err := service.Create(ownerContext(), &models.Todo{})
 
 		assert.NoError(t, err)
 		assert.Equal(t, mockTodo, result)
 	})
 
+	t.Run("success when create keeps the owner", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := todoservice.New(mockRepository, newPolicy(t), noQuota(), todoservice.NewBroker(16))
+
+		mockRepository.On("Store", mock.Anything, &models.Todo{Title: "title", OwnerID: DefaultOwnerID}).Return(&models.Todo{}, nil)
+
+		_, err := service.Create(ownerContext(), &models.Todo{Title: "title", OwnerID: DefaultOwnerID})
+
+		assert.NoError(t, err)
+		mockRepository.AssertExpectations(t)
+	})
+
 	t.Run("error when create", func(t *testing.T) {
 		mockRepository := new(mockrepository.Repository)
-		service := todoservice.New(mockRepository)
+		service := todoservice.New(mockRepository, newPolicy(t), noQuota(), todoservice.NewBroker(16))
 
-		mockRepository.On("Store", mock.AnythingOfType("*models.Todo")).Return(nil, errorsutil.ErrDefault)
-		result, err := service.Create(&models.Todo{})
+		mockRepository.On("Store", mock.Anything, mock.AnythingOfType("*models.Todo")).Return(nil, errorsutil.ErrDefault)
+		result, err := service.Create(ownerContext(), &models.Todo{})
 
 		assert.Nil(t, result)
 		assert.Error(t, err)
 	})
+
+	t.Run("error when create exceeds a quota", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		mockUsage := new(mockusage.Service)
+		service := todoservice.New(mockRepository, newPolicy(t), mockUsage, todoservice.NewBroker(16))
+
+		value := &models.Todo{Title: "title", OwnerID: DefaultOwnerID}
+		mockUsage.On("CheckTodos", mock.Anything, DefaultOwnerID, []*models.Todo{value}).Return(&quota.ExceededError{Quota: quota.Todos, Limit: 10})
+
+		result, err := service.Create(ownerContext(), value)
+
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, errorsutil.ErrQuotaExceeded)
+		mockRepository.AssertNotCalled(t, "Store", mock.Anything, mock.Anything)
+	})
 }
 
 func TestTodoUpdate(t *testing.T) {
@@ -118,25 +174,27 @@ func TestTodoUpdate(t *testing.T) {
 		var mockTodo = &models.Todo{}
 
 		mockRepository := new(mockrepository.Repository)
-		service := todoservice.New(mockRepository)
+		service := todoservice.New(mockRepository, newPolicy(t), noQuota(), todoservice.NewBroker(16))
 
-		mockRepository.On("CountFindByID", mock.AnythingOfType("string")).Return(10, nil)
-		mockRepository.On("Update", mock.AnythingOfType("string"), mock.AnythingOfType("*models.Todo")).Return(mockTodo, nil)
+		mockRepository.On("FindById", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(&models.Todo{OwnerID: DefaultOwnerID}, nil)
+		mockRepository.On("Update", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.AnythingOfType("*models.Todo")).Return(mockTodo, nil)
 
-		result, err := service.Update(DefaultID, &models.Todo{})
+		result, err := service.Update(ownerContext(), DefaultOwnerID, DefaultID, &models.Todo{Title: "title", Description: "description"})
 
 		assert.NoError(t, err)
-		assert.Nil(t, result)
+		assert.Equal(t, "title", result.Title)
+		assert.Equal(t, "description", result.Description)
+		assert.Equal(t, DefaultOwnerID, result.OwnerID)
 	})
 
-	t.Run("error when count find by id", func(t *testing.T) {
+	t.Run("error when find by id", func(t *testing.T) {
 		mockRepository := new(mockrepository.Repository)
-		service := todoservice.New(mockRepository)
+		service := todoservice.New(mockRepository, newPolicy(t), noQuota(), todoservice.NewBroker(16))
 
-		mockRepository.On("CountFindByID", mock.AnythingOfType("string")).Return(0, errorsutil.ErrDefault)
-		mockRepository.On("Update", mock.AnythingOfType("string"), mock.AnythingOfType("*models.Todo")).Return(nil, nil)
+		mockRepository.On("FindById", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(nil, errorsutil.ErrDefault)
+		mockRepository.On("Update", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.AnythingOfType("*models.Todo")).Return(nil, nil)
 
-		result, err := service.Update(DefaultID, &models.Todo{})
+		result, err := service.Update(ownerContext(), DefaultOwnerID, DefaultID, &models.Todo{})
 
 		assert.Nil(t, result)
 		assert.Error(t, err)
@@ -144,38 +202,333 @@ func TestTodoUpdate(t *testing.T) {
 
 	t.Run("error when update", func(t *testing.T) {
 		mockRepository := new(mockrepository.Repository)
-		service := todoservice.New(mockRepository)
+		service := todoservice.New(mockRepository, newPolicy(t), noQuota(), todoservice.NewBroker(16))
 
-		mockRepository.On("CountFindByID", mock.AnythingOfType("string")).Return(10, nil)
-		mockRepository.On("Update", mock.AnythingOfType("string"), mock.AnythingOfType("*models.Todo")).Return(nil, errorsutil.ErrDefault)
+		mockRepository.On("FindById", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(&models.Todo{OwnerID: DefaultOwnerID}, nil)
+		mockRepository.On("Update", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.AnythingOfType("*models.Todo")).Return(nil, errorsutil.ErrDefault)
 
-		result, err := service.Update(DefaultID, &models.Todo{})
+		result, err := service.Update(ownerContext(), DefaultOwnerID, DefaultID, &models.Todo{})
 
 		assert.Nil(t, result)
 		assert.Error(t, err)
 	})
+
+	t.Run("error when the description exceeds its quota", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		mockUsage := new(mockusage.Service)
+		service := todoservice.New(mockRepository, newPolicy(t), mockUsage, todoservice.NewBroker(16))
+
+		mockRepository.On("FindById", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(&models.Todo{OwnerID: DefaultOwnerID}, nil)
+		mockUsage.On("CheckDescription", mock.Anything, "long description").Return(&quota.ExceededError{Quota: quota.DescriptionSize, Limit: 8})
+
+		result, err := service.Update(ownerContext(), DefaultOwnerID, DefaultID, &models.Todo{Description: "long description"})
+
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, errorsutil.ErrQuotaExceeded)
+		mockRepository.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
 }
 
 func TestTodoDelete(t *testing.T) {
 	t.Run("success when delete", func(t *testing.T) {
 		mockRepository := new(mockrepository.Repository)
-		service := todoservice.New(mockRepository)
+		service := todoservice.New(mockRepository, newPolicy(t), noQuota(), todoservice.NewBroker(16))
 
-		mockRepository.On("Delete", mock.AnythingOfType("string")).Return(nil)
+		mockRepository.On("FindById", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(&models.Todo{OwnerID: DefaultOwnerID}, nil)
+		mockRepository.On("Delete", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(nil)
 
-		err := service.Delete(DefaultID)
+		err := service.Delete(ownerContext(), DefaultOwnerID, DefaultID)
 
 		assert.NoError(t, err)
 	})
 
 	t.Run("error when delete", func(t *testing.T) {
 		mockRepository := new(mockrepository.Repository)
-		service := todoservice.New(mockRepository)
+		service := todoservice.New(mockRepository, newPolicy(t), noQuota(), todoservice.NewBroker(16))
 
-		mockRepository.On("Delete", mock.AnythingOfType("string")).Return(errorsutil.ErrDefault)
+		mockRepository.On("FindById", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(&models.Todo{OwnerID: DefaultOwnerID}, nil)
+		mockRepository.On("Delete", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(errorsutil.ErrDefault)
 
-		err := service.Delete(DefaultID)
+		err := service.Delete(ownerContext(), DefaultOwnerID, DefaultID)
 
 		assert.Error(t, err)
 	})
 }
+
+func TestTodoAuthorization(t *testing.T) {
+	t.Run("error when viewer creates", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := todoservice.New(mockRepository, newPolicy(t), noQuota(), todoservice.NewBroker(16))
+
+		_, err := service.Create(ownerContext("viewer"), &models.Todo{Title: "title", OwnerID: DefaultOwnerID})
+
+		assert.ErrorIs(t, err, errorsutil.ErrForbidden)
+		mockRepository.AssertNotCalled(t, "Store", mock.Anything, mock.Anything)
+	})
+
+	t.Run("error when viewer deletes", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := todoservice.New(mockRepository, newPolicy(t), noQuota(), todoservice.NewBroker(16))
+
+		mockRepository.On("FindById", mock.Anything, DefaultOwnerID, DefaultID).Return(&models.Todo{OwnerID: DefaultOwnerID}, nil)
+
+		err := service.Delete(ownerContext("viewer"), DefaultOwnerID, DefaultID)
+
+		assert.ErrorIs(t, err, errorsutil.ErrForbidden)
+		mockRepository.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("success when admin reads a todo of another user", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := todoservice.New(mockRepository, newPolicy(t), noQuota(), todoservice.NewBroker(16))
+
+		mockRepository.On("FindAnyById", mock.Anything, DefaultID).Return(&models.Todo{OwnerID: "user-2"}, nil)
+
+		res, err := service.GetByID(ownerContext("admin"), DefaultOwnerID, DefaultID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "user-2", res.OwnerID)
+		mockRepository.AssertNotCalled(t, "FindById", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("success when admin deletes a todo of another user", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := todoservice.New(mockRepository, newPolicy(t), noQuota(), todoservice.NewBroker(16))
+
+		mockRepository.On("FindAnyById", mock.Anything, DefaultID).Return(&models.Todo{OwnerID: "user-2"}, nil)
+		mockRepository.On("DeleteAny", mock.Anything, DefaultID).Return(nil)
+
+		err := service.Delete(ownerContext("admin"), DefaultOwnerID, DefaultID)
+
+		assert.NoError(t, err)
+		mockRepository.AssertExpectations(t)
+		mockRepository.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("error when editor deletes a todo of another user", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := todoservice.New(mockRepository, newPolicy(t), noQuota(), todoservice.NewBroker(16))
+
+		mockRepository.On("FindById", mock.Anything, DefaultOwnerID, DefaultID).Return(nil, errorsutil.ErrNotFound)
+
+		err := service.Delete(ownerContext("editor"), DefaultOwnerID, DefaultID)
+
+		assert.ErrorIs(t, err, errorsutil.ErrNotFound)
+		mockRepository.AssertNotCalled(t, "FindAnyById", mock.Anything, mock.Anything)
+		mockRepository.AssertNotCalled(t, "DeleteAny", mock.Anything, mock.Anything)
+	})
+
+	t.Run("error when there is no principal", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := todoservice.New(mockRepository, newPolicy(t), noQuota(), todoservice.NewBroker(16))
+
+		_, _, err := service.GetAll(context.Background(), DefaultOwnerID, "", 10, 0)
+
+		assert.ErrorIs(t, err, errorsutil.ErrForbidden)
+	})
+}
+
+func TestTodoSharing(t *testing.T) {
+	shared := func(permission string) *models.Todo {
+		return &models.Todo{OwnerID: "owner-2", Collaborators: []*models.Collaborator{{UserID: DefaultOwnerID, Permission: permission}}}
+	}
+
+	t.Run("success when edit collaborator updates", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := todoservice.New(mockRepository, newPolicy(t), noQuota(), todoservice.NewBroker(16))
+
+		mockRepository.On("FindById", mock.Anything, DefaultOwnerID, DefaultID).Return(shared(models.PermissionEdit), nil)
+		mockRepository.On("Update", mock.Anything, DefaultOwnerID, DefaultID, mock.AnythingOfType("*models.Todo")).Return(&models.Todo{}, nil)
+
+		_, err := service.Update(ownerContext(), DefaultOwnerID, DefaultID, &models.Todo{Title: "title"})
+
+		assert.NoError(t, err)
+		mockRepository.AssertExpectations(t)
+	})
+
+	t.Run("error when view collaborator updates", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := todoservice.New(mockRepository, newPolicy(t), noQuota(), todoservice.NewBroker(16))
+
+		mockRepository.On("FindById", mock.Anything, DefaultOwnerID, DefaultID).Return(shared(models.PermissionView), nil)
+
+		_, err := service.Update(ownerContext(), DefaultOwnerID, DefaultID, &models.Todo{Title: "title"})
+
+		assert.ErrorIs(t, err, errorsutil.ErrForbidden)
+		mockRepository.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("success when owner collaborator deletes", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := todoservice.New(mockRepository, newPolicy(t), noQuota(), todoservice.NewBroker(16))
+
+		mockRepository.On("FindById", mock.Anything, DefaultOwnerID, DefaultID).Return(shared(models.PermissionOwner), nil)
+		mockRepository.On("Delete", mock.Anything, DefaultOwnerID, DefaultID).Return(nil)
+
+		err := service.Delete(ownerContext(), DefaultOwnerID, DefaultID)
+
+		assert.NoError(t, err)
+		mockRepository.AssertExpectations(t)
+	})
+
+	t.Run("error when edit collaborator deletes", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := todoservice.New(mockRepository, newPolicy(t), noQuota(), todoservice.NewBroker(16))
+
+		mockRepository.On("FindById", mock.Anything, DefaultOwnerID, DefaultID).Return(shared(models.PermissionEdit), nil)
+
+		err := service.Delete(ownerContext(), DefaultOwnerID, DefaultID)
+
+		assert.ErrorIs(t, err, errorsutil.ErrForbidden)
+	})
+
+	t.Run("success when owner invites", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := todoservice.New(mockRepository, newPolicy(t), noQuota(), todoservice.NewBroker(16))
+
+		collaborator := &models.Collaborator{UserID: "user-3", Permission: models.PermissionView}
+		mockRepository.On("FindById", mock.Anything, DefaultOwnerID, DefaultID).Return(&models.Todo{OwnerID: DefaultOwnerID}, nil)
+		mockRepository.On("AddCollaborator", mock.Anything, DefaultOwnerID, DefaultID, collaborator).Return(nil)
+
+		err := service.AddCollaborator(ownerContext(), DefaultOwnerID, DefaultID, collaborator)
+
+		assert.NoError(t, err)
+		mockRepository.AssertExpectations(t)
+	})
+
+	t.Run("error when inviting an existing collaborator", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := todoservice.New(mockRepository, newPolicy(t), noQuota(), todoservice.NewBroker(16))
+
+		mockRepository.On("FindById", mock.Anything, DefaultOwnerID, DefaultID).Return(&models.Todo{OwnerID: DefaultOwnerID, Collaborators: []*models.Collaborator{{UserID: "user-3", Permission: models.PermissionView}}}, nil)
+
+		err := service.AddCollaborator(ownerContext(), DefaultOwnerID, DefaultID, &models.Collaborator{UserID: "user-3", Permission: models.PermissionEdit})
+
+		assert.ErrorIs(t, err, errorsutil.ErrAlreadyExists)
+	})
+
+	t.Run("error when edit collaborator removes a collaborator", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := todoservice.New(mockRepository, newPolicy(t), noQuota(), todoservice.NewBroker(16))
+
+		mockRepository.On("FindById", mock.Anything, DefaultOwnerID, DefaultID).Return(shared(models.PermissionEdit), nil)
+
+		err := service.RemoveCollaborator(ownerContext(), DefaultOwnerID, DefaultID, "user-3")
+
+		assert.ErrorIs(t, err, errorsutil.ErrForbidden)
+		mockRepository.AssertNotCalled(t, "RemoveCollaborator", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("error when viewer role shares", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := todoservice.New(mockRepository, newPolicy(t), noQuota(), todoservice.NewBroker(16))
+
+		mockRepository.On("FindById", mock.Anything, DefaultOwnerID, DefaultID).Return(&models.Todo{OwnerID: DefaultOwnerID}, nil)
+
+		err := service.UpdateCollaborator(ownerContext("viewer"), DefaultOwnerID, DefaultID, &models.Collaborator{UserID: "user-3", Permission: models.PermissionEdit})
+
+		assert.ErrorIs(t, err, errorsutil.ErrForbidden)
+	})
+}
+
+// receive - next event of events, nil when none arrives in time
+func receive(events <-chan *models.Event) *models.Event {
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(time.Second):
+		return nil
+	}
+}
+
+func TestTodoWatch(t *testing.T) {
+	t.Run("success when watching changes", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := todoservice.New(mockRepository, newPolicy(t), noQuota(), todoservice.NewBroker(16))
+
+		created := &models.Todo{Title: "title", OwnerID: DefaultOwnerID}
+		mockRepository.On("Store", mock.Anything, mock.AnythingOfType("*models.Todo")).Return(created, nil)
+		mockRepository.On("FindById", mock.Anything, DefaultOwnerID, DefaultID).Return(created, nil)
+		mockRepository.On("Update", mock.Anything, DefaultOwnerID, DefaultID, mock.AnythingOfType("*models.Todo")).Return(created, nil)
+		mockRepository.On("Delete", mock.Anything, DefaultOwnerID, DefaultID).Return(nil)
+
+		ctx, cancel := context.WithCancel(ownerContext())
+		events, err := service.Watch(ctx, DefaultOwnerID, "")
+		assert.NoError(t, err)
+
+		_, err = service.Create(ownerContext(), &models.Todo{Title: "title", OwnerID: DefaultOwnerID})
+		assert.NoError(t, err)
+		_, err = service.Update(ownerContext(), DefaultOwnerID, DefaultID, &models.Todo{Title: "changed"})
+		assert.NoError(t, err)
+		assert.NoError(t, service.Delete(ownerContext(), DefaultOwnerID, DefaultID))
+
+		event := receive(events)
+		assert.Equal(t, models.EventCreated, event.Type)
+		assert.True(t, strings.HasSuffix(event.ID, "-1"))
+		event = receive(events)
+		assert.Equal(t, models.EventUpdated, event.Type)
+		assert.Equal(t, "changed", event.Todo.Title)
+		event = receive(events)
+		assert.Equal(t, models.EventDeleted, event.Type)
+
+		cancel()
+		_, open := <-events
+		assert.False(t, open)
+	})
+
+	t.Run("success when skipping todos the caller cannot see", func(t *testing.T) {
+		broker := todoservice.NewBroker(16)
+		service := todoservice.New(new(mockrepository.Repository), newPolicy(t), noQuota(), broker)
+
+		ctx, cancel := context.WithCancel(tenant.NewContext(ownerContext(), &tenant.Tenant{ID: "acme"}))
+		defer cancel()
+		events, err := service.Watch(ctx, DefaultOwnerID, "")
+		assert.NoError(t, err)
+
+		broker.Publish(&models.Event{Type: models.EventCreated, Todo: &models.Todo{OwnerID: "user-2"}, TenantID: "acme"})
+		broker.Publish(&models.Event{Type: models.EventCreated, Todo: &models.Todo{OwnerID: DefaultOwnerID}, TenantID: "other"})
+		broker.Publish(&models.Event{Type: models.EventUpdated, Todo: &models.Todo{OwnerID: "owner-2", Collaborators: []*models.Collaborator{{UserID: DefaultOwnerID, Permission: models.PermissionView}}}, TenantID: "acme"})
+
+		event := receive(events)
+		assert.Equal(t, models.EventUpdated, event.Type)
+		assert.True(t, strings.HasSuffix(event.ID, "-3"))
+	})
+
+	t.Run("success when resuming after the last event received", func(t *testing.T) {
+		broker := todoservice.NewBroker(16)
+		service := todoservice.New(new(mockrepository.Repository), newPolicy(t), noQuota(), broker)
+
+		first := &models.Event{Type: models.EventCreated, Todo: &models.Todo{OwnerID: DefaultOwnerID}}
+		broker.Publish(first)
+		broker.Publish(&models.Event{Type: models.EventCreated, Todo: &models.Todo{OwnerID: "user-2"}})
+		broker.Publish(&models.Event{Type: models.EventDeleted, Todo: &models.Todo{OwnerID: DefaultOwnerID}})
+
+		ctx, cancel := context.WithCancel(ownerContext())
+		defer cancel()
+		events, err := service.Watch(ctx, DefaultOwnerID, first.ID)
+		assert.NoError(t, err)
+
+		event := receive(events)
+		assert.Equal(t, models.EventDeleted, event.Type)
+		assert.True(t, strings.HasSuffix(event.ID, "-3"))
+	})
+
+	t.Run("success when the last event received is unknown", func(t *testing.T) {
+		service := todoservice.New(new(mockrepository.Repository), newPolicy(t), noQuota(), todoservice.NewBroker(16))
+
+		ctx, cancel := context.WithCancel(ownerContext())
+		defer cancel()
+		events, err := service.Watch(ctx, DefaultOwnerID, "earlier-run-42")
+		assert.NoError(t, err)
+
+		assert.Equal(t, models.EventReset, receive(events).Type)
+	})
+
+	t.Run("error when policy denies listing", func(t *testing.T) {
+		service := todoservice.New(new(mockrepository.Repository), newPolicy(t), noQuota(), todoservice.NewBroker(16))
+
+		_, err := service.Watch(context.Background(), DefaultOwnerID, "")
+
+		assert.ErrorIs(t, err, errorsutil.ErrForbidden)
+	})
+}
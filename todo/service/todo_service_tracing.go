@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+
+	pkgtracing "go-clean-architecture/pkg/tracing"
+	"go-clean-architecture/todo/models"
+)
+
+type TracingService struct {
+	next Service
+}
+
+// WithTracing will wrap a Service and start a span for every method
+func WithTracing(next Service) Service {
+	return &TracingService{
+		next: next,
+	}
+}
+
+// GetAll - get all todo service
+func (s *TracingService) GetAll(ctx context.Context, userID string, keyword string, limit int, offset int) (res []*models.Todo, total int, err error) {
+	ctx, span := pkgtracing.Start(ctx, "todo.Service/GetAll")
+	defer pkgtracing.End(span, &err)
+
+	return s.next.GetAll(ctx, userID, keyword, limit, offset)
+}
+
+// GetByID - get todo by id service
+func (s *TracingService) GetByID(ctx context.Context, userID string, id string) (res *models.Todo, err error) {
+	ctx, span := pkgtracing.Start(ctx, "todo.Service/GetByID")
+	defer pkgtracing.End(span, &err)
+
+	return s.next.GetByID(ctx, userID, id)
+}
+
+// Create - creating todo service
+func (s *TracingService) Create(ctx context.Context, value *models.Todo) (res *models.Todo, err error) {
+	ctx, span := pkgtracing.Start(ctx, "todo.Service/Create")
+	defer pkgtracing.End(span, &err)
+
+	return s.next.Create(ctx, value)
+}
+
+// Update - update todo service
+func (s *TracingService) Update(ctx context.Context, userID string, id string, value *models.Todo) (res *models.Todo, err error) {
+	ctx, span := pkgtracing.Start(ctx, "todo.Service/Update")
+	defer pkgtracing.End(span, &err)
+
+	return s.next.Update(ctx, userID, id, value)
+}
+
+// Delete - delete todo service
+func (s *TracingService) Delete(ctx context.Context, userID string, id string) (err error) {
+	ctx, span := pkgtracing.Start(ctx, "todo.Service/Delete")
+	defer pkgtracing.End(span, &err)
+
+	return s.next.Delete(ctx, userID, id)
+}
+
+// AddCollaborator - share todo service
+func (s *TracingService) AddCollaborator(ctx context.Context, userID string, id string, value *models.Collaborator) (err error) {
+	ctx, span := pkgtracing.Start(ctx, "todo.Service/AddCollaborator")
+	defer pkgtracing.End(span, &err)
+
+	return s.next.AddCollaborator(ctx, userID, id, value)
+}
+
+// UpdateCollaborator - change collaborator permission service
+func (s *TracingService) UpdateCollaborator(ctx context.Context, userID string, id string, value *models.Collaborator) (err error) {
+	ctx, span := pkgtracing.Start(ctx, "todo.Service/UpdateCollaborator")
+	defer pkgtracing.End(span, &err)
+
+	return s.next.UpdateCollaborator(ctx, userID, id, value)
+}
+
+// RemoveCollaborator - unshare todo service
+func (s *TracingService) RemoveCollaborator(ctx context.Context, userID string, id string, collaboratorID string) (err error) {
+	ctx, span := pkgtracing.Start(ctx, "todo.Service/RemoveCollaborator")
+	defer pkgtracing.End(span, &err)
+
+	return s.next.RemoveCollaborator(ctx, userID, id, collaboratorID)
+}
+
+// Watch - watch todo events service
+func (s *TracingService) Watch(ctx context.Context, userID string, lastEventID string) (res <-chan *models.Event, err error) {
+	ctx, span := pkgtracing.Start(ctx, "todo.Service/Watch")
+	defer pkgtracing.End(span, &err)
+
+	return s.next.Watch(ctx, userID, lastEventID)
+}
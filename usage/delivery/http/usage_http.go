@@ -0,0 +1,80 @@
+package httpdelivery
+
+import (
+	"errors"
+	"net/http"
+
+	"go-clean-architecture/pkg/auth"
+	"go-clean-architecture/pkg/logger"
+	"go-clean-architecture/pkg/quota"
+	usageservice "go-clean-architecture/usage/service"
+	responseutil "go-clean-architecture/utils/response"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type HTTPHandler interface {
+	RegisterRoutes(router chi.Router)
+	Get(w http.ResponseWriter, r *http.Request)
+	CountRequests(next http.Handler) http.Handler
+}
+
+type HTTPHandlerImpl struct {
+	service usageservice.Service
+}
+
+// New - make http handler
+func New(service usageservice.Service) HTTPHandler {
+	return &HTTPHandlerImpl{
+		service: service,
+	}
+}
+
+// RegisterRoutes - usage is reported to any caller resolved by auth.Middleware, it never counts as a request
+func (h *HTTPHandlerImpl) RegisterRoutes(router chi.Router) {
+	router.Group(func(r chi.Router) {
+		r.Use(auth.Require)
+		r.Use(responseutil.Negotiate(responseutil.Formats...))
+
+		r.Get("/usage", h.Get)
+	})
+}
+
+// Get - get usage http handler
+func (h *HTTPHandlerImpl) Get(w http.ResponseWriter, r *http.Request) {
+	result, err := h.service.Get(r.Context(), auth.UserID(r))
+	if err != nil {
+		responseutil.ResponseError(w, r, err)
+		return
+	}
+
+	responseutil.ResponseOK(w, r, &responseutil.ResponseSuccess{
+		Data: result,
+	})
+}
+
+// CountRequests - middleware counting the requests of the caller towards their daily quota, requests over it
+// are rejected with 429. Anonymous requests are not counted and counter failures never reject a request
+func (h *HTTPHandlerImpl) CountRequests(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		userID := auth.UserID(r)
+		if userID == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		err := h.service.CountRequest(r.Context(), userID)
+		exceeded := &quota.ExceededError{}
+		if errors.As(err, &exceeded) {
+			responseutil.ResponseQuotaExceeded(w, r, exceeded.Message(), exceeded.RetryAfter)
+			return
+		}
+		if err != nil {
+			logger.ErrorContext(r.Context(), err)
+		}
+
+		next.ServeHTTP(w, r)
+	}
+
+	return http.HandlerFunc(fn)
+}
@@ -0,0 +1,113 @@
+package httpdelivery_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go-clean-architecture/pkg/auth"
+	"go-clean-architecture/pkg/quota"
+	usagedelivery "go-clean-architecture/usage/delivery/http"
+	"go-clean-architecture/usage/models"
+	errorsutil "go-clean-architecture/utils/errors"
+
+	mockservice "go-clean-architecture/usage/mocks/service"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var user = &auth.Principal{Subject: "user-1"}
+
+func serve(handler http.Handler, principal *auth.Principal, method string, target string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	if principal != nil {
+		req = req.WithContext(auth.NewContext(req.Context(), principal))
+	}
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	return rr
+}
+
+func newRouter(service *mockservice.Service) chi.Router {
+	router := chi.NewMux()
+	usagedelivery.New(service).RegisterRoutes(router)
+	return router
+}
+
+func TestUsageGet(t *testing.T) {
+	t.Run("when return 401 unauthorized (anonymous)", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+
+		rr := serve(newRouter(mockService), nil, http.MethodGet, "/usage")
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		mockService.AssertExpectations(t)
+	})
+	t.Run("when return 200 ok", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+		mockService.On("Get", mock.Anything, "user-1").Return(&models.Usage{
+			Scope:    "user",
+			Todos:    &models.Quota{Used: 4, Limit: 10},
+			Requests: &models.Quota{Used: 12, Limit: 100},
+		}, nil)
+
+		rr := serve(newRouter(mockService), user, http.MethodGet, "/usage")
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"todos":{"used":4,"limit":10}`)
+		mockService.AssertExpectations(t)
+	})
+	t.Run("when return 500 internal error (error service)", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+		mockService.On("Get", mock.Anything, "user-1").Return(nil, errorsutil.ErrDefault)
+
+		rr := serve(newRouter(mockService), user, http.MethodGet, "/usage")
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
+}
+
+func TestUsageCountRequests(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	t.Run("when anonymous", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+
+		rr := serve(usagedelivery.New(mockService).CountRequests(ok), nil, http.MethodGet, "/todo")
+
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		mockService.AssertExpectations(t)
+	})
+	t.Run("when within the quota", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+		mockService.On("CountRequest", mock.Anything, "user-1").Return(nil)
+
+		rr := serve(usagedelivery.New(mockService).CountRequests(ok), user, http.MethodGet, "/todo")
+
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		mockService.AssertExpectations(t)
+	})
+	t.Run("when return 429 too many requests (quota used up)", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+		mockService.On("CountRequest", mock.Anything, "user-1").Return(&quota.ExceededError{Quota: quota.Requests, Limit: 100, RetryAfter: time.Hour})
+
+		rr := serve(usagedelivery.New(mockService).CountRequests(ok), user, http.MethodGet, "/todo")
+
+		assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+		assert.Equal(t, "3600", rr.Header().Get("Retry-After"))
+	})
+	t.Run("when the counter fails", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+		mockService.On("CountRequest", mock.Anything, "user-1").Return(errorsutil.ErrDefault)
+
+		rr := serve(usagedelivery.New(mockService).CountRequests(ok), user, http.MethodGet, "/todo")
+
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+	})
+}
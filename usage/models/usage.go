@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// Counter - todo API requests of a quota subject on a UTC day
+type Counter struct {
+	Subject  string    `bson:"subject"`
+	Day      time.Time `bson:"day"`
+	Requests int       `bson:"requests"`
+	// ExpiresAt - counters of past days are removed by a TTL index
+	ExpiresAt time.Time `bson:"expiresAt"`
+}
+
+// Quota - usage against a limit, a zero Limit means unlimited
+type Quota struct {
+	Used  int `json:"used" xml:"used"`
+	Limit int `json:"limit" xml:"limit"`
+}
+
+// Usage - usage of the caller and the quotas applied to them
+type Usage struct {
+	// Scope - "user" when the quotas are the caller's own, "tenant" when the users of the tenant share them
+	Scope              string `json:"scope" xml:"scope"`
+	Todos              *Quota `json:"todos" xml:"todos"`
+	Requests           *Quota `json:"requests" xml:"requests"`
+	MaxDescriptionSize int    `json:"max_description_size" xml:"max_description_size"`
+	// ResetAt - when the daily request count starts over
+	ResetAt time.Time `json:"reset_at" xml:"reset_at"`
+}
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"go-clean-architecture/pkg/logger"
+	pkgmongodb "go-clean-architecture/pkg/mongodb"
+	"go-clean-architecture/usage/models"
+)
+
+// counterTTL - how long a daily counter is kept after its day started
+const counterTTL = 48 * time.Hour
+
+type Repository interface {
+	// Increment - count a request of subject on day and return the requests of that day so far
+	Increment(ctx context.Context, subject string, day time.Time) (int, error)
+	// Requests - requests of subject on day, zero when none were counted
+	Requests(ctx context.Context, subject string, day time.Time) (int, error)
+}
+
+type RepositoryImpl struct {
+	databases *pkgmongodb.Databases
+	log       logger.Logger
+}
+
+// New will create an object that represent the Repository interface
+func New(databases *pkgmongodb.Databases) Repository {
+	return &RepositoryImpl{
+		databases: databases,
+		log:       logger.Named("usage/repository"),
+	}
+}
+
+// collection - usage collection of the database of the tenant of ctx
+func (r *RepositoryImpl) collection(ctx context.Context) (*mongo.Collection, error) {
+	db, err := r.databases.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return db.Collection("usage"), nil
+}
+
+// Indexes - create the counter and expiry indexes, provisions every database
+func Indexes(ctx context.Context, db *mongo.Database) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	_, err := db.Collection("usage").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "subject", Value: 1}, {Key: "day", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "expiresAt", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	return err
+}
+
+// Increment - increment the request counter of subject
+func (r *RepositoryImpl) Increment(ctx context.Context, subject string, day time.Time) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	collection, err := r.collection(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	increment := func() (*models.Counter, error) {
+		result := &models.Counter{}
+		err := collection.FindOneAndUpdate(ctx,
+			bson.M{"subject": subject, "day": day},
+			bson.M{"$inc": bson.M{"requests": 1}, "$setOnInsert": bson.M{"expiresAt": day.Add(counterTTL)}},
+			options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+		).Decode(result)
+		return result, err
+	}
+
+	result, err := increment()
+	if mongo.IsDuplicateKeyError(err) {
+		// Another request created the counter of the day first, it exists now
+		result, err = increment()
+	}
+	if err != nil {
+		r.logError(ctx, "Increment", err)
+		return 0, err
+	}
+
+	return result.Requests, nil
+}
+
+// Requests - find the request counter of subject
+func (r *RepositoryImpl) Requests(ctx context.Context, subject string, day time.Time) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	collection, err := r.collection(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	result := &models.Counter{}
+	err = collection.FindOne(ctx, bson.M{"subject": subject, "day": day}).Decode(result)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return 0, nil
+		}
+
+		r.logError(ctx, "Requests", err)
+		return 0, err
+	}
+
+	return result.Requests, nil
+}
+
+// logError - log a database error with the request context of ctx
+func (r *RepositoryImpl) logError(ctx context.Context, operation string, err error) {
+	r.log.WithContext(ctx).Error("database operation failed", "operation", operation, "error", err)
+}
@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	pkgmetrics "go-clean-architecture/pkg/metrics"
+)
+
+type MetricsRepository struct {
+	next Repository
+}
+
+// WithMetrics will wrap a Repository and record the latency of every method
+func WithMetrics(next Repository) Repository {
+	return &MetricsRepository{
+		next: next,
+	}
+}
+
+// Increment - increment the request counter of subject
+func (r *MetricsRepository) Increment(ctx context.Context, subject string, day time.Time) (total int, err error) {
+	defer pkgmetrics.ObserveRepository("usage", "Increment", time.Now(), &err)
+
+	return r.next.Increment(ctx, subject, day)
+}
+
+// Requests - find the request counter of subject
+func (r *MetricsRepository) Requests(ctx context.Context, subject string, day time.Time) (total int, err error) {
+	defer pkgmetrics.ObserveRepository("usage", "Requests", time.Now(), &err)
+
+	return r.next.Requests(ctx, subject, day)
+}
@@ -0,0 +1,116 @@
+package repository_test
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	pkgmongodb "go-clean-architecture/pkg/mongodb"
+	"go-clean-architecture/usage/repository"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestMain(m *testing.M) {
+	// See todo/repository: mtest needs a cluster, skip in short mode
+	flag.Parse()
+	if testing.Short() {
+		log.Print("skipping mtest integration test in short mode")
+		return
+	}
+
+	if err := mtest.Setup(); err != nil {
+		log.Fatal(err)
+	}
+	defer os.Exit(m.Run())
+	if err := mtest.Teardown(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+var day = time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+
+func TestUsageIncrement(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("when success", func(mt *mtest.T) {
+		repo := repository.New(pkgmongodb.NewDatabases(mt.Client, "test"))
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "value", Value: bson.D{
+			{Key: "subject", Value: "user-1"},
+			{Key: "day", Value: day},
+			{Key: "requests", Value: 3},
+		}}))
+
+		total, err := repo.Increment(context.Background(), "user-1", day)
+
+		assert.NoError(mt, err)
+		assert.Equal(mt, 3, total)
+	})
+
+	mt.Run("when the counter was created concurrently", func(mt *mtest.T) {
+		repo := repository.New(pkgmongodb.NewDatabases(mt.Client, "test"))
+		mt.AddMockResponses(
+			mtest.CreateCommandErrorResponse(mtest.CommandError{Code: 11000, Message: "duplicate key error"}),
+			mtest.CreateSuccessResponse(bson.E{Key: "value", Value: bson.D{{Key: "requests", Value: 2}}}),
+		)
+
+		total, err := repo.Increment(context.Background(), "user-1", day)
+
+		assert.NoError(mt, err)
+		assert.Equal(mt, 2, total)
+	})
+
+	mt.Run("when error", func(mt *mtest.T) {
+		repo := repository.New(pkgmongodb.NewDatabases(mt.Client, "test"))
+		mt.AddMockResponses(mtest.CreateCommandErrorResponse(mtest.CommandError{Code: 1, Message: "failed"}))
+
+		_, err := repo.Increment(context.Background(), "user-1", day)
+
+		assert.Error(mt, err)
+	})
+}
+
+func TestUsageRequests(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("when success", func(mt *mtest.T) {
+		repo := repository.New(pkgmongodb.NewDatabases(mt.Client, "test"))
+		mt.AddMockResponses(mtest.CreateCursorResponse(1, "test.usage", mtest.FirstBatch, bson.D{
+			{Key: "subject", Value: "user-1"},
+			{Key: "requests", Value: 7},
+		}))
+
+		total, err := repo.Requests(context.Background(), "user-1", day)
+
+		assert.NoError(mt, err)
+		assert.Equal(mt, 7, total)
+	})
+
+	mt.Run("when no request was counted", func(mt *mtest.T) {
+		repo := repository.New(pkgmongodb.NewDatabases(mt.Client, "test"))
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "test.usage", mtest.FirstBatch))
+
+		total, err := repo.Requests(context.Background(), "user-1", day)
+
+		assert.NoError(mt, err)
+		assert.Equal(mt, 0, total)
+	})
+
+	mt.Run("when error", func(mt *mtest.T) {
+		repo := repository.New(pkgmongodb.NewDatabases(mt.Client, "test"))
+		mt.AddMockResponses(mtest.CreateCommandErrorResponse(mtest.CommandError{Code: 1, Message: "failed"}))
+
+		_, err := repo.Requests(context.Background(), "user-1", day)
+
+		assert.Error(mt, err)
+		assert.NotErrorIs(mt, err, mongo.ErrNoDocuments)
+	})
+}
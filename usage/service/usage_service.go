@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"go-clean-architecture/pkg/quota"
+	todomodels "go-clean-architecture/todo/models"
+	todorepository "go-clean-architecture/todo/repository"
+	"go-clean-architecture/usage/models"
+	usagerepository "go-clean-architecture/usage/repository"
+)
+
+// Service represent the usage service
+type Service interface {
+	// Get - usage of userID against the quotas of the tenant of ctx
+	Get(ctx context.Context, userID string) (*models.Usage, error)
+	// CountRequest - count a todo API request of userID, *quota.ExceededError once the requests of the day are used up
+	CountRequest(ctx context.Context, userID string) error
+	// CheckTodos - *quota.ExceededError when storing todos for ownerID would exceed a quota, bulk paths pass
+	// every todo at once. The check is not atomic with the store, concurrent creates may overshoot slightly
+	CheckTodos(ctx context.Context, ownerID string, todos []*todomodels.Todo) error
+	// CheckDescription - *quota.ExceededError when description is larger than allowed
+	CheckDescription(ctx context.Context, description string) error
+}
+
+type ServiceImpl struct {
+	repository     usagerepository.Repository
+	todoRepository todorepository.Repository
+	now            func() time.Time
+}
+
+// New will create new an ServiceImpl object representation of Service interface,
+// limits are read per tenant on every call so overrides apply without a restart
+func New(repository usagerepository.Repository, todoRepository todorepository.Repository) Service {
+	return &ServiceImpl{
+		repository:     repository,
+		todoRepository: todoRepository,
+		now:            time.Now,
+	}
+}
+
+// Get - get usage service
+func (s *ServiceImpl) Get(ctx context.Context, userID string) (*models.Usage, error) {
+	limits := quota.LoadLimits(ctx)
+	subject := limits.Subject(userID)
+	day := quota.Day(s.now())
+
+	todos, err := s.todoRepository.CountOwned(ctx, subject)
+	if err != nil {
+		return nil, err
+	}
+
+	requests, err := s.repository.Requests(ctx, subject, day)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.Usage{
+		Scope:              string(limits.Scope),
+		Todos:              &models.Quota{Used: todos, Limit: limits.MaxTodos},
+		Requests:           &models.Quota{Used: requests, Limit: limits.RequestsPerDay},
+		MaxDescriptionSize: limits.MaxDescriptionSize,
+		ResetAt:            day.AddDate(0, 0, 1),
+	}, nil
+}
+
+// CountRequest - count request service
+func (s *ServiceImpl) CountRequest(ctx context.Context, userID string) error {
+	limits := quota.LoadLimits(ctx)
+	now := s.now()
+	day := quota.Day(now)
+
+	requests, err := s.repository.Increment(ctx, limits.Subject(userID), day)
+	if err != nil {
+		return err
+	}
+
+	if limits.RequestsPerDay > 0 && requests > limits.RequestsPerDay {
+		return &quota.ExceededError{
+			Quota:      quota.Requests,
+			Limit:      limits.RequestsPerDay,
+			RetryAfter: day.AddDate(0, 0, 1).Sub(now),
+		}
+	}
+
+	return nil
+}
+
+// CheckTodos - check todo quotas service
+func (s *ServiceImpl) CheckTodos(ctx context.Context, ownerID string, todos []*todomodels.Todo) error {
+	limits := quota.LoadLimits(ctx)
+
+	for _, todo := range todos {
+		if err := checkDescription(limits, todo.Description); err != nil {
+			return err
+		}
+	}
+
+	if limits.MaxTodos <= 0 {
+		return nil
+	}
+
+	stored, err := s.todoRepository.CountOwned(ctx, limits.Subject(ownerID))
+	if err != nil {
+		return err
+	}
+
+	if stored+len(todos) > limits.MaxTodos {
+		return &quota.ExceededError{Quota: quota.Todos, Limit: limits.MaxTodos}
+	}
+
+	return nil
+}
+
+// CheckDescription - check description size service
+func (s *ServiceImpl) CheckDescription(ctx context.Context, description string) error {
+	return checkDescription(quota.LoadLimits(ctx), description)
+}
+
+func checkDescription(limits *quota.Limits, description string) error {
+	if limits.MaxDescriptionSize > 0 && len(description) > limits.MaxDescriptionSize {
+		return &quota.ExceededError{Quota: quota.DescriptionSize, Limit: limits.MaxDescriptionSize}
+	}
+
+	return nil
+}
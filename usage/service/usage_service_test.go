@@ -0,0 +1,174 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go-clean-architecture/pkg/quota"
+	"go-clean-architecture/pkg/tenant"
+	todomocks "go-clean-architecture/todo/mocks/repository"
+	todomodels "go-clean-architecture/todo/models"
+	mockrepository "go-clean-architecture/usage/mocks/repository"
+	usageservice "go-clean-architecture/usage/service"
+	errorsutil "go-clean-architecture/utils/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestUsageGet(t *testing.T) {
+	t.Setenv("QUOTA_MAX_TODOS", "10")
+	t.Setenv("QUOTA_REQUESTS_PER_DAY", "100")
+
+	t.Run("when scoped to the user", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		mockTodoRepository := new(todomocks.Repository)
+		service := usageservice.New(mockRepository, mockTodoRepository)
+
+		mockTodoRepository.On("CountOwned", mock.Anything, "user-1").Return(4, nil)
+		mockRepository.On("Requests", mock.Anything, "user-1", quota.Day(time.Now())).Return(12, nil)
+
+		result, err := service.Get(context.Background(), "user-1")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "user", result.Scope)
+		assert.Equal(t, 4, result.Todos.Used)
+		assert.Equal(t, 10, result.Todos.Limit)
+		assert.Equal(t, 12, result.Requests.Used)
+		assert.Equal(t, 100, result.Requests.Limit)
+		assert.Equal(t, quota.Day(time.Now()).AddDate(0, 0, 1), result.ResetAt)
+	})
+
+	t.Run("when the tenant shares the quotas", func(t *testing.T) {
+		t.Setenv("TENANT_ACME_QUOTA_SCOPE", "tenant")
+		t.Setenv("TENANT_ACME_QUOTA_MAX_TODOS", "1000")
+
+		mockRepository := new(mockrepository.Repository)
+		mockTodoRepository := new(todomocks.Repository)
+		service := usageservice.New(mockRepository, mockTodoRepository)
+
+		mockTodoRepository.On("CountOwned", mock.Anything, "").Return(40, nil)
+		mockRepository.On("Requests", mock.Anything, "", mock.Anything).Return(120, nil)
+
+		ctx := tenant.NewContext(context.Background(), &tenant.Tenant{ID: "acme"})
+		result, err := service.Get(ctx, "user-1")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "tenant", result.Scope)
+		assert.Equal(t, 40, result.Todos.Used)
+		assert.Equal(t, 1000, result.Todos.Limit)
+		assert.Equal(t, 100, result.Requests.Limit)
+	})
+
+	t.Run("when error", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		mockTodoRepository := new(todomocks.Repository)
+		service := usageservice.New(mockRepository, mockTodoRepository)
+
+		mockTodoRepository.On("CountOwned", mock.Anything, "user-1").Return(0, errorsutil.ErrDefault)
+
+		result, err := service.Get(context.Background(), "user-1")
+
+		assert.Nil(t, result)
+		assert.Error(t, err)
+	})
+}
+
+func TestUsageCountRequest(t *testing.T) {
+	t.Setenv("QUOTA_REQUESTS_PER_DAY", "100")
+
+	t.Run("when within the quota", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := usageservice.New(mockRepository, new(todomocks.Repository))
+
+		mockRepository.On("Increment", mock.Anything, "user-1", mock.Anything).Return(100, nil)
+
+		assert.NoError(t, service.CountRequest(context.Background(), "user-1"))
+	})
+
+	t.Run("when the quota is used up", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := usageservice.New(mockRepository, new(todomocks.Repository))
+
+		mockRepository.On("Increment", mock.Anything, "user-1", mock.Anything).Return(101, nil)
+
+		err := service.CountRequest(context.Background(), "user-1")
+
+		assert.ErrorIs(t, err, errorsutil.ErrQuotaExceeded)
+		exceeded := &quota.ExceededError{}
+		assert.True(t, errors.As(err, &exceeded))
+		assert.Equal(t, quota.Requests, exceeded.Quota)
+		assert.Greater(t, exceeded.RetryAfter, time.Duration(0))
+		assert.LessOrEqual(t, exceeded.RetryAfter, 24*time.Hour)
+	})
+
+	t.Run("when unlimited", func(t *testing.T) {
+		t.Setenv("QUOTA_REQUESTS_PER_DAY", "0")
+
+		mockRepository := new(mockrepository.Repository)
+		service := usageservice.New(mockRepository, new(todomocks.Repository))
+
+		mockRepository.On("Increment", mock.Anything, "user-1", mock.Anything).Return(100000, nil)
+
+		assert.NoError(t, service.CountRequest(context.Background(), "user-1"))
+	})
+}
+
+func TestUsageCheckTodos(t *testing.T) {
+	t.Setenv("QUOTA_MAX_TODOS", "10")
+	t.Setenv("QUOTA_MAX_DESCRIPTION_SIZE", "8")
+
+	todos := func(n int) []*todomodels.Todo {
+		result := []*todomodels.Todo{}
+		for i := 0; i < n; i++ {
+			result = append(result, &todomodels.Todo{Title: "title", Description: "short"})
+		}
+		return result
+	}
+
+	t.Run("when within the quota", func(t *testing.T) {
+		mockTodoRepository := new(todomocks.Repository)
+		service := usageservice.New(new(mockrepository.Repository), mockTodoRepository)
+
+		mockTodoRepository.On("CountOwned", mock.Anything, "user-1").Return(8, nil)
+
+		assert.NoError(t, service.CheckTodos(context.Background(), "user-1", todos(2)))
+	})
+
+	t.Run("when a bulk create would exceed the quota", func(t *testing.T) {
+		mockTodoRepository := new(todomocks.Repository)
+		service := usageservice.New(new(mockrepository.Repository), mockTodoRepository)
+
+		mockTodoRepository.On("CountOwned", mock.Anything, "user-1").Return(8, nil)
+
+		err := service.CheckTodos(context.Background(), "user-1", todos(3))
+
+		exceeded := &quota.ExceededError{}
+		assert.True(t, errors.As(err, &exceeded))
+		assert.Equal(t, quota.Todos, exceeded.Quota)
+		assert.Equal(t, time.Duration(0), exceeded.RetryAfter)
+	})
+
+	t.Run("when a description is too large", func(t *testing.T) {
+		mockTodoRepository := new(todomocks.Repository)
+		service := usageservice.New(new(mockrepository.Repository), mockTodoRepository)
+
+		err := service.CheckTodos(context.Background(), "user-1", []*todomodels.Todo{{Description: "too long description"}})
+
+		exceeded := &quota.ExceededError{}
+		assert.True(t, errors.As(err, &exceeded))
+		assert.Equal(t, quota.DescriptionSize, exceeded.Quota)
+		mockTodoRepository.AssertNotCalled(t, "CountOwned", mock.Anything, mock.Anything)
+	})
+
+	t.Run("when count error", func(t *testing.T) {
+		mockTodoRepository := new(todomocks.Repository)
+		service := usageservice.New(new(mockrepository.Repository), mockTodoRepository)
+
+		mockTodoRepository.On("CountOwned", mock.Anything, "user-1").Return(0, errorsutil.ErrDefault)
+
+		assert.ErrorIs(t, service.CheckTodos(context.Background(), "user-1", todos(1)), errorsutil.ErrDefault)
+	})
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+
+	pkgtracing "go-clean-architecture/pkg/tracing"
+	todomodels "go-clean-architecture/todo/models"
+	"go-clean-architecture/usage/models"
+)
+
+type TracingService struct {
+	next Service
+}
+
+// WithTracing will wrap a Service and start a span for every method
+func WithTracing(next Service) Service {
+	return &TracingService{
+		next: next,
+	}
+}
+
+// Get - get usage service
+func (s *TracingService) Get(ctx context.Context, userID string) (res *models.Usage, err error) {
+	ctx, span := pkgtracing.Start(ctx, "usage.Service/Get")
+	defer pkgtracing.End(span, &err)
+
+	return s.next.Get(ctx, userID)
+}
+
+// CountRequest - count request service
+func (s *TracingService) CountRequest(ctx context.Context, userID string) (err error) {
+	ctx, span := pkgtracing.Start(ctx, "usage.Service/CountRequest")
+	defer pkgtracing.End(span, &err)
+
+	return s.next.CountRequest(ctx, userID)
+}
+
+// CheckTodos - check todo quotas service
+func (s *TracingService) CheckTodos(ctx context.Context, ownerID string, todos []*todomodels.Todo) (err error) {
+	ctx, span := pkgtracing.Start(ctx, "usage.Service/CheckTodos")
+	defer pkgtracing.End(span, &err)
+
+	return s.next.CheckTodos(ctx, ownerID, todos)
+}
+
+// CheckDescription - check description size service
+func (s *TracingService) CheckDescription(ctx context.Context, description string) (err error) {
+	ctx, span := pkgtracing.Start(ctx, "usage.Service/CheckDescription")
+	defer pkgtracing.End(span, &err)
+
+	return s.next.CheckDescription(ctx, description)
+}
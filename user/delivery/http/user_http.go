@@ -0,0 +1,166 @@
+package httpdelivery
+
+import (
+	"errors"
+	"net/http"
+
+	"go-clean-architecture/pkg/auth"
+	"go-clean-architecture/user/models"
+	userservice "go-clean-architecture/user/service"
+	errorsutil "go-clean-architecture/utils/errors"
+	requestutil "go-clean-architecture/utils/request"
+	responseutil "go-clean-architecture/utils/response"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type HTTPHandler interface {
+	RegisterRoutes(router chi.Router)
+	Register(w http.ResponseWriter, r *http.Request)
+	Login(w http.ResponseWriter, r *http.Request)
+	Refresh(w http.ResponseWriter, r *http.Request)
+	Logout(w http.ResponseWriter, r *http.Request)
+	Me(w http.ResponseWriter, r *http.Request)
+}
+
+// maxBodySize - limit of credential request bodies
+const maxBodySize = 16 << 10
+
+type HTTPHandlerImpl struct {
+	service userservice.Service
+}
+
+// New - make http handler
+func New(service userservice.Service) HTTPHandler {
+	return &HTTPHandlerImpl{
+		service: service,
+	}
+}
+
+func (h *HTTPHandlerImpl) RegisterRoutes(router chi.Router) {
+	router.Group(func(r chi.Router) {
+		r.Use(responseutil.Negotiate(responseutil.Formats...))
+		r.Use(requestutil.MaxBodySize(maxBodySize))
+
+		r.Post("/auth/register", h.Register)
+		r.Post("/auth/login", h.Login)
+		r.Post("/auth/refresh", h.Refresh)
+		r.Post("/auth/logout", h.Logout)
+		r.With(auth.Require).Get("/auth/me", h.Me)
+	})
+}
+
+// Register - register user http handler
+func (h *HTTPHandlerImpl) Register(w http.ResponseWriter, r *http.Request) {
+	data := &models.RegisterRequest{}
+	if err := requestutil.Bind(r, data); err != nil {
+		responseutil.ResponseBindError(w, r, err)
+		return
+	}
+
+	result, err := h.service.Register(r.Context(), &models.User{
+		Email: data.Email,
+		Name:  data.Name,
+	}, data.Password)
+	if err != nil {
+		if errors.Is(err, errorsutil.ErrAlreadyExists) {
+			responseutil.ResponseConflict(w, r, "Email is already registered")
+			return
+		}
+
+		responseutil.ResponseError(w, r, err)
+		return
+	}
+
+	responseutil.ResponseCreated(w, r, &responseutil.ResponseSuccess{
+		Data: result,
+	})
+}
+
+// Login - login http handler
+func (h *HTTPHandlerImpl) Login(w http.ResponseWriter, r *http.Request) {
+	data := &models.LoginRequest{}
+	if err := requestutil.Bind(r, data); err != nil {
+		responseutil.ResponseBindError(w, r, err)
+		return
+	}
+
+	result, err := h.service.Login(r.Context(), data.Email, data.Password)
+	if err != nil {
+		if errors.Is(err, errorsutil.ErrInvalidCredentials) {
+			responseutil.ResponseUnauthorized(w, r, "Invalid email or password")
+			return
+		}
+
+		responseutil.ResponseError(w, r, err)
+		return
+	}
+
+	responseutil.ResponseOK(w, r, &responseutil.ResponseSuccess{
+		Data: result,
+	})
+}
+
+// Refresh - rotate refresh token http handler
+func (h *HTTPHandlerImpl) Refresh(w http.ResponseWriter, r *http.Request) {
+	data := &models.RefreshRequest{}
+	if err := requestutil.Bind(r, data); err != nil {
+		responseutil.ResponseBindError(w, r, err)
+		return
+	}
+
+	result, err := h.service.Refresh(r.Context(), data.RefreshToken)
+	if err != nil {
+		if errors.Is(err, errorsutil.ErrUnauthorized) {
+			responseutil.ResponseUnauthorized(w, r, "Invalid or expired refresh token")
+			return
+		}
+
+		responseutil.ResponseError(w, r, err)
+		return
+	}
+
+	responseutil.ResponseOK(w, r, &responseutil.ResponseSuccess{
+		Data: result,
+	})
+}
+
+// Logout - revoke tokens http handler
+func (h *HTTPHandlerImpl) Logout(w http.ResponseWriter, r *http.Request) {
+	data := &models.RefreshRequest{}
+	if err := requestutil.Bind(r, data); err != nil {
+		responseutil.ResponseBindError(w, r, err)
+		return
+	}
+
+	if err := h.service.Logout(r.Context(), data.RefreshToken); err != nil {
+		responseutil.ResponseError(w, r, err)
+		return
+	}
+
+	responseutil.ResponseOK(w, r, &responseutil.ResponseSuccess{
+		Data: responseutil.H{
+			"revoked": true,
+		},
+	})
+}
+
+// Me - current user http handler
+func (h *HTTPHandlerImpl) Me(w http.ResponseWriter, r *http.Request) {
+	principal := auth.FromContext(r.Context())
+
+	result, err := h.service.GetByID(r.Context(), principal.Subject)
+	if err != nil {
+		if errors.Is(err, errorsutil.ErrNotFound) {
+			responseutil.ResponseNotFound(w, r, "User not found")
+			return
+		}
+
+		responseutil.ResponseError(w, r, err)
+		return
+	}
+
+	responseutil.ResponseOK(w, r, &responseutil.ResponseSuccess{
+		Data: result,
+	})
+}
@@ -0,0 +1,137 @@
+package httpdelivery_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go-clean-architecture/pkg/auth"
+	pkgvalidator "go-clean-architecture/pkg/validator"
+	userdelivery "go-clean-architecture/user/delivery/http"
+	errorsutil "go-clean-architecture/utils/errors"
+
+	mockservice "go-clean-architecture/user/mocks/service"
+
+	"go-clean-architecture/user/models"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNewUserHTTPHandler(t *testing.T) {
+	handler := userdelivery.New(new(mockservice.Service))
+	handler.RegisterRoutes(chi.NewMux())
+}
+
+func post(handler http.HandlerFunc, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/auth", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestUserRegister(t *testing.T) {
+	t.Run("when return 400 bad request (error validation)", func(t *testing.T) {
+		pkgvalidator.New()
+		mockService := new(mockservice.Service)
+
+		rr := post(userdelivery.New(mockService).Register, `{"email":"not-an-email","name":"Jane","password":"short"}`)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockService.AssertExpectations(t)
+	})
+	t.Run("when return 409 conflict (email taken)", func(t *testing.T) {
+		pkgvalidator.New()
+		mockService := new(mockservice.Service)
+		mockService.On("Register", mock.Anything, mock.AnythingOfType("*models.User"), "password123").Return(nil, errorsutil.ErrAlreadyExists)
+
+		rr := post(userdelivery.New(mockService).Register, `{"email":"jane@example.com","name":"Jane","password":"password123"}`)
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+		mockService.AssertExpectations(t)
+	})
+	t.Run("when return 201 created", func(t *testing.T) {
+		pkgvalidator.New()
+		mockService := new(mockservice.Service)
+		mockService.On("Register", mock.Anything, mock.AnythingOfType("*models.User"), "password123").Return(&models.User{Email: "jane@example.com", PasswordHash: "secret-hash"}, nil)
+
+		rr := post(userdelivery.New(mockService).Register, `{"email":"jane@example.com","name":"Jane","password":"password123"}`)
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		assert.NotContains(t, rr.Body.String(), "secret-hash")
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestUserLogin(t *testing.T) {
+	t.Run("when return 401 unauthorized (invalid credentials)", func(t *testing.T) {
+		pkgvalidator.New()
+		mockService := new(mockservice.Service)
+		mockService.On("Login", mock.Anything, "jane@example.com", "wrong").Return(nil, errorsutil.ErrInvalidCredentials)
+
+		rr := post(userdelivery.New(mockService).Login, `{"email":"jane@example.com","password":"wrong"}`)
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		assert.NotEmpty(t, rr.Header().Get("WWW-Authenticate"))
+	})
+	t.Run("when return 200 ok", func(t *testing.T) {
+		pkgvalidator.New()
+		mockService := new(mockservice.Service)
+		mockService.On("Login", mock.Anything, "jane@example.com", "password123").Return(&models.TokenPair{AccessToken: "access", RefreshToken: "refresh"}, nil)
+
+		rr := post(userdelivery.New(mockService).Login, `{"email":"jane@example.com","password":"password123"}`)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"refresh_token":"refresh"`)
+	})
+}
+
+func TestUserRefresh(t *testing.T) {
+	t.Run("when return 401 unauthorized (token reused)", func(t *testing.T) {
+		pkgvalidator.New()
+		mockService := new(mockservice.Service)
+		mockService.On("Refresh", mock.Anything, "refresh").Return(nil, errorsutil.ErrUnauthorized)
+
+		rr := post(userdelivery.New(mockService).Refresh, `{"refresh_token":"refresh"}`)
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+	t.Run("when return 200 ok", func(t *testing.T) {
+		pkgvalidator.New()
+		mockService := new(mockservice.Service)
+		mockService.On("Refresh", mock.Anything, "refresh").Return(&models.TokenPair{AccessToken: "access", RefreshToken: "next"}, nil)
+
+		rr := post(userdelivery.New(mockService).Refresh, `{"refresh_token":"refresh"}`)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+}
+
+func TestUserMe(t *testing.T) {
+	router := chi.NewMux()
+	mockService := new(mockservice.Service)
+	userdelivery.New(mockService).RegisterRoutes(router)
+
+	t.Run("when return 401 unauthorized (anonymous)", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/auth/me", nil))
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+	t.Run("when return 200 ok", func(t *testing.T) {
+		mockService.On("GetByID", mock.Anything, "1").Return(&models.User{Email: "jane@example.com"}, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/auth/me", nil)
+		req = req.WithContext(auth.NewContext(context.Background(), &auth.Principal{Subject: "1"}))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		mockService.AssertExpectations(t)
+	})
+}
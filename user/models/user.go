@@ -0,0 +1,72 @@
+package models
+
+import (
+	pkgvalidator "go-clean-architecture/pkg/validator"
+	"net/http"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// User - user model, the password hash is never serialized
+type User struct {
+	ID           primitive.ObjectID `json:"id" xml:"id" bson:"_id,omitempty"`
+	Email        string             `json:"email" xml:"email" bson:"email"`
+	Name         string             `json:"name" xml:"name" bson:"name"`
+	PasswordHash string             `json:"-" xml:"-" bson:"passwordHash"`
+	// Roles - roles of the todo policy, assigned by operators, none the policy defines means its default role
+	Roles     []string  `json:"roles" xml:"roles>role" bson:"roles,omitempty"`
+	CreatedAt time.Time `json:"created_at" xml:"created_at" bson:"createdAt"`
+	UpdatedAt time.Time `json:"updated_at" xml:"updated_at" bson:"updatedAt"`
+}
+
+// RefreshToken - stored refresh token, only the sha256 of the token is kept.
+// Every rotation issues a new token in the same family so reuse of a rotated token revokes the family
+type RefreshToken struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	TokenHash string             `bson:"tokenHash"`
+	FamilyID  string             `bson:"familyId"`
+	UserID    string             `bson:"userId"`
+	ExpiresAt time.Time          `bson:"expiresAt"`
+	RevokedAt *time.Time         `bson:"revokedAt,omitempty"`
+	CreatedAt time.Time          `bson:"createdAt"`
+}
+
+// TokenPair - tokens returned by login and refresh
+type TokenPair struct {
+	AccessToken  string `json:"access_token" xml:"access_token"`
+	RefreshToken string `json:"refresh_token" xml:"refresh_token"`
+	TokenType    string `json:"token_type" xml:"token_type"`
+	// ExpiresIn - lifetime of the access token in seconds
+	ExpiresIn int `json:"expires_in" xml:"expires_in"`
+}
+
+// RegisterRequest - register request
+type RegisterRequest struct {
+	Email    string `form:"email" json:"email" validate:"required,email,max=255"`
+	Name     string `form:"name" json:"name" validate:"required,max=255"`
+	Password string `form:"password" json:"password" validate:"required,min=8,max=72"`
+}
+
+func (rr *RegisterRequest) Bind(r *http.Request) error {
+	return pkgvalidator.ValidateStruct(rr)
+}
+
+// LoginRequest - login request
+type LoginRequest struct {
+	Email    string `form:"email" json:"email" validate:"required,email"`
+	Password string `form:"password" json:"password" validate:"required"`
+}
+
+func (lr *LoginRequest) Bind(r *http.Request) error {
+	return pkgvalidator.ValidateStruct(lr)
+}
+
+// RefreshRequest - refresh and logout request
+type RefreshRequest struct {
+	RefreshToken string `form:"refresh_token" json:"refresh_token" validate:"required"`
+}
+
+func (rr *RefreshRequest) Bind(r *http.Request) error {
+	return pkgvalidator.ValidateStruct(rr)
+}
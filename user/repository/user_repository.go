@@ -0,0 +1,297 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"go-clean-architecture/pkg/logger"
+	pkgmongodb "go-clean-architecture/pkg/mongodb"
+	"go-clean-architecture/user/models"
+	errorsutil "go-clean-architecture/utils/errors"
+	timeutil "go-clean-architecture/utils/time"
+)
+
+type Repository interface {
+	Store(ctx context.Context, value *models.User) (*models.User, error)
+	FindByID(ctx context.Context, id string) (*models.User, error)
+	FindByEmail(ctx context.Context, email string) (*models.User, error)
+	StoreRefreshToken(ctx context.Context, value *models.RefreshToken) error
+	FindRefreshToken(ctx context.Context, tokenHash string) (*models.RefreshToken, error)
+	// RevokeRefreshToken - revoke a token that is not revoked yet, false when it already was
+	RevokeRefreshToken(ctx context.Context, tokenHash string) (bool, error)
+	RevokeRefreshTokenFamily(ctx context.Context, familyID string) error
+	RevokeAccessToken(ctx context.Context, tokenID string, expiresAt time.Time) error
+	IsAccessTokenRevoked(ctx context.Context, tokenID string) (bool, error)
+}
+
+type RepositoryImpl struct {
+	databases *pkgmongodb.Databases
+	log       logger.Logger
+}
+
+// New will create an object that represent the Repository interface
+func New(databases *pkgmongodb.Databases) Repository {
+	return &RepositoryImpl{
+		databases: databases,
+		log:       logger.Named("user/repository"),
+	}
+}
+
+// collection - collection of the database of the tenant of ctx
+func (r *RepositoryImpl) collection(ctx context.Context, name string) (*mongo.Collection, error) {
+	db, err := r.databases.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return db.Collection(name), nil
+}
+
+// Indexes - create the unique email index and the TTL indexes dropping expired tokens, provisions every database
+func Indexes(ctx context.Context, db *mongo.Database) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	_, err := db.Collection("user").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "email", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Collection("refresh_token").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "tokenHash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "familyId", Value: 1}}},
+		{Keys: bson.D{{Key: "expiresAt", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Collection("revoked_token").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	return err
+}
+
+// Store - store user, ErrAlreadyExists when the email is taken
+func (r *RepositoryImpl) Store(ctx context.Context, value *models.User) (*models.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	timeNow := timeutil.GetTimeNow()
+	email := strings.ToLower(value.Email)
+	collection, err := r.collection(ctx, "user")
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := collection.InsertOne(ctx, bson.M{
+		"email":        email,
+		"name":         value.Name,
+		"passwordHash": value.PasswordHash,
+		"createdAt":    timeNow,
+		"updatedAt":    timeNow,
+	})
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, errorsutil.ErrAlreadyExists
+		}
+
+		r.logError(ctx, "Store", err)
+		return nil, err
+	}
+
+	result := &models.User{
+		ID:           res.InsertedID.(primitive.ObjectID),
+		Email:        email,
+		Name:         value.Name,
+		PasswordHash: value.PasswordHash,
+		CreatedAt:    timeNow,
+		UpdatedAt:    timeNow,
+	}
+
+	return result, nil
+}
+
+// FindByID - find user by id
+func (r *RepositoryImpl) FindByID(ctx context.Context, id string) (*models.User, error) {
+	docID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errorsutil.ErrNotFound
+	}
+
+	return r.findOne(ctx, "FindByID", bson.M{"_id": docID})
+}
+
+// FindByEmail - find user by email, case insensitive
+func (r *RepositoryImpl) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	return r.findOne(ctx, "FindByEmail", bson.M{"email": strings.ToLower(email)})
+}
+
+func (r *RepositoryImpl) findOne(ctx context.Context, operation string, filter bson.M) (*models.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result := &models.User{}
+	collection, err := r.collection(ctx, "user")
+	if err != nil {
+		return nil, err
+	}
+
+	err = collection.FindOne(ctx, filter).Decode(result)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errorsutil.ErrNotFound
+		}
+
+		r.logError(ctx, operation, err)
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// StoreRefreshToken - store refresh token
+func (r *RepositoryImpl) StoreRefreshToken(ctx context.Context, value *models.RefreshToken) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	value.CreatedAt = timeutil.GetTimeNow()
+	collection, err := r.collection(ctx, "refresh_token")
+	if err != nil {
+		return err
+	}
+
+	_, err = collection.InsertOne(ctx, value)
+	if err != nil {
+		r.logError(ctx, "StoreRefreshToken", err)
+		return err
+	}
+
+	return nil
+}
+
+// FindRefreshToken - find refresh token by hash
+func (r *RepositoryImpl) FindRefreshToken(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result := &models.RefreshToken{}
+	collection, err := r.collection(ctx, "refresh_token")
+	if err != nil {
+		return nil, err
+	}
+
+	err = collection.FindOne(ctx, bson.M{"tokenHash": tokenHash}).Decode(result)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errorsutil.ErrNotFound
+		}
+
+		r.logError(ctx, "FindRefreshToken", err)
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// RevokeRefreshToken - revoke refresh token, the filter on revokedAt makes concurrent rotations of one token fail
+func (r *RepositoryImpl) RevokeRefreshToken(ctx context.Context, tokenHash string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	collection, err := r.collection(ctx, "refresh_token")
+	if err != nil {
+		return false, err
+	}
+
+	res, err := collection.UpdateOne(ctx,
+		bson.M{"tokenHash": tokenHash, "revokedAt": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revokedAt": timeutil.GetTimeNow()}},
+	)
+	if err != nil {
+		r.logError(ctx, "RevokeRefreshToken", err)
+		return false, err
+	}
+
+	return res.ModifiedCount > 0, nil
+}
+
+// RevokeRefreshTokenFamily - revoke every token of a family
+func (r *RepositoryImpl) RevokeRefreshTokenFamily(ctx context.Context, familyID string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	collection, err := r.collection(ctx, "refresh_token")
+	if err != nil {
+		return err
+	}
+
+	_, err = collection.UpdateMany(ctx,
+		bson.M{"familyId": familyID, "revokedAt": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revokedAt": timeutil.GetTimeNow()}},
+	)
+	if err != nil {
+		r.logError(ctx, "RevokeRefreshTokenFamily", err)
+		return err
+	}
+
+	return nil
+}
+
+// RevokeAccessToken - deny an access token until it expires
+func (r *RepositoryImpl) RevokeAccessToken(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	collection, err := r.collection(ctx, "revoked_token")
+	if err != nil {
+		return err
+	}
+
+	_, err = collection.UpdateOne(ctx,
+		bson.M{"_id": tokenID},
+		bson.M{"$set": bson.M{"expiresAt": expiresAt}},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		r.logError(ctx, "RevokeAccessToken", err)
+		return err
+	}
+
+	return nil
+}
+
+// IsAccessTokenRevoked - whether an access token was revoked
+func (r *RepositoryImpl) IsAccessTokenRevoked(ctx context.Context, tokenID string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	collection, err := r.collection(ctx, "revoked_token")
+	if err != nil {
+		return false, err
+	}
+
+	total, err := collection.CountDocuments(ctx, bson.M{"_id": tokenID})
+	if err != nil {
+		r.logError(ctx, "IsAccessTokenRevoked", err)
+		return false, err
+	}
+
+	return total > 0, nil
+}
+
+// logError - log a database error with the request context of ctx
+func (r *RepositoryImpl) logError(ctx context.Context, operation string, err error) {
+	r.log.WithContext(ctx).Error("database operation failed", "operation", operation, "error", err)
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	pkgmetrics "go-clean-architecture/pkg/metrics"
+	"go-clean-architecture/user/models"
+)
+
+type MetricsRepository struct {
+	next Repository
+}
+
+// WithMetrics will wrap a Repository and record the latency of every method
+func WithMetrics(next Repository) Repository {
+	return &MetricsRepository{
+		next: next,
+	}
+}
+
+// Store - store user
+func (r *MetricsRepository) Store(ctx context.Context, value *models.User) (res *models.User, err error) {
+	defer pkgmetrics.ObserveRepository("user", "Store", time.Now(), &err)
+
+	return r.next.Store(ctx, value)
+}
+
+// FindByID - find user by id
+func (r *MetricsRepository) FindByID(ctx context.Context, id string) (res *models.User, err error) {
+	defer pkgmetrics.ObserveRepository("user", "FindByID", time.Now(), &err)
+
+	return r.next.FindByID(ctx, id)
+}
+
+// FindByEmail - find user by email
+func (r *MetricsRepository) FindByEmail(ctx context.Context, email string) (res *models.User, err error) {
+	defer pkgmetrics.ObserveRepository("user", "FindByEmail", time.Now(), &err)
+
+	return r.next.FindByEmail(ctx, email)
+}
+
+// StoreRefreshToken - store refresh token
+func (r *MetricsRepository) StoreRefreshToken(ctx context.Context, value *models.RefreshToken) (err error) {
+	defer pkgmetrics.ObserveRepository("user", "StoreRefreshToken", time.Now(), &err)
+
+	return r.next.StoreRefreshToken(ctx, value)
+}
+
+// FindRefreshToken - find refresh token by hash
+func (r *MetricsRepository) FindRefreshToken(ctx context.Context, tokenHash string) (res *models.RefreshToken, err error) {
+	defer pkgmetrics.ObserveRepository("user", "FindRefreshToken", time.Now(), &err)
+
+	return r.next.FindRefreshToken(ctx, tokenHash)
+}
+
+// RevokeRefreshToken - revoke refresh token
+func (r *MetricsRepository) RevokeRefreshToken(ctx context.Context, tokenHash string) (revoked bool, err error) {
+	defer pkgmetrics.ObserveRepository("user", "RevokeRefreshToken", time.Now(), &err)
+
+	return r.next.RevokeRefreshToken(ctx, tokenHash)
+}
+
+// RevokeRefreshTokenFamily - revoke every token of a family
+func (r *MetricsRepository) RevokeRefreshTokenFamily(ctx context.Context, familyID string) (err error) {
+	defer pkgmetrics.ObserveRepository("user", "RevokeRefreshTokenFamily", time.Now(), &err)
+
+	return r.next.RevokeRefreshTokenFamily(ctx, familyID)
+}
+
+// RevokeAccessToken - deny an access token until it expires
+func (r *MetricsRepository) RevokeAccessToken(ctx context.Context, tokenID string, expiresAt time.Time) (err error) {
+	defer pkgmetrics.ObserveRepository("user", "RevokeAccessToken", time.Now(), &err)
+
+	return r.next.RevokeAccessToken(ctx, tokenID, expiresAt)
+}
+
+// IsAccessTokenRevoked - whether an access token was revoked
+func (r *MetricsRepository) IsAccessTokenRevoked(ctx context.Context, tokenID string) (revoked bool, err error) {
+	defer pkgmetrics.ObserveRepository("user", "IsAccessTokenRevoked", time.Now(), &err)
+
+	return r.next.IsAccessTokenRevoked(ctx, tokenID)
+}
@@ -0,0 +1,111 @@
+package repository_test
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"testing"
+
+	pkgmongodb "go-clean-architecture/pkg/mongodb"
+	"go-clean-architecture/user/models"
+	"go-clean-architecture/user/repository"
+	errorsutil "go-clean-architecture/utils/errors"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestMain(m *testing.M) {
+	// See todo/repository: mtest needs a cluster, skip in short mode
+	flag.Parse()
+	if testing.Short() {
+		log.Print("skipping mtest integration test in short mode")
+		return
+	}
+
+	if err := mtest.Setup(); err != nil {
+		log.Fatal(err)
+	}
+	defer os.Exit(m.Run())
+	if err := mtest.Teardown(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func TestUserStore(t *testing.T) {
+	os.Setenv("DB_NAME", "test")
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("when success", func(mt *mtest.T) {
+		repo := repository.New(pkgmongodb.NewDatabases(mt.Client, "test"))
+		mt.AddMockResponses(mtest.CreateSuccessResponse())
+
+		result, err := repo.Store(context.Background(), &models.User{Email: "Jane@Example.com", Name: "Jane"})
+
+		assert.NoError(mt, err)
+		assert.Equal(mt, "jane@example.com", result.Email)
+	})
+
+	mt.Run("when email is taken", func(mt *mtest.T) {
+		repo := repository.New(pkgmongodb.NewDatabases(mt.Client, "test"))
+		mt.AddMockResponses(mtest.CreateWriteErrorsResponse(mtest.WriteError{
+			Index:   0,
+			Code:    11000,
+			Message: "duplicate key error",
+		}))
+
+		_, err := repo.Store(context.Background(), &models.User{Email: "jane@example.com", Name: "Jane"})
+
+		assert.ErrorIs(mt, err, errorsutil.ErrAlreadyExists)
+	})
+}
+
+func TestUserFindByEmail(t *testing.T) {
+	os.Setenv("DB_NAME", "test")
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("when success", func(mt *mtest.T) {
+		repo := repository.New(pkgmongodb.NewDatabases(mt.Client, "test"))
+		mt.AddMockResponses(mtest.CreateCursorResponse(1, "test.user", mtest.FirstBatch, bson.D{
+			{Key: "email", Value: "jane@example.com"},
+			{Key: "name", Value: "Jane"},
+		}))
+
+		result, err := repo.FindByEmail(context.Background(), "jane@example.com")
+
+		assert.NoError(mt, err)
+		assert.Equal(mt, "Jane", result.Name)
+	})
+
+	mt.Run("when not found", func(mt *mtest.T) {
+		repo := repository.New(pkgmongodb.NewDatabases(mt.Client, "test"))
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "test.user", mtest.FirstBatch))
+
+		_, err := repo.FindByEmail(context.Background(), "jane@example.com")
+
+		assert.ErrorIs(mt, err, errorsutil.ErrNotFound)
+	})
+}
+
+func TestUserRevokeRefreshToken(t *testing.T) {
+	os.Setenv("DB_NAME", "test")
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("when already revoked", func(mt *mtest.T) {
+		repo := repository.New(pkgmongodb.NewDatabases(mt.Client, "test"))
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 0}, bson.E{Key: "nModified", Value: 0}))
+
+		revoked, err := repo.RevokeRefreshToken(context.Background(), "hash")
+
+		assert.NoError(mt, err)
+		assert.False(mt, revoked)
+	})
+}
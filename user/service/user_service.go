@@ -0,0 +1,239 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"go-clean-architecture/pkg/auth"
+	"go-clean-architecture/pkg/logger"
+	"go-clean-architecture/pkg/tenant"
+	"go-clean-architecture/user/models"
+	userrepository "go-clean-architecture/user/repository"
+	errorsutil "go-clean-architecture/utils/errors"
+	timeutil "go-clean-architecture/utils/time"
+)
+
+// dummyHash - compared against when the email is unknown so login takes as long as for a wrong password
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy-password"), bcrypt.DefaultCost)
+
+// Service represent the user service
+type Service interface {
+	Register(ctx context.Context, value *models.User, password string) (*models.User, error)
+	Login(ctx context.Context, email string, password string) (*models.TokenPair, error)
+	// Refresh - rotate a refresh token, reusing a rotated token revokes its whole family
+	Refresh(ctx context.Context, refreshToken string) (*models.TokenPair, error)
+	// Logout - revoke the refresh token family and the access token of the principal in ctx
+	Logout(ctx context.Context, refreshToken string) error
+	GetByID(ctx context.Context, id string) (*models.User, error)
+	// Authenticate - principal of a valid, not revoked access token
+	Authenticate(ctx context.Context, token string) (*auth.Principal, error)
+}
+
+type ServiceImpl struct {
+	repository      userrepository.Repository
+	issuer          *auth.Issuer
+	refreshTokenTTL time.Duration
+	log             logger.Logger
+}
+
+// New will create new an ServiceImpl object representation of Service interface
+func New(repository userrepository.Repository, issuer *auth.Issuer, refreshTokenTTL time.Duration) Service {
+	return &ServiceImpl{
+		repository:      repository,
+		issuer:          issuer,
+		refreshTokenTTL: refreshTokenTTL,
+		log:             logger.Named("user/service"),
+	}
+}
+
+// Register - hash the password and store the user
+func (s *ServiceImpl) Register(ctx context.Context, value *models.User, password string) (*models.User, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repository.Store(ctx, &models.User{
+		Email:        value.Email,
+		Name:         value.Name,
+		PasswordHash: string(hash),
+	})
+}
+
+// Login - check the password and issue a token pair in a new family
+func (s *ServiceImpl) Login(ctx context.Context, email string, password string) (*models.TokenPair, error) {
+	user, err := s.repository.FindByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, errorsutil.ErrNotFound) {
+			bcrypt.CompareHashAndPassword(dummyHash, []byte(password)) //nolint:errcheck
+			return nil, errorsutil.ErrInvalidCredentials
+		}
+		return nil, err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return nil, errorsutil.ErrInvalidCredentials
+	}
+
+	familyID, err := randomToken(16)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.issue(ctx, user, familyID)
+}
+
+// Refresh - rotate refresh token
+func (s *ServiceImpl) Refresh(ctx context.Context, refreshToken string) (*models.TokenPair, error) {
+	stored, err := s.repository.FindRefreshToken(ctx, hashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, errorsutil.ErrNotFound) {
+			return nil, errorsutil.ErrUnauthorized
+		}
+		return nil, err
+	}
+
+	if stored.RevokedAt != nil {
+		return nil, s.revokeReusedFamily(ctx, stored)
+	}
+	if !timeutil.GetTimeNow().Before(stored.ExpiresAt) {
+		return nil, errorsutil.ErrUnauthorized
+	}
+
+	revoked, err := s.repository.RevokeRefreshToken(ctx, stored.TokenHash)
+	if err != nil {
+		return nil, err
+	}
+	if !revoked {
+		// Rotated concurrently by someone else
+		return nil, s.revokeReusedFamily(ctx, stored)
+	}
+
+	user, err := s.repository.FindByID(ctx, stored.UserID)
+	if err != nil {
+		if errors.Is(err, errorsutil.ErrNotFound) {
+			return nil, errorsutil.ErrUnauthorized
+		}
+		return nil, err
+	}
+
+	return s.issue(ctx, user, stored.FamilyID)
+}
+
+// revokeReusedFamily - a rotated token was presented again, so it may have been stolen: end the session
+func (s *ServiceImpl) revokeReusedFamily(ctx context.Context, stored *models.RefreshToken) error {
+	s.log.WithContext(ctx).Warn("refresh token reused, revoking session", "user_id", stored.UserID, "family_id", stored.FamilyID)
+
+	if err := s.repository.RevokeRefreshTokenFamily(ctx, stored.FamilyID); err != nil {
+		return err
+	}
+
+	return errorsutil.ErrUnauthorized
+}
+
+// Logout - revoke refresh token family and current access token
+func (s *ServiceImpl) Logout(ctx context.Context, refreshToken string) error {
+	principal := auth.FromContext(ctx)
+
+	stored, err := s.repository.FindRefreshToken(ctx, hashToken(refreshToken))
+	if err != nil && !errors.Is(err, errorsutil.ErrNotFound) {
+		return err
+	}
+	if stored != nil && (principal == nil || principal.Subject == stored.UserID) {
+		if err := s.repository.RevokeRefreshTokenFamily(ctx, stored.FamilyID); err != nil {
+			return err
+		}
+	}
+
+	// Principals resolved from a trusted header carry no access token
+	if principal != nil && principal.TokenID != "" {
+		return s.repository.RevokeAccessToken(ctx, principal.TokenID, principal.ExpiresAt)
+	}
+
+	return nil
+}
+
+// GetByID - get user by id service
+func (s *ServiceImpl) GetByID(ctx context.Context, id string) (*models.User, error) {
+	return s.repository.FindByID(ctx, id)
+}
+
+// Authenticate - verify access token of the tenant of ctx
+func (s *ServiceImpl) Authenticate(ctx context.Context, token string) (*auth.Principal, error) {
+	principal, err := s.issuer.Verify(token)
+	if err != nil {
+		return nil, err
+	}
+	// A token of one tenant is never valid in another, whose database knows nothing of its revocation
+	if principal.TenantID != tenant.ID(ctx) {
+		return nil, errorsutil.ErrUnauthorized
+	}
+
+	revoked, err := s.repository.IsAccessTokenRevoked(ctx, principal.TokenID)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, errorsutil.ErrUnauthorized
+	}
+
+	return principal, nil
+}
+
+// issue - sign an access token for user and store a new refresh token of family
+func (s *ServiceImpl) issue(ctx context.Context, user *models.User, familyID string) (*models.TokenPair, error) {
+	userID := user.ID.Hex()
+	accessToken, err := s.issuer.Issue(&auth.Principal{
+		Subject:  userID,
+		Email:    user.Email,
+		Roles:    user.Roles,
+		TenantID: tenant.ID(ctx),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.repository.StoreRefreshToken(ctx, &models.RefreshToken{
+		TokenHash: hashToken(refreshToken),
+		FamilyID:  familyID,
+		UserID:    userID,
+		ExpiresAt: timeutil.GetTimeNow().Add(s.refreshTokenTTL),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(s.issuer.TTL().Seconds()),
+	}, nil
+}
+
+func randomToken(size int) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken - refresh tokens are stored hashed, a database leak does not leak usable tokens
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,246 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-clean-architecture/pkg/auth"
+	"go-clean-architecture/pkg/tenant"
+	mockrepository "go-clean-architecture/user/mocks/repository"
+	"go-clean-architecture/user/models"
+	userservice "go-clean-architecture/user/service"
+	errorsutil "go-clean-architecture/utils/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var DefaultID string = primitive.NewObjectID().Hex()
+
+func newIssuer(t *testing.T) *auth.Issuer {
+	issuer, err := auth.NewIssuer(&auth.Config{
+		Secret:         "0123456789abcdef0123456789abcdef",
+		Issuer:         "test",
+		AccessTokenTTL: time.Minute,
+	})
+	assert.NoError(t, err)
+
+	return issuer
+}
+
+func newUser(t *testing.T, password string) *models.User {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	assert.NoError(t, err)
+
+	id, _ := primitive.ObjectIDFromHex(DefaultID)
+	return &models.User{ID: id, Email: "jane@example.com", PasswordHash: string(hash)}
+}
+
+func TestUserRegister(t *testing.T) {
+	t.Run("success when register", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := userservice.New(mockRepository, newIssuer(t), time.Hour)
+
+		mockRepository.On("Store", mock.Anything, mock.MatchedBy(func(u *models.User) bool {
+			return bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte("password123")) == nil
+		})).Return(&models.User{Email: "jane@example.com"}, nil)
+
+		result, err := service.Register(context.Background(), &models.User{Email: "jane@example.com"}, "password123")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "jane@example.com", result.Email)
+		mockRepository.AssertExpectations(t)
+	})
+
+	t.Run("error when email is taken", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := userservice.New(mockRepository, newIssuer(t), time.Hour)
+
+		mockRepository.On("Store", mock.Anything, mock.AnythingOfType("*models.User")).Return(nil, errorsutil.ErrAlreadyExists)
+
+		_, err := service.Register(context.Background(), &models.User{Email: "jane@example.com"}, "password123")
+
+		assert.ErrorIs(t, err, errorsutil.ErrAlreadyExists)
+	})
+}
+
+func TestUserLogin(t *testing.T) {
+	t.Run("success when login", func(t *testing.T) {
+		issuer := newIssuer(t)
+		mockRepository := new(mockrepository.Repository)
+		service := userservice.New(mockRepository, issuer, time.Hour)
+
+		mockRepository.On("FindByEmail", mock.Anything, "jane@example.com").Return(newUser(t, "password123"), nil)
+		mockRepository.On("StoreRefreshToken", mock.Anything, mock.AnythingOfType("*models.RefreshToken")).Return(nil)
+
+		result, err := service.Login(context.Background(), "jane@example.com", "password123")
+
+		assert.NoError(t, err)
+		assert.NotEmpty(t, result.RefreshToken)
+		assert.Equal(t, "Bearer", result.TokenType)
+		assert.Equal(t, 60, result.ExpiresIn)
+
+		principal, err := issuer.Verify(result.AccessToken)
+		assert.NoError(t, err)
+		assert.Equal(t, DefaultID, principal.Subject)
+	})
+
+	t.Run("error when password is wrong", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := userservice.New(mockRepository, newIssuer(t), time.Hour)
+
+		mockRepository.On("FindByEmail", mock.Anything, "jane@example.com").Return(newUser(t, "password123"), nil)
+
+		_, err := service.Login(context.Background(), "jane@example.com", "wrong-password")
+
+		assert.ErrorIs(t, err, errorsutil.ErrInvalidCredentials)
+	})
+
+	t.Run("error when email is unknown", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := userservice.New(mockRepository, newIssuer(t), time.Hour)
+
+		mockRepository.On("FindByEmail", mock.Anything, "jane@example.com").Return(nil, errorsutil.ErrNotFound)
+
+		_, err := service.Login(context.Background(), "jane@example.com", "password123")
+
+		assert.ErrorIs(t, err, errorsutil.ErrInvalidCredentials)
+	})
+}
+
+func TestUserRefresh(t *testing.T) {
+	stored := func(revoked bool, expiresAt time.Time) *models.RefreshToken {
+		token := &models.RefreshToken{TokenHash: "hash", FamilyID: "family", UserID: DefaultID, ExpiresAt: expiresAt}
+		if revoked {
+			now := time.Now()
+			token.RevokedAt = &now
+		}
+		return token
+	}
+
+	t.Run("success when refresh token is rotated", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := userservice.New(mockRepository, newIssuer(t), time.Hour)
+
+		mockRepository.On("FindRefreshToken", mock.Anything, mock.AnythingOfType("string")).Return(stored(false, time.Now().Add(time.Hour)), nil)
+		mockRepository.On("RevokeRefreshToken", mock.Anything, "hash").Return(true, nil)
+		mockRepository.On("FindByID", mock.Anything, DefaultID).Return(newUser(t, "password123"), nil)
+		mockRepository.On("StoreRefreshToken", mock.Anything, mock.MatchedBy(func(token *models.RefreshToken) bool {
+			return token.FamilyID == "family" && token.TokenHash != "hash"
+		})).Return(nil)
+
+		result, err := service.Refresh(context.Background(), "token")
+
+		assert.NoError(t, err)
+		assert.NotEqual(t, "token", result.RefreshToken)
+		mockRepository.AssertExpectations(t)
+	})
+
+	t.Run("error when rotated token is reused", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := userservice.New(mockRepository, newIssuer(t), time.Hour)
+
+		mockRepository.On("FindRefreshToken", mock.Anything, mock.AnythingOfType("string")).Return(stored(true, time.Now().Add(time.Hour)), nil)
+		mockRepository.On("RevokeRefreshTokenFamily", mock.Anything, "family").Return(nil)
+
+		_, err := service.Refresh(context.Background(), "token")
+
+		assert.ErrorIs(t, err, errorsutil.ErrUnauthorized)
+		mockRepository.AssertExpectations(t)
+	})
+
+	t.Run("error when refresh token is expired", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := userservice.New(mockRepository, newIssuer(t), time.Hour)
+
+		mockRepository.On("FindRefreshToken", mock.Anything, mock.AnythingOfType("string")).Return(stored(false, time.Now().Add(-time.Minute)), nil)
+
+		_, err := service.Refresh(context.Background(), "token")
+
+		assert.ErrorIs(t, err, errorsutil.ErrUnauthorized)
+	})
+
+	t.Run("error when refresh token is unknown", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := userservice.New(mockRepository, newIssuer(t), time.Hour)
+
+		mockRepository.On("FindRefreshToken", mock.Anything, mock.AnythingOfType("string")).Return(nil, errorsutil.ErrNotFound)
+
+		_, err := service.Refresh(context.Background(), "token")
+
+		assert.ErrorIs(t, err, errorsutil.ErrUnauthorized)
+	})
+}
+
+func TestUserLogout(t *testing.T) {
+	t.Run("success when logout", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := userservice.New(mockRepository, newIssuer(t), time.Hour)
+
+		expiresAt := time.Now().Add(time.Minute)
+		ctx := auth.NewContext(context.Background(), &auth.Principal{Subject: DefaultID, TokenID: "jti", ExpiresAt: expiresAt})
+
+		mockRepository.On("FindRefreshToken", mock.Anything, mock.AnythingOfType("string")).Return(&models.RefreshToken{FamilyID: "family", UserID: DefaultID}, nil)
+		mockRepository.On("RevokeRefreshTokenFamily", mock.Anything, "family").Return(nil)
+		mockRepository.On("RevokeAccessToken", mock.Anything, "jti", expiresAt).Return(nil)
+
+		err := service.Logout(ctx, "token")
+
+		assert.NoError(t, err)
+		mockRepository.AssertExpectations(t)
+	})
+}
+
+func TestUserAuthenticate(t *testing.T) {
+	issuer := newIssuer(t)
+	token, err := issuer.Issue(&auth.Principal{Subject: DefaultID})
+	assert.NoError(t, err)
+
+	t.Run("success when token is valid", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := userservice.New(mockRepository, issuer, time.Hour)
+
+		mockRepository.On("IsAccessTokenRevoked", mock.Anything, mock.AnythingOfType("string")).Return(false, nil)
+
+		principal, err := service.Authenticate(context.Background(), token)
+
+		assert.NoError(t, err)
+		assert.Equal(t, DefaultID, principal.Subject)
+	})
+
+	t.Run("error when token is revoked", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := userservice.New(mockRepository, issuer, time.Hour)
+
+		mockRepository.On("IsAccessTokenRevoked", mock.Anything, mock.AnythingOfType("string")).Return(true, nil)
+
+		_, err := service.Authenticate(context.Background(), token)
+
+		assert.ErrorIs(t, err, errorsutil.ErrUnauthorized)
+	})
+	t.Run("error when token was issued in another tenant", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := userservice.New(mockRepository, issuer, time.Hour)
+
+		acmeToken, err := issuer.Issue(&auth.Principal{Subject: DefaultID, TenantID: "acme"})
+		assert.NoError(t, err)
+		globex := tenant.NewContext(context.Background(), &tenant.Tenant{ID: "globex"})
+
+		_, err = service.Authenticate(globex, acmeToken)
+
+		assert.ErrorIs(t, err, errorsutil.ErrUnauthorized)
+		mockRepository.AssertNotCalled(t, "IsAccessTokenRevoked", mock.Anything, mock.Anything)
+	})
+
+	t.Run("error when token without tenant is used in a tenant", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := userservice.New(mockRepository, issuer, time.Hour)
+
+		_, err := service.Authenticate(tenant.NewContext(context.Background(), &tenant.Tenant{ID: "acme"}), token)
+
+		assert.ErrorIs(t, err, errorsutil.ErrUnauthorized)
+	})
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+
+	"go-clean-architecture/pkg/auth"
+	pkgtracing "go-clean-architecture/pkg/tracing"
+	"go-clean-architecture/user/models"
+)
+
+type TracingService struct {
+	next Service
+}
+
+// WithTracing will wrap a Service and start a span for every method
+func WithTracing(next Service) Service {
+	return &TracingService{
+		next: next,
+	}
+}
+
+// Register - register user service
+func (s *TracingService) Register(ctx context.Context, value *models.User, password string) (res *models.User, err error) {
+	ctx, span := pkgtracing.Start(ctx, "user.Service/Register")
+	defer pkgtracing.End(span, &err)
+
+	return s.next.Register(ctx, value, password)
+}
+
+// Login - login service
+func (s *TracingService) Login(ctx context.Context, email string, password string) (res *models.TokenPair, err error) {
+	ctx, span := pkgtracing.Start(ctx, "user.Service/Login")
+	defer pkgtracing.End(span, &err)
+
+	return s.next.Login(ctx, email, password)
+}
+
+// Refresh - rotate refresh token service
+func (s *TracingService) Refresh(ctx context.Context, refreshToken string) (res *models.TokenPair, err error) {
+	ctx, span := pkgtracing.Start(ctx, "user.Service/Refresh")
+	defer pkgtracing.End(span, &err)
+
+	return s.next.Refresh(ctx, refreshToken)
+}
+
+// Logout - logout service
+func (s *TracingService) Logout(ctx context.Context, refreshToken string) (err error) {
+	ctx, span := pkgtracing.Start(ctx, "user.Service/Logout")
+	defer pkgtracing.End(span, &err)
+
+	return s.next.Logout(ctx, refreshToken)
+}
+
+// GetByID - get user by id service
+func (s *TracingService) GetByID(ctx context.Context, id string) (res *models.User, err error) {
+	ctx, span := pkgtracing.Start(ctx, "user.Service/GetByID")
+	defer pkgtracing.End(span, &err)
+
+	return s.next.GetByID(ctx, id)
+}
+
+// Authenticate - verify access token service
+func (s *TracingService) Authenticate(ctx context.Context, token string) (res *auth.Principal, err error) {
+	ctx, span := pkgtracing.Start(ctx, "user.Service/Authenticate")
+	defer pkgtracing.End(span, &err)
+
+	return s.next.Authenticate(ctx, token)
+}
@@ -1,6 +1,47 @@
 package errorsutil
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var ErrDefault error = errors.New("error")
 var ErrNotFound error = errors.New("not found")
+var ErrUnsupportedMediaType error = errors.New("unsupported media type")
+var ErrAlreadyExists error = errors.New("already exists")
+var ErrInvalidCredentials error = errors.New("invalid credentials")
+var ErrUnauthorized error = errors.New("unauthorized")
+var ErrForbidden error = errors.New("forbidden")
+var ErrQuotaExceeded error = errors.New("quota exceeded")
+
+// Request body errors, wrapped in a BodyError
+var ErrEmptyBody error = errors.New("empty body")
+var ErrMalformedBody error = errors.New("malformed body")
+var ErrInvalidType error = errors.New("invalid type")
+var ErrUnknownField error = errors.New("unknown field")
+var ErrBodyTooLarge error = errors.New("body too large")
+
+// BodyError - request body that cannot be decoded, errors.Is matches Err
+type BodyError struct {
+	// Err - one of the request body errors
+	Err error
+	// Field - path of the field of an invalid type or unknown field error
+	Field string
+	// Line, Column - position of the error in the body, zero when unknown
+	Line   int
+	Column int
+	// Detail - explanation for the client
+	Detail string
+}
+
+func (e *BodyError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("%s: line %d, column %d: %s", e.Err, e.Line, e.Column, e.Detail)
+	}
+
+	return fmt.Sprintf("%s: %s", e.Err, e.Detail)
+}
+
+func (e *BodyError) Unwrap() error {
+	return e.Err
+}
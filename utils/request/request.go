@@ -0,0 +1,229 @@
+package request
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/go-chi/render"
+	"github.com/vmihailenco/msgpack/v5"
+
+	errorsutil "go-clean-architecture/utils/errors"
+	responseutil "go-clean-architecture/utils/response"
+)
+
+// defaultMaxBodySize - limit of request bodies on routes without MaxBodySize
+var defaultMaxBodySize int64 = 1 << 20
+
+// SetDefaultMaxBodySize - set the deployment wide body limit in bytes, values below one are ignored
+func SetDefaultMaxBodySize(limit int64) {
+	if limit > 0 {
+		defaultMaxBodySize = limit
+	}
+}
+
+type maxBodySizeKey struct{}
+
+// MaxBodySize - middleware limiting the request bodies of a route to limit bytes. Bodies announced larger by
+// Content-Length are rejected with 413 before the handler runs, others fail to decode with ErrBodyTooLarge
+func MaxBodySize(limit int64) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > limit {
+				responseutil.ResponseBindError(w, r, bodyTooLarge(limit))
+				return
+			}
+
+			r.Body = http.MaxBytesReader(w, r.Body, limit)
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), maxBodySizeKey{}, limit)))
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// maxBodySize - body limit of the route of r
+func maxBodySize(r *http.Request) int64 {
+	if limit, ok := r.Context().Value(maxBodySizeKey{}).(int64); ok {
+		return limit
+	}
+
+	return defaultMaxBodySize
+}
+
+func bodyTooLarge(limit int64) error {
+	return &errorsutil.BodyError{
+		Err:    errorsutil.ErrBodyTooLarge,
+		Detail: fmt.Sprintf("body must not be larger than %d bytes", limit),
+	}
+}
+
+// Bind - decode the request body by its Content-Type then run the binder
+func Bind(r *http.Request, v render.Binder) error {
+	if err := Decode(r, v); err != nil {
+		return err
+	}
+
+	return v.Bind(r)
+}
+
+// Decode - decode json, form or msgpack request body, a missing Content-Type is decoded as json. Decoding is
+// strict: unknown fields, data after the body and bodies over the limit of the route are errorsutil.BodyError
+func Decode(r *http.Request, v interface{}) error {
+	mediaType := responseutil.ContentTypeJSON
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		var err error
+		mediaType, _, err = mime.ParseMediaType(contentType)
+		if err != nil {
+			return errorsutil.ErrUnsupportedMediaType
+		}
+	}
+
+	switch mediaType {
+	case responseutil.ContentTypeJSON, responseutil.ContentTypeForm,
+		responseutil.ContentTypeMsgPack, "application/x-msgpack", "application/vnd.msgpack":
+	default:
+		return errorsutil.ErrUnsupportedMediaType
+	}
+
+	data, err := readBody(r)
+	if err != nil {
+		return err
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return &errorsutil.BodyError{Err: errorsutil.ErrEmptyBody, Detail: "body is empty"}
+	}
+
+	switch mediaType {
+	case responseutil.ContentTypeJSON:
+		return decodeJSON(data, v)
+	case responseutil.ContentTypeForm:
+		return render.DecodeForm(bytes.NewReader(data), v)
+	default:
+		return decodeMsgPack(data, v)
+	}
+}
+
+// readBody - read the whole body, it is small enough once it is within the limit of the route
+func readBody(r *http.Request) ([]byte, error) {
+	limit := maxBodySize(r)
+	if r.ContentLength > limit {
+		return nil, bodyTooLarge(limit)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r.Body, limit+1))
+	// MaxBodySize fails the read itself once limit bytes were read
+	if int64(len(data)) > limit || (err != nil && int64(len(data)) >= limit) {
+		return nil, bodyTooLarge(limit)
+	}
+
+	return data, err
+}
+
+func decodeJSON(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(v); err != nil {
+		return jsonError(data, err)
+	}
+
+	if rest := bytes.TrimLeft(data[dec.InputOffset():], " \t\r\n"); len(rest) > 0 {
+		line, column := position(data, len(data)-len(rest)+1)
+		return &errorsutil.BodyError{Err: errorsutil.ErrMalformedBody, Line: line, Column: column, Detail: "unexpected data after the body"}
+	}
+
+	return nil
+}
+
+// jsonError - body error describing err of decoding data
+func jsonError(data []byte, err error) error {
+	var syntaxError *json.SyntaxError
+	var typeError *json.UnmarshalTypeError
+
+	switch {
+	case errors.As(err, &syntaxError):
+		line, column := position(data, int(syntaxError.Offset))
+		return &errorsutil.BodyError{Err: errorsutil.ErrMalformedBody, Line: line, Column: column, Detail: syntaxError.Error()}
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		line, column := position(data, len(data))
+		return &errorsutil.BodyError{Err: errorsutil.ErrMalformedBody, Line: line, Column: column, Detail: "unexpected end of body"}
+	case errors.As(err, &typeError):
+		line, column := position(data, int(typeError.Offset))
+		return &errorsutil.BodyError{
+			Err:    errorsutil.ErrInvalidType,
+			Field:  typeError.Field,
+			Line:   line,
+			Column: column,
+			Detail: fmt.Sprintf("%s must be %s, not %s", typeError.Field, jsonType(typeError.Type), typeError.Value),
+		}
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return &errorsutil.BodyError{Err: errorsutil.ErrUnknownField, Field: field, Detail: field + " is not a known field"}
+	}
+
+	return err
+}
+
+// jsonType - json type a value of t is decoded from
+func jsonType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Ptr:
+		return jsonType(t.Elem())
+	}
+
+	return "an object"
+}
+
+// position - 1-based line and column of the last of the first offset bytes of data
+func position(data []byte, offset int) (int, int) {
+	if offset > len(data) {
+		offset = len(data)
+	}
+	if offset < 1 {
+		return 1, 1
+	}
+
+	index := offset - 1
+	before := data[:index]
+	return bytes.Count(before, []byte("\n")) + 1, index - bytes.LastIndexByte(before, '\n')
+}
+
+func decodeMsgPack(data []byte, v interface{}) error {
+	r := bytes.NewReader(data)
+	dec := msgpack.NewDecoder(r)
+	dec.SetCustomStructTag("json")
+	dec.DisallowUnknownFields(true)
+
+	if err := dec.Decode(v); err != nil {
+		if strings.HasPrefix(err.Error(), "msgpack: unknown field ") {
+			field := strings.Trim(strings.TrimPrefix(err.Error(), "msgpack: unknown field "), `"`)
+			return &errorsutil.BodyError{Err: errorsutil.ErrUnknownField, Field: field, Detail: field + " is not a known field"}
+		}
+
+		return &errorsutil.BodyError{Err: errorsutil.ErrMalformedBody, Detail: err.Error()}
+	}
+
+	if r.Len() > 0 {
+		return &errorsutil.BodyError{Err: errorsutil.ErrMalformedBody, Detail: "unexpected data after the body"}
+	}
+
+	return nil
+}
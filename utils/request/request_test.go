@@ -0,0 +1,214 @@
+package request_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	errorsutil "go-clean-architecture/utils/errors"
+	requestutil "go-clean-architecture/utils/request"
+	responseutil "go-clean-architecture/utils/response"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+type todoForm struct {
+	Title string   `json:"title"`
+	Tags  []string `json:"tags"`
+	Done  bool     `json:"done"`
+}
+
+func decode(contentType string, body string) (*todoForm, error) {
+	req := httptest.NewRequest(http.MethodPost, "/todo", strings.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+
+	v := &todoForm{}
+	return v, requestutil.Decode(req, v)
+}
+
+func bodyError(t *testing.T, err error) *errorsutil.BodyError {
+	e := &errorsutil.BodyError{}
+	assert.ErrorAs(t, err, &e)
+
+	return e
+}
+
+func TestDecodeJSON(t *testing.T) {
+	t.Run("when valid", func(t *testing.T) {
+		v, err := decode("application/json", `{"title":"lorem","tags":["a"]}`+"\n")
+
+		assert.NoError(t, err)
+		assert.Equal(t, &todoForm{Title: "lorem", Tags: []string{"a"}}, v)
+	})
+
+	t.Run("when empty", func(t *testing.T) {
+		for _, body := range []string{"", "  \n"} {
+			_, err := decode("application/json", body)
+
+			assert.ErrorIs(t, err, errorsutil.ErrEmptyBody)
+		}
+	})
+
+	t.Run("when malformed", func(t *testing.T) {
+		_, err := decode("application/json", "{\n  \"title\": \"lorem\",\n  \"done\": tru\n}")
+
+		assert.ErrorIs(t, err, errorsutil.ErrMalformedBody)
+		e := bodyError(t, err)
+		assert.Equal(t, 3, e.Line)
+		assert.Equal(t, 14, e.Column)
+	})
+
+	t.Run("when truncated", func(t *testing.T) {
+		_, err := decode("application/json", `{"title":"lorem"`)
+
+		assert.ErrorIs(t, err, errorsutil.ErrMalformedBody)
+	})
+
+	t.Run("when data follows the body", func(t *testing.T) {
+		_, err := decode("application/json", `{"title":"lorem"} {"title":"ipsum"}`)
+
+		assert.ErrorIs(t, err, errorsutil.ErrMalformedBody)
+		e := bodyError(t, err)
+		assert.Equal(t, 1, e.Line)
+		assert.Equal(t, 19, e.Column)
+	})
+
+	t.Run("when a field has the wrong type", func(t *testing.T) {
+		_, err := decode("application/json", `{"title":42}`)
+
+		assert.ErrorIs(t, err, errorsutil.ErrInvalidType)
+		e := bodyError(t, err)
+		assert.Equal(t, "title", e.Field)
+		assert.Equal(t, "title must be a string, not number", e.Detail)
+	})
+
+	t.Run("when a field is unknown", func(t *testing.T) {
+		_, err := decode("application/json", `{"title":"lorem","owner_id":"someone-else"}`)
+
+		assert.ErrorIs(t, err, errorsutil.ErrUnknownField)
+		assert.Equal(t, "owner_id", bodyError(t, err).Field)
+	})
+
+	t.Run("when the media type is not supported", func(t *testing.T) {
+		_, err := decode("text/plain", `lorem`)
+
+		assert.ErrorIs(t, err, errorsutil.ErrUnsupportedMediaType)
+	})
+}
+
+func TestDecodeMsgPack(t *testing.T) {
+	t.Run("when valid", func(t *testing.T) {
+		body, _ := msgpack.Marshal(map[string]interface{}{"title": "lorem"})
+
+		v, err := decode("application/msgpack", string(body))
+
+		assert.NoError(t, err)
+		assert.Equal(t, "lorem", v.Title)
+	})
+
+	t.Run("when a field is unknown", func(t *testing.T) {
+		body, _ := msgpack.Marshal(map[string]interface{}{"title": "lorem", "owner_id": "someone-else"})
+
+		_, err := decode("application/msgpack", string(body))
+
+		assert.ErrorIs(t, err, errorsutil.ErrUnknownField)
+	})
+
+	t.Run("when data follows the body", func(t *testing.T) {
+		body, _ := msgpack.Marshal(map[string]interface{}{"title": "lorem"})
+
+		_, err := decode("application/msgpack", string(body)+string(body))
+
+		assert.ErrorIs(t, err, errorsutil.ErrMalformedBody)
+	})
+}
+
+func TestMaxBodySize(t *testing.T) {
+	serve := func(req *http.Request) (*httptest.ResponseRecorder, bool) {
+		called := false
+		handler := requestutil.MaxBodySize(16)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			called = true
+			if err := requestutil.Decode(r, &todoForm{}); err != nil {
+				responseutil.ResponseBindError(w, r, err)
+			}
+		}))
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		return rr, called
+	}
+
+	t.Run("when within the limit", func(t *testing.T) {
+		rr, called := serve(httptest.NewRequest(http.MethodPost, "/todo", strings.NewReader(`{"title":"a"}`)))
+
+		assert.True(t, called)
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("when Content-Length is over the limit", func(t *testing.T) {
+		rr, called := serve(httptest.NewRequest(http.MethodPost, "/todo", strings.NewReader(`{"title":"lorem ipsum"}`)))
+
+		assert.False(t, called)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+	})
+
+	t.Run("when the body without length is over the limit", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/todo", io.NopCloser(strings.NewReader(`{"title":"lorem ipsum"}`)))
+		req.ContentLength = -1
+
+		rr, called := serve(req)
+
+		assert.True(t, called)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+	})
+}
+
+func TestResponseBindError(t *testing.T) {
+	cases := []struct {
+		name   string
+		body   string
+		status int
+		code   string
+	}{
+		{"when empty", "", http.StatusBadRequest, responseutil.CodeEmptyBody},
+		{"when malformed", `{"title":}`, http.StatusBadRequest, responseutil.CodeMalformedBody},
+		{"when a field has the wrong type", `{"tags":"a"}`, http.StatusBadRequest, responseutil.CodeInvalidType},
+		{"when a field is unknown", `{"titel":"a"}`, http.StatusBadRequest, responseutil.CodeUnknownField},
+		{"when too large", `{"title":"` + strings.Repeat("a", 2<<20) + `"}`, http.StatusRequestEntityTooLarge, responseutil.CodeBodyTooLarge},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/todo", bytes.NewReader([]byte(c.body)))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Accept", "application/problem+json")
+			rr := httptest.NewRecorder()
+
+			responseutil.ResponseBindError(rr, req, requestutil.Decode(req, &todoForm{}))
+
+			assert.Equal(t, c.status, rr.Code)
+			problem := &responseutil.Problem{}
+			assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), problem))
+			assert.Equal(t, c.code, problem.Code)
+		})
+		t.Run(c.name+" in the envelope", func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/todo", bytes.NewReader([]byte(c.body)))
+			req.Header.Set("Content-Type", "application/json")
+			rr := httptest.NewRecorder()
+
+			responseutil.ResponseBindError(rr, req, requestutil.Decode(req, &todoForm{}))
+
+			assert.Equal(t, c.status, rr.Code)
+			body := map[string]interface{}{}
+			assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+			assert.Equal(t, float64(c.status), body["code"])
+			assert.Equal(t, c.code, body["error_code"])
+		})
+	}
+}
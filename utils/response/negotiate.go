@@ -0,0 +1,305 @@
+package response
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"mime"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/render"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Format - response representation negotiated from the Accept header
+type Format string
+
+const (
+	FormatJSON    Format = "json"
+	FormatXML     Format = "xml"
+	FormatMsgPack Format = "msgpack"
+	FormatCSV     Format = "csv"
+)
+
+const (
+	ContentTypeJSON    = "application/json"
+	ContentTypeXML     = "application/xml"
+	ContentTypeMsgPack = "application/msgpack"
+	ContentTypeCSV     = "text/csv"
+	ContentTypeForm    = "application/x-www-form-urlencoded"
+)
+
+// requestMediaTypes - request body media types understood by utils/request
+var requestMediaTypes = []string{ContentTypeJSON, ContentTypeForm, ContentTypeMsgPack}
+
+// Formats - representations available for every route
+var Formats = []Format{FormatJSON, FormatXML, FormatMsgPack}
+
+// ListFormats - representations available for list routes
+var ListFormats = []Format{FormatJSON, FormatXML, FormatMsgPack, FormatCSV}
+
+// mediaTypes - media types (and aliases) accepted for each format
+var mediaTypes = map[Format][]string{
+	FormatJSON:    {ContentTypeJSON, ContentTypeProblemJSON, "text/javascript"},
+	FormatXML:     {ContentTypeXML, "text/xml"},
+	FormatMsgPack: {ContentTypeMsgPack, "application/x-msgpack", "application/vnd.msgpack"},
+	FormatCSV:     {ContentTypeCSV},
+}
+
+type formatCtxKey struct{}
+
+// CSVMarshaler - implemented by values that can be rendered as CSV rows
+type CSVMarshaler interface {
+	CSVHeader() []string
+	CSVRecord() []string
+}
+
+// Negotiate - pick the response format from the Accept header, send 406 when none of formats is acceptable
+func Negotiate(formats ...Format) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			format, ok := negotiateFormat(r.Header.Get("Accept"), formats)
+			if !ok {
+				ResponseNotAcceptable(w, r, formats)
+				return
+			}
+
+			r = r.WithContext(context.WithValue(r.Context(), formatCtxKey{}, format))
+			next.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// GetFormat - get negotiated format, the default value is json
+func GetFormat(r *http.Request) Format {
+	if format, ok := r.Context().Value(formatCtxKey{}).(Format); ok {
+		return format
+	}
+
+	return FormatJSON
+}
+
+type acceptRange struct {
+	mediaType string
+	quality   float64
+}
+
+// negotiateFormat - pick the offered format with the highest quality in accept
+func negotiateFormat(accept string, offers []Format) (Format, bool) {
+	if strings.TrimSpace(accept) == "" {
+		return offers[0], true
+	}
+
+	ranges := []acceptRange{}
+	for _, field := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(field))
+		if err != nil {
+			continue
+		}
+
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+		if quality <= 0 {
+			continue
+		}
+
+		ranges = append(ranges, acceptRange{mediaType: mediaType, quality: quality})
+	}
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].quality > ranges[j].quality
+	})
+
+	for _, ar := range ranges {
+		for _, offer := range offers {
+			for _, mediaType := range mediaTypes[offer] {
+				if matchMediaType(ar.mediaType, mediaType) {
+					return offer, true
+				}
+			}
+		}
+	}
+
+	return "", false
+}
+
+func matchMediaType(pattern string, mediaType string) bool {
+	if pattern == "*/*" || pattern == mediaType {
+		return true
+	}
+
+	if strings.HasSuffix(pattern, "/*") {
+		return strings.HasPrefix(mediaType, strings.TrimSuffix(pattern, "*"))
+	}
+
+	return false
+}
+
+// respond - write body in the negotiated format
+func respond(w http.ResponseWriter, r *http.Request, status int, body H) {
+	switch GetFormat(r) {
+	case FormatXML:
+		render.Status(r, status)
+		render.XML(w, r, body)
+	case FormatMsgPack:
+		writeMsgPack(w, status, body)
+	case FormatCSV:
+		writeCSV(w, status, body["data"])
+	default:
+		render.Status(r, status)
+		render.JSON(w, r, body)
+	}
+}
+
+func writeMsgPack(w http.ResponseWriter, status int, v interface{}) {
+	buf := &bytes.Buffer{}
+	enc := msgpack.NewEncoder(buf)
+	enc.SetCustomStructTag("json")
+	if err := enc.Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", ContentTypeMsgPack)
+	w.WriteHeader(status)
+	w.Write(buf.Bytes()) //nolint:errcheck
+}
+
+func writeCSV(w http.ResponseWriter, status int, data interface{}) {
+	records, err := csvRecords(data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	buf := &bytes.Buffer{}
+	writer := csv.NewWriter(buf)
+	if err := writer.WriteAll(records); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", ContentTypeCSV+"; charset=utf-8")
+	w.WriteHeader(status)
+	w.Write(buf.Bytes()) //nolint:errcheck
+}
+
+// csvRecords - convert a CSVMarshaler, a slice of them or a map into header and records
+func csvRecords(data interface{}) ([][]string, error) {
+	switch value := data.(type) {
+	case CSVMarshaler:
+		return [][]string{value.CSVHeader(), value.CSVRecord()}, nil
+	case H:
+		return csvMapRecords(value), nil
+	case map[string]interface{}:
+		return csvMapRecords(value), nil
+	}
+
+	rv := reflect.ValueOf(data)
+	if rv.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("response: %T can not be rendered as csv", data)
+	}
+
+	// Header is taken from the element type so empty lists still get one
+	elemType := rv.Type().Elem()
+	zero := reflect.New(elemType).Elem()
+	if elemType.Kind() == reflect.Ptr {
+		zero = reflect.New(elemType.Elem())
+	}
+	header, ok := zero.Interface().(CSVMarshaler)
+	if !ok {
+		return nil, fmt.Errorf("response: %s can not be rendered as csv", elemType)
+	}
+
+	records := [][]string{header.CSVHeader()}
+	for i := 0; i < rv.Len(); i++ {
+		elem, ok := rv.Index(i).Interface().(CSVMarshaler)
+		if !ok || reflect.ValueOf(elem).IsNil() {
+			continue
+		}
+		records = append(records, elem.CSVRecord())
+	}
+
+	return records, nil
+}
+
+func csvMapRecords(m map[string]interface{}) [][]string {
+	keys := sortedKeys(m)
+	record := make([]string, 0, len(keys))
+	for _, key := range keys {
+		record = append(record, fmt.Sprint(m[key]))
+	}
+
+	return [][]string{keys, record}
+}
+
+// MarshalXML - encode H as <response> with one child element per key
+func (h H) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if start.Name.Local == "H" {
+		start.Name.Local = "response"
+	}
+
+	return encodeXMLMap(e, start, h)
+}
+
+func encodeXMLMap(e *xml.Encoder, start xml.StartElement, m map[string]interface{}) error {
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+
+	for _, key := range sortedKeys(m) {
+		if err := encodeXMLValue(e, key, m[key]); err != nil {
+			return err
+		}
+	}
+
+	return e.EncodeToken(start.End())
+}
+
+func encodeXMLValue(e *xml.Encoder, name string, v interface{}) error {
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+
+	switch value := v.(type) {
+	case nil:
+		return nil
+	case H:
+		return encodeXMLMap(e, start, value)
+	case map[string]interface{}:
+		return encodeXMLMap(e, start, value)
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() != reflect.Uint8 {
+		if err := e.EncodeToken(start); err != nil {
+			return err
+		}
+		for i := 0; i < rv.Len(); i++ {
+			if err := encodeXMLValue(e, "item", rv.Index(i).Interface()); err != nil {
+				return err
+			}
+		}
+		return e.EncodeToken(start.End())
+	}
+
+	return e.EncodeElement(v, start)
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
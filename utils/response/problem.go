@@ -0,0 +1,168 @@
+package response
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/render"
+
+	"go-clean-architecture/pkg/requestid"
+)
+
+// ErrorFormat - how error responses are rendered
+type ErrorFormat string
+
+const (
+	// ErrorFormatEnvelope - the {success, code, error_code, message} envelope (default)
+	ErrorFormatEnvelope ErrorFormat = "envelope"
+	// ErrorFormatProblem - RFC 7807 application/problem+json documents
+	ErrorFormatProblem ErrorFormat = "problem"
+)
+
+// ContentTypeProblemJSON - media type of RFC 7807 documents
+const ContentTypeProblemJSON = "application/problem+json"
+
+// Machine-readable error codes, stable across both error formats: the problem "code" member and the
+// envelope "error_code" member
+const (
+	CodeValidation  = "validation_error"
+	CodeInvalidBody = "invalid_body"
+	CodeNotFound    = "not_found"
+	CodeInternal    = "internal_error"
+
+	CodeUnsupportedMediaType = "unsupported_media_type"
+	CodeNotAcceptable        = "not_acceptable"
+	CodeTooManyRequests      = "too_many_requests"
+	CodeOverloaded           = "overloaded"
+	CodeUnauthorized         = "unauthorized"
+	CodeForbidden            = "forbidden"
+	CodeConflict             = "conflict"
+	CodeBadRequest           = "bad_request"
+	CodeQuotaExceeded        = "quota_exceeded"
+
+	CodeEmptyBody     = "empty_body"
+	CodeMalformedBody = "malformed_body"
+	CodeInvalidType   = "invalid_type"
+	CodeUnknownField  = "unknown_field"
+	CodeBodyTooLarge  = "body_too_large"
+)
+
+var (
+	errorFormat     = ErrorFormatEnvelope
+	problemTypeBase = "urn:problem-type:"
+)
+
+// SetErrorFormat - set the deployment wide error format, unknown values fall back to envelope
+func SetErrorFormat(format string) {
+	switch ErrorFormat(strings.ToLower(strings.TrimSpace(format))) {
+	case ErrorFormatProblem:
+		errorFormat = ErrorFormatProblem
+	default:
+		errorFormat = ErrorFormatEnvelope
+	}
+}
+
+// SetProblemTypeBase - set the prefix used to build the problem "type" member from the error code
+func SetProblemTypeBase(base string) {
+	if base != "" {
+		problemTypeBase = base
+	}
+}
+
+// Problem - RFC 7807 problem details document
+type Problem struct {
+	Type      string                 `json:"type"`
+	Title     string                 `json:"title"`
+	Status    int                    `json:"status"`
+	Detail    string                 `json:"detail,omitempty"`
+	Instance  string                 `json:"instance,omitempty"`
+	Code      string                 `json:"code"`
+	RequestID string                 `json:"request_id,omitempty"`
+	Errors    map[string]interface{} `json:"errors,omitempty"`
+}
+
+// apiError - error description shared by every error format
+type apiError struct {
+	Status  int
+	Code    string
+	Message string
+	Detail  string
+	Errors  map[string]interface{}
+}
+
+// wantsProblem - problem format is used when configured or explicitly accepted by the client
+func wantsProblem(r *http.Request) bool {
+	if errorFormat == ErrorFormatProblem {
+		return true
+	}
+
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accept))
+		if err == nil && mediaType == ContentTypeProblemJSON {
+			return true
+		}
+	}
+
+	return false
+}
+
+// renderError - render error in the format selected for the request
+func renderError(w http.ResponseWriter, r *http.Request, e *apiError) {
+	if wantsProblem(r) {
+		renderProblem(w, r, e)
+		return
+	}
+
+	body := H{
+		"success": false,
+		"code":    e.Status,
+		"message": e.Message,
+	}
+	if e.Code != "" {
+		body["error_code"] = e.Code
+	}
+	if e.Detail != "" {
+		body["error"] = e.Detail
+	}
+	if e.Errors != nil {
+		body["errors"] = e.Errors
+	}
+	if id := requestid.FromContext(r.Context()); id != "" {
+		body["request_id"] = id
+	}
+
+	// Errors of list routes are never rendered as csv
+	if GetFormat(r) == FormatCSV {
+		render.Status(r, e.Status)
+		render.JSON(w, r, body)
+		return
+	}
+
+	respond(w, r, e.Status, body)
+}
+
+func renderProblem(w http.ResponseWriter, r *http.Request, e *apiError) {
+	problem := &Problem{
+		Type:      problemTypeBase + e.Code,
+		Title:     e.Message,
+		Status:    e.Status,
+		Detail:    e.Detail,
+		Instance:  r.URL.RequestURI(),
+		Code:      e.Code,
+		RequestID: requestid.FromContext(r.Context()),
+		Errors:    e.Errors,
+	}
+
+	buf := &bytes.Buffer{}
+	if err := json.NewEncoder(buf).Encode(problem); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", ContentTypeProblemJSON)
+	w.WriteHeader(e.Status)
+	w.Write(buf.Bytes()) //nolint:errcheck
+}
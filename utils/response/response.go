@@ -1,11 +1,20 @@
 package response
 
 import (
+	"errors"
+	"fmt"
 	"go-clean-architecture/pkg/logger"
 	pkgvalidator "go-clean-architecture/pkg/validator"
+	errorsutil "go-clean-architecture/utils/errors"
+	"io"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
 )
 
 // H is a shortcut for map[string]interface{}
@@ -17,10 +26,10 @@ type ResponseSuccessList struct {
 }
 
 type Meta struct {
-	PerPage     int `json:"per_page"`
-	CurrentPage int `json:"page"`
-	TotalPage   int `json:"page_count"`
-	TotalData   int `json:"total_count"`
+	PerPage     int `json:"per_page" xml:"per_page"`
+	CurrentPage int `json:"page" xml:"page"`
+	TotalPage   int `json:"page_count" xml:"page_count"`
+	TotalData   int `json:"total_count" xml:"total_count"`
 }
 
 type ResponseSuccess struct {
@@ -28,51 +37,213 @@ type ResponseSuccess struct {
 }
 
 func ResponseErrorValidation(w http.ResponseWriter, r *http.Request, err error) {
-	render.Status(r, http.StatusBadRequest)
-	render.JSON(w, r, H{
-		"success": false,
-		"code":    http.StatusBadRequest,
-		"message": "Validation errors in your request",
-		"errors":  pkgvalidator.ValidatonError(err).Errors,
+	renderError(w, r, &apiError{
+		Status:  http.StatusBadRequest,
+		Code:    CodeValidation,
+		Message: "Validation errors in your request",
+		Errors:  pkgvalidator.ValidatonError(err).Errors,
 	})
 }
 
 func ResponseBodyError(w http.ResponseWriter, r *http.Request, err error) {
-	render.Status(r, http.StatusBadRequest)
-	render.JSON(w, r, H{
-		"success": false,
-		"code":    http.StatusBadRequest,
-		"message": "Validation errors in your request",
-		"error":   "Check your body request",
+	renderError(w, r, &apiError{
+		Status:  http.StatusBadRequest,
+		Code:    CodeInvalidBody,
+		Message: "Validation errors in your request",
+		Detail:  "Check your body request",
 	})
 }
 
 // ResponseError - send response error (500)
 func ResponseError(w http.ResponseWriter, r *http.Request, err error) {
-	logger.Error(err)
+	logger.ErrorContext(r.Context(), err)
 
-	render.Status(r, http.StatusInternalServerError)
-	render.JSON(w, r, H{
-		"success": false,
-		"code":    http.StatusInternalServerError,
-		"message": "There is something error",
+	renderError(w, r, &apiError{
+		Status:  http.StatusInternalServerError,
+		Code:    CodeInternal,
+		Message: "There is something error",
+	})
+}
+
+// ResponseBindError - send response for an error returned while binding the request body
+func ResponseBindError(w http.ResponseWriter, r *http.Request, err error) {
+	var validationErrors validator.ValidationErrors
+	bodyError := &errorsutil.BodyError{}
+	errors.As(err, &bodyError)
+
+	switch {
+	case errors.Is(err, errorsutil.ErrUnsupportedMediaType):
+		ResponseUnsupportedMediaType(w, r)
+	case errors.As(err, &validationErrors):
+		ResponseErrorValidation(w, r, validationErrors)
+	case errors.Is(err, errorsutil.ErrBodyTooLarge):
+		renderError(w, r, &apiError{
+			Status:  http.StatusRequestEntityTooLarge,
+			Code:    CodeBodyTooLarge,
+			Message: "Request body is too large",
+			Detail:  bodyError.Detail,
+		})
+	case errors.Is(err, errorsutil.ErrEmptyBody), errors.Is(err, io.EOF):
+		renderError(w, r, &apiError{
+			Status:  http.StatusBadRequest,
+			Code:    CodeEmptyBody,
+			Message: "Request body is empty",
+		})
+	case errors.Is(err, errorsutil.ErrMalformedBody):
+		renderError(w, r, &apiError{
+			Status:  http.StatusBadRequest,
+			Code:    CodeMalformedBody,
+			Message: "Request body is malformed",
+			Detail:  bodyDetail(bodyError),
+			Errors:  bodyPosition(bodyError),
+		})
+	case errors.Is(err, errorsutil.ErrInvalidType):
+		renderError(w, r, &apiError{
+			Status:  http.StatusBadRequest,
+			Code:    CodeInvalidType,
+			Message: "Request body has a field of the wrong type",
+			Detail:  bodyDetail(bodyError),
+			Errors:  H{bodyError.Field: bodyError.Detail},
+		})
+	case errors.Is(err, errorsutil.ErrUnknownField):
+		renderError(w, r, &apiError{
+			Status:  http.StatusBadRequest,
+			Code:    CodeUnknownField,
+			Message: "Request body has an unknown field",
+			Errors:  H{bodyError.Field: bodyError.Detail},
+		})
+	default:
+		ResponseBodyError(w, r, err)
+	}
+}
+
+// bodyDetail - detail of a body error with its position when known
+func bodyDetail(e *errorsutil.BodyError) string {
+	if e.Line > 0 {
+		return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Detail)
+	}
+
+	return e.Detail
+}
+
+// bodyPosition - position of a body error as errors members, nil when unknown
+func bodyPosition(e *errorsutil.BodyError) map[string]interface{} {
+	if e.Line <= 0 {
+		return nil
+	}
+
+	return H{"line": e.Line, "column": e.Column}
+}
+
+// ResponseUnsupportedMediaType - send response unsupported media type (415)
+func ResponseUnsupportedMediaType(w http.ResponseWriter, r *http.Request) {
+	renderError(w, r, &apiError{
+		Status:  http.StatusUnsupportedMediaType,
+		Code:    CodeUnsupportedMediaType,
+		Message: "Unsupported media type",
+		Detail:  "Content-Type must be one of " + strings.Join(requestMediaTypes, ", "),
+	})
+}
+
+// ResponseNotAcceptable - send response not acceptable (406)
+func ResponseNotAcceptable(w http.ResponseWriter, r *http.Request, formats []Format) {
+	offers := []string{}
+	for _, format := range formats {
+		offers = append(offers, mediaTypes[format][0])
+	}
+
+	renderError(w, r, &apiError{
+		Status:  http.StatusNotAcceptable,
+		Code:    CodeNotAcceptable,
+		Message: "Not acceptable",
+		Detail:  "Accept must allow one of " + strings.Join(offers, ", "),
+	})
+}
+
+// ResponseTooManyRequests - send response too many requests (429), Retry-After is set by the caller
+func ResponseTooManyRequests(w http.ResponseWriter, r *http.Request) {
+	renderError(w, r, &apiError{
+		Status:  http.StatusTooManyRequests,
+		Code:    CodeTooManyRequests,
+		Message: "Too many requests",
+		Detail:  "Rate limit exceeded, retry after the number of seconds in Retry-After",
+	})
+}
+
+// ResponseOverloaded - send response service unavailable (503) for shed requests, Retry-After is set by the caller
+func ResponseOverloaded(w http.ResponseWriter, r *http.Request) {
+	renderError(w, r, &apiError{
+		Status:  http.StatusServiceUnavailable,
+		Code:    CodeOverloaded,
+		Message: "Service is overloaded",
+		Detail:  "Too many requests in flight, retry after the number of seconds in Retry-After",
+	})
+}
+
+// ResponseQuotaExceeded - send response for a used up quota, too many requests (429) with Retry-After when
+// the quota resets after retryAfter, forbidden (403) when it only frees up as usage goes down
+func ResponseQuotaExceeded(w http.ResponseWriter, r *http.Request, message string, retryAfter time.Duration) {
+	status := http.StatusForbidden
+	if retryAfter > 0 {
+		status = http.StatusTooManyRequests
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	}
+
+	renderError(w, r, &apiError{
+		Status:  status,
+		Code:    CodeQuotaExceeded,
+		Message: message,
+	})
+}
+
+// ResponseBadRequest - send response bad request (400)
+func ResponseBadRequest(w http.ResponseWriter, r *http.Request, message string) {
+	renderError(w, r, &apiError{
+		Status:  http.StatusBadRequest,
+		Code:    CodeBadRequest,
+		Message: message,
+	})
+}
+
+// ResponseUnauthorized - send response unauthorized (401) with a Bearer challenge
+func ResponseUnauthorized(w http.ResponseWriter, r *http.Request, message string) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+	renderError(w, r, &apiError{
+		Status:  http.StatusUnauthorized,
+		Code:    CodeUnauthorized,
+		Message: message,
+	})
+}
+
+// ResponseForbidden - send response forbidden (403)
+func ResponseForbidden(w http.ResponseWriter, r *http.Request, message string) {
+	renderError(w, r, &apiError{
+		Status:  http.StatusForbidden,
+		Code:    CodeForbidden,
+		Message: message,
+	})
+}
+
+// ResponseConflict - send response conflict (409)
+func ResponseConflict(w http.ResponseWriter, r *http.Request, message string) {
+	renderError(w, r, &apiError{
+		Status:  http.StatusConflict,
+		Code:    CodeConflict,
+		Message: message,
 	})
 }
 
 // ResponseNotFound - send response not found (404)
 func ResponseNotFound(w http.ResponseWriter, r *http.Request, message string) {
-	render.Status(r, http.StatusNotFound)
-	render.JSON(w, r, H{
-		"success": false,
-		"code":    http.StatusNotFound,
-		"message": message,
+	renderError(w, r, &apiError{
+		Status:  http.StatusNotFound,
+		Code:    CodeNotFound,
+		Message: message,
 	})
 }
 
 func ResponseCreated(w http.ResponseWriter, r *http.Request, data *ResponseSuccess) {
-	render.Status(r, http.StatusCreated)
-
-	render.JSON(w, r, H{
+	respond(w, r, http.StatusCreated, H{
 		"success": true,
 		"code":    http.StatusCreated,
 		"data":    data.Data,
@@ -80,9 +251,7 @@ func ResponseCreated(w http.ResponseWriter, r *http.Request, data *ResponseSucce
 }
 
 func ResponseOK(w http.ResponseWriter, r *http.Request, data *ResponseSuccess) {
-	render.Status(r, http.StatusOK)
-
-	render.JSON(w, r, H{
+	respond(w, r, http.StatusOK, H{
 		"success": true,
 		"code":    http.StatusOK,
 		"data":    data.Data,
@@ -90,9 +259,15 @@ func ResponseOK(w http.ResponseWriter, r *http.Request, data *ResponseSuccess) {
 }
 
 func ResponseOKList(w http.ResponseWriter, r *http.Request, data *ResponseSuccessList) {
-	render.Status(r, http.StatusOK)
+	// CSV has no room for the meta object, expose it as headers instead
+	if GetFormat(r) == FormatCSV && data.Meta != nil {
+		w.Header().Set("X-Page", strconv.Itoa(data.Meta.CurrentPage))
+		w.Header().Set("X-Per-Page", strconv.Itoa(data.Meta.PerPage))
+		w.Header().Set("X-Page-Count", strconv.Itoa(data.Meta.TotalPage))
+		w.Header().Set("X-Total-Count", strconv.Itoa(data.Meta.TotalData))
+	}
 
-	render.JSON(w, r, H{
+	respond(w, r, http.StatusOK, H{
 		"success": true,
 		"code":    http.StatusOK,
 		"data":    data.Data,
@@ -101,9 +276,14 @@ func ResponseOKList(w http.ResponseWriter, r *http.Request, data *ResponseSucces
 }
 
 func ResponseInternalServerError(w http.ResponseWriter, r *http.Request, err error) {
+	if wantsProblem(r) {
+		ResponseError(w, r, err)
+		return
+	}
+
 	render.Status(r, http.StatusOK)
 
-	logger.Error(err)
+	logger.ErrorContext(r.Context(), err)
 	render.JSON(w, r, H{
 		"success": false,
 		"code":    http.StatusInternalServerError,
@@ -0,0 +1,211 @@
+package response_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go-clean-architecture/pkg/requestid"
+	pkgvalidator "go-clean-architecture/pkg/validator"
+	responseutil "go-clean-architecture/utils/response"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+type validationForm struct {
+	Title string `json:"title" validate:"required"`
+}
+
+func TestResponseErrorEnvelope(t *testing.T) {
+	responseutil.SetErrorFormat("")
+
+	req := httptest.NewRequest(http.MethodGet, "/todo/1", nil)
+	req = req.WithContext(requestid.NewContext(req.Context(), "abc"))
+	rr := httptest.NewRecorder()
+
+	responseutil.ResponseNotFound(rr, req, "Item not found")
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Contains(t, rr.Header().Get("Content-Type"), "application/json")
+
+	body := map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+	assert.Equal(t, false, body["success"])
+	assert.Equal(t, float64(http.StatusNotFound), body["code"])
+	assert.Equal(t, responseutil.CodeNotFound, body["error_code"])
+	assert.Equal(t, "Item not found", body["message"])
+	assert.Equal(t, "abc", body["request_id"])
+}
+
+func TestResponseErrorProblem(t *testing.T) {
+	t.Run("when accepted by the client", func(t *testing.T) {
+		responseutil.SetErrorFormat("")
+
+		req := httptest.NewRequest(http.MethodGet, "/todo/1?q=a", nil)
+		req.Header.Set("Accept", "application/problem+json, application/json")
+		rr := httptest.NewRecorder()
+
+		responseutil.ResponseNotFound(rr, req, "Item not found")
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		assert.Equal(t, responseutil.ContentTypeProblemJSON, rr.Header().Get("Content-Type"))
+
+		problem := &responseutil.Problem{}
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), problem))
+		assert.Equal(t, "urn:problem-type:not_found", problem.Type)
+		assert.Equal(t, "Item not found", problem.Title)
+		assert.Equal(t, http.StatusNotFound, problem.Status)
+		assert.Equal(t, "/todo/1?q=a", problem.Instance)
+		assert.Equal(t, responseutil.CodeNotFound, problem.Code)
+	})
+
+	t.Run("when configured for the deployment", func(t *testing.T) {
+		responseutil.SetErrorFormat("problem")
+		defer responseutil.SetErrorFormat("")
+
+		pkgvalidator.New()
+		err := pkgvalidator.ValidateStruct(&validationForm{})
+
+		req := httptest.NewRequest(http.MethodPost, "/todo", nil)
+		rr := httptest.NewRecorder()
+
+		responseutil.ResponseErrorValidation(rr, req, err)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Equal(t, responseutil.ContentTypeProblemJSON, rr.Header().Get("Content-Type"))
+
+		problem := &responseutil.Problem{}
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), problem))
+		assert.Equal(t, responseutil.CodeValidation, problem.Code)
+		assert.Equal(t, "title is required", problem.Errors["title"])
+	})
+}
+
+func TestResponseForbidden(t *testing.T) {
+	responseutil.SetErrorFormat("")
+
+	req := httptest.NewRequest(http.MethodDelete, "/todo/1", nil)
+	req.Header.Set("Accept", "application/problem+json")
+	rr := httptest.NewRecorder()
+
+	responseutil.ResponseForbidden(rr, req, "You are not allowed to delete this item")
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	problem := &responseutil.Problem{}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), problem))
+	assert.Equal(t, responseutil.CodeForbidden, problem.Code)
+	assert.Equal(t, "urn:problem-type:forbidden", problem.Type)
+}
+
+func TestResponseQuotaExceeded(t *testing.T) {
+	responseutil.SetErrorFormat("")
+
+	t.Run("when the quota resets", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/todo", nil)
+		req.Header.Set("Accept", "application/problem+json")
+		rr := httptest.NewRecorder()
+
+		responseutil.ResponseQuotaExceeded(rr, req, "requests quota of 100 exceeded", 90*time.Minute+time.Millisecond)
+
+		assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+		assert.Equal(t, "5401", rr.Header().Get("Retry-After"))
+		assert.Contains(t, rr.Body.String(), `"quota_exceeded"`)
+	})
+
+	t.Run("when the quota frees up with usage", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/todo", nil)
+		req.Header.Set("Accept", "application/problem+json")
+		rr := httptest.NewRecorder()
+
+		responseutil.ResponseQuotaExceeded(rr, req, "todos quota of 10 exceeded", 0)
+
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+		assert.Empty(t, rr.Header().Get("Retry-After"))
+		assert.Contains(t, rr.Body.String(), `"quota_exceeded"`)
+	})
+}
+
+type csvRow struct {
+	Name string `json:"name" xml:"name"`
+}
+
+func (c *csvRow) CSVHeader() []string {
+	return []string{"name"}
+}
+
+func (c *csvRow) CSVRecord() []string {
+	return []string{c.Name}
+}
+
+func TestNegotiate(t *testing.T) {
+	handler := responseutil.Negotiate(responseutil.ListFormats...)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		responseutil.ResponseOKList(w, r, &responseutil.ResponseSuccessList{
+			Data: []*csvRow{{Name: "lorem"}},
+			Meta: &responseutil.Meta{PerPage: 10, CurrentPage: 1, TotalPage: 1, TotalData: 1},
+		})
+	}))
+
+	t.Run("when accept is empty", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/todo", nil)
+		rr := httptest.NewRecorder()
+
+		handler.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Header().Get("Content-Type"), responseutil.ContentTypeJSON)
+	})
+
+	t.Run("when accept xml", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/todo", nil)
+		req.Header.Set("Accept", "application/json;q=0.5, application/xml")
+		rr := httptest.NewRecorder()
+
+		handler.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Header().Get("Content-Type"), responseutil.ContentTypeXML)
+		assert.Contains(t, rr.Body.String(), "<data><item><name>lorem</name></item></data>")
+		assert.Contains(t, rr.Body.String(), "<total_count>1</total_count>")
+	})
+
+	t.Run("when accept msgpack", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/todo", nil)
+		req.Header.Set("Accept", "application/msgpack")
+		rr := httptest.NewRecorder()
+
+		handler.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, responseutil.ContentTypeMsgPack, rr.Header().Get("Content-Type"))
+
+		body := map[string]interface{}{}
+		assert.NoError(t, msgpack.Unmarshal(rr.Body.Bytes(), &body))
+		assert.Equal(t, true, body["success"])
+	})
+
+	t.Run("when accept csv", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/todo", nil)
+		req.Header.Set("Accept", "text/csv")
+		rr := httptest.NewRecorder()
+
+		handler.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "name\nlorem\n", rr.Body.String())
+		assert.Equal(t, "1", rr.Header().Get("X-Total-Count"))
+	})
+
+	t.Run("when return 406 not acceptable", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/todo", nil)
+		req.Header.Set("Accept", "image/png")
+		rr := httptest.NewRecorder()
+
+		handler.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusNotAcceptable, rr.Code)
+	})
+}
@@ -0,0 +1,235 @@
+package httpdelivery
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"go-clean-architecture/pkg/auth"
+	pkgvalidator "go-clean-architecture/pkg/validator"
+	errorsutil "go-clean-architecture/utils/errors"
+	paginationutil "go-clean-architecture/utils/pagination"
+	requestutil "go-clean-architecture/utils/request"
+	responseutil "go-clean-architecture/utils/response"
+	"go-clean-architecture/webhook/models"
+	webhookservice "go-clean-architecture/webhook/service"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type HTTPHandler interface {
+	RegisterRoutes(router chi.Router)
+	GetAll(w http.ResponseWriter, r *http.Request)
+	GetByID(w http.ResponseWriter, r *http.Request)
+	Create(w http.ResponseWriter, r *http.Request)
+	Update(w http.ResponseWriter, r *http.Request)
+	Delete(w http.ResponseWriter, r *http.Request)
+	GetDeliveries(w http.ResponseWriter, r *http.Request)
+	Redeliver(w http.ResponseWriter, r *http.Request)
+}
+
+// maxBodySize - limit of subscription request bodies
+const maxBodySize = 16 << 10
+
+type HTTPHandlerImpl struct {
+	service webhookservice.Service
+}
+
+// New - make http handler
+func New(service webhookservice.Service) HTTPHandler {
+	return &HTTPHandlerImpl{
+		service: service,
+	}
+}
+
+// RegisterRoutes - subscriptions are managed by their owner's user session like API keys, a key could otherwise
+// have the todos it can read sent anywhere
+func (h *HTTPHandlerImpl) RegisterRoutes(router chi.Router) {
+	router.Group(func(r chi.Router) {
+		r.Use(auth.RequireUser)
+		r.Use(responseutil.Negotiate(responseutil.Formats...))
+		r.Use(requestutil.MaxBodySize(maxBodySize))
+
+		r.Get("/webhooks", h.GetAll)
+		r.Post("/webhooks", h.Create)
+		r.Get("/webhooks/{id}", h.GetByID)
+		r.Put("/webhooks/{id}", h.Update)
+		r.Delete("/webhooks/{id}", h.Delete)
+		r.Get("/webhooks/{id}/deliveries", h.GetDeliveries)
+		r.Post("/webhooks/{id}/deliveries/{deliveryId}/redeliver", h.Redeliver)
+	})
+}
+
+// GetAll - get all subscriptions http handler
+func (h *HTTPHandlerImpl) GetAll(w http.ResponseWriter, r *http.Request) {
+	results, err := h.service.GetAll(r.Context(), auth.UserID(r))
+	if err != nil {
+		responseutil.ResponseError(w, r, err)
+		return
+	}
+
+	responseutil.ResponseOK(w, r, &responseutil.ResponseSuccess{
+		Data: results,
+	})
+}
+
+// GetByID - get subscription by id http handler
+func (h *HTTPHandlerImpl) GetByID(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	result, err := h.service.GetByID(r.Context(), auth.UserID(r), id)
+	if err != nil {
+		if errors.Is(err, errorsutil.ErrNotFound) {
+			responseutil.ResponseNotFound(w, r, "Webhook not found")
+			return
+		}
+
+		responseutil.ResponseError(w, r, err)
+		return
+	}
+
+	responseutil.ResponseOK(w, r, &responseutil.ResponseSuccess{
+		Data: result,
+	})
+}
+
+// Create - create subscription http handler
+func (h *HTTPHandlerImpl) Create(w http.ResponseWriter, r *http.Request) {
+	data := &models.SubscriptionRequest{}
+	if err := requestutil.Bind(r, data); err != nil {
+		responseutil.ResponseBindError(w, r, err)
+		return
+	}
+
+	result, err := h.service.Create(r.Context(), &models.Subscription{
+		OwnerID: auth.UserID(r),
+		URL:     data.URL,
+		Events:  data.Events,
+		Secret:  data.Secret,
+	})
+	if err != nil {
+		responseutil.ResponseError(w, r, err)
+		return
+	}
+
+	responseutil.ResponseCreated(w, r, &responseutil.ResponseSuccess{
+		Data: result,
+	})
+}
+
+// Update - update subscription by id http handler
+func (h *HTTPHandlerImpl) Update(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	data := &models.SubscriptionRequest{}
+	if err := requestutil.Bind(r, data); err != nil {
+		responseutil.ResponseBindError(w, r, err)
+		return
+	}
+
+	result, err := h.service.Update(r.Context(), auth.UserID(r), id, &models.Subscription{
+		URL:    data.URL,
+		Events: data.Events,
+		Secret: data.Secret,
+	})
+	if err != nil {
+		if errors.Is(err, errorsutil.ErrNotFound) {
+			responseutil.ResponseNotFound(w, r, "Webhook not found")
+			return
+		}
+
+		responseutil.ResponseError(w, r, err)
+		return
+	}
+
+	responseutil.ResponseOK(w, r, &responseutil.ResponseSuccess{
+		Data: result,
+	})
+}
+
+// Delete - delete subscription by id http handler
+func (h *HTTPHandlerImpl) Delete(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	err := h.service.Delete(r.Context(), auth.UserID(r), id)
+	if err != nil {
+		if errors.Is(err, errorsutil.ErrNotFound) {
+			responseutil.ResponseNotFound(w, r, "Webhook not found")
+			return
+		}
+
+		responseutil.ResponseError(w, r, err)
+		return
+	}
+
+	responseutil.ResponseOK(w, r, &responseutil.ResponseSuccess{
+		Data: responseutil.H{
+			"id": id,
+		},
+	})
+}
+
+// GetDeliveries - get delivery log of subscription by id http handler
+func (h *HTTPHandlerImpl) GetDeliveries(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	pageQueryStr := r.URL.Query().Get("page")
+	perPageQueryStr := r.URL.Query().Get("per_page")
+
+	err := pkgvalidator.ValidateStruct(&models.DeliveryListRequest{
+		Page:    pageQueryStr,
+		PerPage: perPageQueryStr,
+	})
+	if err != nil {
+		responseutil.ResponseErrorValidation(w, r, err)
+		return
+	}
+
+	pageQuery, _ := strconv.Atoi(pageQueryStr)
+	perPageQuery, _ := strconv.Atoi(perPageQueryStr)
+
+	currentPage := paginationutil.CurrentPage(pageQuery)
+	perPage := paginationutil.PerPage(perPageQuery)
+	offset := paginationutil.Offset(currentPage, perPage)
+
+	results, totalData, err := h.service.GetDeliveries(r.Context(), auth.UserID(r), id, perPage, offset)
+	if err != nil {
+		if errors.Is(err, errorsutil.ErrNotFound) {
+			responseutil.ResponseNotFound(w, r, "Webhook not found")
+			return
+		}
+
+		responseutil.ResponseError(w, r, err)
+		return
+	}
+
+	responseutil.ResponseOKList(w, r, &responseutil.ResponseSuccessList{
+		Data: results,
+		Meta: &responseutil.Meta{
+			PerPage:     perPage,
+			CurrentPage: currentPage,
+			TotalPage:   paginationutil.TotalPage(totalData, perPage),
+			TotalData:   totalData,
+		},
+	})
+}
+
+// Redeliver - deliver a delivery of subscription again http handler
+func (h *HTTPHandlerImpl) Redeliver(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	deliveryID := chi.URLParam(r, "deliveryId")
+
+	result, err := h.service.Redeliver(r.Context(), auth.UserID(r), id, deliveryID)
+	if err != nil {
+		if errors.Is(err, errorsutil.ErrNotFound) {
+			responseutil.ResponseNotFound(w, r, "Webhook delivery not found")
+			return
+		}
+
+		responseutil.ResponseError(w, r, err)
+		return
+	}
+
+	responseutil.ResponseCreated(w, r, &responseutil.ResponseSuccess{
+		Data: result,
+	})
+}
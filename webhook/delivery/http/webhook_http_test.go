@@ -0,0 +1,161 @@
+package httpdelivery_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go-clean-architecture/pkg/auth"
+	pkgvalidator "go-clean-architecture/pkg/validator"
+	errorsutil "go-clean-architecture/utils/errors"
+	webhookdelivery "go-clean-architecture/webhook/delivery/http"
+	"go-clean-architecture/webhook/models"
+
+	mockservice "go-clean-architecture/webhook/mocks/service"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var user = &auth.Principal{Subject: "user-1"}
+
+func serve(handler http.Handler, principal *auth.Principal, method string, target string, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if principal != nil {
+		req = req.WithContext(auth.NewContext(req.Context(), principal))
+	}
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	return rr
+}
+
+func newRouter(service *mockservice.Service) chi.Router {
+	router := chi.NewMux()
+	webhookdelivery.New(service).RegisterRoutes(router)
+	return router
+}
+
+func TestWebhookRoutes(t *testing.T) {
+	t.Run("when return 401 unauthorized (anonymous)", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+
+		rr := serve(newRouter(mockService), nil, http.MethodGet, "/webhooks", "")
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		mockService.AssertExpectations(t)
+	})
+	t.Run("when return 403 forbidden (called with an API key)", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+
+		rr := serve(newRouter(mockService), &auth.Principal{Subject: "user-1", KeyID: "key-1", Scopes: []string{}}, http.MethodGet, "/webhooks", "")
+
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestWebhookCreate(t *testing.T) {
+	t.Run("when return 400 bad request (unknown event)", func(t *testing.T) {
+		pkgvalidator.New()
+		mockService := new(mockservice.Service)
+
+		rr := serve(newRouter(mockService), user, http.MethodPost, "/webhooks", `{"url":"https://example.com/hook","events":["todo.archived"]}`)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockService.AssertExpectations(t)
+	})
+	t.Run("when return 400 bad request (not an http url)", func(t *testing.T) {
+		pkgvalidator.New()
+		mockService := new(mockservice.Service)
+
+		rr := serve(newRouter(mockService), user, http.MethodPost, "/webhooks", `{"url":"ftp://example.com/hook","events":["todo.created"]}`)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockService.AssertExpectations(t)
+	})
+	t.Run("when return 201 created", func(t *testing.T) {
+		pkgvalidator.New()
+		mockService := new(mockservice.Service)
+		mockService.On("Create", mock.Anything, &models.Subscription{OwnerID: "user-1", URL: "https://example.com/hook", Events: []string{"todo.created"}}).
+			Return(&models.CreatedSubscription{Subscription: &models.Subscription{URL: "https://example.com/hook"}, Secret: "whsec_secret"}, nil)
+
+		rr := serve(newRouter(mockService), user, http.MethodPost, "/webhooks", `{"url":"https://example.com/hook","events":["todo.created"]}`)
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		assert.Contains(t, rr.Body.String(), "whsec_secret")
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestWebhookGetByID(t *testing.T) {
+	t.Run("when return 404 not found", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+		mockService.On("GetByID", mock.Anything, "user-1", "hook-1").Return(nil, errorsutil.ErrNotFound)
+
+		rr := serve(newRouter(mockService), user, http.MethodGet, "/webhooks/hook-1", "")
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		mockService.AssertExpectations(t)
+	})
+	t.Run("when return 200 ok without secret", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+		mockService.On("GetByID", mock.Anything, "user-1", "hook-1").Return(&models.Subscription{URL: "https://example.com/hook", Secret: "whsec_secret"}, nil)
+
+		rr := serve(newRouter(mockService), user, http.MethodGet, "/webhooks/hook-1", "")
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.NotContains(t, rr.Body.String(), "whsec_secret")
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestWebhookGetDeliveries(t *testing.T) {
+	t.Run("when return 400 bad request (per_page out of range)", func(t *testing.T) {
+		pkgvalidator.New()
+		mockService := new(mockservice.Service)
+
+		rr := serve(newRouter(mockService), user, http.MethodGet, "/webhooks/hook-1/deliveries?per_page=1000", "")
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockService.AssertExpectations(t)
+	})
+	t.Run("when return 200 ok", func(t *testing.T) {
+		pkgvalidator.New()
+		mockService := new(mockservice.Service)
+		mockService.On("GetDeliveries", mock.Anything, "user-1", "hook-1", 5, 5).
+			Return([]*models.Delivery{{ID: primitive.NewObjectID(), Status: models.DeliveryDead}}, 6, nil)
+
+		rr := serve(newRouter(mockService), user, http.MethodGet, "/webhooks/hook-1/deliveries?page=2&per_page=5", "")
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"status":"dead"`)
+		assert.Contains(t, rr.Body.String(), `"total_count":6`)
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestWebhookRedeliver(t *testing.T) {
+	t.Run("when return 404 not found", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+		mockService.On("Redeliver", mock.Anything, "user-1", "hook-1", "delivery-1").Return(nil, errorsutil.ErrNotFound)
+
+		rr := serve(newRouter(mockService), user, http.MethodPost, "/webhooks/hook-1/deliveries/delivery-1/redeliver", "")
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		mockService.AssertExpectations(t)
+	})
+	t.Run("when return 201 created", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+		mockService.On("Redeliver", mock.Anything, "user-1", "hook-1", "delivery-1").Return(&models.Delivery{Status: models.DeliveryPending}, nil)
+
+		rr := serve(newRouter(mockService), user, http.MethodPost, "/webhooks/hook-1/deliveries/delivery-1/redeliver", "")
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		mockService.AssertExpectations(t)
+	})
+}
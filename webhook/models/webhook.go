@@ -0,0 +1,87 @@
+package models
+
+import (
+	"encoding/json"
+	pkgvalidator "go-clean-architecture/pkg/validator"
+	"net/http"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Subscription - webhook subscription, the todo events of Events are posted to URL signed with Secret
+type Subscription struct {
+	ID      primitive.ObjectID `json:"id" xml:"id" bson:"_id,omitempty"`
+	OwnerID string             `json:"owner_id" xml:"owner_id" bson:"ownerId"`
+	URL     string             `json:"url" xml:"url" bson:"url"`
+	Events  []string           `json:"events" xml:"events>event" bson:"events"`
+	// Secret - key of the HMAC-SHA256 signatures, never returned once it is set
+	Secret    string    `json:"-" xml:"-" bson:"secret"`
+	CreatedAt time.Time `json:"created_at" xml:"created_at" bson:"createdAt"`
+	UpdatedAt time.Time `json:"updated_at" xml:"updated_at" bson:"updatedAt"`
+}
+
+// CreatedSubscription - subscription with its secret, returned once by create
+type CreatedSubscription struct {
+	*Subscription
+	Secret string `json:"secret" xml:"secret"`
+}
+
+// Delivery statuses, a failed attempt leaves the delivery pending until it runs out of attempts
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryDead      = "dead"
+)
+
+// Delivery - event posted to a subscription, with every attempt made
+type Delivery struct {
+	ID             primitive.ObjectID `json:"id" xml:"id" bson:"_id,omitempty"`
+	SubscriptionID primitive.ObjectID `json:"subscription_id" xml:"subscription_id" bson:"subscriptionId"`
+	EventID        string             `json:"event_id" xml:"event_id" bson:"eventId"`
+	EventType      string             `json:"event_type" xml:"event_type" bson:"eventType"`
+	// DedupeKey - the same for a change seen by every instance, a change is only delivered once
+	DedupeKey string `json:"-" xml:"-" bson:"dedupeKey,omitempty"`
+	// Payload - JSON body posted to the subscription
+	Payload json.RawMessage `json:"payload" xml:"-" bson:"payload"`
+	Status  string          `json:"status" xml:"status" bson:"status"`
+	// RedeliveryOf - delivery this one was manually redelivered from
+	RedeliveryOf  *primitive.ObjectID `json:"redelivery_of,omitempty" xml:"redelivery_of,omitempty" bson:"redeliveryOf,omitempty"`
+	Attempts      []*Attempt          `json:"attempts" xml:"attempts>attempt" bson:"attempts"`
+	NextAttemptAt *time.Time          `json:"next_attempt_at" xml:"next_attempt_at,omitempty" bson:"nextAttemptAt,omitempty"`
+	CreatedAt     time.Time           `json:"created_at" xml:"created_at" bson:"createdAt"`
+	UpdatedAt     time.Time           `json:"updated_at" xml:"updated_at" bson:"updatedAt"`
+}
+
+// Attempt - outcome of posting a delivery once
+type Attempt struct {
+	At         time.Time `json:"at" xml:"at" bson:"at"`
+	DurationMS int64     `json:"duration_ms" xml:"duration_ms" bson:"durationMs"`
+	// StatusCode - status of the response, 0 when none was received
+	StatusCode int `json:"status_code" xml:"status_code" bson:"statusCode"`
+	// Response - beginning of the response body
+	Response string `json:"response,omitempty" xml:"response,omitempty" bson:"response,omitempty"`
+	Error    string `json:"error,omitempty" xml:"error,omitempty" bson:"error,omitempty"`
+}
+
+// Succeeded - whether the receiver accepted the delivery
+func (a *Attempt) Succeeded() bool {
+	return a.StatusCode >= http.StatusOK && a.StatusCode < http.StatusMultipleChoices
+}
+
+// SubscriptionRequest - webhook subscription request, a secret is generated when none is given
+type SubscriptionRequest struct {
+	URL    string   `form:"url" json:"url" validate:"required,httpurl,max=2048"`
+	Events []string `form:"events" json:"events" validate:"required,min=1,dive,oneof=todo.created todo.updated todo.deleted"`
+	Secret string   `form:"secret" json:"secret" validate:"omitempty,min=16,max=255"`
+}
+
+func (sr *SubscriptionRequest) Bind(r *http.Request) error {
+	return pkgvalidator.ValidateStruct(sr)
+}
+
+// DeliveryListRequest - form for delivery list validation
+type DeliveryListRequest struct {
+	Page    string `form:"page" json:"page" validate:"sgte=1"`
+	PerPage string `form:"per_page" json:"per_page" validate:"sgte=1,slte=100"`
+}
@@ -0,0 +1,444 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"go-clean-architecture/pkg/logger"
+	pkgmongodb "go-clean-architecture/pkg/mongodb"
+	errorsutil "go-clean-architecture/utils/errors"
+	timeutil "go-clean-architecture/utils/time"
+	"go-clean-architecture/webhook/models"
+)
+
+// deliveryRetention - deliveries are removed from the log this long after they were made
+const deliveryRetention = 30 * 24 * time.Hour
+
+type Repository interface {
+	FindAll(ctx context.Context, ownerID string) ([]*models.Subscription, error)
+	FindByID(ctx context.Context, ownerID string, id string) (*models.Subscription, error)
+	// FindByEvent - subscriptions of every owner to eventType
+	FindByEvent(ctx context.Context, eventType string) ([]*models.Subscription, error)
+	// FindSubscription - subscription by id whoever owns it, for delivering to it
+	FindSubscription(ctx context.Context, id primitive.ObjectID) (*models.Subscription, error)
+	Store(ctx context.Context, value *models.Subscription) (*models.Subscription, error)
+	// Update - replace URL and events, and the secret when value has one
+	Update(ctx context.Context, ownerID string, id string, value *models.Subscription) (*models.Subscription, error)
+	// Delete - delete subscription and its deliveries
+	Delete(ctx context.Context, ownerID string, id string) error
+
+	// StoreDelivery - store delivery, ErrAlreadyExists when one with its dedupe key was stored before
+	StoreDelivery(ctx context.Context, value *models.Delivery) (*models.Delivery, error)
+	FindDeliveries(ctx context.Context, subscriptionID primitive.ObjectID, limit int, offset int) ([]*models.Delivery, error)
+	CountDeliveries(ctx context.Context, subscriptionID primitive.ObjectID) (int, error)
+	FindDelivery(ctx context.Context, subscriptionID primitive.ObjectID, id string) (*models.Delivery, error)
+	// ClaimDelivery - pending delivery due at now, hidden from other claims for lease. ErrNotFound when none is due
+	ClaimDelivery(ctx context.Context, now time.Time, lease time.Duration) (*models.Delivery, error)
+	// RecordAttempt - append attempt to delivery by id and set its status, nextAttemptAt is nil unless it is pending
+	RecordAttempt(ctx context.Context, id primitive.ObjectID, attempt *models.Attempt, status string, nextAttemptAt *time.Time) error
+}
+
+type RepositoryImpl struct {
+	databases *pkgmongodb.Databases
+	log       logger.Logger
+}
+
+// New will create an object that represent the Repository interface
+func New(databases *pkgmongodb.Databases) Repository {
+	return &RepositoryImpl{
+		databases: databases,
+		log:       logger.Named("webhook/repository"),
+	}
+}
+
+// collection - collection name of the database of the tenant of ctx
+func (r *RepositoryImpl) collection(ctx context.Context, name string) (*mongo.Collection, error) {
+	db, err := r.databases.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return db.Collection(name), nil
+}
+
+// Indexes - create webhook indexes, provisions every database
+func Indexes(ctx context.Context, db *mongo.Database) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	_, err := db.Collection("webhook").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "ownerId", Value: 1}}},
+		{Keys: bson.D{{Key: "events", Value: 1}}},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Collection("webhook_delivery").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "subscriptionId", Value: 1}, {Key: "createdAt", Value: -1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "nextAttemptAt", Value: 1}}},
+		{Keys: bson.D{{Key: "dedupeKey", Value: 1}}, Options: options.Index().SetUnique(true).SetSparse(true)},
+		{Keys: bson.D{{Key: "createdAt", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(int32(deliveryRetention.Seconds()))},
+	})
+	return err
+}
+
+// FindAll - find all subscriptions of owner
+func (r *RepositoryImpl) FindAll(ctx context.Context, ownerID string) ([]*models.Subscription, error) {
+	return r.findSubscriptions(ctx, "FindAll", bson.M{"ownerId": ownerID})
+}
+
+// FindByEvent - find subscriptions to event type
+func (r *RepositoryImpl) FindByEvent(ctx context.Context, eventType string) ([]*models.Subscription, error) {
+	return r.findSubscriptions(ctx, "FindByEvent", bson.M{"events": eventType})
+}
+
+func (r *RepositoryImpl) findSubscriptions(ctx context.Context, operation string, filter bson.M) ([]*models.Subscription, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	collection, err := r.collection(ctx, "webhook")
+	if err != nil {
+		return nil, err
+	}
+
+	cur, err := collection.Find(ctx, filter, options.Find().SetSort(bson.M{"createdAt": -1}))
+	if err != nil {
+		r.logError(ctx, operation, err)
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	results := []*models.Subscription{}
+	if err := cur.All(ctx, &results); err != nil {
+		r.logError(ctx, operation, err)
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// FindByID - find subscription of owner by id
+func (r *RepositoryImpl) FindByID(ctx context.Context, ownerID string, id string) (*models.Subscription, error) {
+	docID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errorsutil.ErrNotFound
+	}
+
+	return r.findSubscription(ctx, "FindByID", bson.M{"_id": docID, "ownerId": ownerID})
+}
+
+// FindSubscription - find subscription by id
+func (r *RepositoryImpl) FindSubscription(ctx context.Context, id primitive.ObjectID) (*models.Subscription, error) {
+	return r.findSubscription(ctx, "FindSubscription", bson.M{"_id": id})
+}
+
+func (r *RepositoryImpl) findSubscription(ctx context.Context, operation string, filter bson.M) (*models.Subscription, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	collection, err := r.collection(ctx, "webhook")
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.Subscription{}
+	if err := collection.FindOne(ctx, filter).Decode(result); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errorsutil.ErrNotFound
+		}
+
+		r.logError(ctx, operation, err)
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Store - store subscription
+func (r *RepositoryImpl) Store(ctx context.Context, value *models.Subscription) (*models.Subscription, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	now := timeutil.GetTimeNow()
+	result := &models.Subscription{
+		OwnerID:   value.OwnerID,
+		URL:       value.URL,
+		Events:    value.Events,
+		Secret:    value.Secret,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	collection, err := r.collection(ctx, "webhook")
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := collection.InsertOne(ctx, result)
+	if err != nil {
+		r.logError(ctx, "Store", err)
+		return nil, err
+	}
+	result.ID = res.InsertedID.(primitive.ObjectID)
+
+	return result, nil
+}
+
+// Update - update subscription of owner by id
+func (r *RepositoryImpl) Update(ctx context.Context, ownerID string, id string, value *models.Subscription) (*models.Subscription, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	docID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errorsutil.ErrNotFound
+	}
+
+	set := bson.M{"url": value.URL, "events": value.Events, "updatedAt": timeutil.GetTimeNow()}
+	if value.Secret != "" {
+		set["secret"] = value.Secret
+	}
+
+	collection, err := r.collection(ctx, "webhook")
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.Subscription{}
+	err = collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": docID, "ownerId": ownerID},
+		bson.M{"$set": set},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(result)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errorsutil.ErrNotFound
+		}
+
+		r.logError(ctx, "Update", err)
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Delete - delete subscription of owner by id and its deliveries
+func (r *RepositoryImpl) Delete(ctx context.Context, ownerID string, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	docID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errorsutil.ErrNotFound
+	}
+
+	collection, err := r.collection(ctx, "webhook")
+	if err != nil {
+		return err
+	}
+
+	res, err := collection.DeleteOne(ctx, bson.M{"_id": docID, "ownerId": ownerID})
+	if err != nil {
+		r.logError(ctx, "Delete", err)
+		return err
+	}
+	if res.DeletedCount <= 0 {
+		return errorsutil.ErrNotFound
+	}
+
+	deliveries, err := r.collection(ctx, "webhook_delivery")
+	if err != nil {
+		return err
+	}
+
+	// Deliveries left behind are never attempted again, they expire with the log
+	if _, err := deliveries.DeleteMany(ctx, bson.M{"subscriptionId": docID}); err != nil {
+		r.logError(ctx, "Delete", err)
+	}
+
+	return nil
+}
+
+// StoreDelivery - store delivery
+func (r *RepositoryImpl) StoreDelivery(ctx context.Context, value *models.Delivery) (*models.Delivery, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	now := timeutil.GetTimeNow()
+	result := &models.Delivery{
+		SubscriptionID: value.SubscriptionID,
+		EventID:        value.EventID,
+		EventType:      value.EventType,
+		DedupeKey:      value.DedupeKey,
+		Payload:        value.Payload,
+		Status:         models.DeliveryPending,
+		RedeliveryOf:   value.RedeliveryOf,
+		Attempts:       []*models.Attempt{},
+		NextAttemptAt:  &now,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+
+	collection, err := r.collection(ctx, "webhook_delivery")
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := collection.InsertOne(ctx, result)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, errorsutil.ErrAlreadyExists
+		}
+
+		r.logError(ctx, "StoreDelivery", err)
+		return nil, err
+	}
+	result.ID = res.InsertedID.(primitive.ObjectID)
+
+	return result, nil
+}
+
+// FindDeliveries - find deliveries of subscription, newest first
+func (r *RepositoryImpl) FindDeliveries(ctx context.Context, subscriptionID primitive.ObjectID, limit int, offset int) ([]*models.Delivery, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	collection, err := r.collection(ctx, "webhook_delivery")
+	if err != nil {
+		return nil, err
+	}
+
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}}).
+		SetLimit(int64(limit)).
+		SetSkip(int64(offset))
+	cur, err := collection.Find(ctx, bson.M{"subscriptionId": subscriptionID}, findOptions)
+	if err != nil {
+		r.logError(ctx, "FindDeliveries", err)
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	results := []*models.Delivery{}
+	if err := cur.All(ctx, &results); err != nil {
+		r.logError(ctx, "FindDeliveries", err)
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// CountDeliveries - count deliveries of subscription
+func (r *RepositoryImpl) CountDeliveries(ctx context.Context, subscriptionID primitive.ObjectID) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	collection, err := r.collection(ctx, "webhook_delivery")
+	if err != nil {
+		return 0, err
+	}
+
+	count, err := collection.CountDocuments(ctx, bson.M{"subscriptionId": subscriptionID})
+	if err != nil {
+		r.logError(ctx, "CountDeliveries", err)
+		return 0, err
+	}
+
+	return int(count), nil
+}
+
+// FindDelivery - find delivery of subscription by id
+func (r *RepositoryImpl) FindDelivery(ctx context.Context, subscriptionID primitive.ObjectID, id string) (*models.Delivery, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	docID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errorsutil.ErrNotFound
+	}
+
+	collection, err := r.collection(ctx, "webhook_delivery")
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.Delivery{}
+	if err := collection.FindOne(ctx, bson.M{"_id": docID, "subscriptionId": subscriptionID}).Decode(result); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errorsutil.ErrNotFound
+		}
+
+		r.logError(ctx, "FindDelivery", err)
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// ClaimDelivery - claim the longest due pending delivery
+func (r *RepositoryImpl) ClaimDelivery(ctx context.Context, now time.Time, lease time.Duration) (*models.Delivery, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	collection, err := r.collection(ctx, "webhook_delivery")
+	if err != nil {
+		return nil, err
+	}
+
+	// Moving the next attempt past the lease hides the delivery from other instances until it is recorded,
+	// or makes it due again when this instance stops before recording it
+	result := &models.Delivery{}
+	err = collection.FindOneAndUpdate(ctx,
+		bson.M{"status": models.DeliveryPending, "nextAttemptAt": bson.M{"$lte": now}},
+		bson.M{"$set": bson.M{"nextAttemptAt": now.Add(lease)}},
+		options.FindOneAndUpdate().SetSort(bson.M{"nextAttemptAt": 1}).SetReturnDocument(options.After),
+	).Decode(result)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errorsutil.ErrNotFound
+		}
+
+		r.logError(ctx, "ClaimDelivery", err)
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// RecordAttempt - record attempt of delivery
+func (r *RepositoryImpl) RecordAttempt(ctx context.Context, id primitive.ObjectID, attempt *models.Attempt, status string, nextAttemptAt *time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	collection, err := r.collection(ctx, "webhook_delivery")
+	if err != nil {
+		return err
+	}
+
+	set := bson.M{"status": status, "updatedAt": timeutil.GetTimeNow()}
+	update := bson.M{"$set": set, "$push": bson.M{"attempts": attempt}}
+	if nextAttemptAt != nil {
+		set["nextAttemptAt"] = *nextAttemptAt
+	} else {
+		update["$unset"] = bson.M{"nextAttemptAt": ""}
+	}
+
+	if _, err := collection.UpdateOne(ctx, bson.M{"_id": id}, update); err != nil {
+		r.logError(ctx, "RecordAttempt", err)
+		return err
+	}
+
+	return nil
+}
+
+// logError - log a database error with the request context of ctx
+func (r *RepositoryImpl) logError(ctx context.Context, operation string, err error) {
+	r.log.WithContext(ctx).Error("database operation failed", "operation", operation, "error", err)
+}
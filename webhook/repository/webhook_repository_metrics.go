@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	pkgmetrics "go-clean-architecture/pkg/metrics"
+	"go-clean-architecture/webhook/models"
+)
+
+type MetricsRepository struct {
+	next Repository
+}
+
+// WithMetrics will wrap a Repository and record the latency of every method
+func WithMetrics(next Repository) Repository {
+	return &MetricsRepository{
+		next: next,
+	}
+}
+
+// FindAll - find all subscriptions of owner
+func (r *MetricsRepository) FindAll(ctx context.Context, ownerID string) (res []*models.Subscription, err error) {
+	defer pkgmetrics.ObserveRepository("webhook", "FindAll", time.Now(), &err)
+
+	return r.next.FindAll(ctx, ownerID)
+}
+
+// FindByID - find subscription of owner by id
+func (r *MetricsRepository) FindByID(ctx context.Context, ownerID string, id string) (res *models.Subscription, err error) {
+	defer pkgmetrics.ObserveRepository("webhook", "FindByID", time.Now(), &err)
+
+	return r.next.FindByID(ctx, ownerID, id)
+}
+
+// FindByEvent - find subscriptions to event type
+func (r *MetricsRepository) FindByEvent(ctx context.Context, eventType string) (res []*models.Subscription, err error) {
+	defer pkgmetrics.ObserveRepository("webhook", "FindByEvent", time.Now(), &err)
+
+	return r.next.FindByEvent(ctx, eventType)
+}
+
+// FindSubscription - find subscription by id
+func (r *MetricsRepository) FindSubscription(ctx context.Context, id primitive.ObjectID) (res *models.Subscription, err error) {
+	defer pkgmetrics.ObserveRepository("webhook", "FindSubscription", time.Now(), &err)
+
+	return r.next.FindSubscription(ctx, id)
+}
+
+// Store - store subscription
+func (r *MetricsRepository) Store(ctx context.Context, value *models.Subscription) (res *models.Subscription, err error) {
+	defer pkgmetrics.ObserveRepository("webhook", "Store", time.Now(), &err)
+
+	return r.next.Store(ctx, value)
+}
+
+// Update - update subscription of owner by id
+func (r *MetricsRepository) Update(ctx context.Context, ownerID string, id string, value *models.Subscription) (res *models.Subscription, err error) {
+	defer pkgmetrics.ObserveRepository("webhook", "Update", time.Now(), &err)
+
+	return r.next.Update(ctx, ownerID, id, value)
+}
+
+// Delete - delete subscription of owner by id and its deliveries
+func (r *MetricsRepository) Delete(ctx context.Context, ownerID string, id string) (err error) {
+	defer pkgmetrics.ObserveRepository("webhook", "Delete", time.Now(), &err)
+
+	return r.next.Delete(ctx, ownerID, id)
+}
+
+// StoreDelivery - store delivery
+func (r *MetricsRepository) StoreDelivery(ctx context.Context, value *models.Delivery) (res *models.Delivery, err error) {
+	defer pkgmetrics.ObserveRepository("webhook", "StoreDelivery", time.Now(), &err)
+
+	return r.next.StoreDelivery(ctx, value)
+}
+
+// FindDeliveries - find deliveries of subscription, newest first
+func (r *MetricsRepository) FindDeliveries(ctx context.Context, subscriptionID primitive.ObjectID, limit int, offset int) (res []*models.Delivery, err error) {
+	defer pkgmetrics.ObserveRepository("webhook", "FindDeliveries", time.Now(), &err)
+
+	return r.next.FindDeliveries(ctx, subscriptionID, limit, offset)
+}
+
+// CountDeliveries - count deliveries of subscription
+func (r *MetricsRepository) CountDeliveries(ctx context.Context, subscriptionID primitive.ObjectID) (res int, err error) {
+	defer pkgmetrics.ObserveRepository("webhook", "CountDeliveries", time.Now(), &err)
+
+	return r.next.CountDeliveries(ctx, subscriptionID)
+}
+
+// FindDelivery - find delivery of subscription by id
+func (r *MetricsRepository) FindDelivery(ctx context.Context, subscriptionID primitive.ObjectID, id string) (res *models.Delivery, err error) {
+	defer pkgmetrics.ObserveRepository("webhook", "FindDelivery", time.Now(), &err)
+
+	return r.next.FindDelivery(ctx, subscriptionID, id)
+}
+
+// ClaimDelivery - claim the longest due pending delivery
+func (r *MetricsRepository) ClaimDelivery(ctx context.Context, now time.Time, lease time.Duration) (res *models.Delivery, err error) {
+	defer pkgmetrics.ObserveRepository("webhook", "ClaimDelivery", time.Now(), &err)
+
+	return r.next.ClaimDelivery(ctx, now, lease)
+}
+
+// RecordAttempt - record attempt of delivery
+func (r *MetricsRepository) RecordAttempt(ctx context.Context, id primitive.ObjectID, attempt *models.Attempt, status string, nextAttemptAt *time.Time) (err error) {
+	defer pkgmetrics.ObserveRepository("webhook", "RecordAttempt", time.Now(), &err)
+
+	return r.next.RecordAttempt(ctx, id, attempt, status, nextAttemptAt)
+}
@@ -0,0 +1,163 @@
+package repository_test
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	pkgmongodb "go-clean-architecture/pkg/mongodb"
+	errorsutil "go-clean-architecture/utils/errors"
+	"go-clean-architecture/webhook/models"
+	"go-clean-architecture/webhook/repository"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestMain(m *testing.M) {
+	// See todo/repository: mtest needs a cluster, skip in short mode
+	flag.Parse()
+	if testing.Short() {
+		log.Print("skipping mtest integration test in short mode")
+		return
+	}
+
+	if err := mtest.Setup(); err != nil {
+		log.Fatal(err)
+	}
+	defer os.Exit(m.Run())
+	if err := mtest.Teardown(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func TestWebhookStore(t *testing.T) {
+	os.Setenv("DB_NAME", "test")
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("when success", func(mt *mtest.T) {
+		repo := repository.New(pkgmongodb.NewDatabases(mt.Client, "test"))
+		mt.AddMockResponses(mtest.CreateSuccessResponse())
+
+		result, err := repo.Store(context.Background(), &models.Subscription{OwnerID: "user-1", URL: "https://example.com/hook", Events: []string{"todo.created"}, Secret: "secret"})
+
+		assert.NoError(mt, err)
+		assert.False(mt, result.ID.IsZero())
+		assert.False(mt, result.CreatedAt.IsZero())
+	})
+}
+
+func TestWebhookFindByID(t *testing.T) {
+	os.Setenv("DB_NAME", "test")
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("when success", func(mt *mtest.T) {
+		repo := repository.New(pkgmongodb.NewDatabases(mt.Client, "test"))
+		id := primitive.NewObjectID()
+		mt.AddMockResponses(mtest.CreateCursorResponse(1, "test.webhook", mtest.FirstBatch, bson.D{
+			{Key: "_id", Value: id},
+			{Key: "ownerId", Value: "user-1"},
+			{Key: "url", Value: "https://example.com/hook"},
+			{Key: "secret", Value: "secret"},
+		}))
+
+		result, err := repo.FindByID(context.Background(), "user-1", id.Hex())
+
+		assert.NoError(mt, err)
+		assert.Equal(mt, "https://example.com/hook", result.URL)
+		assert.Equal(mt, "secret", result.Secret)
+	})
+
+	mt.Run("when id is invalid", func(mt *mtest.T) {
+		repo := repository.New(pkgmongodb.NewDatabases(mt.Client, "test"))
+
+		_, err := repo.FindByID(context.Background(), "user-1", "not-an-id")
+
+		assert.ErrorIs(mt, err, errorsutil.ErrNotFound)
+	})
+}
+
+func TestWebhookStoreDelivery(t *testing.T) {
+	os.Setenv("DB_NAME", "test")
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("when success", func(mt *mtest.T) {
+		repo := repository.New(pkgmongodb.NewDatabases(mt.Client, "test"))
+		mt.AddMockResponses(mtest.CreateSuccessResponse())
+
+		result, err := repo.StoreDelivery(context.Background(), &models.Delivery{SubscriptionID: primitive.NewObjectID(), EventType: "todo.created", DedupeKey: "key"})
+
+		assert.NoError(mt, err)
+		assert.False(mt, result.ID.IsZero())
+		assert.Equal(mt, models.DeliveryPending, result.Status)
+		assert.NotNil(mt, result.NextAttemptAt)
+	})
+
+	mt.Run("when dedupe key was stored already", func(mt *mtest.T) {
+		repo := repository.New(pkgmongodb.NewDatabases(mt.Client, "test"))
+		mt.AddMockResponses(mtest.CreateWriteErrorsResponse(mtest.WriteError{Index: 0, Code: 11000, Message: "duplicate key error"}))
+
+		_, err := repo.StoreDelivery(context.Background(), &models.Delivery{SubscriptionID: primitive.NewObjectID(), EventType: "todo.created", DedupeKey: "key"})
+
+		assert.ErrorIs(mt, err, errorsutil.ErrAlreadyExists)
+	})
+}
+
+func TestWebhookClaimDelivery(t *testing.T) {
+	os.Setenv("DB_NAME", "test")
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("when success", func(mt *mtest.T) {
+		repo := repository.New(pkgmongodb.NewDatabases(mt.Client, "test"))
+		id := primitive.NewObjectID()
+		mt.AddMockResponses(bson.D{
+			{Key: "ok", Value: 1},
+			{Key: "value", Value: bson.D{
+				{Key: "_id", Value: id},
+				{Key: "status", Value: models.DeliveryPending},
+			}},
+		})
+
+		result, err := repo.ClaimDelivery(context.Background(), time.Now(), time.Minute)
+
+		assert.NoError(mt, err)
+		assert.Equal(mt, id, result.ID)
+	})
+
+	mt.Run("when none is due", func(mt *mtest.T) {
+		repo := repository.New(pkgmongodb.NewDatabases(mt.Client, "test"))
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}, {Key: "value", Value: nil}})
+
+		_, err := repo.ClaimDelivery(context.Background(), time.Now(), time.Minute)
+
+		assert.ErrorIs(mt, err, errorsutil.ErrNotFound)
+	})
+}
+
+func TestWebhookFindDelivery(t *testing.T) {
+	os.Setenv("DB_NAME", "test")
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("when id is invalid", func(mt *mtest.T) {
+		repo := repository.New(pkgmongodb.NewDatabases(mt.Client, "test"))
+
+		_, err := repo.FindDelivery(context.Background(), primitive.NewObjectID(), "not-an-id")
+
+		assert.ErrorIs(mt, err, errorsutil.ErrNotFound)
+	})
+}
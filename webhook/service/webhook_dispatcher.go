@@ -0,0 +1,395 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"go-clean-architecture/pkg/config"
+	"go-clean-architecture/pkg/logger"
+	pkgmetrics "go-clean-architecture/pkg/metrics"
+	"go-clean-architecture/pkg/tenant"
+	todomodels "go-clean-architecture/todo/models"
+	todoservice "go-clean-architecture/todo/service"
+	errorsutil "go-clean-architecture/utils/errors"
+	timeutil "go-clean-architecture/utils/time"
+	"go-clean-architecture/webhook/models"
+	webhookrepository "go-clean-architecture/webhook/repository"
+)
+
+// Headers of deliveries
+const (
+	HeaderID        = "X-Webhook-ID"
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+const (
+	// dispatcherBuffer - events the dispatcher may fall behind before it resumes from its last event
+	dispatcherBuffer = 256
+	// responseSnippet - bytes of a response body kept in the delivery log
+	responseSnippet = 1 << 10
+	// claimMargin - a claimed delivery is attempted again when it is not recorded within the timeout and this margin
+	claimMargin = time.Minute
+)
+
+// Config - webhook delivery configuration
+type Config struct {
+	// Enabled - whether subscriptions can be made and events are delivered
+	Enabled bool
+	// MaxAttempts - attempts of a delivery before it is dead
+	MaxAttempts int
+	// MinBackoff - delay before the second attempt, doubled after every failure up to MaxBackoff
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// Timeout - of a single attempt, a slower receiver fails it
+	Timeout time.Duration
+	// PollInterval - how often due retries and redeliveries are looked for
+	PollInterval time.Duration
+	// Concurrency - attempts made at once per tenant
+	Concurrency int
+	// AllowPrivateNetworks - deliver to loopback and private addresses, otherwise refused as they reach internal services
+	AllowPrivateNetworks bool
+}
+
+// LoadConfig - read webhook delivery configuration from environment variables
+func LoadConfig() *Config {
+	return &Config{
+		Enabled:              config.GetBool("WEBHOOKS_ENABLED", false),
+		MaxAttempts:          config.GetInt("WEBHOOK_MAX_ATTEMPTS", 8),
+		MinBackoff:           config.GetDuration("WEBHOOK_MIN_BACKOFF", 30*time.Second),
+		MaxBackoff:           config.GetDuration("WEBHOOK_MAX_BACKOFF", time.Hour),
+		Timeout:              config.GetDuration("WEBHOOK_TIMEOUT", 10*time.Second),
+		PollInterval:         config.GetDuration("WEBHOOK_POLL_INTERVAL", 5*time.Second),
+		Concurrency:          config.GetInt("WEBHOOK_CONCURRENCY", 10),
+		AllowPrivateNetworks: config.GetBool("WEBHOOK_ALLOW_PRIVATE_NETWORKS", false),
+	}
+}
+
+// Sign - hex HMAC-SHA256 of "<timestamp>.<body>" keyed with secret, sent as "X-Webhook-Signature: sha256=<hex>".
+// Receivers compute it again and reject deliveries with another signature or an old timestamp
+func Sign(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Dispatcher - deliver todo events to the subscriptions of their tenant
+type Dispatcher interface {
+	// Run - turn the events of the tenant of ctx into deliveries and attempt due deliveries until ctx is done.
+	// Deliveries are stored before they are attempted, pending ones survive restarts and are shared by every
+	// instance, each attempt is made by a single one. Receivers may still see a delivery twice and should
+	// ignore X-Webhook-ID values they already processed
+	Run(ctx context.Context)
+}
+
+type DispatcherImpl struct {
+	repository webhookrepository.Repository
+	events     todoservice.Broker
+	cfg        Config
+	client     *http.Client
+	log        logger.Logger
+}
+
+// NewDispatcher - make dispatcher of the events published to events
+func NewDispatcher(repository webhookrepository.Repository, events todoservice.Broker, cfg *Config) Dispatcher {
+	d := &DispatcherImpl{
+		repository: repository,
+		events:     events,
+		cfg:        *cfg,
+		log:        logger.Named("webhook/service"),
+	}
+	if d.cfg.MaxAttempts <= 0 {
+		d.cfg.MaxAttempts = 1
+	}
+	if d.cfg.MinBackoff <= 0 {
+		d.cfg.MinBackoff = time.Second
+	}
+	if d.cfg.MaxBackoff < d.cfg.MinBackoff {
+		d.cfg.MaxBackoff = d.cfg.MinBackoff
+	}
+	if d.cfg.Timeout <= 0 {
+		d.cfg.Timeout = 10 * time.Second
+	}
+	if d.cfg.PollInterval <= 0 {
+		d.cfg.PollInterval = 5 * time.Second
+	}
+	if d.cfg.Concurrency <= 0 {
+		d.cfg.Concurrency = 1
+	}
+	d.client = newHTTPClient(&d.cfg)
+
+	return d
+}
+
+// Run - run dispatcher
+func (d *DispatcherImpl) Run(ctx context.Context) {
+	due := make(chan struct{}, 1)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		d.work(ctx, due)
+	}()
+	defer wg.Wait()
+
+	lastEventID := ""
+	for ctx.Err() == nil {
+		// A dispatcher that fell behind resumes after the last event it stored deliveries for
+		for event := range d.events.Subscribe(ctx, lastEventID, dispatcherBuffer) {
+			lastEventID = event.ID
+			if event.Type == todomodels.EventReset {
+				d.log.WithContext(ctx).Warn("webhook events were missed", "tenant", tenant.ID(ctx))
+				continue
+			}
+
+			if d.enqueue(ctx, event) {
+				select {
+				case due <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}
+}
+
+// enqueue - store a delivery of event for every subscription to it whose owner can see its todo, whether any was
+func (d *DispatcherImpl) enqueue(ctx context.Context, event *todomodels.Event) bool {
+	if event.TenantID != tenant.ID(ctx) || event.Todo == nil {
+		return false
+	}
+
+	subscriptions, err := d.repository.FindByEvent(ctx, event.Type)
+	if err != nil {
+		d.log.WithContext(ctx).Error("webhook event was not delivered", "event", event.ID, "error", err)
+		return false
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		d.log.WithContext(ctx).Error("encoding webhook event failed", "event", event.ID, "error", err)
+		return false
+	}
+	todo, _ := json.Marshal(event.Todo)
+
+	stored := false
+	for _, subscription := range subscriptions {
+		if !event.Todo.HasPermission(subscription.OwnerID, todomodels.PermissionView) {
+			continue
+		}
+
+		_, err := d.repository.StoreDelivery(ctx, &models.Delivery{
+			SubscriptionID: subscription.ID,
+			EventID:        event.ID,
+			EventType:      event.Type,
+			DedupeKey:      dedupeKey(subscription, event.Type, todo),
+			Payload:        payload,
+		})
+		if err != nil {
+			if !errors.Is(err, errorsutil.ErrAlreadyExists) {
+				d.log.WithContext(ctx).Error("webhook event was not delivered", "event", event.ID, "subscription", subscription.ID.Hex(), "error", err)
+			}
+			continue
+		}
+		stored = true
+	}
+
+	return stored
+}
+
+// work - attempt due deliveries every poll interval and whenever due receives, until ctx is done
+func (d *DispatcherImpl) work(ctx context.Context, due <-chan struct{}) {
+	ticker := time.NewTicker(d.cfg.PollInterval)
+	defer ticker.Stop()
+
+	slots := make(chan struct{}, d.cfg.Concurrency)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+	claim:
+		for {
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+
+			delivery, err := d.repository.ClaimDelivery(ctx, timeutil.GetTimeNow(), d.cfg.Timeout+claimMargin)
+			if err != nil {
+				<-slots
+				if !errors.Is(err, errorsutil.ErrNotFound) && ctx.Err() == nil {
+					d.log.WithContext(ctx).Error("claiming webhook delivery failed", "error", err)
+				}
+				break claim
+			}
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer func() { <-slots }()
+				d.deliver(ctx, delivery)
+			}()
+		}
+
+		select {
+		case <-ticker.C:
+		case <-due:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// deliver - attempt delivery and record the outcome
+func (d *DispatcherImpl) deliver(ctx context.Context, delivery *models.Delivery) {
+	attempt := &models.Attempt{At: timeutil.GetTimeNow()}
+
+	subscription, err := d.repository.FindSubscription(ctx, delivery.SubscriptionID)
+	if err != nil {
+		if errors.Is(err, errorsutil.ErrNotFound) {
+			attempt.Error = "subscription was deleted"
+			d.record(ctx, delivery, attempt, models.DeliveryDead, nil)
+		}
+		// Otherwise attempted again once the claim expires
+		return
+	}
+
+	d.send(ctx, subscription, delivery, attempt)
+	if ctx.Err() != nil {
+		// Stopping, attempted again once the claim expires
+		return
+	}
+
+	attempts := len(delivery.Attempts) + 1
+	switch {
+	case attempt.Succeeded():
+		d.record(ctx, delivery, attempt, models.DeliverySucceeded, nil)
+	case attempts >= d.cfg.MaxAttempts:
+		d.log.WithContext(ctx).Warn("webhook delivery is dead", "delivery", delivery.ID.Hex(), "subscription", subscription.ID.Hex(), "attempts", attempts)
+		d.record(ctx, delivery, attempt, models.DeliveryDead, nil)
+	default:
+		next := attempt.At.Add(d.backoff(attempts))
+		d.record(ctx, delivery, attempt, models.DeliveryPending, &next)
+	}
+}
+
+// send - post the payload of delivery to subscription, filling in attempt
+func (d *DispatcherImpl) send(ctx context.Context, subscription *models.Subscription, delivery *models.Delivery, attempt *models.Attempt) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		attempt.Error = err.Error()
+		return
+	}
+
+	timestamp := strconv.FormatInt(attempt.At.Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "go-clean-architecture-webhooks")
+	req.Header.Set(HeaderID, delivery.ID.Hex())
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, "sha256="+Sign(subscription.Secret, timestamp, delivery.Payload))
+
+	start := time.Now()
+	res, err := d.client.Do(req)
+	attempt.DurationMS = time.Since(start).Milliseconds()
+	if err != nil {
+		attempt.Error = err.Error()
+		return
+	}
+	defer res.Body.Close()
+
+	attempt.StatusCode = res.StatusCode
+	body, _ := io.ReadAll(io.LimitReader(res.Body, responseSnippet))
+	attempt.Response = strings.ToValidUTF8(string(body), "�")
+}
+
+// record - record attempt of delivery, a delivery that cannot be recorded is attempted again once its claim expires
+func (d *DispatcherImpl) record(ctx context.Context, delivery *models.Delivery, attempt *models.Attempt, status string, nextAttemptAt *time.Time) {
+	result := status
+	if status == models.DeliveryPending {
+		result = "retried"
+	}
+	pkgmetrics.ObserveWebhookAttempt(result)
+
+	if err := d.repository.RecordAttempt(ctx, delivery.ID, attempt, status, nextAttemptAt); err != nil {
+		d.log.WithContext(ctx).Error("recording webhook attempt failed", "delivery", delivery.ID.Hex(), "error", err)
+	}
+}
+
+// backoff - delay after the failed attempt number attempts
+func (d *DispatcherImpl) backoff(attempts int) time.Duration {
+	backoff := d.cfg.MinBackoff
+	for i := 1; i < attempts && backoff < d.cfg.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > d.cfg.MaxBackoff {
+		backoff = d.cfg.MaxBackoff
+	}
+
+	return backoff
+}
+
+// dedupeKey - key of the change of todo delivered to subscription. The ids of events differ between the instances
+// following the same change stream, their todos do not
+func dedupeKey(subscription *models.Subscription, eventType string, todo []byte) string {
+	sum := sha256.New()
+	sum.Write([]byte(subscription.ID.Hex() + "\n" + eventType + "\n"))
+	sum.Write(todo)
+
+	return hex.EncodeToString(sum.Sum(nil))
+}
+
+// newHTTPClient - client of deliveries, redirects are not followed and only public addresses are dialed unless
+// private networks are allowed
+func newHTTPClient(cfg *Config) *http.Client {
+	dialer := &net.Dialer{Timeout: cfg.Timeout, KeepAlive: 30 * time.Second}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if !cfg.AllowPrivateNetworks {
+		// Addresses are checked once resolved, a name resolving to a private address is refused too
+		dialer.Control = publicOnly
+		// A proxy would be dialed instead of the receiver
+		transport.Proxy = nil
+	}
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Transport: transport,
+		Timeout:   cfg.Timeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+func publicOnly(network string, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return fmt.Errorf("webhook: %s is not a public address", host)
+	}
+
+	return nil
+}
@@ -0,0 +1,248 @@
+package service_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go-clean-architecture/pkg/tenant"
+	todomodels "go-clean-architecture/todo/models"
+	errorsutil "go-clean-architecture/utils/errors"
+	mockrepository "go-clean-architecture/webhook/mocks/repository"
+	"go-clean-architecture/webhook/models"
+	webhookservice "go-clean-architecture/webhook/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// events - broker sending its events to every subscriber, keeping the subscription open until ctx is done
+type events []*todomodels.Event
+
+func (e events) Publish(*todomodels.Event) {}
+
+func (e events) Subscribe(ctx context.Context, lastEventID string, buffer int) <-chan *todomodels.Event {
+	ch := make(chan *todomodels.Event, len(e))
+	if lastEventID == "" {
+		for _, event := range e {
+			ch <- event
+		}
+	}
+	go func() {
+		<-ctx.Done()
+		close(ch)
+	}()
+
+	return ch
+}
+
+var dispatcherConfig = webhookservice.Config{
+	MaxAttempts:          3,
+	MinBackoff:           time.Minute,
+	MaxBackoff:           time.Hour,
+	Timeout:              5 * time.Second,
+	PollInterval:         time.Hour,
+	Concurrency:          2,
+	AllowPrivateNetworks: true,
+}
+
+// run - run dispatcher until done receives or the test times out
+func run(t *testing.T, ctx context.Context, dispatcher webhookservice.Dispatcher, done <-chan struct{}) {
+	ctx, cancel := context.WithCancel(ctx)
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		dispatcher.Run(ctx)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Error("timed out")
+	}
+	cancel()
+	<-stopped
+}
+
+// claim - make repository hand out delivery once
+func claim(repository *mockrepository.Repository, delivery *models.Delivery) {
+	repository.On("ClaimDelivery", mock.Anything, mock.Anything, mock.Anything).Return(delivery, nil).Once()
+	repository.On("ClaimDelivery", mock.Anything, mock.Anything, mock.Anything).Return(nil, errorsutil.ErrNotFound)
+}
+
+func TestWebhookDispatcherDeliver(t *testing.T) {
+	payload := []byte(`{"id":"1","type":"todo.created"}`)
+
+	t.Run("success when delivery is signed", func(t *testing.T) {
+		var received *http.Request
+		var body []byte
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received = r
+			body, _ = io.ReadAll(r.Body)
+			w.Write([]byte("ok"))
+		}))
+		defer receiver.Close()
+
+		subscription := &models.Subscription{ID: primitive.NewObjectID(), URL: receiver.URL, Secret: "secret"}
+		delivery := &models.Delivery{ID: primitive.NewObjectID(), SubscriptionID: subscription.ID, EventType: "todo.created", Payload: payload}
+
+		mockRepository := new(mockrepository.Repository)
+		claim(mockRepository, delivery)
+		mockRepository.On("FindSubscription", mock.Anything, subscription.ID).Return(subscription, nil)
+		done := make(chan struct{})
+		var attempt *models.Attempt
+		mockRepository.On("RecordAttempt", mock.Anything, delivery.ID, mock.Anything, models.DeliverySucceeded, (*time.Time)(nil)).
+			Run(func(args mock.Arguments) {
+				attempt = args.Get(2).(*models.Attempt)
+				close(done)
+			}).Return(nil)
+
+		run(t, context.Background(), webhookservice.NewDispatcher(mockRepository, events{}, &dispatcherConfig), done)
+
+		assert.Equal(t, payload, body)
+		assert.Equal(t, delivery.ID.Hex(), received.Header.Get(webhookservice.HeaderID))
+		assert.Equal(t, "todo.created", received.Header.Get(webhookservice.HeaderEvent))
+		timestamp := received.Header.Get(webhookservice.HeaderTimestamp)
+		assert.Equal(t, "sha256="+webhookservice.Sign("secret", timestamp, payload), received.Header.Get(webhookservice.HeaderSignature))
+		assert.Equal(t, http.StatusOK, attempt.StatusCode)
+		assert.Equal(t, "ok", attempt.Response)
+		mockRepository.AssertExpectations(t)
+	})
+	t.Run("when receiver fails, retried after backoff", func(t *testing.T) {
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer receiver.Close()
+
+		subscription := &models.Subscription{ID: primitive.NewObjectID(), URL: receiver.URL, Secret: "secret"}
+		delivery := &models.Delivery{ID: primitive.NewObjectID(), SubscriptionID: subscription.ID, Payload: payload,
+			Attempts: []*models.Attempt{{StatusCode: http.StatusInternalServerError}}}
+
+		mockRepository := new(mockrepository.Repository)
+		claim(mockRepository, delivery)
+		mockRepository.On("FindSubscription", mock.Anything, subscription.ID).Return(subscription, nil)
+		done := make(chan struct{})
+		var attempt *models.Attempt
+		var next *time.Time
+		mockRepository.On("RecordAttempt", mock.Anything, delivery.ID, mock.Anything, models.DeliveryPending, mock.Anything).
+			Run(func(args mock.Arguments) {
+				attempt = args.Get(2).(*models.Attempt)
+				next = args.Get(4).(*time.Time)
+				close(done)
+			}).Return(nil)
+
+		run(t, context.Background(), webhookservice.NewDispatcher(mockRepository, events{}, &dispatcherConfig), done)
+
+		assert.Equal(t, http.StatusInternalServerError, attempt.StatusCode)
+		assert.Equal(t, 2*time.Minute, next.Sub(attempt.At))
+		mockRepository.AssertExpectations(t)
+	})
+	t.Run("when out of attempts, dead", func(t *testing.T) {
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusGone)
+		}))
+		defer receiver.Close()
+
+		subscription := &models.Subscription{ID: primitive.NewObjectID(), URL: receiver.URL, Secret: "secret"}
+		delivery := &models.Delivery{ID: primitive.NewObjectID(), SubscriptionID: subscription.ID, Payload: payload,
+			Attempts: []*models.Attempt{{StatusCode: http.StatusGone}, {StatusCode: http.StatusGone}}}
+
+		mockRepository := new(mockrepository.Repository)
+		claim(mockRepository, delivery)
+		mockRepository.On("FindSubscription", mock.Anything, subscription.ID).Return(subscription, nil)
+		done := make(chan struct{})
+		mockRepository.On("RecordAttempt", mock.Anything, delivery.ID, mock.Anything, models.DeliveryDead, (*time.Time)(nil)).
+			Run(func(args mock.Arguments) { close(done) }).Return(nil)
+
+		run(t, context.Background(), webhookservice.NewDispatcher(mockRepository, events{}, &dispatcherConfig), done)
+
+		mockRepository.AssertExpectations(t)
+	})
+	t.Run("when subscription was deleted, dead", func(t *testing.T) {
+		delivery := &models.Delivery{ID: primitive.NewObjectID(), SubscriptionID: primitive.NewObjectID(), Payload: payload}
+
+		mockRepository := new(mockrepository.Repository)
+		claim(mockRepository, delivery)
+		mockRepository.On("FindSubscription", mock.Anything, delivery.SubscriptionID).Return(nil, errorsutil.ErrNotFound)
+		done := make(chan struct{})
+		mockRepository.On("RecordAttempt", mock.Anything, delivery.ID, mock.Anything, models.DeliveryDead, (*time.Time)(nil)).
+			Run(func(args mock.Arguments) { close(done) }).Return(nil)
+
+		run(t, context.Background(), webhookservice.NewDispatcher(mockRepository, events{}, &dispatcherConfig), done)
+
+		mockRepository.AssertExpectations(t)
+	})
+	t.Run("when address is private, refused", func(t *testing.T) {
+		requests := 0
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+		}))
+		defer receiver.Close()
+
+		subscription := &models.Subscription{ID: primitive.NewObjectID(), URL: receiver.URL, Secret: "secret"}
+		delivery := &models.Delivery{ID: primitive.NewObjectID(), SubscriptionID: subscription.ID, Payload: payload}
+
+		mockRepository := new(mockrepository.Repository)
+		claim(mockRepository, delivery)
+		mockRepository.On("FindSubscription", mock.Anything, subscription.ID).Return(subscription, nil)
+		done := make(chan struct{})
+		var attempt *models.Attempt
+		mockRepository.On("RecordAttempt", mock.Anything, delivery.ID, mock.Anything, models.DeliveryPending, mock.Anything).
+			Run(func(args mock.Arguments) {
+				attempt = args.Get(2).(*models.Attempt)
+				close(done)
+			}).Return(nil)
+
+		cfg := dispatcherConfig
+		cfg.AllowPrivateNetworks = false
+		run(t, context.Background(), webhookservice.NewDispatcher(mockRepository, events{}, &cfg), done)
+
+		assert.Zero(t, requests)
+		assert.Contains(t, attempt.Error, "not a public address")
+		mockRepository.AssertExpectations(t)
+	})
+}
+
+func TestWebhookDispatcherEnqueue(t *testing.T) {
+	t.Run("success when stored for subscribers who can view the todo of their tenant", func(t *testing.T) {
+		todo := &todomodels.Todo{ID: primitive.NewObjectID(), OwnerID: "user-1",
+			Collaborators: []*todomodels.Collaborator{{UserID: "user-2", Permission: todomodels.PermissionView}}}
+		owner := &models.Subscription{ID: primitive.NewObjectID(), OwnerID: "user-1"}
+		collaborator := &models.Subscription{ID: primitive.NewObjectID(), OwnerID: "user-2"}
+		stranger := &models.Subscription{ID: primitive.NewObjectID(), OwnerID: "user-3"}
+
+		mockRepository := new(mockrepository.Repository)
+		mockRepository.On("ClaimDelivery", mock.Anything, mock.Anything, mock.Anything).Return(nil, errorsutil.ErrNotFound)
+		mockRepository.On("FindByEvent", mock.Anything, todomodels.EventUpdated).Return([]*models.Subscription{owner, collaborator, stranger}, nil)
+		var stored []*models.Delivery
+		done := make(chan struct{})
+		mockRepository.On("StoreDelivery", mock.Anything, mock.AnythingOfType("*models.Delivery")).
+			Run(func(args mock.Arguments) {
+				stored = append(stored, args.Get(1).(*models.Delivery))
+				if len(stored) == 2 {
+					close(done)
+				}
+			}).
+			Return(func(ctx context.Context, value *models.Delivery) *models.Delivery { return value }, nil)
+
+		ctx := tenant.NewContext(context.Background(), &tenant.Tenant{ID: "acme"})
+		run(t, ctx, webhookservice.NewDispatcher(mockRepository, events{
+			{ID: "1", Type: todomodels.EventReset},
+			{ID: "2", Type: todomodels.EventUpdated, Todo: todo, TenantID: "globex"},
+			{ID: "3", Type: todomodels.EventUpdated, Todo: todo, TenantID: "acme"},
+		}, &dispatcherConfig), done)
+
+		assert.Len(t, stored, 2)
+		assert.Equal(t, owner.ID, stored[0].SubscriptionID)
+		assert.Equal(t, collaborator.ID, stored[1].SubscriptionID)
+		assert.Equal(t, "3", stored[0].EventID)
+		assert.NotEmpty(t, stored[0].DedupeKey)
+		assert.NotEqual(t, stored[0].DedupeKey, stored[1].DedupeKey)
+		assert.Contains(t, string(stored[0].Payload), `"type":"todo.updated"`)
+		mockRepository.AssertNumberOfCalls(t, "FindByEvent", 1)
+	})
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+
+	"go-clean-architecture/pkg/logger"
+	"go-clean-architecture/webhook/models"
+	webhookrepository "go-clean-architecture/webhook/repository"
+)
+
+// SecretPrefix - prefix of generated secrets
+const SecretPrefix = "whsec_"
+
+// Service represent the webhook service
+type Service interface {
+	GetAll(ctx context.Context, ownerID string) ([]*models.Subscription, error)
+	GetByID(ctx context.Context, ownerID string, id string) (*models.Subscription, error)
+	// Create - subscribe, a secret is generated when value has none. The secret is only returned here
+	Create(ctx context.Context, value *models.Subscription) (*models.CreatedSubscription, error)
+	// Update - replace URL and events, and the secret when value has one
+	Update(ctx context.Context, ownerID string, id string, value *models.Subscription) (*models.Subscription, error)
+	// Delete - unsubscribe, pending deliveries are dropped
+	Delete(ctx context.Context, ownerID string, id string) error
+	// GetDeliveries - delivery log of subscription by id, newest first
+	GetDeliveries(ctx context.Context, ownerID string, id string, limit int, offset int) ([]*models.Delivery, int, error)
+	// Redeliver - deliver the payload of a delivery again as a new delivery, whatever became of it
+	Redeliver(ctx context.Context, ownerID string, id string, deliveryID string) (*models.Delivery, error)
+}
+
+type ServiceImpl struct {
+	repository webhookrepository.Repository
+	log        logger.Logger
+}
+
+// New will create new an ServiceImpl object representation of Service interface
+func New(repository webhookrepository.Repository) Service {
+	return &ServiceImpl{
+		repository: repository,
+		log:        logger.Named("webhook/service"),
+	}
+}
+
+// GetAll - get all subscriptions of owner service
+func (s *ServiceImpl) GetAll(ctx context.Context, ownerID string) ([]*models.Subscription, error) {
+	return s.repository.FindAll(ctx, ownerID)
+}
+
+// GetByID - get subscription by id service
+func (s *ServiceImpl) GetByID(ctx context.Context, ownerID string, id string) (*models.Subscription, error) {
+	return s.repository.FindByID(ctx, ownerID, id)
+}
+
+// Create - create subscription service
+func (s *ServiceImpl) Create(ctx context.Context, value *models.Subscription) (*models.CreatedSubscription, error) {
+	secret := value.Secret
+	if secret == "" {
+		var err error
+		if secret, err = generateSecret(); err != nil {
+			return nil, err
+		}
+	}
+
+	res, err := s.repository.Store(ctx, &models.Subscription{
+		OwnerID: value.OwnerID,
+		URL:     value.URL,
+		Events:  value.Events,
+		Secret:  secret,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.CreatedSubscription{Subscription: res, Secret: secret}, nil
+}
+
+// Update - update subscription service
+func (s *ServiceImpl) Update(ctx context.Context, ownerID string, id string, value *models.Subscription) (*models.Subscription, error) {
+	return s.repository.Update(ctx, ownerID, id, value)
+}
+
+// Delete - delete subscription service
+func (s *ServiceImpl) Delete(ctx context.Context, ownerID string, id string) error {
+	return s.repository.Delete(ctx, ownerID, id)
+}
+
+// GetDeliveries - get deliveries of subscription service
+func (s *ServiceImpl) GetDeliveries(ctx context.Context, ownerID string, id string, limit int, offset int) ([]*models.Delivery, int, error) {
+	subscription, err := s.repository.FindByID(ctx, ownerID, id)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	results, err := s.repository.FindDeliveries(ctx, subscription.ID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	count, err := s.repository.CountDeliveries(ctx, subscription.ID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return results, count, nil
+}
+
+// Redeliver - redeliver delivery service
+func (s *ServiceImpl) Redeliver(ctx context.Context, ownerID string, id string, deliveryID string) (*models.Delivery, error) {
+	subscription, err := s.repository.FindByID(ctx, ownerID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery, err := s.repository.FindDelivery(ctx, subscription.ID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Without a dedupe key, redeliveries are never mistaken for a change seen twice
+	return s.repository.StoreDelivery(ctx, &models.Delivery{
+		SubscriptionID: subscription.ID,
+		EventID:        delivery.EventID,
+		EventType:      delivery.EventType,
+		Payload:        delivery.Payload,
+		RedeliveryOf:   &delivery.ID,
+	})
+}
+
+func generateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return SecretPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
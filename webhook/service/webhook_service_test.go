@@ -0,0 +1,128 @@
+package service_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	errorsutil "go-clean-architecture/utils/errors"
+	mockrepository "go-clean-architecture/webhook/mocks/repository"
+	"go-clean-architecture/webhook/models"
+	webhookservice "go-clean-architecture/webhook/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestWebhookCreate(t *testing.T) {
+	t.Run("success when secret is generated", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := webhookservice.New(mockRepository)
+
+		var stored *models.Subscription
+		mockRepository.On("Store", mock.Anything, mock.AnythingOfType("*models.Subscription")).
+			Run(func(args mock.Arguments) { stored = args.Get(1).(*models.Subscription) }).
+			Return(func(ctx context.Context, value *models.Subscription) *models.Subscription { return value }, nil)
+
+		result, err := service.Create(context.Background(), &models.Subscription{OwnerID: "user-1", URL: "https://example.com/hook", Events: []string{"todo.created"}})
+
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(result.Secret, webhookservice.SecretPrefix))
+		assert.Equal(t, result.Secret, stored.Secret)
+		assert.Equal(t, "user-1", stored.OwnerID)
+		mockRepository.AssertExpectations(t)
+	})
+	t.Run("success when secret is given", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := webhookservice.New(mockRepository)
+
+		mockRepository.On("Store", mock.Anything, mock.AnythingOfType("*models.Subscription")).
+			Return(func(ctx context.Context, value *models.Subscription) *models.Subscription { return value }, nil)
+
+		result, err := service.Create(context.Background(), &models.Subscription{OwnerID: "user-1", Secret: "0123456789abcdef"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "0123456789abcdef", result.Secret)
+		mockRepository.AssertExpectations(t)
+	})
+}
+
+func TestWebhookGetDeliveries(t *testing.T) {
+	subscription := &models.Subscription{ID: primitive.NewObjectID(), OwnerID: "user-1"}
+
+	t.Run("success when get deliveries", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := webhookservice.New(mockRepository)
+
+		deliveries := []*models.Delivery{{ID: primitive.NewObjectID(), SubscriptionID: subscription.ID}}
+		mockRepository.On("FindByID", mock.Anything, "user-1", subscription.ID.Hex()).Return(subscription, nil)
+		mockRepository.On("FindDeliveries", mock.Anything, subscription.ID, 10, 0).Return(deliveries, nil)
+		mockRepository.On("CountDeliveries", mock.Anything, subscription.ID).Return(1, nil)
+
+		results, count, err := service.GetDeliveries(context.Background(), "user-1", subscription.ID.Hex(), 10, 0)
+
+		assert.NoError(t, err)
+		assert.Equal(t, deliveries, results)
+		assert.Equal(t, 1, count)
+		mockRepository.AssertExpectations(t)
+	})
+	t.Run("error when subscription belongs to another user", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := webhookservice.New(mockRepository)
+
+		mockRepository.On("FindByID", mock.Anything, "user-2", subscription.ID.Hex()).Return(nil, errorsutil.ErrNotFound)
+
+		_, _, err := service.GetDeliveries(context.Background(), "user-2", subscription.ID.Hex(), 10, 0)
+
+		assert.ErrorIs(t, err, errorsutil.ErrNotFound)
+		mockRepository.AssertExpectations(t)
+	})
+}
+
+func TestWebhookRedeliver(t *testing.T) {
+	subscription := &models.Subscription{ID: primitive.NewObjectID(), OwnerID: "user-1"}
+	delivery := &models.Delivery{
+		ID:             primitive.NewObjectID(),
+		SubscriptionID: subscription.ID,
+		EventID:        "event-1",
+		EventType:      "todo.created",
+		DedupeKey:      "key",
+		Payload:        []byte(`{"type":"todo.created"}`),
+		Status:         models.DeliveryDead,
+	}
+
+	t.Run("success when redeliver", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := webhookservice.New(mockRepository)
+
+		mockRepository.On("FindByID", mock.Anything, "user-1", subscription.ID.Hex()).Return(subscription, nil)
+		mockRepository.On("FindDelivery", mock.Anything, subscription.ID, delivery.ID.Hex()).Return(delivery, nil)
+		mockRepository.On("StoreDelivery", mock.Anything, &models.Delivery{
+			SubscriptionID: subscription.ID,
+			EventID:        "event-1",
+			EventType:      "todo.created",
+			Payload:        delivery.Payload,
+			RedeliveryOf:   &delivery.ID,
+		}).Return(func(ctx context.Context, value *models.Delivery) *models.Delivery { return value }, nil)
+
+		result, err := service.Redeliver(context.Background(), "user-1", subscription.ID.Hex(), delivery.ID.Hex())
+
+		assert.NoError(t, err)
+		assert.Empty(t, result.DedupeKey)
+		assert.Equal(t, delivery.ID, *result.RedeliveryOf)
+		mockRepository.AssertExpectations(t)
+	})
+	t.Run("error when delivery is not found", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := webhookservice.New(mockRepository)
+
+		mockRepository.On("FindByID", mock.Anything, "user-1", subscription.ID.Hex()).Return(subscription, nil)
+		mockRepository.On("FindDelivery", mock.Anything, subscription.ID, "missing").Return(nil, errorsutil.ErrNotFound)
+
+		_, err := service.Redeliver(context.Background(), "user-1", subscription.ID.Hex(), "missing")
+
+		assert.ErrorIs(t, err, errorsutil.ErrNotFound)
+		mockRepository.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+
+	pkgtracing "go-clean-architecture/pkg/tracing"
+	"go-clean-architecture/webhook/models"
+)
+
+type TracingService struct {
+	next Service
+}
+
+// WithTracing will wrap a Service and start a span for every method
+func WithTracing(next Service) Service {
+	return &TracingService{
+		next: next,
+	}
+}
+
+// GetAll - get all subscriptions of owner service
+func (s *TracingService) GetAll(ctx context.Context, ownerID string) (res []*models.Subscription, err error) {
+	ctx, span := pkgtracing.Start(ctx, "webhook.Service/GetAll")
+	defer pkgtracing.End(span, &err)
+
+	return s.next.GetAll(ctx, ownerID)
+}
+
+// GetByID - get subscription by id service
+func (s *TracingService) GetByID(ctx context.Context, ownerID string, id string) (res *models.Subscription, err error) {
+	ctx, span := pkgtracing.Start(ctx, "webhook.Service/GetByID")
+	defer pkgtracing.End(span, &err)
+
+	return s.next.GetByID(ctx, ownerID, id)
+}
+
+// Create - create subscription service
+func (s *TracingService) Create(ctx context.Context, value *models.Subscription) (res *models.CreatedSubscription, err error) {
+	ctx, span := pkgtracing.Start(ctx, "webhook.Service/Create")
+	defer pkgtracing.End(span, &err)
+
+	return s.next.Create(ctx, value)
+}
+
+// Update - update subscription service
+func (s *TracingService) Update(ctx context.Context, ownerID string, id string, value *models.Subscription) (res *models.Subscription, err error) {
+	ctx, span := pkgtracing.Start(ctx, "webhook.Service/Update")
+	defer pkgtracing.End(span, &err)
+
+	return s.next.Update(ctx, ownerID, id, value)
+}
+
+// Delete - delete subscription service
+func (s *TracingService) Delete(ctx context.Context, ownerID string, id string) (err error) {
+	ctx, span := pkgtracing.Start(ctx, "webhook.Service/Delete")
+	defer pkgtracing.End(span, &err)
+
+	return s.next.Delete(ctx, ownerID, id)
+}
+
+// GetDeliveries - get deliveries of subscription service
+func (s *TracingService) GetDeliveries(ctx context.Context, ownerID string, id string, limit int, offset int) (res []*models.Delivery, count int, err error) {
+	ctx, span := pkgtracing.Start(ctx, "webhook.Service/GetDeliveries")
+	defer pkgtracing.End(span, &err)
+
+	return s.next.GetDeliveries(ctx, ownerID, id, limit, offset)
+}
+
+// Redeliver - redeliver delivery service
+func (s *TracingService) Redeliver(ctx context.Context, ownerID string, id string, deliveryID string) (res *models.Delivery, err error) {
+	ctx, span := pkgtracing.Start(ctx, "webhook.Service/Redeliver")
+	defer pkgtracing.End(span, &err)
+
+	return s.next.Redeliver(ctx, ownerID, id, deliveryID)
+}